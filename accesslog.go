@@ -0,0 +1,167 @@
+package rstf
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redacted replaces the values of redacted query parameters and headers.
+const redacted = "[REDACTED]"
+
+// AccessLog configures the request log enabled with App.SetAccessLog.
+type AccessLog struct {
+	// Logger receives one entry per request. Defaults to NewLogger().
+	Logger *Logger
+	// Skip lists path prefixes that are never logged, such as "/health".
+	Skip []string
+	// SkipStatic leaves out requests for files under /rstf/static/.
+	SkipStatic bool
+	// Sample maps path prefixes to the fraction of their requests that is
+	// logged, from 0 to 1, e.g. "/api/poll": 0.01. The longest matching
+	// prefix applies. Responses with a 5xx status are always logged.
+	Sample map[string]float64
+	// RedactQuery lists query parameters whose values are replaced with
+	// [REDACTED], such as "token".
+	RedactQuery []string
+	// Headers adds the request headers to each entry.
+	Headers bool
+	// RedactHeaders lists headers whose values are replaced with [REDACTED],
+	// in addition to Authorization, Proxy-Authorization, and Cookie.
+	RedactHeaders []string
+}
+
+// SetAccessLog logs every request the app serves with its method, path,
+// status, and duration. An invalid sample rate is an error.
+func (a *App) SetAccessLog(log AccessLog) error {
+	for prefix, rate := range log.Sample {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("access log: sample rate %v for %q must be between 0 and 1", rate, prefix)
+		}
+	}
+	if log.Logger == nil {
+		log.Logger = NewLogger()
+	}
+	a.accessLog = &log
+	return nil
+}
+
+// NewAccessLogMiddleware returns middleware writing the App's access log. It
+// passes requests through unchanged when no access log is set.
+func NewAccessLogMiddleware(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			log := app.accessLog
+			if log == nil || log.skips(req.URL.Path) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			tracker := NewResponseTracker(w)
+			start := time.Now()
+			completed := false
+			defer func() {
+				status := tracker.StatusCode()
+				if !completed && !tracker.Written() {
+					status = http.StatusInternalServerError
+				}
+				app.logRequest(req, status, time.Since(start))
+			}()
+			next.ServeHTTP(tracker, req)
+			completed = true
+		})
+	}
+}
+
+func (l *AccessLog) skips(path string) bool {
+	if l.SkipStatic && strings.Contains(path, "/rstf/static/") {
+		return true
+	}
+	for _, prefix := range l.Skip {
+		if hasPathPrefix(path, strings.TrimSuffix(prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleRate returns the fraction of requests to path that is logged.
+func (l *AccessLog) sampleRate(path string) float64 {
+	rate, longest := 1.0, -1
+	for prefix, r := range l.Sample {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if len(prefix) > longest && hasPathPrefix(path, prefix) {
+			rate, longest = r, len(prefix)
+		}
+	}
+	return rate
+}
+
+func (a *App) logRequest(req *http.Request, status int, elapsed time.Duration) {
+	log := a.accessLog
+	rate := log.sampleRate(req.URL.Path)
+	if status < 500 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	attrs := []any{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", status,
+		"durationMs", float64(elapsed.Microseconds()) / 1000,
+	}
+	if req.URL.RawQuery != "" {
+		attrs = append(attrs, "query", log.redactQuery(req.URL.RawQuery))
+	}
+	if addr := a.clientAddr(req); addr.IsValid() {
+		attrs = append(attrs, "clientIP", addr.String())
+	}
+	if log.Headers {
+		attrs = append(attrs, "headers", log.redactHeaders(req.Header))
+	}
+	if rate < 1 {
+		attrs = append(attrs, "sampleRate", rate)
+	}
+	if status >= 500 {
+		log.Logger.Error("http request", attrs...)
+		return
+	}
+	log.Logger.Info("http request", attrs...)
+}
+
+func (l *AccessLog) redactQuery(raw string) string {
+	if len(l.RedactQuery) == 0 {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return redacted
+	}
+	for _, name := range l.RedactQuery {
+		if vs, ok := values[name]; ok {
+			for i := range vs {
+				vs[i] = redacted
+			}
+		}
+	}
+	return values.Encode()
+}
+
+func (l *AccessLog) redactHeaders(header http.Header) map[string]string {
+	hidden := map[string]bool{"Authorization": true, "Proxy-Authorization": true, "Cookie": true}
+	for _, name := range l.RedactHeaders {
+		hidden[http.CanonicalHeaderKey(name)] = true
+	}
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if hidden[name] {
+			out[name] = redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package rstf
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveAccessLogged(app *App, status int, targets ...string) {
+	handler := NewAccessLogMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+	}))
+	for _, target := range targets {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Api-Key", "key")
+		req.Header.Set("User-Agent", "test")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func TestAccessLog_LogsRequests(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	require.NoError(t, app.SetAccessLog(AccessLog{Logger: &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))}}))
+
+	serveAccessLogged(app, http.StatusCreated, "/posts?page=2")
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "http request", entries[0]["msg"])
+	assert.Equal(t, "INFO", entries[0]["level"])
+	assert.Equal(t, "GET", entries[0]["method"])
+	assert.Equal(t, "/posts", entries[0]["path"])
+	assert.Equal(t, "page=2", entries[0]["query"])
+	assert.Equal(t, float64(201), entries[0]["status"])
+	assert.Equal(t, "192.0.2.1", entries[0]["clientIP"])
+	assert.Contains(t, entries[0], "durationMs")
+	assert.NotContains(t, entries[0], "headers")
+	assert.NotContains(t, entries[0], "sampleRate")
+}
+
+func TestAccessLog_SkipsPathsAndStatic(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	require.NoError(t, app.SetAccessLog(AccessLog{
+		Logger:     &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))},
+		Skip:       []string{"/health"},
+		SkipStatic: true,
+	}))
+
+	serveAccessLogged(app, http.StatusOK, "/health", "/health/db", "/rstf/static/main.css", "/admin/rstf/static/bundle.js", "/healthy")
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/healthy", entries[0]["path"])
+}
+
+func TestAccessLog_Samples(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	require.NoError(t, app.SetAccessLog(AccessLog{
+		Logger: &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))},
+		Sample: map[string]float64{"/api": 0, "/api/orders": 1},
+	}))
+
+	serveAccessLogged(app, http.StatusOK, "/api/poll", "/api/poll", "/api/orders/1")
+	serveAccessLogged(app, http.StatusBadGateway, "/api/poll")
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/api/orders/1", entries[0]["path"])
+	assert.NotContains(t, entries[0], "sampleRate")
+	assert.Equal(t, "/api/poll", entries[1]["path"])
+	assert.Equal(t, "ERROR", entries[1]["level"])
+	assert.Equal(t, float64(0), entries[1]["sampleRate"])
+}
+
+func TestAccessLog_Redacts(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	require.NoError(t, app.SetAccessLog(AccessLog{
+		Logger:        &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))},
+		RedactQuery:   []string{"token"},
+		Headers:       true,
+		RedactHeaders: []string{"x-api-key"},
+	}))
+
+	serveAccessLogged(app, http.StatusOK, "/callback?token=abc&state=xyz")
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "state=xyz&token=%5BREDACTED%5D", entries[0]["query"])
+	headers := entries[0]["headers"].(map[string]any)
+	assert.Equal(t, "[REDACTED]", headers["Authorization"])
+	assert.Equal(t, "[REDACTED]", headers["X-Api-Key"])
+	assert.Equal(t, "test", headers["User-Agent"])
+}
+
+func TestAccessLog_RejectsInvalidSampleRate(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.SetAccessLog(AccessLog{Sample: map[string]float64{"/api": 1.5}}))
+	assert.Nil(t, app.accessLog)
+}
+
+func TestAccessLog_DisabledPassesThrough(t *testing.T) {
+	app := NewApp()
+	called := false
+	handler := NewAccessLogMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		_, ok := w.(*ResponseTracker)
+		assert.False(t, ok)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.True(t, called)
+}
@@ -3,6 +3,9 @@ package rstf
 import (
 	"database/sql"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -18,8 +21,43 @@ type App struct {
 	readTimeout           time.Duration
 	writeTimeout          time.Duration
 	idleTimeout           time.Duration
+	errorHandlers         []ErrorHandler
+	routes                []AppRoute
+	tenantResolver        TenantResolver
+	tenantDatabase        TenantDatabaseFunc
+	revalidateToken       string
+	revalidateHandlers    []RevalidateHandler
+	requestHooks          []RequestHook
+	responseHooks         []ResponseHook
+	ipFilters             []ipFilter
+	clientIPHeader        string
+	templateRenderer      TemplateRenderer
+	metricsToken          string
+	metrics               map[string]MetricsFunc
+	queryLog              *QueryLog
+	accessLog             *AccessLog
+	auditLog              *AuditLog
+	databasePool          *DatabasePool
+	buildInfo             *BuildInfo
+	pageShell             PageShell
+	policies              map[string]Policy
+	devMode               bool
+	renderTiming          bool
 }
 
+// AppRoute is a handler registered with App.Route. An empty Method matches
+// every method.
+type AppRoute struct {
+	Method  string
+	Pattern string
+	Handler http.Handler
+}
+
+// ErrorHandler receives errors the framework could not hand back to a route:
+// handler panics, SSR failures, and renderer errors. stack is the Go stack for
+// panics, the JavaScript stack for render failures, or nil when unavailable.
+type ErrorHandler func(ctx *Context, err error, stack []byte)
+
 const (
 	DefaultReadHeaderTimeout = 5 * time.Second
 	DefaultReadTimeout       = 30 * time.Second
@@ -38,15 +76,29 @@ func NewApp() *App {
 		readTimeout:           DefaultReadTimeout,
 		writeTimeout:          DefaultWriteTimeout,
 		idleTimeout:           DefaultIdleTimeout,
+		devMode:               os.Getenv(DevModeEnv) == "1",
+		renderTiming:          os.Getenv(RenderTimingEnv) == "1",
 	}
 }
 
-// Database opens a connection pool using the given driver and DSN.
+// Database opens a connection pool using the given driver and DSN, tuned by
+// SetDatabasePool. Its stats are published on the metrics endpoint.
 func (a *App) Database(driverName, dataSourceName string) error {
 	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return err
 	}
+	if a.queryLog != nil && a.DevMode() {
+		logged, err := openLoggedDB(db, dataSourceName, a.queryLog)
+		db.Close()
+		if err != nil {
+			return err
+		}
+		db = logged
+	}
+	if a.databasePool != nil {
+		applyDatabasePool(db, *a.databasePool)
+	}
 	if err := db.Ping(); err != nil {
 		db.Close()
 		return err
@@ -196,6 +248,53 @@ func (a *App) IdleTimeout() time.Duration {
 	return a.idleTimeout
 }
 
+// OnError registers a handler invoked for every reported error. Handlers run in
+// registration order, making this the single place to wire Sentry, Rollbar, etc.
+func (a *App) OnError(handler ErrorHandler) {
+	if handler == nil {
+		return
+	}
+	a.errorHandlers = append(a.errorHandlers, handler)
+}
+
+// ReportError forwards err to all handlers registered with OnError.
+func (a *App) ReportError(ctx *Context, err error, stack []byte) {
+	if a == nil || err == nil {
+		return
+	}
+	for _, handler := range a.errorHandlers {
+		handler(ctx, err, stack)
+	}
+}
+
+// Route registers handler at pattern alongside the convention-based routes.
+// The pattern is "METHOD /path" or "/path", with {name} segments readable via
+// Request.PathValue. Use it to migrate existing handlers incrementally or for
+// endpoints that don't fit the folder convention.
+func (a *App) Route(pattern string, handler http.Handler) error {
+	if handler == nil {
+		return fmt.Errorf("route %q: handler must not be nil", pattern)
+	}
+	method, path, found := strings.Cut(strings.TrimSpace(pattern), " ")
+	if !found {
+		method, path = "", method
+	}
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("route %q: path must start with /", pattern)
+	}
+	if method != "" && method != strings.ToUpper(method) {
+		return fmt.Errorf("route %q: method must be uppercase", pattern)
+	}
+	a.routes = append(a.routes, AppRoute{Method: method, Pattern: path, Handler: handler})
+	return nil
+}
+
+// Routes returns the handlers registered with Route, in registration order.
+func (a *App) Routes() []AppRoute {
+	return append([]AppRoute(nil), a.routes...)
+}
+
 // Close shuts down the application, closing the database connection pool if open.
 func (a *App) Close() error {
 	if a.db != nil {
@@ -1,6 +1,8 @@
 package rstf
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -63,3 +65,39 @@ func TestAppAdmissionSettersRejectInvalid(t *testing.T) {
 	require.Error(t, app.SetWriteTimeout(0))
 	require.Error(t, app.SetIdleTimeout(0))
 }
+
+func TestAppReportError_CallsHandlersInOrder(t *testing.T) {
+	app := NewApp()
+	var calls []string
+	app.OnError(func(*Context, error, []byte) { calls = append(calls, "first") })
+	app.OnError(nil)
+	app.OnError(func(*Context, error, []byte) { calls = append(calls, "second") })
+
+	app.ReportError(nil, errors.New("render failed"), nil)
+	app.ReportError(nil, nil, nil)
+
+	require.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestAppRoute(t *testing.T) {
+	app := NewApp()
+	handler := http.NotFoundHandler()
+
+	require.NoError(t, app.Route("GET /legacy/{id}", handler))
+	require.NoError(t, app.Route("/webhooks/stripe", handler))
+
+	routes := app.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "GET", routes[0].Method)
+	require.Equal(t, "/legacy/{id}", routes[0].Pattern)
+	require.Equal(t, "", routes[1].Method)
+	require.Equal(t, "/webhooks/stripe", routes[1].Pattern)
+}
+
+func TestAppRouteRejectsInvalid(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.Route("GET /ok", nil))
+	require.Error(t, app.Route("GET legacy", http.NotFoundHandler()))
+	require.Error(t, app.Route("get /legacy", http.NotFoundHandler()))
+	require.Empty(t, app.Routes())
+}
@@ -0,0 +1,214 @@
+package rstf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAuditTable is the table the default audit sink writes to.
+	DefaultAuditTable = "rstf_audit_log"
+	// DefaultAuditTimeout bounds each write of the database audit sink.
+	DefaultAuditTimeout = 5 * time.Second
+)
+
+// AuditEntry records one request that may have changed data: a route handler
+// for POST, PUT, PATCH, or DELETE, or an RPC mutation or action.
+type AuditEntry struct {
+	Time time.Time
+	// Actor is who made the request, as AuditLog.Actor reports it.
+	Actor  string
+	Method string
+	// Route is the URL pattern the request matched, such as "/posts/{id}",
+	// or for an RPC call the route's name, such as "posts._id".
+	Route string
+	// Action is the RPC mutation or action called, "" for route handlers.
+	Action string
+	// ParamsDigest is the hex SHA-256 of the route params, so entries for
+	// the same record can be matched without storing its identifiers.
+	ParamsDigest string
+	Status       int
+}
+
+// AuditSink stores audit entries.
+type AuditSink interface {
+	WriteAudit(ctx *Context, entry AuditEntry) error
+}
+
+// AuditSinkFunc adapts a function to AuditSink.
+type AuditSinkFunc func(ctx *Context, entry AuditEntry) error
+
+func (f AuditSinkFunc) WriteAudit(ctx *Context, entry AuditEntry) error { return f(ctx, entry) }
+
+// AuditLog configures the audit log enabled with App.SetAuditLog.
+type AuditLog struct {
+	// Actor returns who is making the request, such as the signed-in user's
+	// ID. Without it, entries have no actor.
+	Actor func(ctx *Context) string
+	// Sink stores the entries. Defaults to
+	// DatabaseAuditSink(DatabaseAuditSinkConfig{}).
+	Sink AuditSink
+}
+
+// SetAuditLog records an AuditEntry for every request that may change data.
+// Entries are written after the response, and a sink error is passed to the
+// App's error handlers.
+func (a *App) SetAuditLog(log AuditLog) {
+	if log.Sink == nil {
+		log.Sink = DatabaseAuditSink(DatabaseAuditSinkConfig{})
+	}
+	a.auditLog = &log
+}
+
+// AuditAction records an RPC mutation or action call. The generated server
+// calls it once the call has responded.
+func (a *App) AuditAction(req *http.Request, route, action string, params map[string]string, status int) {
+	if a.auditLog == nil {
+		return
+	}
+	ctx := NewContext(req)
+	ctx.App = a
+	a.audit(ctx, route, action, params, status)
+}
+
+// auditRoute records a route handler's response to a mutating method.
+func (a *App) auditRoute(ctx *Context, route string, status int) {
+	switch ctx.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return
+	}
+	params := map[string]string{}
+	for _, m := range patternParamRe.FindAllStringSubmatch(route, -1) {
+		params[m[1]] = ctx.Request.PathValue(m[1])
+	}
+	a.audit(ctx, route, "", params, status)
+}
+
+// patternParamRe matches the {name} and {name...} segments of a pattern.
+var patternParamRe = regexp.MustCompile(`\{([^}.]+)(?:\.\.\.)?\}`)
+
+func (a *App) audit(ctx *Context, route, action string, params map[string]string, status int) {
+	log := a.auditLog
+	entry := AuditEntry{
+		Time:         time.Now().UTC(),
+		Method:       ctx.Request.Method,
+		Route:        route,
+		Action:       action,
+		ParamsDigest: paramsDigest(params),
+		Status:       status,
+	}
+	if log.Actor != nil {
+		entry.Actor = log.Actor(ctx)
+	}
+	if err := log.Sink.WriteAudit(ctx, entry); err != nil {
+		a.ReportError(ctx, fmt.Errorf("audit log: %w", err), nil)
+	}
+}
+
+// paramsDigest hashes params as sorted name=value lines. No params hash to
+// the empty string.
+func paramsDigest(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, params[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Placeholders is the bind parameter syntax a SQL driver expects.
+type Placeholders int
+
+const (
+	// QuestionPlaceholders is "?", as SQLite and MySQL drivers use.
+	QuestionPlaceholders Placeholders = iota
+	// DollarPlaceholders is "$1", "$2", and so on, as PostgreSQL drivers use.
+	DollarPlaceholders
+)
+
+// DatabaseAuditSinkConfig configures DatabaseAuditSink.
+type DatabaseAuditSinkConfig struct {
+	// Table is the table entries are written to. Defaults to DefaultAuditTable.
+	Table string
+	// Placeholders is the syntax of the database driver's bind parameters.
+	// Defaults to QuestionPlaceholders.
+	Placeholders Placeholders
+	// Timeout bounds each write, so a slow database holds up the request by
+	// at most this long. Defaults to DefaultAuditTimeout.
+	Timeout time.Duration
+}
+
+// DatabaseAuditSink writes entries to a table in the request's database: the
+// tenant's database under SetTenantDatabase, the App's otherwise. The table
+// is created on first use if it does not exist.
+func DatabaseAuditSink(cfg DatabaseAuditSinkConfig) AuditSink {
+	if cfg.Table == "" {
+		cfg.Table = DefaultAuditTable
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultAuditTimeout
+	}
+	placeholders := "?, ?, ?, ?, ?, ?, ?"
+	if cfg.Placeholders == DollarPlaceholders {
+		placeholders = "$1, $2, $3, $4, $5, $6, $7"
+	}
+	s := &dbAuditSink{
+		table:   cfg.Table,
+		timeout: cfg.Timeout,
+		insert:  `INSERT INTO ` + cfg.Table + ` (time, actor, method, route, action, params_digest, status) VALUES (` + placeholders + `)`,
+	}
+	return AuditSinkFunc(s.write)
+}
+
+type dbAuditSink struct {
+	table   string
+	timeout time.Duration
+	insert  string
+	created sync.Map // *sql.DB -> struct{}, databases with the table
+}
+
+func (s *dbAuditSink) write(ctx *Context, entry AuditEntry) error {
+	db, err := ctx.App.TenantDB(ctx.Tenant())
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return fmt.Errorf("no database configured")
+	}
+	execCtx, cancel := context.WithTimeout(ctx.Request.Context(), s.timeout)
+	defer cancel()
+	if _, ok := s.created.Load(db); !ok {
+		_, err := db.ExecContext(execCtx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	time TIMESTAMP NOT NULL,
+	actor TEXT NOT NULL,
+	method TEXT NOT NULL,
+	route TEXT NOT NULL,
+	action TEXT NOT NULL,
+	params_digest TEXT NOT NULL,
+	status INTEGER NOT NULL
+)`)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", s.table, err)
+		}
+		s.created.Store(db, struct{}{})
+	}
+
+	_, err = db.ExecContext(execCtx, s.insert,
+		entry.Time, entry.Actor, entry.Method, entry.Route, entry.Action, entry.ParamsDigest, entry.Status)
+	return err
+}
@@ -0,0 +1,165 @@
+package rstf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func auditedApp(entries *[]AuditEntry) *App {
+	app := NewApp()
+	app.SetAuditLog(AuditLog{
+		Actor: func(ctx *Context) string { return ctx.Request.Header.Get("X-User") },
+		Sink: AuditSinkFunc(func(ctx *Context, entry AuditEntry) error {
+			*entries = append(*entries, entry)
+			return nil
+		}),
+	})
+	return app
+}
+
+func TestAuditLog_RecordsMutatingRouteRequests(t *testing.T) {
+	var entries []AuditEntry
+	app := auditedApp(&entries)
+	handler := NewLifecycleHandler(app, "/posts/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/posts/7", nil)
+		req.SetPathValue("id", "7")
+		req.Header.Set("X-User", "user-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "user-1", entry.Actor)
+	assert.Equal(t, http.MethodDelete, entry.Method)
+	assert.Equal(t, "/posts/{id}", entry.Route)
+	assert.Empty(t, entry.Action)
+	assert.Equal(t, paramsDigest(map[string]string{"id": "7"}), entry.ParamsDigest)
+	assert.Len(t, entry.ParamsDigest, 64)
+	assert.Equal(t, http.StatusNoContent, entry.Status)
+	assert.False(t, entry.Time.IsZero())
+}
+
+func TestAuditLog_ActorSeesWhatTheHandlerSet(t *testing.T) {
+	var entries []AuditEntry
+	app := auditedApp(&entries)
+	handler := NewLifecycleHandler(app, "/posts", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := ContextFromRequest(req)
+		signedIn := req.Clone(req.Context())
+		signedIn.Header.Set("X-User", "user-2")
+		ctx.Request = signedIn
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, "user-2", entries[0].Actor)
+}
+
+func TestAuditLog_RecordsActions(t *testing.T) {
+	var entries []AuditEntry
+	app := auditedApp(&entries)
+
+	app.AuditAction(httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil), "posts._id", "UpdatePost", map[string]string{"id": "7"}, http.StatusBadRequest)
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, "posts._id", entries[0].Route)
+	assert.Equal(t, "UpdatePost", entries[0].Action)
+	assert.Equal(t, http.StatusBadRequest, entries[0].Status)
+}
+
+func TestAuditLog_Disabled(t *testing.T) {
+	app := NewApp()
+	app.AuditAction(httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil), "posts", "Create", nil, http.StatusOK)
+	assert.Nil(t, app.auditLog)
+}
+
+func TestAuditLog_ReportsSinkErrors(t *testing.T) {
+	app := NewApp()
+	var reported error
+	app.OnError(func(ctx *Context, err error, stack []byte) { reported = err })
+	app.SetAuditLog(AuditLog{Sink: AuditSinkFunc(func(ctx *Context, entry AuditEntry) error {
+		return errors.New("disk full")
+	})})
+
+	app.AuditAction(httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil), "posts", "Create", nil, http.StatusOK)
+	require.EqualError(t, reported, "audit log: disk full")
+}
+
+func TestDatabaseAuditSink(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+	app.DB().SetMaxOpenConns(1)
+	app.SetAuditLog(AuditLog{Actor: func(ctx *Context) string { return "admin" }})
+
+	req := httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil)
+	app.AuditAction(req, "posts", "Create", nil, http.StatusOK)
+	app.AuditAction(req, "posts._id", "Delete", map[string]string{"id": "3"}, http.StatusForbidden)
+
+	rows, err := app.DB().Query(`SELECT actor, method, route, action, params_digest, status FROM ` + DefaultAuditTable + ` ORDER BY status`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got [][]any
+	for rows.Next() {
+		var actor, method, route, action, digest string
+		var status int
+		require.NoError(t, rows.Scan(&actor, &method, &route, &action, &digest, &status))
+		got = append(got, []any{actor, method, route, action, digest, status})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]any{
+		{"admin", "POST", "posts", "Create", "", 200},
+		{"admin", "POST", "posts._id", "Delete", paramsDigest(map[string]string{"id": "3"}), 403},
+	}, got)
+}
+
+func TestDatabaseAuditSink_Config(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+	app.DB().SetMaxOpenConns(1)
+	app.SetAuditLog(AuditLog{Sink: DatabaseAuditSink(DatabaseAuditSinkConfig{
+		Table:        "audit_events",
+		Placeholders: DollarPlaceholders,
+	})})
+	var reported error
+	app.OnError(func(ctx *Context, err error, stack []byte) { reported = err })
+
+	app.AuditAction(httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil), "posts", "Create", nil, http.StatusOK)
+
+	require.NoError(t, reported)
+	var action string
+	require.NoError(t, app.DB().QueryRow(`SELECT action FROM audit_events`).Scan(&action))
+	assert.Equal(t, "Create", action)
+}
+
+func TestDatabaseAuditSink_Timeout(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+	app.DB().SetMaxOpenConns(1)
+	app.SetAuditLog(AuditLog{Sink: DatabaseAuditSink(DatabaseAuditSinkConfig{Timeout: 10 * time.Millisecond})})
+	var reported error
+	app.OnError(func(ctx *Context, err error, stack []byte) { reported = err })
+
+	// The open transaction holds the only connection, so the write waits.
+	tx, err := app.DB().Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	app.AuditAction(httptest.NewRequest(http.MethodPost, "/__rstf/rpc", nil), "posts", "Create", nil, http.StatusOK)
+
+	require.ErrorIs(t, reported, context.DeadlineExceeded)
+}
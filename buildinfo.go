@@ -0,0 +1,82 @@
+package rstf
+
+import "runtime/debug"
+
+// frameworkModule is the module path of the rstf framework.
+const frameworkModule = "github.com/rafbgarcia/rstf"
+
+const esbuildModule = "github.com/evanw/esbuild"
+
+// BuildInfo describes how a binary was built. The generated server publishes
+// its BuildInfo on the metrics endpoint as "build".
+type BuildInfo struct {
+	// Framework is the rstf module version linked into the binary,
+	// "(devel)" when it comes from a local checkout.
+	Framework string `json:"framework"`
+	// CLI and Esbuild are the versions of the rstf CLI and esbuild that
+	// generated and bundled the app. The generated server stamps them.
+	CLI     string `json:"cli,omitempty"`
+	Esbuild string `json:"esbuild,omitempty"`
+	Go      string `json:"go"`
+	// Module is the main module of the binary: the app's module path for a
+	// generated server.
+	Module string `json:"module,omitempty"`
+	// Commit is the VCS revision the binary was built from, and Modified
+	// whether the working tree had uncommitted changes. Both are empty when
+	// the build did not record VCS information.
+	Commit   string `json:"commit,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// ReadBuildInfo reads the build information Go embeds in the running binary.
+func ReadBuildInfo() BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}
+	}
+	info := BuildInfo{Go: bi.GoVersion, Module: bi.Main.Path}
+	if bi.Main.Path == frameworkModule {
+		info.Framework = bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		switch dep.Path {
+		case frameworkModule:
+			info.Framework = moduleVersion(dep)
+		case esbuildModule:
+			info.Esbuild = moduleVersion(dep)
+		}
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// moduleVersion reports a replaced module by its replacement's version.
+func moduleVersion(m *debug.Module) string {
+	if m.Replace != nil {
+		if m.Replace.Version == "" {
+			return "(devel)"
+		}
+		return m.Replace.Version
+	}
+	return m.Version
+}
+
+// SetBuildInfo sets the BuildInfo published on the metrics endpoint.
+func (a *App) SetBuildInfo(info BuildInfo) {
+	a.buildInfo = &info
+}
+
+// BuildInfo returns the BuildInfo set with SetBuildInfo.
+func (a *App) BuildInfo() BuildInfo {
+	if a.buildInfo == nil {
+		return BuildInfo{}
+	}
+	return *a.buildInfo
+}
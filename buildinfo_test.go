@@ -0,0 +1,24 @@
+package rstf
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	info := ReadBuildInfo()
+
+	assert.Equal(t, runtime.Version(), info.Go)
+}
+
+func TestSetBuildInfo_PublishedInMetrics(t *testing.T) {
+	app := NewApp()
+	assert.NotContains(t, app.Metrics(), "build")
+
+	app.SetBuildInfo(BuildInfo{Framework: "v1.2.3", CLI: "1.2.3", Go: "go1.24.6", Commit: "abc123"})
+
+	assert.Equal(t, "abc123", app.BuildInfo().Commit)
+	assert.Equal(t, app.BuildInfo(), app.Metrics()["build"])
+}
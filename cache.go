@@ -0,0 +1,19 @@
+package rstf
+
+// CacheControl sets the response's Cache-Control header, e.g.
+// "public, max-age=60". A handler's response gets it right away. Set from a
+// page's data functions, it goes on both the HTML and the ?_data JSON, so
+// browsers, CDNs, and the client runtime see the same policy. The last call
+// wins: the layout's data functions run first, then the route's and its
+// shared components' in path order. Meta runs only for the HTML, so a policy
+// it sets doesn't reach ?_data.
+func (c *Context) CacheControl(value string) {
+	c.Header().Set("Cache-Control", value)
+}
+
+// SurrogateControl sets the Surrogate-Control header, which CDNs honor in
+// place of Cache-Control and strip before the response reaches the browser,
+// e.g. "max-age=3600" with CacheControl("no-cache").
+func (c *Context) SurrogateControl(value string) {
+	c.Header().Set("Surrogate-Control", value)
+}
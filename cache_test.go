@@ -0,0 +1,29 @@
+package rstf
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextCacheControl(t *testing.T) {
+	// A page's data functions run before there is a response.
+	ctx := NewContext(httptest.NewRequest("GET", "/posts", nil))
+	ctx.CacheControl("public, max-age=60")
+	ctx.CacheControl("public, max-age=30")
+	ctx.SurrogateControl("max-age=3600")
+
+	rec := httptest.NewRecorder()
+	ctx.WriteHeaders(rec)
+	assert.Equal(t, "public, max-age=30", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "max-age=3600", rec.Header().Get("Surrogate-Control"))
+
+	// Handlers write straight to their response.
+	rec = httptest.NewRecorder()
+	ctx = NewContext(httptest.NewRequest("GET", "/feed", nil))
+	ctx.Writer = rec
+	ctx.CacheControl("no-store")
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.Empty(t, rec.Header().Get("Surrogate-Control"))
+}
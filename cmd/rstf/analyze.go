@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rafbgarcia/rstf/internal/bundler"
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newAnalyzeCmd() *cobra.Command {
+	var check bool
+	var baseline string
+	var modules int
+
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Report route bundle sizes against the previous build and budgets",
+		Long: "List each route's JS and CSS size from the last build, the change since the baseline manifest,\n" +
+			"and the route's budget from rstf.json, then the largest modules in each route's bundle and the\n" +
+			"modules more than one route bundles. With --check, exit with an error when a bundle is over budget.",
+		Example: "  rstf analyze\n" +
+			"  rstf analyze --modules 10\n" +
+			"  rstf analyze --check --baseline main-manifest.json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalyze(check, baseline, modules)
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Fail when a bundle exceeds its budget in rstf.json")
+	cmd.Flags().IntVar(&modules, "modules", 5, "Number of largest modules to list per route, 0 to skip the breakdown")
+	cmd.Flags().StringVar(&baseline, "baseline", filepath.Join("dist", "rstf", "manifest.json"), "Manifest of the build to compare sizes with")
+
+	return cmd
+}
+
+func runAnalyze(check bool, baseline string, modules int) error {
+	cfg, err := config.Load(".")
+	if err != nil {
+		return err
+	}
+	sizes, err := measureBundleSizes(cfg, baseline)
+	if err != nil {
+		return fmt.Errorf("%w (run `rstf build` first)", err)
+	}
+
+	bundles, err := routeBundles()
+	if err != nil {
+		return err
+	}
+	analysis, err := bundler.Analyze("rstf")
+	if err != nil {
+		return fmt.Errorf("%w (run `rstf build` first)", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "  Route\tJS\tGzip\tCSS\t")
+	for _, size := range sizes {
+		gzip := "-"
+		if bundle, ok := analysis.Bundles[bundles[size.Route]]; ok {
+			gzip = config.Size(bundle.GzipBytes).String()
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t\n", size.Route, size.Describe("js"), gzip, size.Describe("css"))
+	}
+	w.Flush()
+
+	if modules > 0 {
+		printModules(sizes, bundles, analysis, modules)
+	}
+
+	if check {
+		return codegen.CheckBudgets(sizes)
+	}
+	return nil
+}
+
+// measureBundleSizes records the size of each route's built bundles in
+// rstf/manifest.json and compares them with the manifest at baseline.
+func measureBundleSizes(cfg config.Config, baseline string) ([]codegen.BundleSize, error) {
+	manifestPath := filepath.Join("rstf", "manifest.json")
+	manifest, err := codegen.ReadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := codegen.MeasureBundles(&manifest, "rstf"); err != nil {
+		return nil, err
+	}
+	if err := codegen.WriteManifest(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	previous, err := codegen.ReadManifest(baseline)
+	if err != nil {
+		return nil, err
+	}
+	return codegen.CompareBundles(manifest, previous, cfg.Build), nil
+}
+
+// printModules lists the largest modules of each route's bundle, then the
+// modules bundled by more than one route.
+func printModules(sizes []codegen.BundleSize, bundles map[string]string, analysis bundler.Analysis, limit int) {
+	routes := map[string]string{}
+	for route, bundle := range bundles {
+		routes[bundle] = route
+	}
+
+	for _, size := range sizes {
+		bundle, ok := analysis.Bundles[bundles[size.Route]]
+		if !ok || len(bundle.Modules) == 0 {
+			continue
+		}
+		fmt.Printf("\n  %s\n", size.Route)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, m := range bundle.Modules[:min(limit, len(bundle.Modules))] {
+			fmt.Fprintf(w, "    %s\t%s\t%.1f%%\t\n", m.Module, config.Size(m.Bytes), 100*float64(m.Bytes)/float64(max(bundle.Bytes, 1)))
+		}
+		if rest := len(bundle.Modules) - limit; rest > 0 {
+			fmt.Fprintf(w, "    (%d more)\t\t\t\n", rest)
+		}
+		w.Flush()
+	}
+
+	if len(analysis.Shared) == 0 {
+		return
+	}
+	fmt.Println("\n  Shared across routes")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, m := range analysis.Shared {
+		var names []string
+		for _, bundle := range m.Bundles {
+			if route, ok := routes[bundle]; ok {
+				bundle = route
+			}
+			names = append(names, bundle)
+		}
+		fmt.Fprintf(w, "    %s\t%s\t%s\t\n", m.Module, config.Size(m.Bytes), strings.Join(names, ", "))
+	}
+	w.Flush()
+}
+
+// routeBundles maps each route name in rstf/manifest.json to its client
+// bundle's path under rstf/static, the key bundler.Analyze uses.
+func routeBundles() (map[string]string, error) {
+	manifest, err := codegen.ReadManifest(filepath.Join("rstf", "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	bundles := map[string]string{}
+	for _, route := range manifest.Routes {
+		if _, rel, ok := strings.Cut(route.Bundle, "/rstf/static/"); ok {
+			bundles[route.Name] = rel
+		}
+	}
+	return bundles, nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	rstf "github.com/rafbgarcia/rstf"
+	"github.com/rafbgarcia/rstf/internal/bench"
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// benchWarmTimeout bounds how long the server may take to answer its first
+// requests.
+const benchWarmTimeout = 30 * time.Second
+
+// benchOutputLines is how much of the server's output a failed run shows.
+const benchOutputLines = 20
+
+func newBenchCmd() *cobra.Command {
+	var (
+		target      string
+		concurrency int
+		duration    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench [paths...]",
+		Short: "Load-test server rendering of the built app",
+		Long: "Start the binary in dist/, or use the server at --url, and request each path for --duration with\n" +
+			"--concurrency requests in flight. Paths default to /.",
+		Example: "  rstf bench\n" +
+			"  rstf bench / /users/1 --concurrency 50 --duration 30s\n" +
+			"  rstf bench --url http://localhost:3000 /",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"/"}
+			}
+			return runBench(args, target, concurrency, duration)
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "url", "", "Base URL of a running server to load-test instead of the dist binary")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 10, "Number of requests in flight at once")
+	cmd.Flags().DurationVar(&duration, "duration", 10*time.Second, "How long to send requests")
+
+	return cmd
+}
+
+func runBench(paths []string, target string, concurrency int, duration time.Duration) error {
+	var server *distServer
+	if target == "" {
+		appName, err := currentAppName()
+		if err != nil {
+			return err
+		}
+		fmt.Print("  Server .......... ")
+		server, err = startDistServer(appName)
+		if err != nil {
+			fmt.Println("FAILED")
+			return err
+		}
+		defer server.stop()
+		fmt.Printf("started (%s)\n", filepath.Join("dist", appName))
+		target = server.url
+	}
+
+	base, err := url.Parse(target)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("invalid --url %q, expected a base URL like http://localhost:3000", target)
+	}
+	urls := make([]string, len(paths))
+	for i, path := range paths {
+		urls[i] = base.JoinPath(path).String()
+	}
+
+	fmt.Print("  Warmup .......... ")
+	ctx, cancel := context.WithTimeout(context.Background(), benchWarmTimeout)
+	err = bench.Warm(ctx, urls)
+	cancel()
+	if err != nil {
+		fmt.Println("FAILED")
+		return server.withOutput(err)
+	}
+	fmt.Println("done")
+
+	fmt.Print("  Load ............ ")
+	result, err := bench.Run(context.Background(), bench.Options{
+		URLs:        urls,
+		Concurrency: concurrency,
+		Duration:    duration,
+	})
+	if err != nil {
+		fmt.Println("FAILED")
+		return err
+	}
+	fmt.Printf("done (%s, %d concurrent)\n", duration, concurrency)
+
+	fmt.Println()
+	fmt.Printf("  Requests ........ %d (%.1f/s), %d failed\n", result.Requests, result.RequestsPerSecond(), result.Failures)
+	fmt.Printf("  Latency ......... p50 %s, p90 %s, p99 %s\n",
+		formatLatency(result.Percentile(50)), formatLatency(result.Percentile(90)), formatLatency(result.Percentile(99)))
+	if server != nil {
+		if peak, ok := server.stop(); ok {
+			fmt.Printf("  Peak memory ..... %s\n", peak)
+		}
+	}
+	if result.Failures > 0 {
+		return server.withOutput(fmt.Errorf("%d of %d requests failed", result.Failures, result.Requests))
+	}
+	return nil
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// distServer runs the built binary in dist/ the way production does, on a
+// listener rstf bench or rstf export opens so requests can be sent right away.
+type distServer struct {
+	url    string
+	cmd    *exec.Cmd
+	output bytes.Buffer
+	done   chan struct{}
+}
+
+func startDistServer(appName string) (*distServer, error) {
+	binary := filepath.Join("dist", appName)
+	if _, err := os.Stat(binary); err != nil {
+		return nil, fmt.Errorf("%s not found (run `rstf build` first)", binary)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	defer ln.Close()
+	listener, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		return nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	defer listener.Close()
+
+	s := &distServer{url: "http://" + ln.Addr().String(), done: make(chan struct{})}
+	s.cmd = exec.Command("./" + appName)
+	s.cmd.Dir = "dist"
+	s.cmd.Env = append(os.Environ(), rstf.ListenFDEnv+"=3")
+	s.cmd.ExtraFiles = []*os.File{listener}
+	s.cmd.Stdout = &s.output
+	s.cmd.Stderr = &s.output
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", binary, err)
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.done)
+	}()
+	return s, nil
+}
+
+// stop shuts the server down gracefully and reports its peak resident memory.
+func (s *distServer) stop() (config.Size, bool) {
+	select {
+	case <-s.done:
+	default:
+		s.cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-s.done:
+		case <-time.After(stopTimeout):
+			s.cmd.Process.Kill()
+			<-s.done
+		}
+	}
+	usage, ok := s.cmd.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0, false
+	}
+	// Linux reports the peak in kilobytes, macOS in bytes.
+	if runtime.GOOS == "darwin" {
+		return config.Size(usage.Maxrss), true
+	}
+	return config.Size(usage.Maxrss * 1024), true
+}
+
+// withOutput stops the server and adds the end of its output to err, which
+// usually explains failed requests. It returns err unchanged when the server
+// is not ours.
+func (s *distServer) withOutput(err error) error {
+	if s == nil {
+		return err
+	}
+	s.stop()
+	lines := strings.Split(strings.TrimSpace(s.output.String()), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return err
+	}
+	lines = lines[max(len(lines)-benchOutputLines, 0):]
+	return fmt.Errorf("%w\n\nserver output:\n%s", err, strings.Join(lines, "\n"))
+}
@@ -1,95 +1,221 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/config"
 	"github.com/rafbgarcia/rstf/internal/gotool"
 	"github.com/spf13/cobra"
 )
 
 func newBuildCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build a deployable dist directory",
+		Long: "Regenerate rstf/, bundle client and SSR code, build CSS, check bundle budgets, and compile\n" +
+			"the server into dist/<app>. Run the result with rstf start, or deploy dist/.",
+		Example: "  rstf build\n" +
+			"  rstf build --tags debug\n" +
+			"  rstf build --json\n" +
+			"  rstf build && rstf start --port 8080",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBuild()
+			tags, _ := cmd.Flags().GetStringSlice("tags")
+			profiling, _ := cmd.Flags().GetBool("profile")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return runBuild(tags, profiling, jsonOutput)
 		},
 	}
+
+	cmd.Flags().StringSlice("tags", nil, "Go build tags for codegen and the server binary, e.g. debug")
+	cmd.Flags().Bool("profile", false, "Report how long each build phase takes, per route")
+	cmd.Flags().Bool("json", false, "Print progress as JSON events, one per line, instead of status lines")
+	return cmd
 }
 
-func runBuild() error {
-	appName, err := currentAppName()
+func runBuild(tags []string, profiling, jsonOutput bool) error {
+	status := newProgress(jsonOutput)
+	appName, err := buildDist(tags, profiling, status)
 	if err != nil {
 		return err
 	}
+	status.message("Build complete. Run `rstf start`, or `cd dist && ./" + appName + "`.")
+	return nil
+}
+
+// buildDist runs the production build into dist/ and returns the name of the
+// server binary in it.
+func buildDist(tags []string, profiling bool, status *progress) (string, error) {
+	appName, err := currentAppName()
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := config.Load(".")
+	if err != nil {
+		return "", err
+	}
 
 	gen, err := codegen.NewGenerator(".")
 	if err != nil {
-		return fmt.Errorf("codegen init error: %w", err)
+		return "", fmt.Errorf("codegen init error: %w", err)
 	}
+	gen.SetBuildTags(tags)
+	gen.ExcludeRoutes(cfg.Build.ExcludeRoutes)
+	span := startProfile(profiling, "build")
 
-	fmt.Print("  Codegen ......... ")
+	status.begin("Codegen")
+	phase := span.Start("codegen")
+	gen.SetProfile(phase)
 	result, err := gen.Generate()
+	phase.End()
 	if err != nil {
-		fmt.Println("FAILED")
-		return fmt.Errorf("codegen error: %w", err)
+		status.fail("", err)
+		return "", fmt.Errorf("codegen error: %w", err)
+	}
+	status.done(fmt.Sprintf("%d routes", result.RouteCount))
+	unused := result.AllUnusedShared()
+	printUnusedShared(status, unused)
+	if cfg.Build.FailOnUnusedShared && len(unused) > 0 {
+		return "", fmt.Errorf("unused shared components: %s", strings.Join(unused, ", "))
 	}
-	fmt.Printf("done (%d routes)\n", result.RouteCount)
 
-	fmt.Print("  Client bundles .. ")
-	if err := buildClientBundles(result); err != nil {
-		fmt.Println("FAILED")
-		return fmt.Errorf("bundling error: %w", err)
+	status.begin("Client bundles")
+	phase = span.Start("client bundles")
+	err = buildClientBundles(result, cfg.Build.AssetBaseURL, phase)
+	phase.End()
+	if err != nil {
+		status.fail("", err)
+		return "", fmt.Errorf("bundling error: %w", err)
 	}
-	fmt.Println("done")
+	status.done("")
 
-	fmt.Print("  SSR bundles ..... ")
-	if err := buildSSRBundles(result); err != nil {
-		fmt.Println("FAILED")
-		return fmt.Errorf("SSR bundling error: %w", err)
+	status.begin("SSR bundles")
+	phase = span.Start("SSR bundles")
+	err = buildSSRBundles(result, cfg.Build.AssetBaseURL, phase)
+	phase.End()
+	if err != nil {
+		status.fail("", err)
+		return "", fmt.Errorf("SSR bundling error: %w", err)
 	}
-	fmt.Println("done")
+	status.done("")
 
-	if _, err := os.Stat("main.css"); err == nil {
-		fmt.Print("  CSS ............. ")
-		if err := buildCSS(); err != nil {
-			fmt.Println("FAILED")
-			return fmt.Errorf("css error: %w", err)
+	if cssEntry() != "" {
+		status.begin("CSS")
+		phase = span.Start("CSS")
+		err := buildCSS()
+		stopCSSWorker()
+		phase.End()
+		if err != nil {
+			status.fail("", err)
+			return "", fmt.Errorf("css error: %w", err)
 		}
-		fmt.Println("done")
+		status.done("")
+	}
+
+	status.begin("Compression")
+	phase = span.Start("compression")
+	err = compressStatic(result)
+	phase.End()
+	if err != nil {
+		status.fail("", err)
+		return "", fmt.Errorf("compression error: %w", err)
 	}
+	status.done("")
 
 	distDir := "dist"
+	status.begin("Bundle sizes")
+	phase = span.Start("bundle sizes")
+	sizes, err := measureBundleSizes(cfg, filepath.Join(distDir, "rstf", "manifest.json"))
+	if err == nil {
+		err = codegen.CheckBudgets(sizes)
+	}
+	phase.End()
+	if err != nil {
+		status.fail("", err)
+		return "", err
+	}
+	status.done("")
+
 	if err := os.RemoveAll(distDir); err != nil {
-		return fmt.Errorf("removing dist: %w", err)
+		return "", fmt.Errorf("removing dist: %w", err)
 	}
 	if err := os.MkdirAll(distDir, 0755); err != nil {
-		return fmt.Errorf("creating dist: %w", err)
+		return "", fmt.Errorf("creating dist: %w", err)
 	}
 
-	fmt.Print("  Dist layout ..... ")
+	status.begin("Dist layout")
+	phase = span.Start("dist layout")
 	if err := copyDir("rstf", filepath.Join(distDir, "rstf")); err != nil {
-		fmt.Println("FAILED")
-		return fmt.Errorf("copying generated assets: %w", err)
+		status.fail("", err)
+		return "", fmt.Errorf("copying generated assets: %w", err)
+	}
+	for dir := range result.Mounts {
+		if err := copyDir(filepath.Join(dir, "rstf"), filepath.Join(distDir, dir, "rstf")); err != nil {
+			status.fail("", err)
+			return "", fmt.Errorf("copying generated assets of %s: %w", dir, err)
+		}
+	}
+	if cfg.Build.AssetBaseURL != "" {
+		manifests := []string{filepath.Join(distDir, "rstf", "manifest.json")}
+		for dir := range result.Mounts {
+			manifests = append(manifests, filepath.Join(distDir, dir, "rstf", "manifest.json"))
+		}
+		for _, manifest := range manifests {
+			if err := codegen.RewriteManifestAssets(manifest, cfg.Build.AssetBaseURL); err != nil {
+				status.fail("", err)
+				return "", err
+			}
+		}
 	}
-	fmt.Println("done")
+	phase.End()
+	status.done("")
 
-	fmt.Print("  Go binary ....... ")
+	status.begin("Go binary")
+	phase = span.Start("go build")
 	outputPath := filepath.Join(distDir, appName)
-	build := exec.Command("go", "build", "-o", outputPath, "./rstf/server_gen.go")
+	buildArgs := []string{"build", "-o", outputPath}
+	if len(tags) > 0 {
+		buildArgs = append(buildArgs, "-tags", strings.Join(tags, ","))
+	}
+	var ldflags []string
+	if cfg.Build.InlineCriticalCSS {
+		ldflags = append(ldflags, "-X main.inlineCriticalCSS=true")
+	}
+	if cfg.Build.AssetBaseURL != "" {
+		ldflags = append(ldflags, "-X main.assetBaseURL="+cfg.Build.AssetBaseURL)
+	}
+	if len(ldflags) > 0 {
+		buildArgs = append(buildArgs, "-ldflags", strings.Join(ldflags, " "))
+	}
+	build := exec.Command("go", append(buildArgs, "./rstf/server_gen.go")...)
 	gotool.Prepare(build)
-	build.Stdout = os.Stdout
-	build.Stderr = os.Stderr
-	if err := build.Run(); err != nil {
-		fmt.Println("FAILED")
-		return fmt.Errorf("building server binary: %w", err)
+	// Compile errors go to the JSON event too, so editors can jump to them.
+	var compileErrors bytes.Buffer
+	build.Stdout = status.stdout()
+	build.Stderr = io.MultiWriter(os.Stderr, &compileErrors)
+	err = build.Run()
+	phase.End()
+	if err != nil {
+		status.fail("", fmt.Errorf("%w\n%s", err, compileErrors.String()))
+		return "", fmt.Errorf("building server binary: %w", err)
 	}
-	fmt.Printf("done (%s)\n", outputPath)
+	status.done(outputPath)
+	printProfile(status, span)
+	return appName, nil
+}
 
-	fmt.Println("\n  Build complete. Run `cd dist && ./" + appName + "`.")
-	return nil
+// printUnusedShared reports shared components whose SSR code no route or
+// layout reaches.
+func printUnusedShared(status *progress, dirs []string) {
+	if len(dirs) > 0 {
+		status.info("Unused shared", strings.Join(dirs, ", "))
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func newCleanCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove generated files and build output",
+		Long: "Remove rstf/ (and mounted apps' rstf/), the .rstf/ directory older versions generated, and dist/,\n" +
+			"which holds the compiled server binary. The next rstf dev or rstf build regenerates them.",
+		Example: "  rstf clean --dry-run\n" +
+			"  rstf clean",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClean(dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List what would be removed without removing it")
+
+	return cmd
+}
+
+func runClean(dryRun bool) error {
+	// dist/ and rstf/ are common names; only remove them from an app root.
+	if _, err := os.Stat("go.mod"); err != nil {
+		return fmt.Errorf("no go.mod in the current directory (run rstf clean from the app root)")
+	}
+
+	targets := []string{"rstf", ".rstf", "dist"}
+	for _, dir := range mountDirs() {
+		targets = append(targets, filepath.Join(dir, "rstf"))
+	}
+
+	removed := 0
+	for _, path := range targets {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		removed++
+		if dryRun {
+			fmt.Printf("  Would remove ..... %s\n", path)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+		fmt.Printf("  Removed .......... %s\n", path)
+	}
+	if removed == 0 {
+		fmt.Println("  Nothing to clean.")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/bundler"
+	"github.com/rafbgarcia/rstf/internal/codegen"
+)
+
+// brotliScript writes a Brotli variant of every file listed on stdin, one path
+// per line, at the highest quality. Go's standard library has no Brotli
+// encoder, and Node's zlib does. A variant that is not smaller is removed.
+const brotliScript = `const { readFileSync, writeFileSync, rmSync } = require("fs");
+const { brotliCompressSync, constants } = require("zlib");
+
+const files = readFileSync(0, "utf8").split("\n").filter(Boolean);
+for (const file of files) {
+  const data = readFileSync(file);
+  const compressed = brotliCompressSync(data, {
+    params: {
+      [constants.BROTLI_PARAM_QUALITY]: constants.BROTLI_MAX_QUALITY,
+      [constants.BROTLI_PARAM_SIZE_HINT]: data.length,
+    },
+  });
+  if (compressed.length < data.length) {
+    writeFileSync(file + ".br", compressed);
+  } else {
+    rmSync(file + ".br", { force: true });
+  }
+}
+`
+
+// compressStatic writes gzip and Brotli variants of the static assets of the
+// app and its mounted apps, for the server to send to clients that accept
+// them.
+func compressStatic(result codegen.GenerateResult) error {
+	dirs := []string{filepath.Join("rstf", "static")}
+	for dir := range result.Mounts {
+		dirs = append(dirs, filepath.Join(dir, "rstf", "static"))
+	}
+	var files []string
+	for _, dir := range dirs {
+		compressed, err := bundler.CompressStatic(dir)
+		if err != nil {
+			return err
+		}
+		files = append(files, compressed...)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command(nodeCommand(), "-e", brotliScript)
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("brotli: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
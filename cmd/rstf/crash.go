@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// crashTailLines is how many of a crashed server's last stderr lines
+	// are repeated under its exit status.
+	crashTailLines = 20
+	// crashBackoffMin is the wait before restarting a server that crashed
+	// once; each further quick crash doubles it, up to crashBackoffMax.
+	crashBackoffMin = 500 * time.Millisecond
+	crashBackoffMax = 8 * time.Second
+	// crashStableAfter is how long a server must run for its crash not to
+	// count as quick. A server that crashes later restarts right away.
+	crashStableAfter = 10 * time.Second
+	// crashLimit is how many quick crashes in a row stop the restarts until
+	// the next change.
+	crashLimit = 5
+)
+
+// handleCrash reports a server that exited without being stopped and
+// schedules its restart, backing off while it keeps crashing soon after
+// starting, so a server that cannot start does not restart in a loop. The
+// caller holds mu.
+func (s *appServer) handleCrash(launch int, ran time.Duration, err error) {
+	status := "exit status 0"
+	if err != nil {
+		status = err.Error()
+	}
+	s.status.warn("HTTP server", fmt.Sprintf("exited after %s (%s)", fmtDuration(ran), status))
+	s.status.output(s.stderr.Lines())
+
+	if ran >= crashStableAfter {
+		s.quickCrashes = 0
+	}
+	s.quickCrashes++
+	if s.quickCrashes >= crashLimit {
+		s.status.warn("HTTP server", fmt.Sprintf("crashed %d times in a row, waiting for a change", s.quickCrashes))
+		return
+	}
+	delay := restartDelay(s.quickCrashes, ran)
+	s.status.info("HTTP server", "restarting in "+fmtDuration(delay))
+	s.retry = time.AfterFunc(delay, func() { s.restartAfterCrash(launch) })
+}
+
+// restartDelay is the wait before restarting a server after its
+// quickCrashes-th quick crash in a row, or right away when it ran long
+// enough to count as stable.
+func restartDelay(quickCrashes int, ran time.Duration) time.Duration {
+	if ran >= crashStableAfter {
+		return 0
+	}
+	return min(crashBackoffMin<<(quickCrashes-1), crashBackoffMax)
+}
+
+// restartAfterCrash starts a new server in place of the one that crashed,
+// unless a change already stopped or replaced it.
+func (s *appServer) restartAfterCrash(launch int) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.mu.Lock()
+	current := s.retry != nil && s.launches == launch
+	s.retry = nil
+	s.mu.Unlock()
+	if !current {
+		return
+	}
+	s.terminate()
+	s.launch()
+}
+
+// cancelRetry drops a pending restart after a crash. The caller holds mu.
+func (s *appServer) cancelRetry() {
+	if s.retry != nil {
+		s.retry.Stop()
+		s.retry = nil
+	}
+}
+
+// lineTail is an io.Writer that keeps the last lines written to it.
+type lineTail struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial []byte // written after the last newline
+}
+
+func newLineTail(max int) *lineTail {
+	return &lineTail{max: max}
+}
+
+func (t *lineTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data := append(t.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		t.lines = append(t.lines, string(data[:i]))
+		data = data[i+1:]
+	}
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+	t.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Lines returns the kept lines, including an unterminated last line.
+func (t *lineTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := append([]string(nil), t.lines...)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+	return lines
+}
+
+// Reset drops everything written so far.
+func (t *lineTail) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines, t.partial = nil, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineTail_KeepsLastLines(t *testing.T) {
+	tail := newLineTail(2)
+	fmt.Fprint(tail, "one\ntw")
+	fmt.Fprint(tail, "o\nthree\nfou")
+
+	require.Equal(t, []string{"two", "three", "fou"}, tail.Lines(), "keeps max lines plus the unterminated one")
+
+	fmt.Fprint(tail, "r\n")
+	require.Equal(t, []string{"three", "four"}, tail.Lines())
+
+	tail.Reset()
+	require.Empty(t, tail.Lines())
+}
+
+func TestRestartDelay(t *testing.T) {
+	require.Equal(t, 500*time.Millisecond, restartDelay(1, time.Second))
+	require.Equal(t, time.Second, restartDelay(2, time.Second))
+	require.Equal(t, 4*time.Second, restartDelay(4, time.Second))
+	require.Equal(t, crashBackoffMax, restartDelay(10, time.Second), "capped")
+	require.Zero(t, restartDelay(1, crashStableAfter), "a stable server restarts right away")
+}
+
+func TestHandleCrash_StopsAfterQuickCrashes(t *testing.T) {
+	s := newAppServer("", nil, nil, newProgress(true))
+	s.launches = 1
+	crash := errors.New("exit status 1")
+
+	for i := 1; i < crashLimit; i++ {
+		s.handleCrash(0, time.Millisecond, crash)
+		require.NotNil(t, s.retry, "crash %d schedules a restart", i)
+		s.cancelRetry()
+	}
+	s.handleCrash(0, time.Millisecond, crash)
+	require.Nil(t, s.retry, "stops restarting after %d quick crashes", crashLimit)
+
+	s.handleCrash(0, crashStableAfter, crash)
+	require.Equal(t, 1, s.quickCrashes, "a stable run resets the count")
+	require.NotNil(t, s.retry)
+	s.cancelRetry()
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rafbgarcia/rstf/internal/config"
+)
+
+// cssEntries are the stylesheet entrypoints buildCSS looks for, in order,
+// when rstf.json sets no css.entry.
+var cssEntries = []string{"main.css", "main.scss", "main.sass"}
+
+// cssEntry returns the project's stylesheet entrypoint, or "" if there is none.
+// rstf.json is read on every call so edits apply on the next rebuild.
+func cssEntry() string {
+	if cfg, err := config.Load("."); err == nil && cfg.CSS.Entry != "" {
+		return cfg.CSS.Entry
+	}
+	for _, name := range cssEntries {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// buildCSS builds the stylesheet entrypoint into rstf/static/main.css. Sass
+// entrypoints are compiled with dart-sass, and the result runs through PostCSS
+// when a postcss.config.mjs is present. A plain .css entrypoint without PostCSS
+// is copied as-is.
+func buildCSS() error {
+	entry := cssEntry()
+	if entry == "" {
+		return nil // no CSS to build
+	}
+
+	outDir := filepath.Join("rstf", "static")
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", outDir, err)
+	}
+
+	outFile := filepath.Join(outDir, "main.css")
+
+	_, err := os.Stat("postcss.config.mjs")
+	usePostCSS := err == nil
+	if usePostCSS || filepath.Ext(entry) != ".css" {
+		return buildCSSWithWorker(cssBuildRequest{Entry: entry, Out: outFile, PostCSS: usePostCSS})
+	}
+
+	// No PostCSS config — copy the entrypoint as-is.
+	src, err := os.ReadFile(entry)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", entry, err)
+	}
+	if err := os.WriteFile(outFile, src, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", outFile, err)
+	}
+	return nil
+}
+
+// cssWorkerScript serves build requests, one JSON object per line on stdin,
+// and answers each with one JSON line on stdout. Sass and PostCSS plugins are
+// loaded once; postcss.config.mjs is re-imported only when it changes.
+const cssWorkerScript = `import { readFileSync, writeFileSync, mkdirSync, statSync } from "fs";
+import { dirname, resolve } from "path";
+import { pathToFileURL } from "url";
+import { createInterface } from "readline";
+
+// stdout carries the protocol; keep plugin logging off it.
+console.log = console.error;
+console.info = console.error;
+
+let sass = null;
+let plugins = null;
+let configMtime = 0;
+
+async function loadSass(entry) {
+  if (!sass) {
+    const mod = await import("sass").catch(() => {
+      throw new Error("Compiling " + entry + " requires the sass package: npm install -D sass");
+    });
+    sass = mod.default || mod;
+  }
+  return sass;
+}
+
+async function loadPlugins() {
+  const configPath = resolve("postcss.config.mjs");
+  const mtime = statSync(configPath).mtimeMs;
+  if (plugins && mtime === configMtime) return plugins;
+  const { default: config } = await import(pathToFileURL(configPath).href + "?t=" + mtime);
+  plugins = await Promise.all(
+    Object.entries(config.plugins || {}).map(async ([name, opts]) => {
+      const mod = await import(name);
+      return (mod.default || mod)(typeof opts === "object" ? opts : {});
+    })
+  );
+  configMtime = mtime;
+  return plugins;
+}
+
+async function build(req) {
+  const entry = resolve(req.entry);
+  const out = resolve(req.out);
+
+  let css;
+  if (/\.s[ac]ss$/.test(entry)) {
+    css = (await loadSass(req.entry)).compile(entry, {
+      loadPaths: [resolve("node_modules")],
+    }).css;
+  } else {
+    css = readFileSync(entry, "utf8");
+  }
+
+  if (req.postcss) {
+    const { default: postcss } = await import("postcss");
+    const result = await postcss(await loadPlugins()).process(css, { from: entry, to: out });
+    css = result.css;
+  }
+
+  mkdirSync(dirname(out), { recursive: true });
+  writeFileSync(out, css);
+}
+
+for await (const line of createInterface({ input: process.stdin })) {
+  let reply;
+  try {
+    await build(JSON.parse(line));
+    reply = { ok: true };
+  } catch (err) {
+    reply = { error: String((err && err.message) || err) };
+  }
+  process.stdout.write(JSON.stringify(reply) + "\n");
+}
+`
+
+type cssBuildRequest struct {
+	Entry   string `json:"entry"`
+	Out     string `json:"out"`
+	PostCSS bool   `json:"postcss"`
+}
+
+type cssBuildResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// cssWorker is a long-lived node process that builds stylesheets on request,
+// so rebuilds skip node startup and reloading Sass and PostCSS plugins.
+type cssWorker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// cssWorkerStderr receives what the CSS worker writes to stderr, such as
+// plugin warnings. rstf dev only shows it with --verbose.
+var cssWorkerStderr io.Writer = os.Stderr
+
+// activeCSSWorker is started by the first build that needs node and reused
+// until stopCSSWorker. Builds run from a single goroutine.
+var activeCSSWorker *cssWorker
+
+func buildCSSWithWorker(req cssBuildRequest) error {
+	if activeCSSWorker == nil {
+		w, err := startCSSWorker()
+		if err != nil {
+			return err
+		}
+		activeCSSWorker = w
+	}
+	if err := activeCSSWorker.build(req); err != nil {
+		var buildErr cssBuildError
+		if !errors.As(err, &buildErr) {
+			// The worker died or broke protocol; start a fresh one next time.
+			stopCSSWorker()
+		}
+		return err
+	}
+	return nil
+}
+
+// stopCSSWorker shuts down the CSS worker, if one is running.
+func stopCSSWorker() {
+	if activeCSSWorker == nil {
+		return
+	}
+	activeCSSWorker.stdin.Close()
+	activeCSSWorker.cmd.Wait()
+	activeCSSWorker = nil
+}
+
+// nodeCommand is the node binary the build runs: css.node from rstf.json, or
+// node from PATH.
+func nodeCommand() string {
+	if cfg, err := config.Load("."); err == nil && cfg.CSS.Node != "" {
+		return cfg.CSS.Node
+	}
+	return "node"
+}
+
+func startCSSWorker() (*cssWorker, error) {
+	scriptPath := filepath.Join("rstf", "css-worker.mjs")
+	if err := os.WriteFile(scriptPath, []byte(cssWorkerScript), 0644); err != nil {
+		return nil, fmt.Errorf("writing css-worker.mjs: %w", err)
+	}
+
+	cmd := exec.Command(nodeCommand(), scriptPath)
+	cmd.Stderr = cssWorkerStderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("starting css worker: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("starting css worker: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting css worker: %w", err)
+	}
+	return &cssWorker{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// cssBuildError is a build failure reported by a healthy worker, such as a
+// Sass syntax error.
+type cssBuildError string
+
+func (e cssBuildError) Error() string { return string(e) }
+
+func (w *cssWorker) build(req cssBuildRequest) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := w.stdin.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("css worker: %w", err)
+	}
+	line, err := w.stdout.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("css worker exited: %w", err)
+	}
+	var resp cssBuildResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("css worker: invalid response %q", line)
+	}
+	if !resp.OK {
+		return cssBuildError(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/gotool"
+	"github.com/spf13/cobra"
+)
+
+func newDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the app database",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "seed",
+		Short: "Run the seeds in seeds/ against the app database",
+		Long: "Run the .sql and .go files in seeds/ in file name order, each in its own transaction.\n" +
+			"The database is the one OnServerStart configures in main.go. A Go seed is an exported\n" +
+			"func(context.Context, *sql.Tx) error; a file may declare several.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSeed()
+		},
+	})
+
+	return cmd
+}
+
+func runSeed() error {
+	fmt.Print("  Codegen ......... ")
+	result, err := codegen.Generate(".")
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("codegen error: %w", err)
+	}
+	fmt.Printf("done (%d routes)\n", result.RouteCount)
+
+	fmt.Println("  Seeding .........")
+	seed := exec.Command("go", "run", "./rstf/server_gen.go", "--seed")
+	gotool.Prepare(seed)
+	seed.Stdout = os.Stdout
+	seed.Stderr = os.Stderr
+	if err := seed.Run(); err != nil {
+		return fmt.Errorf("seeding: %w", err)
+	}
+	fmt.Println("\n  Seeds applied.")
+	return nil
+}
@@ -2,16 +2,26 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/rafbgarcia/rstf"
 	"github.com/rafbgarcia/rstf/internal/bundler"
 	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/config"
 	"github.com/rafbgarcia/rstf/internal/gotool"
+	"github.com/rafbgarcia/rstf/internal/profile"
 	"github.com/rafbgarcia/rstf/internal/watcher"
 	"github.com/spf13/cobra"
 )
@@ -20,69 +30,147 @@ func newDevCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "dev",
 		Short: "Start the development server",
+		Long: "Run codegen, bundle every route, build CSS, and start the app server behind the dev listener,\n" +
+			"then watch Go, TSX, and CSS sources and rebuild and restart the server on change.",
+		Example: "  rstf dev\n" +
+			"  rstf dev --port 8080 --profile\n" +
+			"  rstf dev --host 127.0.0.1\n" +
+			"  rstf dev --tags debug\n" +
+			"  rstf dev --open\n" +
+			"  rstf dev --json\n" +
+			"  rstf dev --verbose",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			port, _ := cmd.Flags().GetString("port")
-			return runDev(port)
+			port, err := portFlag(cmd)
+			if err != nil {
+				return err
+			}
+			host, _ := cmd.Flags().GetString("host")
+			tags, _ := cmd.Flags().GetStringSlice("tags")
+			profiling, _ := cmd.Flags().GetBool("profile")
+			open, _ := cmd.Flags().GetBool("open")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			level := normal
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				level = verbose
+			}
+			if q, _ := cmd.Flags().GetBool("quiet"); q {
+				level = quiet
+			}
+			return runDev(host, port, tags, profiling, open, jsonOutput, level)
 		},
 	}
 
-	cmd.Flags().String("port", "3000", "HTTP server port")
+	cmd.Flags().String("port", "3000", "HTTP server port, overriding port in rstf.json; the next free one is used when it is taken, 0 picks a random one")
+	cmd.Flags().String("host", "", "Address to bind, e.g. 127.0.0.1 (default: all interfaces)")
+	cmd.Flags().StringSlice("tags", nil, "Go build tags for codegen and the server, e.g. debug")
+	cmd.Flags().Bool("profile", false, "Report how long each build phase takes, per route, after every rebuild")
+	cmd.Flags().Bool("open", false, "Open the app in the default browser once the server is up")
+	cmd.Flags().Bool("json", false, "Print progress as JSON events, one per line, instead of status lines")
+	cmd.Flags().Bool("verbose", false, "Also print codegen decisions, watcher events, and the CSS worker's stderr")
+	cmd.Flags().Bool("quiet", false, "Only print failures, server crashes, and unresolved errors")
+	cmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 	return cmd
 }
 
-func runDev(port string) error {
+func runDev(host, port string, tags []string, profiling, open, jsonOutput bool, level verbosity) error {
 	// Step 1: Create generator and run initial codegen.
 	gen, err := codegen.NewGenerator(".")
 	if err != nil {
 		return fmt.Errorf("codegen init error: %w", err)
 	}
+	gen.SetBuildTags(tags)
+	span := startProfile(profiling, "dev")
+	status := newProgress(jsonOutput)
+	status.verbosity = level
+	gen.SetTrace(func(msg string) { status.debug("Codegen", msg) })
+	cssWorkerStderr = status.sidecar()
+
+	// The dev listener comes up before the first build, so the browser gets
+	// a building page instead of a refused connection. It serves static
+	// assets itself and proxies the rest to the Go server on an internal port.
+	childAddr, childListener, err := listenChild()
+	if err != nil {
+		return err
+	}
+	defer childListener.Close()
+	server := newAppServer(childAddr, childListener, tags, status)
 
-	fmt.Print("  Codegen ......... ")
-	t := time.Now()
+	ln, err := listenDev(host, port)
+	if err != nil {
+		return err
+	}
+	url := devURL(host, ln.Addr())
+	if got := fallbackPort(port, ln.Addr()); got != "" {
+		status.info("Port", fmt.Sprintf("%s is in use, using %s", port, got))
+	}
+	ready := make(chan struct{})
+	devServer := &http.Server{Handler: newDevHandler(childAddr, server.exited, ready)}
+	go devServer.Serve(ln)
+	defer devServer.Close()
+
+	status.begin("Codegen")
+	phase := span.Start("codegen")
+	gen.SetProfile(phase)
 	result, err := gen.Generate()
+	phase.End()
 	if err != nil {
-		fmt.Println("FAILED")
+		status.fail("", err)
 		return fmt.Errorf("codegen error: %w", err)
 	}
-	fmt.Printf("done (%d routes) [%s]\n", result.RouteCount, fmtDuration(time.Since(t)))
+	status.done(fmt.Sprintf("%d routes", result.RouteCount))
+	printUnusedShared(status, result.AllUnusedShared())
 
 	// Step 2: Bundle client JS for each route.
-	fmt.Print("  Client bundles .. ")
-	t = time.Now()
-	if err := buildClientBundles(result); err != nil {
-		fmt.Println("FAILED")
+	status.begin("Client bundles")
+	phase = span.Start("client bundles")
+	err = buildClientBundles(result, "", phase)
+	phase.End()
+	if err != nil {
+		status.fail("", err)
 		return fmt.Errorf("bundling error: %w", err)
 	}
-	fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
+	status.done(clientBundleSize().String() + " JS")
 
-	fmt.Print("  SSR bundles ..... ")
-	t = time.Now()
-	if err := buildSSRBundles(result); err != nil {
-		fmt.Println("FAILED")
+	status.begin("SSR bundles")
+	phase = span.Start("SSR bundles")
+	err = buildSSRBundles(result, "", phase)
+	phase.End()
+	if err != nil {
+		status.fail("", err)
 		return fmt.Errorf("SSR bundling error: %w", err)
 	}
-	fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
-
-	// Step 3: Build CSS (if main.css exists).
-	if _, err := os.Stat("main.css"); err == nil {
-		fmt.Print("  CSS ............. ")
-		t = time.Now()
-		if err := buildCSS(); err != nil {
-			fmt.Println("FAILED")
+	status.done("")
+
+	// Step 3: Build CSS (if a stylesheet entrypoint exists).
+	if cssEntry() != "" {
+		status.begin("CSS")
+		phase = span.Start("CSS")
+		err := buildCSS()
+		phase.End()
+		if err != nil {
+			status.fail("", err)
 			return fmt.Errorf("css error: %w", err)
 		}
-		fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
+		status.done("")
+	}
+	printProfile(status, span)
+
+	// Step 4: Start the Go HTTP server. Requests that arrive while it
+	// compiles wait in its listener's accept backlog.
+	status.info("HTTP server", "starting on "+url)
+	server.start()
+	close(ready)
+	if open {
+		go openWhenReady(status, url, childAddr, server.exited())
 	}
-
-	// Step 4: Start the Go HTTP server.
-	fmt.Printf("  HTTP server ..... starting on :%s\n", port)
-	server := startServer(port)
 
 	// Step 5: Start file watcher.
-	fmt.Println("\n  Watching for changes...")
+	status.message("Watching for changes...")
 
 	eventCh := make(chan []watcher.Event, 100)
 	w := watcher.New(".", func(batch []watcher.Event) { eventCh <- batch })
+	w.SetIgnore(gen.Ignored)
 	if err := w.Start(); err != nil {
 		return fmt.Errorf("watcher error: %w", err)
 	}
@@ -109,29 +197,33 @@ func runDev(port string) error {
 
 			// Print changed files.
 			for _, ev := range batch {
-				fmt.Printf("\n  [change] %s\n", ev.Path)
+				status.change(ev.Path)
+				status.debug("Watcher", fmt.Sprintf("%s (%s)", ev.Op, ev.Kind))
 			}
 
 			if hasGo || hasTsx {
-				server = handleCodeChange(gen, server, &result, port, batch, hasGo)
+				handleCodeChange(gen, server, status, &result, batch, hasGo, profiling)
 			}
 			if hasCss {
-				handleCssChange()
+				handleCssChange(status)
 			}
+			status.summary()
 
 		case <-sigCh:
 			w.Stop()
-			stopServer(server)
+			server.stop()
+			stopCSSWorker()
 			return nil
 		}
 	}
 }
 
 // handleCodeChange runs incremental codegen, re-bundles, and restarts the
-// server if Go files changed or the server_gen.go content changed.
-func handleCodeChange(gen *codegen.Generator, server *exec.Cmd, result *codegen.GenerateResult, port string, batch []watcher.Event, hasGo bool) *exec.Cmd {
+// server if Go files changed or the server_gen.go content changed. With
+// profiling on, it reports how long each phase of the rebuild took.
+func handleCodeChange(gen *codegen.Generator, server *appServer, status *progress, result *codegen.GenerateResult, batch []watcher.Event, hasGo, profiling bool) {
 	if hasGo {
-		stopServer(server)
+		server.stop()
 	}
 
 	// Convert watcher events to codegen change events.
@@ -142,138 +234,326 @@ func handleCodeChange(gen *codegen.Generator, server *exec.Cmd, result *codegen.
 		}
 	}
 
-	fmt.Print("  Codegen ......... ")
-	t := time.Now()
+	span := startProfile(profiling, "rebuild")
+	status.begin("Codegen")
+	phase := span.Start("codegen")
+	gen.SetProfile(phase)
 	regenResult, err := gen.Regenerate(events)
+	phase.End()
 	if err != nil {
-		fmt.Println("FAILED")
-		fmt.Fprintf(os.Stderr, "  codegen error: %s\n", err)
+		status.failAndPrint("", err)
 		if hasGo {
-			fmt.Printf("  HTTP server ..... restarting on :%s\n", port)
-			return startServer(port)
+			status.note("HTTP server", "restarting")
+			server.start()
 		}
-		return server
+		return
+	}
+	status.done(fmt.Sprintf("%d routes", regenResult.RouteCount))
+	if unused := regenResult.AllUnusedShared(); !slices.Equal(unused, result.AllUnusedShared()) {
+		printUnusedShared(status, unused)
 	}
-	fmt.Printf("done (%d routes) [%s]\n", regenResult.RouteCount, fmtDuration(time.Since(t)))
 
-	fmt.Print("  Client bundles .. ")
-	t = time.Now()
-	if err := buildClientBundles(regenResult.GenerateResult); err != nil {
-		fmt.Println("FAILED")
-		fmt.Fprintf(os.Stderr, "  bundling error: %s\n", err)
+	stylesBefore := routeStylesheets()
+	status.begin("Client bundles")
+	phase = span.Start("client bundles")
+	err = buildClientBundles(regenResult.GenerateResult, "", phase)
+	phase.End()
+	if err != nil {
+		status.failAndPrint("", err)
 	} else {
-		fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
+		status.done(clientBundleSize().String() + " JS")
 	}
 
-	fmt.Print("  SSR bundles ..... ")
-	t = time.Now()
-	if err := buildSSRBundles(regenResult.GenerateResult); err != nil {
-		fmt.Println("FAILED")
-		fmt.Fprintf(os.Stderr, "  SSR bundling error: %s\n", err)
+	status.begin("SSR bundles")
+	phase = span.Start("SSR bundles")
+	err = buildSSRBundles(regenResult.GenerateResult, "", phase)
+	phase.End()
+	if err != nil {
+		status.failAndPrint("", err)
 	} else {
-		fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
+		status.done("")
 	}
 
-	if err := buildCSS(); err != nil {
-		fmt.Fprintf(os.Stderr, "  css error: %s\n", err)
+	phase = span.Start("CSS")
+	err = buildCSS()
+	phase.End()
+	if err != nil {
+		status.failAndPrint("CSS", err)
+	} else {
+		status.clear("CSS")
 	}
+	printProfile(status, span)
 
 	*result = regenResult.GenerateResult
 
-	if hasGo || regenResult.ServerChanged {
-		fmt.Printf("  HTTP server ..... restarting on :%s\n", port)
-		return startServer(port)
+	// The server reads which routes have stylesheets at startup.
+	stylesChanged := !slices.Equal(stylesBefore, routeStylesheets())
+
+	if hasGo || regenResult.ServerChanged || stylesChanged {
+		if !hasGo {
+			server.stop()
+		}
+		status.note("HTTP server", "restarting")
+		server.start()
+	}
+}
+
+// routeStylesheets lists the per-route stylesheets esbuild extracted,
+// including those of mounted apps.
+func routeStylesheets() []string {
+	paths, _ := filepath.Glob(filepath.Join("rstf", "static", "*", "bundle.css"))
+	for _, dir := range mountDirs() {
+		mounted, _ := filepath.Glob(filepath.Join(dir, "rstf", "static", "*", "bundle.css"))
+		paths = append(paths, mounted...)
 	}
+	return paths
+}
 
-	return server
+// mountDirs lists the directories of the apps mounted through rstf.json.
+func mountDirs() []string {
+	cfg, err := config.Load(".")
+	if err != nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(cfg.Mounts))
+	for _, m := range cfg.Mounts {
+		dirs = append(dirs, m.Dir)
+	}
+	return dirs
 }
 
 // handleCssChange rebuilds CSS. No JS rebundle or sidecar invalidation needed
 // since CSS is served statically via FileServer.
-func handleCssChange() {
-	fmt.Print("  CSS ............. ")
-	t := time.Now()
+func handleCssChange(status *progress) {
+	status.begin("CSS")
 	if err := buildCSS(); err != nil {
-		fmt.Println("FAILED")
-		fmt.Fprintf(os.Stderr, "  css error: %s\n", err)
+		status.failAndPrint("", err)
 		return
 	}
-	fmt.Printf("done [%s]\n", fmtDuration(time.Since(t)))
+	status.done("")
 }
 
-// startServer launches the generated Go server as a child process.
-// The process is placed in its own process group so stopServer can kill
-// both `go run` and the child binary it spawns.
-func startServer(port string) *exec.Cmd {
-	cmd := exec.Command("go", "run", "./rstf/server_gen.go", "--port", port)
+// appServer runs the generated Go server as a child process. Every process it
+// starts accepts on the same listener, which the dev process owns, so a
+// restart never refuses a connection: requests wait in the accept backlog
+// until the new server picks them up.
+type appServer struct {
+	addr     string
+	listener *os.File
+	tags     []string // Go build tags the server is compiled with
+	status   *progress
+	stderr   *lineTail // the current process's last stderr lines
+
+	// runMu serializes start, stop, and restarts after a crash, so at most
+	// one process accepts on the listener.
+	runMu sync.Mutex
+
+	mu           sync.Mutex
+	cmd          *exec.Cmd
+	done         chan struct{} // closed when cmd's process group is gone
+	crashed      chan struct{} // closed when cmd exits without being stopped
+	stopping     bool
+	launches     int         // processes started, identifies the current one
+	quickCrashes int         // crashes in a row, each soon after its start
+	retry        *time.Timer // pending restart after a crash, nil when none
+}
+
+func newAppServer(addr string, listener *os.File, tags []string, status *progress) *appServer {
+	return &appServer{
+		addr:     addr,
+		listener: listener,
+		tags:     tags,
+		status:   status,
+		stderr:   newLineTail(crashTailLines),
+		crashed:  make(chan struct{}),
+	}
+}
+
+// start launches the server after a change. It cancels a pending restart
+// and forgets earlier crashes, since the change may have fixed them.
+func (s *appServer) start() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.mu.Lock()
+	s.cancelRetry()
+	s.quickCrashes = 0
+	s.mu.Unlock()
+	s.launch()
+}
+
+// launch starts the server on the inherited listener (fd 3). The process is
+// placed in its own process group so stop can signal both `go run` and the
+// child binary it spawns. The caller holds runMu.
+func (s *appServer) launch() {
+	_, port, _ := net.SplitHostPort(s.addr)
+	args := []string{"run"}
+	if len(s.tags) > 0 {
+		args = append(args, "-tags", strings.Join(s.tags, ","))
+	}
+	cmd := exec.Command("go", append(args, "./rstf/server_gen.go", "--port", port)...)
+	s.status.debug("HTTP server", strings.Join(cmd.Args, " "))
 	gotool.Prepare(cmd)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Env = append(cmd.Env, rstf.ListenFDEnv+"=3", rstf.DevModeEnv+"=1")
+	if s.status.verbosity >= verbose {
+		cmd.Env = append(cmd.Env, rstf.RenderTimingEnv+"=1")
+	}
+	cmd.ExtraFiles = []*os.File{s.listener}
+	cmd.Stdout = s.status.stdout()
+	s.stderr.Reset()
+	cmd.Stderr = io.MultiWriter(os.Stderr, s.stderr)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	if err := cmd.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to start server: %s\n", err)
 		os.Exit(1)
 	}
-	return cmd
+
+	started := time.Now()
+	done := make(chan struct{})
+	crashed := make(chan struct{})
+	s.mu.Lock()
+	s.cmd, s.done, s.crashed, s.stopping = cmd, done, crashed, false
+	s.launches++
+	launch := s.launches
+	s.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		s.mu.Lock()
+		if !s.stopping {
+			close(crashed)
+			s.handleCrash(launch, time.Since(started), err)
+		}
+		s.mu.Unlock()
+		close(done)
+	}()
 }
 
-func buildClientBundles(result codegen.GenerateResult) error {
-	return bundler.BundleEntries(".", result.Entries)
+// exited returns a channel that closes if the current server exits on its
+// own, such as a crash or a compile error.
+func (s *appServer) exited() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.crashed
 }
 
-func buildSSRBundles(result codegen.GenerateResult) error {
-	return bundler.BundleSSREntries(".", result.SSREntries)
+// stopTimeout bounds the graceful shutdown in stop. It is longer than the
+// generated server's own shutdown timeout.
+const stopTimeout = 15 * time.Second
+
+// stop asks the server to shut down gracefully with SIGTERM, so in-flight
+// requests finish, and kills it if it has not exited within stopTimeout. A
+// pending restart after a crash is cancelled.
+func (s *appServer) stop() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.mu.Lock()
+	s.cancelRetry()
+	s.mu.Unlock()
+	s.terminate()
 }
 
-// stopServer kills the server's entire process group (go run + child binary),
-// then waits for the process to exit.
-func stopServer(cmd *exec.Cmd) {
-	if cmd == nil || cmd.Process == nil {
+// terminate stops the current process, if any. The caller holds runMu.
+func (s *appServer) terminate() {
+	s.mu.Lock()
+	cmd, done := s.cmd, s.done
+	if cmd == nil {
+		s.mu.Unlock()
 		return
 	}
-	// Kill the entire process group: negative PID targets the group.
-	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-	cmd.Wait()
+	s.cmd = nil
+	s.stopping = true
+	s.mu.Unlock()
+
+	// A negative PID targets the whole process group.
+	pgid := -cmd.Process.Pid
+	syscall.Kill(pgid, syscall.SIGTERM)
+	<-done
+
+	// `go run` exits on SIGTERM without waiting for the binary it started,
+	// so wait for the rest of the group to drain before the next server
+	// starts accepting on the shared listener.
+	deadline := time.Now().Add(stopTimeout)
+	for syscall.Kill(pgid, 0) == nil {
+		if time.Now().After(deadline) {
+			syscall.Kill(pgid, syscall.SIGKILL)
+			deadline = time.Now().Add(stopTimeout)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
 }
 
-// fmtDuration formats a duration as a human-friendly string (e.g. "12ms", "1.3s").
-func fmtDuration(d time.Duration) string {
-	if d < time.Second {
-		return fmt.Sprintf("%dms", d.Milliseconds())
+// buildClientBundles bundles the project's entries, and each mounted app's
+// into that app's own rstf/static. Imported assets are linked from
+// assetBaseURL, which is empty in dev. Each mounted app is timed under span.
+func buildClientBundles(result codegen.GenerateResult, assetBaseURL string, span *profile.Span) error {
+	opts, err := bundlerOptions()
+	if err != nil {
+		return err
 	}
-	return fmt.Sprintf("%.1fs", d.Seconds())
+	opts.AssetBaseURL = assetBaseURL
+	if err := bundler.BundleEntries(".", result.Entries, opts); err != nil {
+		return err
+	}
+	for dir, mount := range result.Mounts {
+		mountSpan := span.Start(dir)
+		err := bundler.BundleEntries(dir, mount.Entries, opts)
+		mountSpan.End()
+		if err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+	}
+	return nil
 }
 
-// buildCSS processes main.css if it exists. If a postcss.config.mjs is present,
-// it runs PostCSS via a generated build script. Otherwise, it copies main.css
-// directly to the static output directory.
-func buildCSS() error {
-	if _, err := os.Stat("main.css"); os.IsNotExist(err) {
-		return nil // no CSS to build
+func buildSSRBundles(result codegen.GenerateResult, assetBaseURL string, span *profile.Span) error {
+	opts, err := bundlerOptions()
+	if err != nil {
+		return err
 	}
-
-	outDir := filepath.Join("rstf", "static")
-	if err := os.MkdirAll(outDir, 0755); err != nil {
-		return fmt.Errorf("creating %s: %w", outDir, err)
+	opts.AssetBaseURL = assetBaseURL
+	if err := bundler.BundleSSREntries(".", result.SSREntries, opts); err != nil {
+		return err
 	}
+	for dir, mount := range result.Mounts {
+		mountSpan := span.Start(dir)
+		err := bundler.BundleSSREntries(dir, mount.SSREntries, opts)
+		mountSpan.End()
+		if err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+	}
+	return nil
+}
 
-	outFile := filepath.Join(outDir, "main.css")
-
-	// If a PostCSS config exists, run PostCSS via a build script.
-	if _, err := os.Stat("postcss.config.mjs"); err == nil {
-		return buildCSSWithPostCSS(outFile)
+// portFlag returns --port, or when it is not given the port set in rstf.json.
+func portFlag(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("port") {
+		return cmd.Flags().GetString("port")
+	}
+	cfg, err := config.Load(".")
+	if err != nil {
+		return "", err
 	}
+	return strconv.Itoa(cfg.PortOrDefault()), nil
+}
 
-	// No PostCSS config — copy main.css as-is.
-	src, err := os.ReadFile("main.css")
+// bundlerOptions reads rstf.json on every build so edits apply on the next
+// rebundle without restarting dev.
+func bundlerOptions() (bundler.Options, error) {
+	cfg, err := config.Load(".")
 	if err != nil {
-		return fmt.Errorf("reading main.css: %w", err)
+		return bundler.Options{}, err
 	}
-	if err := os.WriteFile(outFile, src, 0644); err != nil {
-		return fmt.Errorf("writing %s: %w", outFile, err)
+	return bundler.NewOptions(cfg.Bundler)
+}
+
+// fmtDuration formats a duration as a human-friendly string (e.g. "12ms", "1.3s").
+func fmtDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
 	}
-	return nil
+	return fmt.Sprintf("%.1fs", d.Seconds())
 }
 
 func currentAppName() (string, error) {
@@ -287,43 +567,3 @@ func currentAppName() (string, error) {
 	}
 	return name, nil
 }
-
-// buildCSSWithPostCSS writes a small build script to rstf/ and runs it with
-// node. The script loads the user's postcss.config.mjs and processes main.css.
-func buildCSSWithPostCSS(outFile string) error {
-	script := `import { readFileSync, writeFileSync, mkdirSync } from "fs";
-import { resolve } from "path";
-import { pathToFileURL } from "url";
-import postcss from "postcss";
-
-const configPath = resolve("postcss.config.mjs");
-const { default: config } = await import(pathToFileURL(configPath).href);
-
-const plugins = await Promise.all(
-  Object.entries(config.plugins || {}).map(async ([name, opts]) => {
-    const mod = await import(name);
-    return (mod.default || mod)(typeof opts === "object" ? opts : {});
-  })
-);
-
-const css = readFileSync(resolve("main.css"), "utf8");
-const result = await postcss(plugins).process(css, {
-  from: resolve("main.css"),
-  to: resolve("` + outFile + `"),
-});
-
-mkdirSync(resolve("rstf/static"), { recursive: true });
-writeFileSync(resolve("` + outFile + `"), result.css);
-`
-	scriptPath := filepath.Join("rstf", "build-css.mjs")
-	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
-		return fmt.Errorf("writing build-css.mjs: %w", err)
-	}
-
-	cmd := exec.Command("node", scriptPath)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("postcss processing: %w", err)
-	}
-	return nil
-}
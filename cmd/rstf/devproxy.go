@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	rstf "github.com/rafbgarcia/rstf"
+)
+
+// errAppServerExited fails proxied requests when the generated server dies
+// on its own. The dev process keeps the server's listener open, so without it
+// those requests would wait for the next restart.
+var errAppServerExited = errors.New("app server exited")
+
+// devReadyPath answers once the first build is done. The building page polls
+// it to know when to reload.
+const devReadyPath = "/__rstf/dev/ready"
+
+// buildingPage is served to page loads that arrive before the first build is
+// done. It reloads itself once the app is ready.
+const buildingPage = `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Building…</title>
+<style>body{margin:0;height:100vh;display:grid;place-items:center;font:15px system-ui,sans-serif;color:#555}</style>
+</head>
+<body>
+<p>rstf dev is building the app…</p>
+<script>
+(function poll() {
+  fetch("` + devReadyPath + `").then(
+    function (res) { res.ok ? location.reload() : setTimeout(poll, 500); },
+    function () { setTimeout(poll, 500); }
+  );
+})();
+</script>
+</body>
+</html>
+`
+
+// newDevHandler serves rstf/static from the dev process itself and proxies
+// everything else to the generated server listening on childAddr. Assets stay
+// reachable while the child rebuilds or crashes, and requests that arrive
+// during a restart wait for it instead of failing. exited reports the channel
+// that closes if the current server exits without being stopped. Until ready
+// closes, page loads get buildingPage and other requests wait.
+func newDevHandler(childAddr string, exited func() <-chan struct{}, ready <-chan struct{}) http.Handler {
+	mime.AddExtensionType(".wasm", "application/wasm")
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: childAddr})
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		// The app server sees every connection come from this process, so
+		// its IP filters need the client's address passed on.
+		req.Header.Set(rstf.DevClientAddrHeader, req.RemoteAddr)
+	}
+	proxy.Transport = &childTransport{base: http.DefaultTransport, exited: exited}
+	// Flush immediately so live query streams (/__rstf/live) are not buffered.
+	proxy.FlushInterval = -1
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("rstf dev: app server unavailable: %s", err), http.StatusBadGateway)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/rstf/static/", http.StripPrefix("/rstf/static/", http.FileServer(http.Dir("rstf/static"))))
+	mux.HandleFunc(devReadyPath, func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-ready:
+			w.WriteHeader(http.StatusNoContent)
+		case <-req.Context().Done():
+		}
+	})
+	mux.Handle("/", waitReady(ready, proxy))
+	return mux
+}
+
+// waitReady holds requests until ready closes. Page loads don't wait: they
+// get buildingPage, so the browser shows progress instead of spinning.
+func waitReady(ready <-chan struct{}, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-ready:
+		default:
+			if req.Method == http.MethodGet && strings.Contains(req.Header.Get("Accept"), "text/html") {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Header().Set("Cache-Control", "no-store")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				io.WriteString(w, buildingPage)
+				return
+			}
+			select {
+			case <-ready:
+			case <-req.Context().Done():
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// childTransport cancels proxied requests, including open response streams,
+// when the generated server exits on its own.
+type childTransport struct {
+	base   http.RoundTripper
+	exited func() <-chan struct{}
+}
+
+func (t *childTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exited := t.exited()
+	ctx, cancel := context.WithCancelCause(req.Context())
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-exited:
+			cancel(errAppServerExited)
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stop)
+			cancel(nil)
+		})
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cause := context.Cause(ctx)
+		release()
+		if errors.Is(cause, errAppServerExited) {
+			return nil, errAppServerExited
+		}
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody ends a proxied request's exit watch when its body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// devPortAttempts is how many ports listenDev tries, starting at the
+// requested one, before giving up.
+const devPortAttempts = 20
+
+// listenDev opens the dev listener on host and port. When the port is taken
+// it tries the ports after it, so a second project or a leftover server does
+// not stop rstf dev from starting. Port 0 picks a random free port.
+func listenDev(host, port string) (net.Listener, error) {
+	first, err := strconv.Atoi(port)
+	if err != nil || first < 0 || first > 65535 {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+	for p := first; ; p++ {
+		addr := net.JoinHostPort(host, strconv.Itoa(p))
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			return ln, nil
+		}
+		if first == 0 || !errors.Is(err, syscall.EADDRINUSE) || p-first+1 >= devPortAttempts || p == 65535 {
+			return nil, fmt.Errorf("listening on %s: %w", addr, err)
+		}
+	}
+}
+
+// fallbackPort is the port the dev listener at addr took in place of the
+// requested port, or "" when it got the port asked for.
+func fallbackPort(port string, addr net.Addr) string {
+	_, got, _ := net.SplitHostPort(addr.String())
+	if port == "0" || got == port {
+		return ""
+	}
+	return got
+}
+
+// devURL is the address to open the dev listener at in a browser.
+func devURL(host string, addr net.Addr) string {
+	_, port, _ := net.SplitHostPort(addr.String())
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// listenChild opens the generated server's listening socket in the dev
+// process and returns it as a file for startServer to hand down. The socket
+// outlives every server process, so connections made while the server
+// restarts queue in the accept backlog instead of being refused.
+func listenChild() (addr string, f *os.File, err error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	defer ln.Close()
+	f, err = ln.(*net.TCPListener).File()
+	if err != nil {
+		return "", nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	return ln.Addr().String(), f, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	rstf "github.com/rafbgarcia/rstf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevHandler_PassesClientAddr(t *testing.T) {
+	var got string
+	child := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get(rstf.DevClientAddrHeader)
+	}))
+	defer child.Close()
+
+	ready := make(chan struct{})
+	close(ready)
+	exited := func() <-chan struct{} { return nil }
+	h := newDevHandler(strings.TrimPrefix(child.URL, "http://"), exited, ready)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.RemoteAddr = "198.51.100.1:6000"
+	req.Header.Set(rstf.DevClientAddrHeader, "10.0.0.1:1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "198.51.100.1:6000", got, "the client can't choose the address")
+}
+
+func TestListenDev_FallsBackWhenPortTaken(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+	_, port, _ := net.SplitHostPort(taken.Addr().String())
+
+	ln, err := listenDev("127.0.0.1", port)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	got := fallbackPort(port, ln.Addr())
+	first, _ := strconv.Atoi(port)
+	next, _ := strconv.Atoi(got)
+	require.Greater(t, next, first, "takes a port after the taken one")
+	require.Less(t, next, first+devPortAttempts)
+	require.Equal(t, "http://127.0.0.1:"+got, devURL("127.0.0.1", ln.Addr()))
+}
+
+func TestListenDev_KeepsFreePort(t *testing.T) {
+	ln, err := listenDev("127.0.0.1", "0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	require.Empty(t, fallbackPort("0", ln.Addr()), "port 0 never reports a fallback")
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	require.Empty(t, fallbackPort(port, ln.Addr()))
+}
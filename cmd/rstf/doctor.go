@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rafbgarcia/rstf/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment rstf dev and rstf build need",
+		Long: "Check for Go with cgo and a C++ compiler, Node.js and npm, a go.mod that requires the framework,\n" +
+			"react and react-dom in node_modules, and a writable rstf/ directory. Run it from the app root.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor()
+		},
+	}
+}
+
+func runDoctor() error {
+	results := doctor.Run(".", doctor.SystemEnv())
+	for _, r := range results {
+		label := "  " + r.Name + " "
+		for len(label) < 20 {
+			label += "."
+		}
+		switch r.Status {
+		case doctor.OK:
+			fmt.Printf("%s ok (%s)\n", label, r.Detail)
+		case doctor.Warn:
+			fmt.Printf("%s warning: %s\n", label, r.Detail)
+		case doctor.Fail:
+			fmt.Printf("%s FAILED: %s\n", label, r.Detail)
+		}
+		if r.Fix != "" {
+			fmt.Printf("                     fix: %s\n", r.Fix)
+		}
+	}
+	if n := doctor.Failed(results); n > 0 {
+		return fmt.Errorf("%d check(s) failed", n)
+	}
+	fmt.Println("\n  Everything rstf needs is in place.")
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/rafbgarcia/rstf/internal/export"
+	"github.com/spf13/cobra"
+)
+
+// exportTimeout bounds how long prerendering every page may take.
+const exportTimeout = 5 * time.Minute
+
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Prerender every page to a static site in dist/",
+		Long: "Run rstf build, start the server it built, and save the HTML of each page with its server data,\n" +
+			"then replace dist/ with the pages and the static assets, ready for a static host or CDN.\n" +
+			"Pages are the routes with a View and no URL parameters, or those export.routes in rstf.json\n" +
+			"selects, plus the paths in export.paths.",
+		Example: "  rstf export\n" +
+			"  rstf export --tags prod\n" +
+			"  rstf export --json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tags, _ := cmd.Flags().GetStringSlice("tags")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return runExport(tags, jsonOutput)
+		},
+	}
+
+	cmd.Flags().StringSlice("tags", nil, "Go build tags for codegen and the server binary, e.g. debug")
+	cmd.Flags().Bool("json", false, "Print progress as JSON events, one per line, instead of status lines")
+	return cmd
+}
+
+func runExport(tags []string, jsonOutput bool) error {
+	status := newProgress(jsonOutput)
+	appName, err := buildDist(tags, false, status)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(".")
+	if err != nil {
+		return err
+	}
+
+	status.begin("Prerender")
+	pages, err := exportPages(cfg)
+	if err != nil {
+		status.fail("", err)
+		return err
+	}
+	out, err := os.MkdirTemp(".", ".rstf-export-")
+	if err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	defer os.RemoveAll(out)
+
+	server, err := startDistServer(appName)
+	if err != nil {
+		status.fail("", err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	err = export.Prerender(ctx, server.url, pages, out)
+	cancel()
+	if err != nil {
+		err = server.withOutput(err)
+		status.fail("", err)
+		return fmt.Errorf("prerendering: %w", err)
+	}
+	server.stop()
+	status.done(fmt.Sprintf("%d pages", len(pages)))
+
+	// dist/ becomes the static site: the pages and every app's static
+	// assets, at the URLs the pages load them from.
+	status.begin("Static site")
+	distDir := "dist"
+	static := map[string]string{filepath.Join(distDir, "rstf", "static"): filepath.Join(out, "rstf", "static")}
+	for _, m := range cfg.Mounts {
+		static[filepath.Join(distDir, m.Dir, "rstf", "static")] = filepath.Join(out, filepath.FromSlash(m.Path), "rstf", "static")
+	}
+	for src, dst := range static {
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyDir(src, dst); err != nil {
+			status.fail("", err)
+			return fmt.Errorf("copying static assets: %w", err)
+		}
+	}
+	if err := os.Chmod(out, 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(distDir); err != nil {
+		return fmt.Errorf("removing dist: %w", err)
+	}
+	if err := os.Rename(out, distDir); err != nil {
+		status.fail("", err)
+		return fmt.Errorf("moving the export to dist: %w", err)
+	}
+	status.done(distDir + "/")
+
+	status.message("Export complete. Serve dist/ from any static host.")
+	return nil
+}
+
+// exportPages lists the URL paths rstf export prerenders, from the manifests
+// of the build in dist/ and the export section of rstf.json.
+func exportPages(cfg config.Config) ([]string, error) {
+	paths := []string{filepath.Join("dist", "rstf", "manifest.json")}
+	for _, m := range cfg.Mounts {
+		paths = append(paths, filepath.Join("dist", m.Dir, "rstf", "manifest.json"))
+	}
+	var manifests []codegen.Manifest
+	for _, path := range paths {
+		manifest, err := codegen.ReadManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	pages, err := export.Pages(manifests, cfg.Export.Routes)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range cfg.Export.Paths {
+		if !slices.Contains(pages, path) {
+			pages = append(pages, path)
+		}
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages to export: no route has a View without URL parameters, and rstf.json sets no export.paths")
+	}
+	return pages, nil
+}
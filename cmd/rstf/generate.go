@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+func newGenerateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Aliases: []string{"g"},
+		Short:   "Run codegen, or generate app files that follow rstf conventions",
+		Long: "Without a subcommand, run codegen once and list the files it wrote to rstf/, without starting\n" +
+			"the dev server. Exits with an error when a route fails to parse.\n\n" +
+			"With a subcommand, create a route or component that follows the routing conventions.",
+		Example: "  rstf generate\n" +
+			"  rstf generate route users._id.edit\n" +
+			"  rstf g component price-tag --ssr\n" +
+			"  rstf generate --json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return runGenerate(jsonOutput)
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print progress and written files as JSON events, one per line")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "route <name>",
+		Short: "Create a route folder with index.go and index.tsx",
+		Long: "Create routes/<name>/ with an index.go returning typed ServerData and an index.tsx View.\n" +
+			"Separate nested segments with dots and write params as _name or $name, e.g. users._id.edit.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			created, err := scaffold.GenerateRoute(".", args[0])
+			printCreated(created)
+			return err
+		},
+	})
+
+	var ssr bool
+	componentCmd := &cobra.Command{
+		Use:   "component <name>",
+		Short: "Create a shared component under shared/ui",
+		Long: "Create shared/ui/<name>/index.tsx exporting the component.\n" +
+			"With --ssr, also create an index.go with server data and a starter index_test.go.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			created, err := scaffold.GenerateComponent(".", args[0], ssr)
+			printCreated(created)
+			return err
+		},
+	}
+	componentCmd.Flags().BoolVar(&ssr, "ssr", false, "Add an index.go with an SSR function and a test for it")
+	cmd.AddCommand(componentCmd)
+
+	return cmd
+}
+
+func runGenerate(jsonOutput bool) error {
+	_, statErr := os.Stat("tsconfig.json")
+
+	status := newProgress(jsonOutput)
+	status.begin("Codegen")
+	result, err := codegen.Generate(".")
+	if err != nil {
+		status.fail("", err)
+		return fmt.Errorf("codegen error: %w", err)
+	}
+	status.done(fmt.Sprintf("%d routes", result.RouteCount))
+	printUnusedShared(status, result.AllUnusedShared())
+
+	// Generate starts from an empty rstf/, so everything in it was just written.
+	dirs := []string{"rstf"}
+	for dir := range result.Mounts {
+		dirs = append(dirs, filepath.Join(dir, "rstf"))
+	}
+	var written []string
+	if os.IsNotExist(statErr) {
+		written = append(written, "tsconfig.json")
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				written = append(written, filepath.ToSlash(path))
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("listing %s: %w", dir, err)
+		}
+	}
+	sort.Strings(written)
+	for _, path := range written {
+		status.wrote(path)
+	}
+	return nil
+}
+
+func printCreated(paths []string) {
+	for _, path := range paths {
+		fmt.Printf("  Created .......... %s\n", path)
+	}
+}
@@ -14,7 +14,11 @@ func newInitCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init <name>",
 		Short: "Create a new rstf app",
-		Args:  cobra.ExactArgs(1),
+		Long: "Create a directory named <name> with a starter app, then install its npm and Go dependencies.\n" +
+			"The module path defaults to the directory name, and the Go package name is derived from it.",
+		Example: "  rstf init my-app\n" +
+			"  rstf init my-app --module github.com/me/my-app --skip-install",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := scaffold.DeriveConfig(args[0], module)
 			if err != nil {
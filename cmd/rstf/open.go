@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+)
+
+// openTimeout bounds how long --open waits for the first server to answer,
+// which includes compiling it.
+const openTimeout = 2 * time.Minute
+
+// openWhenReady opens the browser at baseURL once the app server at childAddr
+// answers its first request. It gives up if the server exits first; the crash
+// is reported on its own.
+func openWhenReady(status *progress, baseURL, childAddr string, exited <-chan struct{}) {
+	path := openPath()
+	ctx, cancel := context.WithTimeout(context.Background(), openTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://"+childAddr+path, nil)
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	url := baseURL + path
+	if err := openBrowser(url); err != nil {
+		status.warn("Browser", fmt.Sprintf("%s (open %s yourself)", err, url))
+		return
+	}
+	status.info("Browser", "opened "+url)
+}
+
+// openPath is the page --open shows: / when a route serves it, otherwise the
+// first route, by name, that renders a view and has no path parameters.
+func openPath() string {
+	manifest, err := codegen.ReadManifest(filepath.Join("rstf", "manifest.json"))
+	if err != nil {
+		return "/"
+	}
+	first := ""
+	for _, route := range manifest.Routes {
+		if !route.HasView || len(route.Params) > 0 {
+			continue
+		}
+		if route.Pattern == "/" {
+			return "/"
+		}
+		if first == "" {
+			first = route.Pattern
+		}
+	}
+	if first == "" {
+		return "/"
+	}
+	return first
+}
+
+// openBrowser opens url in the default browser without waiting for it.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rafbgarcia/rstf/internal/profile"
+)
+
+// startProfile starts timing a run of dev or build for --profile, returning
+// nil when profiling is off.
+func startProfile(enabled bool, name string) *profile.Span {
+	if !enabled {
+		return nil
+	}
+	return profile.Start(name)
+}
+
+// printProfile stops timing span and prints how long each phase of the run
+// took.
+func printProfile(status *progress, span *profile.Span) {
+	if span == nil {
+		return
+	}
+	span.End()
+	if status.json {
+		status.mu.Lock()
+		defer status.mu.Unlock()
+		status.emit(event{Event: "profile", Profile: span})
+		return
+	}
+	fmt.Println("\n  Profile:")
+	span.Write(os.Stdout)
+}
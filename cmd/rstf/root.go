@@ -1,29 +1,35 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/rafbgarcia/rstf/internal/release"
 	"github.com/spf13/cobra"
 )
 
 func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:     "rstf",
-		Short:   "rstf framework CLI",
+		Use:   "rstf",
+		Short: "rstf framework CLI",
+		Long: "rstf builds React apps with Go server data. Run commands from the app root.\n\n" +
+			"Shell completion: see `rstf completion --help`.",
 		Version: release.Version,
 	}
 
 	rootCmd.AddCommand(newInitCmd())
 	rootCmd.AddCommand(newDevCmd())
 	rootCmd.AddCommand(newBuildCmd())
-	rootCmd.AddCommand(&cobra.Command{
-		Use:   "version",
-		Short: "Print the rstf release version",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Fprintln(cmd.OutOrStdout(), release.Version)
-		},
-	})
+	rootCmd.AddCommand(newStartCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newRoutesCmd())
+	rootCmd.AddCommand(newTypecheckCmd())
+	rootCmd.AddCommand(newTestCmd())
+	rootCmd.AddCommand(newCleanCmd())
+	rootCmd.AddCommand(newAnalyzeCmd())
+	rootCmd.AddCommand(newBenchCmd())
+	rootCmd.AddCommand(newGenerateCmd())
+	rootCmd.AddCommand(newDBCmd())
+	rootCmd.AddCommand(newUpgradeCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newVersionCmd())
 
 	return rootCmd
 }
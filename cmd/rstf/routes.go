@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/spf13/cobra"
+)
+
+func newRoutesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "routes",
+		Short: "Print the route table resolved from routes/",
+		Long: "Run codegen and print every route: its URL pattern, directory, whether it has a View and an SSR\n" +
+			"function, the HTTP methods its handlers serve, and the directories its View imports.\n" +
+			"Directories under routes/ that did not become routes are listed with the reason.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRoutes()
+		},
+	}
+}
+
+func runRoutes() error {
+	result, err := codegen.Generate(".")
+	if err != nil {
+		return fmt.Errorf("codegen error: %w", err)
+	}
+
+	appDirs := []string{"."}
+	for dir := range result.Mounts {
+		appDirs = append(appDirs, dir)
+	}
+	sort.Strings(appDirs[1:])
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "  Pattern\tDir\tView\tSSR\tMethods\tDeps\t")
+	var skipped []string
+	for _, appDir := range appDirs {
+		manifest, err := codegen.ReadManifest(filepath.Join(appDir, "rstf", "manifest.json"))
+		if err != nil {
+			return err
+		}
+		routeDirs := map[string]bool{}
+		for _, route := range manifest.Routes {
+			routeDirs[route.Dir] = true
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t\n",
+				route.Pattern,
+				filepath.ToSlash(filepath.Join(appDir, route.Dir)),
+				yesNo(route.HasView),
+				yesNo(route.HasSSR),
+				orDash(strings.Join(route.Methods, ",")),
+				orDash(strings.Join(otherDeps(route.Dir, route.Deps), ",")),
+			)
+		}
+		notRoutes, err := dirsNotRoutes(appDir, routeDirs)
+		if err != nil {
+			return err
+		}
+		skipped = append(skipped, notRoutes...)
+	}
+	w.Flush()
+
+	if len(skipped) > 0 {
+		fmt.Println("\n  Not routes (no index.tsx and no exported route functions in .go files):")
+		for _, dir := range skipped {
+			fmt.Printf("  %s\n", dir)
+		}
+	}
+	return nil
+}
+
+// dirsNotRoutes returns the directories directly under appDir/routes that are
+// not in routeDirs.
+func dirsNotRoutes(appDir string, routeDirs map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(appDir, "routes"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if dir := "routes/" + e.Name(); !routeDirs[dir] {
+			dirs = append(dirs, filepath.ToSlash(filepath.Join(appDir, dir)))
+		}
+	}
+	return dirs, nil
+}
+
+// otherDeps drops the route's own directory from the directories its View
+// imports.
+func otherDeps(routeDir string, deps []string) []string {
+	var out []string
+	for _, dep := range deps {
+		if dep != routeDir {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rafbgarcia/rstf"
+	"github.com/spf13/cobra"
+)
+
+func newStartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Run the production build in dist/",
+		Long: "Run the binary rstf build wrote to dist/, after checking that no source file changed since.\n" +
+			"Run it from the app root.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			port, err := portFlag(cmd)
+			if err != nil {
+				return err
+			}
+			host, _ := cmd.Flags().GetString("host")
+			return runStart(host, port)
+		},
+	}
+
+	cmd.Flags().String("port", "3000", "HTTP server port, overriding port in rstf.json")
+	cmd.Flags().String("host", "", "Address to bind, e.g. 127.0.0.1 (default: all interfaces)")
+	return cmd
+}
+
+func runStart(host, port string) error {
+	appName, err := currentAppName()
+	if err != nil {
+		return err
+	}
+	binary := filepath.Join("dist", appName)
+	info, err := os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("%s not found (run `rstf build` first)", binary)
+	}
+	if changed, err := newerSource(".", info.ModTime()); err != nil {
+		return err
+	} else if changed != "" {
+		return fmt.Errorf("%s changed after %s was built (run `rstf build` again)", changed, binary)
+	}
+
+	// The server inherits the listener, as under rstf dev, so --host works
+	// without the binary knowing about it.
+	addr := net.JoinHostPort(host, port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	addr = ln.Addr().String()
+	listener, err := ln.(*net.TCPListener).File()
+	ln.Close()
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	defer listener.Close()
+
+	cmd := exec.Command("./" + appName)
+	cmd.Dir = "dist"
+	cmd.Env = append(os.Environ(), rstf.ListenFDEnv+"=3")
+	cmd.ExtraFiles = []*os.File{listener}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", binary, err)
+	}
+	fmt.Printf("  HTTP server ..... listening on %s\n", addr)
+
+	// Forward shutdown signals so the server drains in-flight requests.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	for {
+		select {
+		case sig := <-sigCh:
+			cmd.Process.Signal(sig)
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("%s: %w", binary, err)
+			}
+			return nil
+		}
+	}
+}
+
+// buildInputExts are the extensions of files rstf build reads.
+var buildInputExts = map[string]bool{
+	".go": true, ".ts": true, ".tsx": true, ".js": true, ".jsx": true, ".mjs": true, ".css": true,
+}
+
+// buildInputFiles are project files rstf build reads besides sources.
+var buildInputFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "rstf.json": true, "package.json": true, "package-lock.json": true,
+}
+
+// newerSource returns the first build input under root modified after since,
+// or "" if the build is up to date. Generated and installed directories are
+// skipped.
+func newerSource(root string, since time.Time) (string, error) {
+	var changed string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "rstf" || name == "dist" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !buildInputExts[filepath.Ext(name)] && !buildInputFiles[name] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(since) {
+			changed = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("checking build inputs: %w", err)
+	}
+	return changed, nil
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/rafbgarcia/rstf/internal/profile"
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiDim    = "\033[2m"
+	ansiReset  = "\033[0m"
+)
+
+// progress prints the progress lines of dev, build, and generate and keeps
+// the last error of each phase. A phase's error is listed after every rebuild
+// until that phase succeeds again, so a failure from an earlier change does
+// not scroll away while unrelated files are edited.
+//
+// With --json it writes one JSON event per line to stdout instead, for CI and
+// editor integrations. See event.
+//
+// dev's --quiet and --verbose set its verbosity: quiet keeps only failures,
+// crashes, and the errors still unresolved after a rebuild; verbose adds debug
+// lines.
+type progress struct {
+	color     bool
+	json      bool
+	verbosity verbosity
+
+	mu     sync.Mutex // serializes output from the crash handler
+	label  string     // phase whose line is open, "" when none
+	start  time.Time  // when the open phase began
+	errors map[string]error
+	order  []string // phases in errors, in the order they failed
+	held   []event  // debug lines printed once the open line closes
+}
+
+// verbosity is how much progress prints.
+type verbosity int
+
+const (
+	quiet verbosity = iota - 1
+	normal
+	verbose
+)
+
+func newProgress(jsonOutput bool) *progress {
+	return &progress{color: !jsonOutput && colorOutput(), json: jsonOutput, errors: map[string]error{}}
+}
+
+// event is one line of --json output. Event is "phase" for a finished phase,
+// with Status "done" or "failed", "note" for a message, "change" for a
+// changed file, "write" for a file generate wrote, "output" for the last
+// output of a crashed server, "unresolved" for the phases still failing after
+// a rebuild, "profile" for the --profile report, and "debug" for a --verbose
+// detail such as a codegen decision or a watcher event.
+type event struct {
+	Event      string        `json:"event"`
+	Phase      string        `json:"phase,omitempty"`
+	Status     string        `json:"status,omitempty"`
+	Detail     string        `json:"detail,omitempty"`
+	DurationMs float64       `json:"durationMs,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	Path       string        `json:"path,omitempty"`
+	Lines      []string      `json:"lines,omitempty"`
+	Errors     []eventError  `json:"errors,omitempty"`
+	Profile    *profile.Span `json:"profile,omitempty"`
+}
+
+// eventError is an error of a failed phase, one per position the error
+// names. File is relative to the project root when it is inside it.
+type eventError struct {
+	Phase   string `json:"phase,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (s *progress) emit(e event) {
+	data, _ := json.Marshal(e)
+	fmt.Println(string(data))
+}
+
+// stdout is where subprocesses such as go build and the app server write
+// their output, kept off stdout in JSON mode so it stays parseable.
+func (s *progress) stdout() io.Writer {
+	if s.json {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// sidecar is where helper processes such as the CSS worker write their
+// stderr. Only a verbose progress shows it; their failures reach progress
+// through the phases that use them.
+func (s *progress) sidecar() io.Writer {
+	if s.verbosity < verbose {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// colorOutput reports whether stdout is a terminal that should get colors.
+// NO_COLOR (https://no-color.org) and TERM=dumb turn them off.
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *progress) paint(code, text string) string {
+	if !s.color {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// begin opens the line of a phase, e.g. "  Codegen ......... ". A quiet
+// progress keeps the line to itself unless the phase fails.
+func (s *progress) begin(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.label, s.start = label, time.Now()
+	if !s.json && s.verbosity > quiet {
+		fmt.Print("  " + dotted(label))
+	}
+}
+
+// done closes the open line with detail, if any, and the phase's duration,
+// and clears the phase's error.
+func (s *progress) done(detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := time.Since(s.start)
+	switch {
+	case s.verbosity == quiet:
+	case s.json:
+		s.emit(event{Event: "phase", Phase: s.label, Status: "done", Detail: detail, DurationMs: durationMs(elapsed)})
+	default:
+		line := s.paint(ansiGreen, "done")
+		if detail != "" {
+			line += " (" + detail + ")"
+		}
+		fmt.Printf("%s [%s]\n", line, fmtDuration(elapsed))
+	}
+	s.forget(s.label)
+	s.label = ""
+	s.release()
+}
+
+// fail closes the open line as failed and records err under its phase. With
+// no line open, err is recorded under label, for phases that only report
+// failures. The caller prints err itself, except in JSON mode, where the
+// event carries it.
+func (s *progress) fail(label string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var elapsed time.Duration
+	if s.label != "" {
+		if !s.json {
+			if s.verbosity == quiet {
+				fmt.Print("  " + dotted(s.label))
+			}
+			fmt.Println(s.paint(ansiRed, "FAILED"))
+		}
+		label, s.label, elapsed = s.label, "", time.Since(s.start)
+		defer s.release()
+	}
+	if s.json {
+		s.emit(event{Event: "phase", Phase: label, Status: "failed", DurationMs: durationMs(elapsed), Errors: errorPositions(err)})
+	}
+	if _, ok := s.errors[label]; !ok {
+		s.order = append(s.order, label)
+	}
+	s.errors[label] = err
+}
+
+// failAndPrint is fail for errors nothing else prints, such as those of a
+// rebuild: in text mode it also prints err below the failed line. --quiet
+// keeps it, since it only drops lines that are not failures.
+func (s *progress) failAndPrint(label string, err error) {
+	s.fail(label, err)
+	if s.json {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(os.Stderr, "    %s\n", line)
+	}
+}
+
+// clear forgets the error of a phase that only reports failures, once it
+// succeeds.
+func (s *progress) clear(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forget(label)
+}
+
+// forget drops the error of a phase. The caller holds mu.
+func (s *progress) forget(label string) {
+	if _, ok := s.errors[label]; !ok {
+		return
+	}
+	delete(s.errors, label)
+	for i, l := range s.order {
+		if l == label {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// note prints a line for a phase that has no outcome to time, like a server
+// restart.
+func (s *progress) note(label, text string) {
+	s.print(normal, "note", label, s.paint(ansiYellow, text), text)
+}
+
+// info prints a line like note's, without highlighting it.
+func (s *progress) info(label, text string) {
+	s.print(normal, "note", label, text, text)
+}
+
+// warn prints a line about something that went wrong outside a phase, like
+// a server crash. Unlike note, it is kept when quiet.
+func (s *progress) warn(label, text string) {
+	s.print(quiet, "note", label, s.paint(ansiRed, text), text)
+}
+
+// debug prints a detail only a verbose progress shows, like a codegen
+// decision. While a phase's line is open, it is held until the line closes.
+func (s *progress) debug(label, text string) {
+	if s.verbosity < verbose {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := event{Event: "debug", Phase: label, Message: text}
+	if s.label != "" && !s.json {
+		s.held = append(s.held, e)
+		return
+	}
+	s.printDebug(e)
+}
+
+// release prints the debug lines held while a line was open. The caller
+// holds mu.
+func (s *progress) release() {
+	for _, e := range s.held {
+		s.printDebug(e)
+	}
+	s.held = nil
+}
+
+func (s *progress) printDebug(e event) {
+	if s.json {
+		s.emit(e)
+		return
+	}
+	fmt.Println("  " + s.paint(ansiDim, dotted(e.Phase)+e.Message))
+}
+
+// print writes a line unless the progress is less verbose than min.
+func (s *progress) print(min verbosity, kind, label, line, text string) {
+	if s.verbosity < min {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json {
+		s.emit(event{Event: kind, Phase: label, Message: text})
+		return
+	}
+	fmt.Println("  " + dotted(label) + line)
+}
+
+// message prints a line that stands apart from the phases, like the end of
+// a build.
+func (s *progress) message(text string) {
+	if s.verbosity == quiet {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json {
+		s.emit(event{Event: "note", Message: text})
+		return
+	}
+	fmt.Println("\n  " + text)
+}
+
+// change prints a changed file.
+func (s *progress) change(path string) {
+	if s.verbosity == quiet {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json {
+		s.emit(event{Event: "change", Path: path})
+		return
+	}
+	fmt.Printf("\n  %s %s\n", s.paint(ansiDim, "[change]"), path)
+}
+
+// wrote prints a file generate wrote.
+func (s *progress) wrote(path string) {
+	if s.verbosity == quiet {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json {
+		s.emit(event{Event: "write", Path: path})
+		return
+	}
+	fmt.Printf("  Wrote ............ %s\n", path)
+}
+
+// output prints the last lines a crashed server wrote to stderr.
+func (s *progress) output(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json {
+		s.emit(event{Event: "output", Phase: "HTTP server", Lines: lines})
+		return
+	}
+	fmt.Println("  Last output:")
+	for _, line := range lines {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+// summary lists the phases still failing, with the first line of each
+// error, after a rebuild.
+func (s *progress) summary() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) == 0 {
+		return
+	}
+	if s.json {
+		var errs []eventError
+		for _, label := range s.order {
+			msg, _, _ := strings.Cut(s.errors[label].Error(), "\n")
+			errs = append(errs, eventError{Phase: label, Message: msg})
+		}
+		s.emit(event{Event: "unresolved", Errors: errs})
+		return
+	}
+	noun := "error"
+	if len(s.order) > 1 {
+		noun = "errors"
+	}
+	fmt.Println("  " + dotted("Unresolved") + s.paint(ansiRed, fmt.Sprintf("%d %s", len(s.order), noun)))
+	for _, label := range s.order {
+		msg, _, _ := strings.Cut(s.errors[label].Error(), "\n")
+		fmt.Printf("    %s: %s\n", label, msg)
+	}
+}
+
+// errorPosRe matches a position-prefixed message, as Go and esbuild write
+// them: "routes/users/index.go:12:5: undefined: db".
+var errorPosRe = regexp.MustCompile(`(?:^|\s)([^\s:]+):(\d+):(\d+): (.+)$`)
+
+// errorPositions splits err into one eventError per line that names a
+// position. An error without positions is a single eventError.
+func errorPositions(err error) []eventError {
+	if err == nil {
+		return nil
+	}
+	wd, _ := os.Getwd()
+	var errs []eventError
+	for _, line := range strings.Split(err.Error(), "\n") {
+		m := errorPosRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		file := m[1]
+		if rel, err := filepath.Rel(wd, file); err == nil && filepath.IsAbs(file) && filepath.IsLocal(rel) {
+			file = filepath.ToSlash(rel)
+		}
+		ln, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		errs = append(errs, eventError{File: file, Line: ln, Column: col, Message: m[4]})
+	}
+	if len(errs) == 0 {
+		errs = append(errs, eventError{Message: err.Error()})
+	}
+	return errs
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// dotted pads a phase label with dots to the width of the progress column.
+func dotted(label string) string {
+	const width = 17
+	if len(label) >= width-1 {
+		return label + " "
+	}
+	return label + " " + strings.Repeat(".", width-len(label)-1) + " "
+}
+
+// clientBundleSize totals the JS in the project's and mounted apps' client
+// bundles.
+func clientBundleSize() config.Size {
+	paths, _ := filepath.Glob(filepath.Join("rstf", "static", "*", "bundle.js"))
+	for _, dir := range mountDirs() {
+		mounted, _ := filepath.Glob(filepath.Join(dir, "rstf", "static", "*", "bundle.js"))
+		paths = append(paths, mounted...)
+	}
+	var total config.Size
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			total += config.Size(info.Size())
+		}
+	}
+	return total
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rafbgarcia/rstf"
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/gotool"
+	"github.com/spf13/cobra"
+)
+
+// testServerTimeout bounds how long rstf test waits for the app server to
+// answer its first request.
+const testServerTimeout = 30 * time.Second
+
+func newTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [packages] [-- go test flags]",
+		Short: "Run Go tests against one app server started for them",
+		Long: "Run codegen, bundle every route, build CSS, compile the server, and start it with its renderer on a\n" +
+			"local port, then run go test with RSTF_TEST_URL set to the server's URL. rstftest.Serve, Render, and\n" +
+			"Browser use that server instead of building the app for each test. Packages default to ./routes/...",
+		Example: "  rstf test\n" +
+			"  rstf test ./routes/users/...\n" +
+			"  rstf test -- -run TestDashboard -v",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			packages, goTestArgs := args, []string(nil)
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				packages, goTestArgs = args[:dash], args[dash:]
+			}
+			if len(packages) == 0 {
+				packages = []string{"./routes/..."}
+			}
+			tags, _ := cmd.Flags().GetStringSlice("tags")
+			return runTest(packages, goTestArgs, tags)
+		},
+	}
+
+	cmd.Flags().StringSlice("tags", nil, "Go build tags for codegen, the server, and the tests, e.g. debug")
+	return cmd
+}
+
+func runTest(packages, goTestArgs, tags []string) error {
+	gen, err := codegen.NewGenerator(".")
+	if err != nil {
+		return fmt.Errorf("codegen init error: %w", err)
+	}
+	gen.SetBuildTags(tags)
+	status := newProgress(false)
+
+	status.begin("Codegen")
+	result, err := gen.Generate()
+	if err != nil {
+		status.fail("", err)
+		return fmt.Errorf("codegen error: %w", err)
+	}
+	status.done(fmt.Sprintf("%d routes", result.RouteCount))
+
+	status.begin("Client bundles")
+	if err := buildClientBundles(result, "", nil); err != nil {
+		status.fail("", err)
+		return fmt.Errorf("bundling error: %w", err)
+	}
+	status.done(clientBundleSize().String() + " JS")
+
+	status.begin("SSR bundles")
+	if err := buildSSRBundles(result, "", nil); err != nil {
+		status.fail("", err)
+		return fmt.Errorf("SSR bundling error: %w", err)
+	}
+	status.done("")
+
+	if cssEntry() != "" {
+		status.begin("CSS")
+		err := buildCSS()
+		stopCSSWorker()
+		if err != nil {
+			status.fail("", err)
+			return fmt.Errorf("css error: %w", err)
+		}
+		status.done("")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "rstf-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	status.begin("Go binary")
+	binary := filepath.Join(tmpDir, "server")
+	buildArgs := []string{"build", "-o", binary}
+	if len(tags) > 0 {
+		buildArgs = append(buildArgs, "-tags", strings.Join(tags, ","))
+	}
+	build := exec.Command("go", append(buildArgs, "./rstf/server_gen.go")...)
+	gotool.Prepare(build)
+	if out, err := build.CombinedOutput(); err != nil {
+		status.fail("", err)
+		return fmt.Errorf("building server binary: %w\n%s", err, out)
+	}
+	status.done("")
+
+	status.begin("HTTP server")
+	server, err := startTestServer(binary)
+	if err != nil {
+		status.fail("", err)
+		return err
+	}
+	defer server.stop()
+	status.done(server.url)
+	fmt.Println()
+
+	testArgs := []string{"test"}
+	if len(tags) > 0 {
+		testArgs = append(testArgs, "-tags", strings.Join(tags, ","))
+	}
+	testArgs = append(testArgs, packages...)
+	test := exec.Command("go", append(testArgs, goTestArgs...)...)
+	test.Stdin = os.Stdin
+	test.Stdout = os.Stdout
+	test.Stderr = os.Stderr
+	gotool.Prepare(test)
+	test.Env = append(test.Env, rstf.TestURLEnv+"="+server.url)
+	if err := test.Run(); err != nil {
+		return fmt.Errorf("go test: %w", err)
+	}
+	return nil
+}
+
+// testServer is the app server rstf test runs the tests against.
+type testServer struct {
+	url  string
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// startTestServer starts binary on a listener the CLI opens, so the port is
+// known before the server runs, and waits until it answers a request. Its
+// output goes to stderr, next to the test output.
+func startTestServer(binary string) (*testServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	defer ln.Close()
+	listener, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		return nil, fmt.Errorf("listening for the app server: %w", err)
+	}
+	defer listener.Close()
+
+	s := &testServer{url: "http://" + ln.Addr().String(), done: make(chan struct{})}
+	s.cmd = exec.Command(binary)
+	s.cmd.Env = append(os.Environ(), rstf.ListenFDEnv+"=3")
+	s.cmd.ExtraFiles = []*os.File{listener}
+	s.cmd.Stdout = os.Stderr
+	s.cmd.Stderr = os.Stderr
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting the app server: %w", err)
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.done)
+	}()
+
+	// Connections queue on the listener until the server accepts them, so
+	// the first request waits for startup instead of polling.
+	client := &http.Client{Timeout: testServerTimeout}
+	ready := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(s.url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		ready <- err
+	}()
+	select {
+	case err := <-ready:
+		if err != nil {
+			s.stop()
+			return nil, fmt.Errorf("app server did not answer: %w", err)
+		}
+	case <-s.done:
+		return nil, fmt.Errorf("app server exited before answering a request")
+	}
+	return s, nil
+}
+
+// stop shuts the server down gracefully, killing it after stopTimeout.
+func (s *testServer) stop() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	s.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-s.done:
+	case <-time.After(stopTimeout):
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/spf13/cobra"
+)
+
+func newTypecheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "typecheck",
+		Short: "Type-check the app's TypeScript against the generated declarations",
+		Long: "Run codegen, then tsc --noEmit with the app's tsconfig.json, which extends rstf/tsconfig.json and\n" +
+			"so includes the declarations generated from Go. A View that reads a field its route's Go struct\n" +
+			"doesn't have fails here instead of only in the editor. Mounted apps are checked with their own config.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTypecheck()
+		},
+	}
+}
+
+func runTypecheck() error {
+	fmt.Print("  Codegen ......... ")
+	result, err := codegen.Generate(".")
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("codegen error: %w", err)
+	}
+	fmt.Printf("done (%d routes)\n", result.RouteCount)
+
+	tsc, err := findTSC()
+	if err != nil {
+		return err
+	}
+
+	appDirs := []string{"."}
+	for dir := range result.Mounts {
+		appDirs = append(appDirs, dir)
+	}
+	sort.Strings(appDirs[1:])
+
+	failed := 0
+	for _, dir := range appDirs {
+		label := "Typecheck"
+		if dir != "." {
+			label += " " + dir
+		}
+		fmt.Print("  " + dotted(label))
+		out, err := exec.Command(tsc, "--noEmit", "--pretty", "false", "-p", filepath.Join(dir, "tsconfig.json")).CombinedOutput()
+		if err == nil {
+			fmt.Println("done")
+			continue
+		}
+		lines, errs := parseTSCErrors(out)
+		if errs == 0 {
+			// tsc failed without reporting diagnostics, e.g. a broken config.
+			fmt.Println("FAILED")
+			return fmt.Errorf("tsc: %w\n%s", err, out)
+		}
+		fmt.Printf("FAILED (%d errors)\n", errs)
+		for _, line := range lines {
+			fmt.Println("    " + line)
+		}
+		failed += errs
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d TypeScript error(s)", failed)
+	}
+	return nil
+}
+
+// findTSC prefers the app's own TypeScript over one on PATH.
+func findTSC() (string, error) {
+	local := filepath.Join("node_modules", ".bin", "tsc")
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		return local, nil
+	}
+	if path, err := exec.LookPath("tsc"); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("tsc not found (run `npm install --save-dev typescript`)")
+}
+
+// tscError matches a diagnostic in tsc's --pretty false output, e.g.
+// "routes/index.tsx(4,7): error TS2339: Property 'x' does not exist".
+var tscError = regexp.MustCompile(`^(.+)\((\d+),(\d+)\): error (TS\d+): (.*)$`)
+
+// parseTSCErrors rewrites tsc's diagnostics as file:line:col, which terminals
+// and editors link to, and counts them. Continuation lines of a diagnostic
+// are kept as they are.
+func parseTSCErrors(out []byte) ([]string, int) {
+	var lines []string
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := tscError.FindStringSubmatch(line); m != nil {
+			count++
+			line = fmt.Sprintf("%s:%s:%s: %s (%s)", filepath.ToSlash(m[1]), m[2], m[3], m[5], m[4])
+		}
+		lines = append(lines, line)
+	}
+	return lines, count
+}
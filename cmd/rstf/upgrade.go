@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/release"
+	"github.com/rafbgarcia/rstf/internal/upgrade"
+	"github.com/spf13/cobra"
+)
+
+func newUpgradeCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Move the app to this CLI's framework version and migrate its code",
+		Long: "Require the framework version that matches this CLI in go.mod, run every codemod over the app's\n" +
+			"sources, and regenerate rstf/. Places a codemod could not rewrite are listed, and the command exits\n" +
+			"with an error.",
+		Example: "  rstf upgrade --dry-run\n" +
+			"  rstf upgrade",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpgrade(dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without touching any file")
+
+	return cmd
+}
+
+func runUpgrade(dryRun bool) error {
+	current, replacedBy, err := upgrade.FrameworkVersion(".")
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("  Framework ....... ")
+	switch {
+	case replacedBy != "":
+		fmt.Printf("skipped (%s is replaced by %s)\n", release.FrameworkModule, replacedBy)
+	case current == release.ModuleVersion:
+		fmt.Printf("%s (up to date)\n", current)
+	default:
+		fmt.Printf("%s → %s\n", current, release.ModuleVersion)
+		if !dryRun {
+			if err := upgrade.BumpFramework(".", release.ModuleVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	report, err := upgrade.RunCodemods(".", !dryRun)
+	if err != nil {
+		return fmt.Errorf("running codemods: %w", err)
+	}
+	fmt.Printf("  Codemods ........ %d changes\n", len(report.Changed))
+	for _, change := range report.Changed {
+		fmt.Printf("    %s (%s)\n", change.Path, change.Codemod)
+	}
+
+	fmt.Print("  Codegen ......... ")
+	if dryRun {
+		fmt.Println("skipped (dry run)")
+	} else {
+		result, err := codegen.Generate(".")
+		if err != nil {
+			fmt.Println("failed")
+			return fmt.Errorf("codegen error: %w", err)
+		}
+		fmt.Printf("%d routes\n", result.RouteCount)
+	}
+
+	if len(report.Manual) == 0 {
+		return nil
+	}
+
+	fmt.Println("\n  Needs a manual fix:")
+	for _, fix := range report.Manual {
+		fmt.Printf("    %s: %s\n", fix.Path, fix.Reason)
+	}
+	return fmt.Errorf("%d occurrences could not be migrated automatically", len(report.Manual))
+}
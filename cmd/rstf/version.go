@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rafbgarcia/rstf"
+	"github.com/rafbgarcia/rstf/internal/release"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the rstf release version and build metadata",
+		Long: "Print the rstf release version, the framework module version apps get, and how this CLI was built:\n" +
+			"its commit, Go version, and the esbuild version it bundles with. rstf --version prints the release\n" +
+			"version alone.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			info := rstf.ReadBuildInfo()
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "  rstf ............ %s\n", release.Version)
+			fmt.Fprintf(out, "  Framework ....... %s\n", release.ModuleVersion)
+			fmt.Fprintf(out, "  Commit .......... %s\n", describeCommit(info))
+			fmt.Fprintf(out, "  Go .............. %s\n", orDash(info.Go))
+			fmt.Fprintf(out, "  esbuild ......... %s\n", orDash(info.Esbuild))
+		},
+	}
+}
+
+func describeCommit(info rstf.BuildInfo) string {
+	if info.Commit == "" {
+		return "-"
+	}
+	if info.Modified {
+		return info.Commit + " (modified)"
+	}
+	return info.Commit
+}
@@ -6,13 +6,16 @@ import (
 )
 
 // Context is the request-scoped framework context passed to route handlers.
-// It provides access to logging, the database connection pool, and other framework utilities.
+// It provides access to logging, the database connection pool, the App the
+// server was started with, and other framework utilities.
 type Context struct {
 	Log                   *Logger
 	Writer                http.ResponseWriter
 	Request               *http.Request
 	DB                    *sql.DB
+	App                   *App
 	requestBodyLimitBytes int64
+	header                http.Header
 }
 
 // NewContext creates a new Context for the given HTTP request.
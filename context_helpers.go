@@ -1,6 +1,7 @@
 package rstf
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const DefaultBodyLimit int64 = 1 << 20
@@ -20,6 +22,8 @@ const (
 	ErrorCodePayloadTooLarge        ErrorCode = "payload_too_large"
 	ErrorCodeUnsupportedContentType ErrorCode = "unsupported_content_type"
 	ErrorCodeValidationFailed       ErrorCode = "validation_failed"
+	ErrorCodeUnauthorized           ErrorCode = "unauthorized"
+	ErrorCodeForbidden              ErrorCode = "forbidden"
 	ErrorCodeOverloaded             ErrorCode = "overloaded"
 	ErrorCodeInternal               ErrorCode = "internal_error"
 )
@@ -166,6 +170,68 @@ func (c *Context) NoContent() error {
 	return nil
 }
 
+// Stream sends a response body that fn produces incrementally, such as a large
+// CSV or NDJSON export. Every write is flushed to the client, the server's
+// write timeout is lifted for this response, and once the client disconnects
+// writes fail with the request context's error so fn can stop early. Set
+// Content-Type on c.Writer before calling; it defaults to
+// application/octet-stream. Headers held on the context are sent too.
+//
+// The response starts with fn's first write. An error fn returns before that
+// is answered like any handler error, with its own status. Once the first
+// byte is out the status is sent, so a later error only ends the stream.
+func (c *Context) Stream(fn func(w io.Writer) error) error {
+	if c == nil || c.Writer == nil || c.Request == nil {
+		return &RequestError{
+			Code:    ErrorCodeInternal,
+			Message: "response writer is not initialized",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetWriteDeadline(time.Time{})
+	c.WriteHeaders(c.Writer)
+	if c.Writer.Header().Get("Content-Type") == "" {
+		c.Writer.Header().Set("Content-Type", "application/octet-stream")
+	}
+	sw := &streamWriter{ctx: c.Request.Context(), w: c.Writer, rc: rc}
+	if err := fn(sw); err != nil {
+		return err
+	}
+	if !sw.started {
+		c.Writer.WriteHeader(http.StatusOK)
+	}
+	return nil
+}
+
+// streamWriter sends the status with the first write, flushes after every
+// write, and stops writing once the request is canceled.
+type streamWriter struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if !s.started {
+		s.started = true
+		s.w.WriteHeader(http.StatusOK)
+	}
+	n, err := s.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
 func WriteErrorEnvelope(w http.ResponseWriter, err error) {
 	status, envelope := ErrorEnvelope(err)
 	w.Header().Set("Content-Type", "application/json")
@@ -236,6 +302,10 @@ func mapErrorCodeToStatus(code ErrorCode) int {
 		return http.StatusUnsupportedMediaType
 	case ErrorCodeValidationFailed:
 		return http.StatusUnprocessableEntity
+	case ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrorCodeForbidden:
+		return http.StatusForbidden
 	case ErrorCodeOverloaded:
 		return http.StatusServiceUnavailable
 	default:
@@ -346,6 +416,22 @@ func (w *ResponseTracker) Write(p []byte) (int, error) {
 	return w.writer.Write(p)
 }
 
+// Flush forwards to the underlying writer so streaming responses keep working
+// when wrapped.
+func (w *ResponseTracker) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *ResponseTracker) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
 func (w *ResponseTracker) StatusCode() int {
 	if !w.wroteHeader {
 		return http.StatusOK
@@ -0,0 +1,105 @@
+package rstf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextStream_FlushesEachWrite(t *testing.T) {
+	next := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := NewContext(req)
+		ctx.Writer = NewResponseTracker(w)
+		ctx.Writer.Header().Set("Content-Type", "application/x-ndjson")
+		_ = ctx.Stream(func(w io.Writer) error {
+			for i := range 2 {
+				fmt.Fprintf(w, "{\"row\":%d}\n", i)
+				<-next
+			}
+			return nil
+		})
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	// The first row arrives while the handler is still blocked.
+	lines := bufio.NewReader(resp.Body)
+	line, err := lines.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "{\"row\":0}\n", line)
+	next <- struct{}{}
+	line, err = lines.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "{\"row\":1}\n", line)
+	next <- struct{}{}
+}
+
+func TestContextStream_ErrorBeforeFirstWriteKeepsItsStatus(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	rec := httptest.NewRecorder()
+	tracker := NewResponseTracker(rec)
+	ctx := NewContext(req)
+	ctx.Writer = tracker
+
+	err := ctx.Stream(func(w io.Writer) error {
+		return &RequestError{Code: ErrorCodeForbidden, Message: "no export for you", Status: http.StatusForbidden}
+	})
+	require.Error(t, err)
+	require.False(t, tracker.Written())
+	WriteErrorEnvelope(tracker, err)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+}
+
+func TestContextStream_SendsHeadersHeldOnTheContext(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	rec := httptest.NewRecorder()
+	ctx := NewContext(req)
+	ctx.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+	ctx.SetCookie(&http.Cookie{Name: "exported", Value: "1"})
+	ctx.Writer = rec
+
+	require.NoError(t, ctx.Stream(func(w io.Writer) error {
+		_, err := io.WriteString(w, "a,b\n")
+		return err
+	}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `attachment; filename="users.csv"`, rec.Header().Get("Content-Disposition"))
+	require.Equal(t, "exported=1", rec.Header().Get("Set-Cookie"))
+}
+
+func TestContextStream_StopsWhenClientDisconnects(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/export", nil).WithContext(reqCtx)
+	rec := httptest.NewRecorder()
+	ctx := NewContext(req)
+	ctx.Writer = rec
+
+	err := ctx.Stream(func(w io.Writer) error {
+		if _, err := io.WriteString(w, "a,b\n"); err != nil {
+			return err
+		}
+		cancel()
+		for {
+			if _, err := io.WriteString(w, "1,2\n"); err != nil {
+				return err
+			}
+		}
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, "a,b\n", rec.Body.String())
+	require.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	require.True(t, rec.Flushed)
+}
@@ -0,0 +1,356 @@
+package rstf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CriticalCSS holds a parsed stylesheet and extracts the subset of rules a
+// rendered page needs for its first paint. Matching is conservative: a rule is
+// kept when every class, id, and element its selector names appears in the
+// page, ignoring combinators, pseudo-classes, and attribute selectors.
+// At-rules without selectors (@font-face, @keyframes, @property, ...) are
+// always kept.
+type CriticalCSS struct {
+	rules []cssRule
+}
+
+// cssRule is either a qualified rule (selectors + block), a conditional group
+// such as @media whose children are filtered, or an at-rule kept verbatim.
+type cssRule struct {
+	prelude   string
+	selectors []cssSelector
+	children  []cssRule
+	group     bool
+	text      string
+}
+
+// cssSelector is one comma-separated selector reduced to the names it requires.
+type cssSelector struct {
+	always  bool
+	classes []string
+	ids     []string
+	tags    []string
+}
+
+// groupAtRules contain rules of their own, which are filtered recursively.
+var groupAtRules = []string{"@media", "@supports", "@layer", "@container", "@scope"}
+
+var (
+	cssCommentRe  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	htmlClassRe   = regexp.MustCompile(`\sclass\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	htmlIDRe      = regexp.MustCompile(`\sid\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	htmlTagRe     = regexp.MustCompile(`<([a-zA-Z][\w-]*)`)
+	styleCloseTag = regexp.MustCompile(`(?i)</style`)
+)
+
+// ParseCriticalCSS parses css for repeated extraction.
+func ParseCriticalCSS(css string) *CriticalCSS {
+	return &CriticalCSS{rules: parseCSSRules(cssCommentRe.ReplaceAllString(css, ""))}
+}
+
+// Extract returns the rules that apply to html, safe to embed in a <style>
+// element.
+func (c *CriticalCSS) Extract(html string) string {
+	doc := scanHTMLNames(html)
+	var b strings.Builder
+	writeCSSRules(&b, c.rules, doc)
+	return styleCloseTag.ReplaceAllString(b.String(), `<\/style`)
+}
+
+func parseCSSRules(css string) []cssRule {
+	var rules []cssRule
+	i := 0
+	for i < len(css) {
+		end, terminator := scanCSS(css, i, "{;}")
+		prelude := strings.TrimSpace(css[i:end])
+		if terminator != '{' {
+			// Statement at-rules (@import, @charset, @layer a, b;) or a stray
+			// closing brace.
+			if strings.HasPrefix(prelude, "@") {
+				rules = append(rules, cssRule{text: prelude + ";"})
+			}
+			i = end + 1
+			continue
+		}
+
+		closeIdx := matchingBrace(css, end)
+		body := css[end+1 : closeIdx]
+		i = closeIdx + 1
+
+		switch {
+		case isGroupAtRule(prelude):
+			rules = append(rules, cssRule{prelude: prelude, group: true, children: parseCSSRules(body)})
+		case strings.HasPrefix(prelude, "@"):
+			rules = append(rules, cssRule{text: prelude + "{" + strings.TrimSpace(body) + "}"})
+		default:
+			rules = append(rules, cssRule{
+				selectors: parseSelectors(prelude),
+				text:      prelude + "{" + strings.TrimSpace(body) + "}",
+			})
+		}
+	}
+	return rules
+}
+
+func writeCSSRules(b *strings.Builder, rules []cssRule, doc htmlNames) {
+	for _, rule := range rules {
+		switch {
+		case rule.group:
+			var inner strings.Builder
+			writeCSSRules(&inner, rule.children, doc)
+			if inner.Len() > 0 {
+				b.WriteString(rule.prelude + "{" + inner.String() + "}")
+			}
+		case rule.selectors == nil:
+			b.WriteString(rule.text)
+		default:
+			for _, sel := range rule.selectors {
+				if doc.matches(sel) {
+					b.WriteString(rule.text)
+					break
+				}
+			}
+		}
+	}
+}
+
+// scanCSS returns the index of the first byte in stops outside of strings and
+// parentheses, or len(css) with terminator 0.
+func scanCSS(css string, i int, stops string) (int, byte) {
+	depth := 0
+	for ; i < len(css); i++ {
+		switch ch := css[i]; ch {
+		case '\\':
+			i++
+		case '"', '\'':
+			for i++; i < len(css) && css[i] != ch; i++ {
+				if css[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.IndexByte(stops, ch) >= 0 {
+				return i, ch
+			}
+		}
+	}
+	return len(css), 0
+}
+
+// matchingBrace returns the index of the brace closing the one at open.
+func matchingBrace(css string, open int) int {
+	depth := 0
+	i := open
+	for i < len(css) {
+		end, ch := scanCSS(css, i, "{}")
+		switch ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return end
+			}
+		default:
+			return len(css) - 1
+		}
+		i = end + 1
+	}
+	return len(css) - 1
+}
+
+func isGroupAtRule(prelude string) bool {
+	for _, name := range groupAtRules {
+		if prelude == name || strings.HasPrefix(prelude, name+" ") || strings.HasPrefix(prelude, name+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSelectors(prelude string) []cssSelector {
+	var selectors []cssSelector
+	for _, raw := range splitSelectorList(prelude) {
+		selectors = append(selectors, parseSelector(raw))
+	}
+	return selectors
+}
+
+// splitSelectorList splits on commas outside of parentheses and brackets.
+func splitSelectorList(prelude string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(prelude); i++ {
+		switch prelude[i] {
+		case '\\':
+			i++
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, prelude[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, prelude[start:])
+}
+
+// parseSelector collects the classes, ids, and element names a selector
+// requires. Pseudo-classes (including :not() and :is() arguments) and
+// attribute selectors are skipped, so the match errs toward keeping rules.
+func parseSelector(raw string) cssSelector {
+	sel := strings.TrimSpace(raw)
+	var s cssSelector
+	compoundStart := true
+	for i := 0; i < len(sel); {
+		ch := sel[i]
+		switch {
+		case ch == '.' || ch == '#':
+			name, next, ok := readCSSIdent(sel, i+1)
+			if !ok {
+				// Hex escapes are rare outside hand-written CSS; keep such
+				// rules rather than decode them.
+				return cssSelector{always: true}
+			}
+			if ch == '.' {
+				s.classes = append(s.classes, name)
+			} else {
+				s.ids = append(s.ids, name)
+			}
+			i = next
+			compoundStart = false
+		case ch == ':':
+			i++
+			for i < len(sel) && sel[i] == ':' {
+				i++
+			}
+			for i < len(sel) && isCSSIdentByte(sel[i]) {
+				i++
+			}
+			if i < len(sel) && sel[i] == '(' {
+				i = skipCSSGroup(sel, i, '(', ')')
+			}
+			compoundStart = false
+		case ch == '[':
+			i = skipCSSGroup(sel, i, '[', ']')
+			compoundStart = false
+		case compoundStart && isCSSIdentByte(ch) && ch != '-' && (ch < '0' || ch > '9'):
+			name, next, _ := readCSSIdent(sel, i)
+			s.tags = append(s.tags, strings.ToLower(name))
+			i = next
+			compoundStart = false
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '>' || ch == '+' || ch == '~':
+			i++
+			compoundStart = true
+		default:
+			i++
+			compoundStart = false
+		}
+	}
+	s.always = len(s.classes) == 0 && len(s.ids) == 0 && len(s.tags) == 0
+	return s
+}
+
+// readCSSIdent reads an identifier starting at i, resolving backslash
+// escapes. ok is false for hex escapes, which it does not decode.
+func readCSSIdent(sel string, i int) (name string, next int, ok bool) {
+	var b strings.Builder
+	for i < len(sel) {
+		ch := sel[i]
+		if ch == '\\' && i+1 < len(sel) {
+			if isHexByte(sel[i+1]) {
+				return "", i, false
+			}
+			b.WriteByte(sel[i+1])
+			i += 2
+			continue
+		}
+		if !isCSSIdentByte(ch) {
+			break
+		}
+		b.WriteByte(ch)
+		i++
+	}
+	return b.String(), i, true
+}
+
+// skipCSSGroup returns the index just past the close matching sel[i].
+func skipCSSGroup(sel string, i int, open, close byte) int {
+	depth := 0
+	for ; i < len(sel); i++ {
+		switch sel[i] {
+		case '\\':
+			i++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(sel)
+}
+
+func isCSSIdentByte(ch byte) bool {
+	return ch == '-' || ch == '_' || ch >= 0x80 ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+func isHexByte(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// htmlNames is the set of classes, ids, and element names used by a page.
+type htmlNames struct {
+	classes map[string]bool
+	ids     map[string]bool
+	tags    map[string]bool
+}
+
+func scanHTMLNames(html string) htmlNames {
+	doc := htmlNames{classes: map[string]bool{}, ids: map[string]bool{}, tags: map[string]bool{}}
+	for _, m := range htmlClassRe.FindAllStringSubmatch(html, -1) {
+		for _, class := range strings.Fields(m[1] + m[2]) {
+			doc.classes[class] = true
+		}
+	}
+	for _, m := range htmlIDRe.FindAllStringSubmatch(html, -1) {
+		doc.ids[strings.TrimSpace(m[1]+m[2])] = true
+	}
+	for _, m := range htmlTagRe.FindAllStringSubmatch(html, -1) {
+		doc.tags[strings.ToLower(m[1])] = true
+	}
+	return doc
+}
+
+func (d htmlNames) matches(s cssSelector) bool {
+	if s.always {
+		return true
+	}
+	for _, class := range s.classes {
+		if !d.classes[class] {
+			return false
+		}
+	}
+	for _, id := range s.ids {
+		if !d.ids[id] {
+			return false
+		}
+	}
+	for _, tag := range s.tags {
+		if !d.tags[tag] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package rstf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriticalCSS_KeepsRulesUsedByPage(t *testing.T) {
+	css := `
+/* reset */
+@charset "utf-8";
+:root { --brand: red; }
+*, ::before { box-sizing: border-box; }
+body { margin: 0; }
+.card { padding: 1rem; }
+.card .title:hover { color: var(--brand); }
+.modal { display: none; }
+#app > nav a { color: blue; }
+table td { border: 0; }
+.md\:flex { display: flex; }
+@media (min-width: 40rem) {
+  .card { padding: 2rem; }
+  .modal { display: block; }
+}
+@media print { .modal { color: black; } }
+@font-face { font-family: Inter; src: url("inter.woff2"); }
+@keyframes spin { to { transform: rotate(360deg); } }
+`
+	html := `<html><body><div id="app"><nav><a href="/">Home</a></nav>` +
+		`<div class="card md:flex"><h2 class="title">Hi</h2></div></div></body></html>`
+
+	got := ParseCriticalCSS(css).Extract(html)
+
+	for _, want := range []string{
+		`@charset "utf-8";`,
+		`:root{--brand: red;}`,
+		`*, ::before{box-sizing: border-box;}`,
+		`body{margin: 0;}`,
+		`.card{padding: 1rem;}`,
+		`.card .title:hover{color: var(--brand);}`,
+		`#app > nav a{color: blue;}`,
+		`.md\:flex{display: flex;}`,
+		`@media (min-width: 40rem){.card{padding: 2rem;}}`,
+		`@font-face{font-family: Inter; src: url("inter.woff2");}`,
+		`@keyframes spin{to { transform: rotate(360deg); }}`,
+	} {
+		assert.Contains(t, got, want)
+	}
+	assert.NotContains(t, got, ".modal")
+	assert.NotContains(t, got, "table td")
+	assert.NotContains(t, got, "@media print")
+	assert.NotContains(t, got, "reset")
+}
+
+func TestCriticalCSS_SelectorLists(t *testing.T) {
+	css := `.a, .b { color: red; } .c:not(.d) { color: blue; } input[type="text"] { border: 0; }`
+
+	got := ParseCriticalCSS(css).Extract(`<p class="b c"></p>`)
+
+	assert.Contains(t, got, `.a, .b{color: red;}`)
+	assert.Contains(t, got, `.c:not(.d){color: blue;}`)
+	assert.NotContains(t, got, "input")
+}
+
+func TestCriticalCSS_EscapesStyleClose(t *testing.T) {
+	css := `.a::after { content: "</style>"; }`
+
+	got := ParseCriticalCSS(css).Extract(`<p class='a'></p>`)
+
+	assert.Equal(t, `.a::after{content: "<\/style>";}`, got)
+}
@@ -0,0 +1,77 @@
+package rstf
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DatabasePool tunes the connection pool Database opens. Zero fields keep the
+// database/sql defaults: unlimited open connections, 2 idle connections, and
+// connections that are reused forever.
+type DatabasePool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DatabaseStats is the pool snapshot published on the metrics endpoint as
+// "database". WaitCount and WaitTime grow when requests queue for a
+// connection because MaxOpenConns are all in use.
+type DatabaseStats struct {
+	MaxOpenConns      int           `json:"maxOpenConns"`
+	OpenConns         int           `json:"openConns"`
+	InUse             int           `json:"inUse"`
+	Idle              int           `json:"idle"`
+	WaitCount         int64         `json:"waitCount"`
+	WaitTime          time.Duration `json:"waitTimeNs"`
+	MaxIdleClosed     int64         `json:"maxIdleClosed"`
+	MaxIdleTimeClosed int64         `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed int64         `json:"maxLifetimeClosed"`
+}
+
+// SetDatabasePool configures the connection pool. Call it before or after
+// Database; settings made before apply when the pool opens.
+func (a *App) SetDatabasePool(pool DatabasePool) error {
+	if pool.MaxOpenConns < 0 || pool.MaxIdleConns < 0 || pool.ConnMaxLifetime < 0 || pool.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("database pool settings must not be negative")
+	}
+	if pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		return fmt.Errorf("database pool max idle conns (%d) must not exceed max open conns (%d)", pool.MaxIdleConns, pool.MaxOpenConns)
+	}
+	a.databasePool = &pool
+	if a.db != nil {
+		applyDatabasePool(a.db, pool)
+	}
+	return nil
+}
+
+func applyDatabasePool(db *sql.DB, pool DatabasePool) {
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+func newDatabaseStats(s sql.DBStats) DatabaseStats {
+	return DatabaseStats{
+		MaxOpenConns:      s.MaxOpenConnections,
+		OpenConns:         s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitTime:          s.WaitDuration,
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
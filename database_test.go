@@ -0,0 +1,49 @@
+package rstf
+
+import (
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDatabasePool_AppliesWhenDatabaseOpens(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetDatabasePool(DatabasePool{MaxOpenConns: 4, MaxIdleConns: 2, ConnMaxLifetime: time.Minute}))
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+
+	assert.Equal(t, 4, app.DB().Stats().MaxOpenConnections)
+}
+
+func TestSetDatabasePool_AppliesToOpenDatabase(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+	require.NoError(t, app.SetDatabasePool(DatabasePool{MaxOpenConns: 3}))
+
+	assert.Equal(t, 3, app.DB().Stats().MaxOpenConnections)
+}
+
+func TestSetDatabasePool_Invalid(t *testing.T) {
+	app := NewApp()
+	require.EqualError(t, app.SetDatabasePool(DatabasePool{MaxOpenConns: -1}), "database pool settings must not be negative")
+	require.EqualError(t, app.SetDatabasePool(DatabasePool{MaxOpenConns: 2, MaxIdleConns: 5}), "database pool max idle conns (5) must not exceed max open conns (2)")
+}
+
+func TestMetrics_IncludesDatabaseStats(t *testing.T) {
+	app := NewApp()
+	assert.NotContains(t, app.Metrics(), "database")
+
+	require.NoError(t, app.SetDatabasePool(DatabasePool{MaxOpenConns: 2}))
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+
+	stats, ok := app.Metrics()["database"].(DatabaseStats)
+	require.True(t, ok)
+	assert.Equal(t, 2, stats.MaxOpenConns)
+	assert.Equal(t, 1, stats.OpenConns, "Database pings, leaving one idle connection")
+	assert.Equal(t, 1, stats.Idle)
+}
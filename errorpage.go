@@ -0,0 +1,99 @@
+package rstf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DevModeEnv is set to "1" by rstf dev for the app server it runs.
+	DevModeEnv = "RSTF_DEV"
+	// RenderTimingEnv is set to "1" by rstf dev --verbose, so the app server
+	// logs how long each page spent loading data and rendering.
+	RenderTimingEnv = "RSTF_RENDER_TIMING"
+)
+
+var serverErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>500 Internal Server Error</title></head>
+<body style="font-family: system-ui, sans-serif; margin: 2rem">
+<h1>500 Internal Server Error</h1>
+{{- if .Dev}}
+<pre style="white-space: pre-wrap">{{.Message}}</pre>
+{{- if .Stack}}
+<pre style="white-space: pre-wrap; color: #666">{{.Stack}}</pre>
+{{- end}}
+{{- else}}
+<p>Something went wrong on our end.</p>
+{{- end}}
+<p style="color: #666">Error ID: <code>{{.ID}}</code></p>
+</body>
+</html>
+`))
+
+// SetDevMode controls whether WriteServerError shows error details. It is on
+// by default only when the server runs under rstf dev.
+func (a *App) SetDevMode(enabled bool) {
+	a.devMode = enabled
+}
+
+// DevMode reports whether error responses include diagnostics.
+func (a *App) DevMode() bool {
+	return a.devMode
+}
+
+// RenderTiming reports whether the generated server logs each page's data
+// and render time.
+func (a *App) RenderTiming() bool {
+	return a.renderTiming
+}
+
+// WriteServerError responds 500 for a request that failed with err and
+// returns the error ID it generated. In dev mode the response shows err and
+// stack. Otherwise it only names the ID, which is logged together with err
+// so the failure can be found. HTML requests get an error page, anything
+// else the JSON error envelope.
+func (a *App) WriteServerError(w http.ResponseWriter, req *http.Request, err error, stack []byte) string {
+	id := newErrorID()
+	message := "internal server error"
+	if err != nil {
+		message = err.Error()
+	}
+	NewLogger().Error("request failed", "errorId", id, "method", req.Method, "path", req.URL.Path, "error", message)
+
+	dev := a.DevMode()
+	if strings.Contains(req.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		serverErrorPage.Execute(w, map[string]any{
+			"Dev":     dev,
+			"Message": message,
+			"Stack":   string(stack),
+			"ID":      id,
+		})
+		return id
+	}
+
+	details := map[string]any{"errorId": id}
+	if !dev {
+		message = "internal server error"
+	} else if len(stack) > 0 {
+		details["stack"] = string(stack)
+	}
+	WriteErrorEnvelope(w, &RequestError{
+		Code:    ErrorCodeInternal,
+		Message: message,
+		Details: details,
+		Status:  http.StatusInternalServerError,
+	})
+	return id
+}
+
+func newErrorID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,67 @@
+package rstf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteServerError_ProductionHidesDetails(t *testing.T) {
+	app := NewApp()
+	app.SetDevMode(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	id := app.WriteServerError(rec, req, errors.New("open /srv/app/secret.db: denied"), []byte("main.go:12"))
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), id)
+	require.NotContains(t, rec.Body.String(), "secret.db")
+	require.NotContains(t, rec.Body.String(), "main.go")
+}
+
+func TestWriteServerError_DevShowsDetails(t *testing.T) {
+	app := NewApp()
+	app.SetDevMode(true)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	app.WriteServerError(rec, req, errors.New("render <Dashboard> failed"), []byte("at Dashboard (routes/dashboard/index.tsx:4)"))
+
+	require.Contains(t, rec.Body.String(), "render &lt;Dashboard&gt; failed")
+	require.Contains(t, rec.Body.String(), "routes/dashboard/index.tsx:4")
+}
+
+func TestWriteServerError_JSONEnvelope(t *testing.T) {
+	app := NewApp()
+	app.SetDevMode(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard?_data", nil)
+	rec := httptest.NewRecorder()
+	id := app.WriteServerError(rec, req, errors.New("db down"), nil)
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.JSONEq(t, `{"error":{"code":"internal_error","message":"internal server error","details":{"errorId":"`+id+`"}}}`, rec.Body.String())
+}
+
+func TestNewApp_DevModeFromEnv(t *testing.T) {
+	t.Setenv(DevModeEnv, "1")
+	require.True(t, NewApp().DevMode())
+
+	t.Setenv(DevModeEnv, "")
+	require.False(t, NewApp().DevMode())
+}
+
+func TestNewApp_RenderTimingFromEnv(t *testing.T) {
+	t.Setenv(DevModeEnv, "1")
+	require.False(t, NewApp().RenderTiming(), "dev mode alone doesn't log render timing")
+
+	t.Setenv(RenderTimingEnv, "1")
+	require.True(t, NewApp().RenderTiming())
+}
@@ -0,0 +1,104 @@
+package rstf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// EventStream sends typed server-sent events to one client. E is a struct with
+// one field per event type, usually pointers to the event payloads:
+//
+//	type ChatEvent struct {
+//		Message *MessagePosted `json:"message"`
+//		Typing  *UserTyping    `json:"typing"`
+//	}
+//
+// Each Send sets exactly one field. The field's JSON name becomes the event's
+// type, which the generated client uses to discriminate between payloads.
+type EventStream[E any] struct {
+	w io.Writer
+}
+
+type streamEvent struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// ServeEvents responds with a text/event-stream and runs fn until it returns
+// or the client disconnects. The generated server calls it for route
+// functions shaped like func Events(ctx *rstf.Context, stream *rstf.EventStream[E]) error.
+func ServeEvents[E any](ctx *Context, fn func(*Context, *EventStream[E]) error) error {
+	if ctx == nil || ctx.Writer == nil {
+		return &RequestError{
+			Code:    ErrorCodeInternal,
+			Message: "response writer is not initialized",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	return ctx.Stream(func(w io.Writer) error {
+		return fn(ctx, &EventStream[E]{w: w})
+	})
+}
+
+// Send writes event to the client. It fails when event does not set exactly
+// one field, and once the client has disconnected.
+func (s *EventStream[E]) Send(event E) error {
+	eventType, data, err := eventPayload(event)
+	if err != nil {
+		return err
+	}
+	data, err = FormatFields(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(streamEvent{Type: eventType, Data: data})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "data: %s\n\n", payload)
+	return err
+}
+
+// eventPayload returns the JSON name and value of the one field event sets.
+func eventPayload(event any) (string, any, error) {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("event stream: %T is not a struct", event)
+	}
+	var name string
+	var data any
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() || v.Field(i).IsZero() {
+			continue
+		}
+		jsonName := eventFieldName(field)
+		if jsonName == "-" {
+			continue
+		}
+		if name != "" {
+			return "", nil, fmt.Errorf("event stream: %T sets both %s and %s", event, name, jsonName)
+		}
+		name, data = jsonName, v.Field(i).Interface()
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("event stream: %T sets no event", event)
+	}
+	return name, data, nil
+}
+
+// eventFieldName matches the name codegen gives the event type: the json tag,
+// or the field name with a lowercase first letter.
+func eventFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name[:1]) + field.Name[1:]
+}
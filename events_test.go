@@ -0,0 +1,69 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+type testTyping struct {
+	User string `json:"user"`
+}
+
+type testEvent struct {
+	Message *testMessage `json:"message"`
+	Typing  *testTyping
+	Ignored *testTyping `json:"-"`
+}
+
+func TestServeEvents_SendsTypedEvents(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/__rstf/live/events", nil)
+	rec := httptest.NewRecorder()
+	ctx := NewContext(req)
+	ctx.Writer = rec
+
+	err := ServeEvents(ctx, func(ctx *Context, stream *EventStream[testEvent]) error {
+		if err := stream.Send(testEvent{Message: &testMessage{Body: "hi"}}); err != nil {
+			return err
+		}
+		return stream.Send(testEvent{Typing: &testTyping{User: "ana"}})
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t,
+		"data: {\"type\":\"message\",\"data\":{\"body\":\"hi\"}}\n\n"+
+			"data: {\"type\":\"typing\",\"data\":{\"user\":\"ana\"}}\n\n",
+		rec.Body.String())
+	require.True(t, rec.Flushed)
+}
+
+func TestEventStream_SendRequiresExactlyOneEvent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/__rstf/live/events", nil)
+	rec := httptest.NewRecorder()
+	ctx := NewContext(req)
+	ctx.Writer = rec
+
+	var errs []error
+	_ = ServeEvents(ctx, func(ctx *Context, stream *EventStream[testEvent]) error {
+		errs = append(errs,
+			stream.Send(testEvent{}),
+			stream.Send(testEvent{Ignored: &testTyping{}}),
+			stream.Send(testEvent{Message: &testMessage{}, Typing: &testTyping{}}),
+		)
+		return nil
+	})
+
+	require.Len(t, errs, 3)
+	require.EqualError(t, errs[0], "event stream: rstf.testEvent sets no event")
+	require.EqualError(t, errs[1], "event stream: rstf.testEvent sets no event")
+	require.EqualError(t, errs[2], "event stream: rstf.testEvent sets both message and typing")
+	require.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,119 @@
+package rstf
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FieldErrors reports which input fields of a mutation or action are invalid,
+// keyed by their json names. A function that returns (T, *rstf.FieldErrors)
+// gets a typed result on the client: { ok: true, data } when it returns nil
+// or no errors, { ok: false, errors } otherwise. <Form> marks the inputs
+// with those names as invalid.
+//
+//	func CreatePost(ctx *rstf.ActionContext, input CreatePostInput) (Post, *rstf.FieldErrors) {
+//		var errs rstf.FieldErrors
+//		if input.Title == "" {
+//			errs.Add("title", "Title is required")
+//		}
+//		if errs.Len() > 0 {
+//			return Post{}, &errs
+//		}
+//		...
+//	}
+type FieldErrors struct {
+	fields map[string]string
+}
+
+// Add records message for field. A field keeps its first message.
+func (e *FieldErrors) Add(field, message string) {
+	if e.fields == nil {
+		e.fields = map[string]string{}
+	}
+	if _, ok := e.fields[field]; !ok {
+		e.fields[field] = message
+	}
+}
+
+// Get returns the message recorded for field, or "".
+func (e *FieldErrors) Get(field string) string {
+	if e == nil {
+		return ""
+	}
+	return e.fields[field]
+}
+
+// Len returns the number of invalid fields.
+func (e *FieldErrors) Len() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.fields)
+}
+
+// MarshalJSON encodes the errors as an object of field names to messages.
+func (e *FieldErrors) MarshalJSON() ([]byte, error) {
+	if e == nil || e.fields == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(e.fields)
+}
+
+// String lists the errors one per line, sorted by field, for responses to
+// forms submitted without JavaScript.
+func (e *FieldErrors) String() string {
+	if e.Len() == 0 {
+		return ""
+	}
+	fields := make([]string, 0, len(e.fields))
+	for field := range e.fields {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field + ": " + e.fields[field] + "\n")
+	}
+	return b.String()
+}
+
+// ActionResult is the result of a mutation or action that returns
+// (T, *FieldErrors), as the generated server sends it to the client.
+type ActionResult struct {
+	Data   any
+	Errors *FieldErrors
+}
+
+// NewActionResult returns the result of a call that returned data and errs.
+func NewActionResult(data any, errs *FieldErrors) ActionResult {
+	return ActionResult{Data: data, Errors: errs}
+}
+
+// OK reports whether the call had no field errors.
+func (r ActionResult) OK() bool {
+	return r.Errors.Len() == 0
+}
+
+// MarshalJSON encodes the result as { "ok": true, "data": ... } or
+// { "ok": false, "errors": { field: message } }.
+func (r ActionResult) MarshalJSON() ([]byte, error) {
+	if r.OK() {
+		data, err := FormatFields(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]any{"ok": true, "data": data})
+	}
+	return json.Marshal(map[string]any{"ok": false, "errors": r.Errors})
+}
+
+// RPCResultStatus returns the HTTP status of an RPC response carrying result:
+// 422 Unprocessable Entity for an ActionResult with field errors, else 200 OK.
+func RPCResultStatus(result any) int {
+	if r, ok := result.(ActionResult); ok && !r.OK() {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
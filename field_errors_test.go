@@ -0,0 +1,50 @@
+package rstf
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldErrors(t *testing.T) {
+	var errs FieldErrors
+	assert.Equal(t, 0, errs.Len())
+	errs.Add("title", "Title is required")
+	errs.Add("title", "Title is too long")
+	assert.Equal(t, 1, errs.Len())
+	assert.Equal(t, "Title is required", errs.Get("title"))
+	assert.Empty(t, errs.Get("body"))
+
+	var none *FieldErrors
+	assert.Equal(t, 0, none.Len())
+	assert.Empty(t, none.Get("title"))
+}
+
+func TestActionResult(t *testing.T) {
+	cases := []struct {
+		name   string
+		result ActionResult
+		json   string
+		status int
+	}{
+		{"nil errors", NewActionResult(map[string]int{"id": 1}, nil), `{"ok":true,"data":{"id":1}}`, http.StatusOK},
+		{"no errors", NewActionResult(0, &FieldErrors{}), `{"ok":true,"data":0}`, http.StatusOK},
+		{"field errors", NewActionResult(nil, fieldErrors("title", "Title is required")), `{"ok":false,"errors":{"title":"Title is required"}}`, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range cases {
+		data, err := json.Marshal(tc.result)
+		require.NoError(t, err, tc.name)
+		assert.JSONEq(t, tc.json, string(data), tc.name)
+		assert.Equal(t, tc.status, RPCResultStatus(tc.result), tc.name)
+	}
+	assert.Equal(t, http.StatusOK, RPCResultStatus(map[string]int{"id": 1}))
+}
+
+func fieldErrors(field, message string) *FieldErrors {
+	var errs FieldErrors
+	errs.Add(field, message)
+	return &errs
+}
@@ -0,0 +1,286 @@
+package rstf
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// formInputField holds the input of a function whose input is not a struct,
+// e.g. func Echo(ctx *rstf.ActionContext, input string).
+const formInputField = "input"
+
+// RPCInput is the input of a mutation or action call: the JSON "input" of an
+// RPC request, or the fields of a submitted <Form>.
+type RPCInput struct {
+	JSON json.RawMessage
+	Form url.Values
+}
+
+// Decode stores the input in target, a pointer to the function's input type.
+func (in RPCInput) Decode(target any) error {
+	if in.Form != nil {
+		return DecodeForm(in.Form, target)
+	}
+	if len(in.JSON) == 0 {
+		return &RequestError{Code: ErrorCodeInvalidPayload, Message: "input is required", Status: http.StatusBadRequest}
+	}
+	if err := json.Unmarshal(in.JSON, target); err != nil {
+		return &RequestError{Code: ErrorCodeInvalidPayload, Message: "invalid rpc input", Status: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// DecodeForm stores form fields in target, a pointer to a struct, matching
+// fields by their json names. Fields may be strings, booleans, numbers,
+// pointers to those, or slices of them, which take every value of a repeated
+// field. A checkbox posts "on" when checked and nothing otherwise, so a
+// missing boolean field is false. A target that is not a struct reads the
+// field named "input".
+func DecodeForm(form url.Values, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("rstf: DecodeForm target must be a non-nil pointer, got %T", target)
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		values, ok := form[formInputField]
+		if !ok {
+			return &RequestError{Code: ErrorCodeInvalidPayload, Message: "input is required", Status: http.StatusBadRequest}
+		}
+		return setFormValue(v, formInputField, values)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		values, ok := form[name]
+		if !ok {
+			continue
+		}
+		if err := setFormValue(v.Field(i), name, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setFormValue(v reflect.Value, name string, values []string) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormScalar(slice.Index(i), name, value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Pointer:
+		if len(values) == 0 {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := setFormScalar(elem.Elem(), name, values[len(values)-1]); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return setFormScalar(v, name, values[len(values)-1])
+}
+
+func setFormScalar(v reflect.Value, name, value string) error {
+	invalid := func() error {
+		return &RequestError{
+			Code:    ErrorCodeInvalidPayload,
+			Message: fmt.Sprintf("invalid form field %q", name),
+			Details: map[string]any{"field": name},
+			Status:  http.StatusBadRequest,
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "on", "true", "1":
+			v.SetBool(true)
+		case "", "off", "false", "0":
+			v.SetBool(false)
+		default:
+			return invalid()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+		v.SetFloat(n)
+	default:
+		return &RequestError{
+			Code:    ErrorCodeInvalidPayload,
+			Message: fmt.Sprintf("form field %q cannot be decoded into %s", name, v.Type()),
+			Details: map[string]any{"field": name},
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// FormSubmission is a mutation or action call posted by a generated <Form>.
+// The form's action URL names the function and its route params; the body
+// holds the input fields.
+type FormSubmission struct {
+	Kind   string
+	Route  string
+	Name   string
+	Params map[string]string
+	Input  RPCInput
+	// Redirect is the local path the browser returns to after a submission
+	// without JavaScript.
+	Redirect string
+}
+
+// IsFormSubmission reports whether req posts form fields rather than a JSON
+// RPC payload.
+func IsFormSubmission(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data")
+}
+
+// ParseFormSubmission reads a form posted to the RPC endpoint, limiting the
+// body to limit bytes.
+func ParseFormSubmission(w http.ResponseWriter, req *http.Request, limit int64) (FormSubmission, error) {
+	query := req.URL.Query()
+	submission := FormSubmission{
+		Kind:     query.Get("kind"),
+		Route:    query.Get("route"),
+		Name:     query.Get("name"),
+		Params:   map[string]string{},
+		Redirect: formRedirect(req, query.Get("redirect")),
+	}
+	if raw := query.Get("params"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &submission.Params); err != nil {
+			return submission, &RequestError{Code: ErrorCodeInvalidPayload, Message: "params must be a JSON object of strings", Status: http.StatusBadRequest}
+		}
+	}
+
+	if err := parseFormBody(w, req, limit); err != nil {
+		return submission, err
+	}
+	submission.Input = RPCInput{Form: req.PostForm}
+	return submission, nil
+}
+
+// parseFormBody parses the form fields of req's body into req.PostForm,
+// limiting the body to limit bytes. It is a no-op once the body is parsed.
+func parseFormBody(w http.ResponseWriter, req *http.Request, limit int64) error {
+	if req.PostForm != nil {
+		return nil
+	}
+	req.Body = http.MaxBytesReader(w, req.Body, limit)
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		err = req.ParseMultipartForm(limit)
+	} else {
+		err = req.ParseForm()
+	}
+	if err != nil {
+		if re := requestErrorFrom(err); re.Code == ErrorCodePayloadTooLarge {
+			return re
+		}
+		return &RequestError{Code: ErrorCodeInvalidPayload, Message: "invalid form body", Status: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// WriteResult answers a form submission. A fetch from a hydrated <Form>,
+// which accepts JSON, gets the RPC response. A plain browser submission is
+// redirected back with 303 See Other, so the page renders with fresh server
+// data, or gets the error or field errors.
+func (s FormSubmission) WriteResult(w http.ResponseWriter, req *http.Request, app *App, result any, err error) {
+	if err != nil {
+		writeFormError(w, req, app, err)
+		return
+	}
+	if acceptsJSONResult(req) {
+		data, err := FormatFields(result)
+		if err != nil {
+			writeFormError(w, req, app, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(RPCResultStatus(result))
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+		return
+	}
+	if r, ok := result.(ActionResult); ok && !r.OK() {
+		http.Error(w, r.Errors.String(), http.StatusUnprocessableEntity)
+		return
+	}
+	http.Redirect(w, req, s.Redirect, http.StatusSeeOther)
+}
+
+// writeFormError answers a form post with err: the error envelope for a
+// fetch, or a plain error for a browser.
+func writeFormError(w http.ResponseWriter, req *http.Request, app *App, err error) {
+	if acceptsJSONResult(req) {
+		WriteErrorEnvelope(w, err)
+		return
+	}
+	if re := requestErrorFrom(err); re.Code != ErrorCodeInternal {
+		http.Error(w, re.Message, re.Status)
+		return
+	}
+	app.WriteServerError(w, req, err, nil)
+}
+
+func acceptsJSONResult(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "application/json")
+}
+
+// formRedirect returns the path to send the browser back to: the form's
+// redirect target when it is a local path, else the submitting page.
+func formRedirect(req *http.Request, target string) string {
+	if isLocalPath(target) {
+		return target
+	}
+	if referer, err := url.Parse(req.Referer()); err == nil && referer.Host == req.Host && isLocalPath(referer.RequestURI()) {
+		return referer.RequestURI()
+	}
+	return "/"
+}
+
+func isLocalPath(target string) bool {
+	return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, "/\\")
+}
@@ -0,0 +1,180 @@
+package rstf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeForm(t *testing.T) {
+	type signup struct {
+		Email    string   `json:"email"`
+		Age      int      `json:"age"`
+		Score    *float64 `json:"score"`
+		Terms    bool     `json:"terms"`
+		Tags     []string `json:"tags"`
+		Nickname string
+		Secret   string `json:"-"`
+	}
+	var got signup
+	require.NoError(t, DecodeForm(url.Values{
+		"email":    {"ada@example.com"},
+		"age":      {" 36 "},
+		"score":    {"9.5"},
+		"terms":    {"on"},
+		"tags":     {"math", "engines"},
+		"Nickname": {"ada"},
+		"Secret":   {"leak"},
+		"unknown":  {"ignored"},
+	}, &got))
+	score := 9.5
+	assert.Equal(t, signup{
+		Email:    "ada@example.com",
+		Age:      36,
+		Score:    &score,
+		Terms:    true,
+		Tags:     []string{"math", "engines"},
+		Nickname: "ada",
+	}, got)
+
+	err := DecodeForm(url.Values{"age": {"old"}}, &got)
+	var re *RequestError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, ErrorCodeInvalidPayload, re.Code)
+	assert.Equal(t, `invalid form field "age"`, re.Message)
+}
+
+func TestDecodeForm_NonStructInput(t *testing.T) {
+	var value string
+	require.NoError(t, DecodeForm(url.Values{"input": {"hello"}}, &value))
+	assert.Equal(t, "hello", value)
+
+	err := DecodeForm(url.Values{}, &value)
+	require.Error(t, err)
+	assert.Equal(t, "input is required", err.Error())
+}
+
+func TestRPCInputDecode(t *testing.T) {
+	var value struct {
+		Body string `json:"body"`
+	}
+	require.NoError(t, RPCInput{JSON: []byte(`{"body":"hi"}`)}.Decode(&value))
+	assert.Equal(t, "hi", value.Body)
+	require.NoError(t, RPCInput{Form: url.Values{"body": {"form"}}}.Decode(&value))
+	assert.Equal(t, "form", value.Body)
+
+	assert.EqualError(t, RPCInput{}.Decode(&value), "input is required")
+	assert.EqualError(t, RPCInput{JSON: []byte(`[`)}.Decode(&value), "invalid rpc input")
+}
+
+func postForm(target, body string, header http.Header) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req
+}
+
+func TestParseFormSubmission(t *testing.T) {
+	req := postForm(
+		"/__rstf/rpc?kind=action&route=users._id&name=Rename&params=%7B%22id%22%3A%227%22%7D",
+		"name=Ada",
+		http.Header{"Referer": {"http://example.com/users/7?tab=profile"}},
+	)
+	require.True(t, IsFormSubmission(req))
+
+	form, err := ParseFormSubmission(httptest.NewRecorder(), req, DefaultBodyLimit)
+	require.NoError(t, err)
+	assert.Equal(t, "action", form.Kind)
+	assert.Equal(t, "users._id", form.Route)
+	assert.Equal(t, "Rename", form.Name)
+	assert.Equal(t, map[string]string{"id": "7"}, form.Params)
+	assert.Equal(t, url.Values{"name": {"Ada"}}, form.Input.Form)
+	assert.Equal(t, "/users/7?tab=profile", form.Redirect)
+
+	req = postForm("/__rstf/rpc?params=nope", "", nil)
+	_, err = ParseFormSubmission(httptest.NewRecorder(), req, DefaultBodyLimit)
+	assert.EqualError(t, err, "params must be a JSON object of strings")
+
+	req = postForm("/__rstf/rpc", strings.Repeat("a", 64), nil)
+	_, err = ParseFormSubmission(httptest.NewRecorder(), req, 16)
+	var re *RequestError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, ErrorCodePayloadTooLarge, re.Code)
+
+	jsonReq := httptest.NewRequest(http.MethodPost, "/__rstf/rpc", strings.NewReader("{}"))
+	jsonReq.Header.Set("Content-Type", "application/json")
+	assert.False(t, IsFormSubmission(jsonReq))
+}
+
+func TestFormRedirect(t *testing.T) {
+	cases := []struct {
+		target  string
+		referer string
+		want    string
+	}{
+		{"/thanks", "", "/thanks"},
+		{"//evil.example", "http://example.com/posts", "/posts"},
+		{"https://evil.example", "", "/"},
+		{"", "http://other.example/posts", "/"},
+	}
+	for _, tc := range cases {
+		req := postForm("http://example.com/__rstf/rpc", "", http.Header{"Referer": {tc.referer}})
+		assert.Equal(t, tc.want, formRedirect(req, tc.target), "redirect=%q referer=%q", tc.target, tc.referer)
+	}
+}
+
+func TestFormSubmissionWriteResult(t *testing.T) {
+	app := NewApp()
+	form := FormSubmission{Redirect: "/posts"}
+
+	rec := httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", nil), app, map[string]string{"id": "1"}, nil)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/posts", rec.Header().Get("Location"))
+
+	rec = httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", http.Header{"Accept": {"application/json"}}), app, map[string]string{"id": "1"}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"id":"1"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", nil), app, nil, ValidationError("title is required", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "title is required")
+
+	rec = httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", http.Header{"Accept": {"application/json"}}), app, nil, ValidationError("title is required", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"validation_failed"`)
+}
+
+func TestFormSubmissionWriteResultFieldErrors(t *testing.T) {
+	app := NewApp()
+	form := FormSubmission{Redirect: "/posts"}
+	var errs FieldErrors
+	errs.Add("title", "Title is required")
+	errs.Add("body", "Body is too short")
+	result := NewActionResult(nil, &errs)
+
+	rec := httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", http.Header{"Accept": {"application/json"}}), app, result, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{"data":{"ok":false,"errors":{"title":"Title is required","body":"Body is too short"}}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", nil), app, result, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "body: Body is too short\ntitle: Title is required\n\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	form.WriteResult(rec, postForm("/__rstf/rpc", "", nil), app, NewActionResult(map[string]string{"id": "1"}, nil), nil)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+}
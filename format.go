@@ -0,0 +1,331 @@
+package rstf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatTag is the struct tag that sets how a field is sent to the browser,
+// for values JSON has no agreed representation for. Codegen gives the field
+// the matching TypeScript type, and @rstf/format has helpers to display it:
+//
+//	type Order struct {
+//		Total    int64     `json:"total" rstf:"format=currency,currency=EUR"`
+//		PlacedAt time.Time `json:"placedAt" rstf:"format=datetime,tz=Europe/Berlin"`
+//		ShipsOn  time.Time `json:"shipsOn" rstf:"format=date"`
+//	}
+//
+// The formats are:
+//
+//   - currency: an integer amount in minor units, such as cents, sent as
+//     { amount: "12.50", currency: "EUR" }. The currency option is required.
+//   - datetime: a time.Time sent as an RFC 3339 string. With tz, the time is
+//     converted to that IANA zone first, so the offset is the zone's.
+//   - date: a time.Time sent as "2006-01-02", in the tz zone when set.
+//
+// Formats apply to server data, query, mutation, and action results, live
+// query updates, and event stream events.
+const FormatTag = "rstf"
+
+// FormatFields returns v with the fields tagged with FormatTag converted to
+// their format, as the JSON value encoding v would produce. It returns v
+// unchanged when its type has no formatted fields. Fields of interface type
+// are not inspected, except the values of a map[string]any.
+func FormatFields(v any) (any, error) {
+	if m, ok := v.(map[string]any); ok {
+		out := make(map[string]any, len(m))
+		for key, value := range m {
+			formatted, err := FormatFields(value)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = formatted
+		}
+		return out, nil
+	}
+	if v == nil || !hasFormats(reflect.TypeOf(v)) {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	out, err = applyFormats(reflect.ValueOf(v), out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fieldFormat is a parsed FormatTag, e.g. format=currency,currency=EUR.
+type fieldFormat struct {
+	name    string
+	options map[string]string
+}
+
+func parseFormat(tag string) (fieldFormat, error) {
+	f := fieldFormat{options: map[string]string{}}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || value == "" {
+			return f, fmt.Errorf("%s tag %q: %q is not key=value", FormatTag, tag, part)
+		}
+		if key == "format" {
+			f.name = value
+			continue
+		}
+		f.options[key] = value
+	}
+	if f.name == "" {
+		return f, fmt.Errorf("%s tag %q has no format", FormatTag, tag)
+	}
+	return f, nil
+}
+
+var formatTypes sync.Map // reflect.Type -> bool, see hasFormats
+
+// hasFormats reports whether values of t can hold a field tagged with
+// FormatTag.
+func hasFormats(t reflect.Type) bool {
+	if cached, ok := formatTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	// Only the outer result is cached: inside a recursive type, a type seen
+	// while it is still being visited reads as false.
+	has := typeHasFormats(t, map[reflect.Type]bool{})
+	formatTypes.Store(t, has)
+	return has
+}
+
+func typeHasFormats(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	var has bool
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		has = typeHasFormats(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if _, ok := f.Tag.Lookup(FormatTag); ok || typeHasFormats(f.Type, visiting) {
+				has = true
+				break
+			}
+		}
+	}
+	return has
+}
+
+// applyFormats replaces the formatted fields of v in out, the JSON value v
+// encodes as, and returns out.
+func applyFormats(v reflect.Value, out any) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return out, nil
+		}
+		v = v.Elem()
+	}
+	if !hasFormats(v.Type()) {
+		return out, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if m, ok := out.(map[string]any); ok {
+			return m, applyStructFormats(v, m)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := out.([]any)
+		if !ok || len(items) != v.Len() {
+			return out, nil
+		}
+		for i := range items {
+			item, err := applyFormats(v.Index(i), items[i])
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+	case reflect.Map:
+		m, ok := out.(map[string]any)
+		if !ok {
+			return out, nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if value, ok := m[key]; ok {
+				formatted, err := applyFormats(iter.Value(), value)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = formatted
+			}
+		}
+	}
+	return out, nil
+}
+
+// applyStructFormats formats the fields of struct v in m. Fields of embedded
+// structs without a json name are promoted into m, as encoding/json does.
+func applyStructFormats(v reflect.Value, m map[string]any) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := applyStructFormats(fv, m); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		value, ok := m[name]
+		if !ok {
+			continue
+		}
+		tag, tagged := f.Tag.Lookup(FormatTag)
+		if !tagged {
+			formatted, err := applyFormats(fv, value)
+			if err != nil {
+				return err
+			}
+			m[name] = formatted
+			continue
+		}
+		format, err := parseFormat(tag)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		formatted, err := formatValue(fv, format)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		m[name] = formatted
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// formatValue converts a tagged field's value to its format. A nil pointer
+// stays null.
+func formatValue(v reflect.Value, format fieldFormat) (any, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch format.name {
+	case "currency":
+		code := format.options["currency"]
+		if len(code) != 3 || strings.ToUpper(code) != code {
+			return nil, fmt.Errorf("format=currency needs currency=<ISO 4217 code>, e.g. currency=USD")
+		}
+		var amount string
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			minor := v.Int()
+			if minor < 0 {
+				// Negating in uint64 keeps math.MinInt64 from overflowing.
+				amount = "-" + formatMinorUnits(-uint64(minor), currencyDigits(code))
+			} else {
+				amount = formatMinorUnits(uint64(minor), currencyDigits(code))
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			amount = formatMinorUnits(v.Uint(), currencyDigits(code))
+		default:
+			return nil, fmt.Errorf("format=currency needs an integer amount in minor units, not %s", v.Type())
+		}
+		return map[string]any{"amount": amount, "currency": code}, nil
+	case "datetime", "date":
+		if v.Type() != timeType {
+			return nil, fmt.Errorf("format=%s needs a time.Time, not %s", format.name, v.Type())
+		}
+		t := v.Interface().(time.Time)
+		if tz := format.options["tz"]; tz != "" {
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return nil, err
+			}
+			t = t.In(loc)
+		}
+		if format.name == "date" {
+			return t.Format(time.DateOnly), nil
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format.name)
+}
+
+// formatMinorUnits writes minor as a decimal with digits fraction digits,
+// e.g. 1250 with 2 digits is "12.50".
+func formatMinorUnits(minor uint64, digits int) string {
+	s := strconv.FormatUint(minor, 10)
+	if digits == 0 {
+		return s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// currencyDigits is the number of minor unit digits of an ISO 4217 currency.
+func currencyDigits(code string) int {
+	switch code {
+	case "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG", "RWF",
+		"UGX", "UYI", "VND", "VUV", "XAF", "XOF", "XPF":
+		return 0
+	case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+		return 3
+	}
+	return 2
+}
+
+var locations sync.Map // zone name -> *time.Location
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("tz=%s: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,173 @@
+package rstf
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formatOrder struct {
+	ID       string     `json:"id"`
+	Total    int64      `json:"total" rstf:"format=currency,currency=EUR"`
+	PlacedAt time.Time  `json:"placedAt" rstf:"format=datetime,tz=America/New_York"`
+	ShipsOn  *time.Time `json:"shipsOn" rstf:"format=date"`
+}
+
+func formatJSON(t *testing.T, v any) string {
+	t.Helper()
+	formatted, err := FormatFields(v)
+	require.NoError(t, err)
+	data, err := json.Marshal(formatted)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFormatFields(t *testing.T) {
+	placed := time.Date(2026, 3, 4, 2, 30, 0, 0, time.UTC)
+	order := formatOrder{ID: "a1", Total: 1250, PlacedAt: placed, ShipsOn: &placed}
+
+	assert.JSONEq(t, `{
+		"id": "a1",
+		"total": {"amount": "12.50", "currency": "EUR"},
+		"placedAt": "2026-03-03T21:30:00-05:00",
+		"shipsOn": "2026-03-04"
+	}`, formatJSON(t, order))
+}
+
+func TestFormatFields_NilPointerStaysNull(t *testing.T) {
+	type shipment struct {
+		ShipsOn *time.Time `json:"shipsOn" rstf:"format=date"`
+	}
+	assert.JSONEq(t, `{"shipsOn": null}`, formatJSON(t, shipment{}))
+}
+
+func TestFormatFields_CurrencyDigits(t *testing.T) {
+	type amounts struct {
+		EUR  int   `json:"eur" rstf:"format=currency,currency=EUR"`
+		Neg  int   `json:"neg" rstf:"format=currency,currency=USD"`
+		JPY  uint  `json:"jpy" rstf:"format=currency,currency=JPY"`
+		KWD  int64 `json:"kwd" rstf:"format=currency,currency=KWD"`
+		Cent int8  `json:"cent" rstf:"format=currency,currency=USD"`
+	}
+	assert.JSONEq(t, `{
+		"eur": {"amount": "1234567.89", "currency": "EUR"},
+		"neg": {"amount": "-0.05", "currency": "USD"},
+		"jpy": {"amount": "1500", "currency": "JPY"},
+		"kwd": {"amount": "1.005", "currency": "KWD"},
+		"cent": {"amount": "0.01", "currency": "USD"}
+	}`, formatJSON(t, amounts{EUR: 123456789, Neg: -5, JPY: 1500, KWD: 1005, Cent: 1}))
+}
+
+func TestFormatFields_CurrencyExtremes(t *testing.T) {
+	type amounts struct {
+		Min int64  `json:"min" rstf:"format=currency,currency=USD"`
+		Max uint64 `json:"max" rstf:"format=currency,currency=USD"`
+	}
+	assert.JSONEq(t, `{
+		"min": {"amount": "-92233720368547758.08", "currency": "USD"},
+		"max": {"amount": "184467440737095516.15", "currency": "USD"}
+	}`, formatJSON(t, amounts{Min: math.MinInt64, Max: math.MaxUint64}))
+}
+
+func TestFormatFields_Nested(t *testing.T) {
+	type Base struct {
+		Price int `rstf:"format=currency,currency=USD"`
+	}
+	type item struct {
+		Base
+		Name string `json:"name"`
+	}
+	type page struct {
+		Items  []item          `json:"items"`
+		ByName map[string]item `json:"byName"`
+	}
+	v := page{
+		Items:  []item{{Base{199}, "a"}, {Base{5}, "b"}},
+		ByName: map[string]item{"c": {Base{1000}, "c"}},
+	}
+	assert.JSONEq(t, `{
+		"items": [
+			{"Price": {"amount": "1.99", "currency": "USD"}, "name": "a"},
+			{"Price": {"amount": "0.05", "currency": "USD"}, "name": "b"}
+		],
+		"byName": {"c": {"Price": {"amount": "10.00", "currency": "USD"}, "name": "c"}}
+	}`, formatJSON(t, &v))
+}
+
+func TestFormatFields_MapValues(t *testing.T) {
+	placed := time.Date(2026, 3, 4, 2, 30, 0, 0, time.UTC)
+	v := map[string]any{"data": []formatOrder{{Total: 5, PlacedAt: placed}}, "ok": true}
+	assert.JSONEq(t, `{
+		"ok": true,
+		"data": [{"id": "", "total": {"amount": "0.05", "currency": "EUR"}, "placedAt": "2026-03-03T21:30:00-05:00", "shipsOn": null}]
+	}`, formatJSON(t, v))
+}
+
+func TestFormatFields_UntaggedTypesPassThrough(t *testing.T) {
+	type plain struct {
+		N int64 `json:"n"`
+	}
+	v := plain{N: 3}
+	formatted, err := FormatFields(v)
+	require.NoError(t, err)
+	assert.Equal(t, v, formatted)
+
+	formatted, err = FormatFields(nil)
+	require.NoError(t, err)
+	assert.Nil(t, formatted)
+}
+
+func TestFormatFields_RecursiveType(t *testing.T) {
+	type node struct {
+		Cost     int    `json:"cost" rstf:"format=currency,currency=USD"`
+		Children []node `json:"children"`
+	}
+	assert.JSONEq(t, `{
+		"cost": {"amount": "1.00", "currency": "USD"},
+		"children": [{"cost": {"amount": "0.50", "currency": "USD"}, "children": null}]
+	}`, formatJSON(t, node{Cost: 100, Children: []node{{Cost: 50}}}))
+}
+
+func TestFormatFields_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"unknown format", struct {
+			X int `rstf:"format=percent"`
+		}{}, `unknown format "percent"`},
+		{"missing currency", struct {
+			X int `rstf:"format=currency"`
+		}{}, "needs currency=<ISO 4217 code>"},
+		{"non-integer amount", struct {
+			X float64 `rstf:"format=currency,currency=USD"`
+		}{}, "needs an integer amount in minor units, not float64"},
+		{"date of a string", struct {
+			X string `rstf:"format=date"`
+		}{}, "format=date needs a time.Time, not string"},
+		{"unknown zone", struct {
+			X time.Time `rstf:"format=datetime,tz=Mars/Olympus"`
+		}{}, "tz=Mars/Olympus"},
+		{"malformed tag", struct {
+			X int `rstf:"currency"`
+		}{}, `"currency" is not key=value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FormatFields(tt.v)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestServerDataProps_Formats(t *testing.T) {
+	props, err := ServerDataProps(formatOrder{Total: 100, PlacedAt: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"amount": "1.00", "currency": "EUR"}, props["total"])
+}
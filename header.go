@@ -0,0 +1,44 @@
+package rstf
+
+import "net/http"
+
+// Header returns the response's headers. A handler writes to its response
+// right away. A page's data functions, and RPC mutations and actions, run
+// before there is a response to write to, so their headers are held on the
+// context until the generated server writes the HTML, ?_data JSON, or RPC
+// result:
+//
+//	func SSR(ctx *rstf.Context) ServerData {
+//		ctx.Header().Set("Content-Language", "en")
+//		...
+//	}
+func (c *Context) Header() http.Header {
+	if c.Writer != nil {
+		return c.Writer.Header()
+	}
+	if c.header == nil {
+		c.header = http.Header{}
+	}
+	return c.header
+}
+
+// SetCookie adds a Set-Cookie header to the response, like http.SetCookie.
+// An invalid cookie is dropped.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	if v := cookie.String(); v != "" {
+		c.Header().Add("Set-Cookie", v)
+	}
+}
+
+// WriteHeaders sets the headers held on the context on w. Cookies are added
+// to those w already carries, say from middleware; other headers replace
+// w's. The generated server calls it before writing a page or RPC result.
+func (c *Context) WriteHeaders(w http.ResponseWriter) {
+	for key, values := range c.header {
+		if key == "Set-Cookie" {
+			w.Header()[key] = append(w.Header()[key], values...)
+		} else {
+			w.Header()[key] = values
+		}
+	}
+}
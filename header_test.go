@@ -0,0 +1,32 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextHeaderAndCookies(t *testing.T) {
+	// A page's data functions run before there is a response.
+	ctx := NewContext(httptest.NewRequest("GET", "/dashboard", nil))
+	ctx.Header().Set("Content-Language", "en")
+	ctx.SetCookie(&http.Cookie{Name: "theme", Value: "dark", Path: "/"})
+	ctx.SetCookie(&http.Cookie{Name: "seen", Value: "1", HttpOnly: true})
+	ctx.SetCookie(&http.Cookie{Name: "bad name", Value: "x"})
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Language", "fr")
+	rec.Header().Add("Set-Cookie", "session=abc")
+	ctx.WriteHeaders(rec)
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	assert.Equal(t, []string{"session=abc", "theme=dark; Path=/", "seen=1; HttpOnly"}, rec.Header().Values("Set-Cookie"))
+
+	// Handlers write straight to their response.
+	rec = httptest.NewRecorder()
+	ctx = NewContext(httptest.NewRequest("POST", "/login", nil))
+	ctx.Writer = rec
+	ctx.SetCookie(&http.Cookie{Name: "session", Value: "abc"})
+	assert.Equal(t, "session=abc", rec.Header().Get("Set-Cookie"))
+}
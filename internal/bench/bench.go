@@ -0,0 +1,154 @@
+// Package bench drives HTTP load against a running rstf server and reports
+// throughput and latency.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Options configures a load run.
+type Options struct {
+	// URLs are requested in turn by every worker.
+	URLs []string
+	// Concurrency is the number of workers sending requests at once.
+	Concurrency int
+	// Duration is how long workers keep sending requests.
+	Duration time.Duration
+	// Header is sent with every request. Accept defaults to text/html so
+	// pages are server-rendered rather than answered with server data.
+	Header http.Header
+	// Client sends the requests. Defaults to a client that keeps enough idle
+	// connections for every worker.
+	Client *http.Client
+}
+
+// Result summarizes a load run.
+type Result struct {
+	Requests int
+	// Failures counts transport errors and responses with a status of 400 or
+	// above. Their latencies are not recorded.
+	Failures  int
+	Elapsed   time.Duration
+	latencies []time.Duration
+}
+
+// RequestsPerSecond returns the rate of successful requests.
+func (r Result) RequestsPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests-r.Failures) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency below which p percent of successful requests
+// completed, or 0 when none did.
+func (r Result) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p/100+0.5) - 1
+	return r.latencies[min(max(i, 0), len(r.latencies)-1)]
+}
+
+// Run sends requests until opts.Duration elapses or ctx is done.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if len(opts.URLs) == 0 {
+		return Result{}, errors.New("bench: no URLs to request")
+	}
+	if opts.Concurrency < 1 {
+		return Result{}, fmt.Errorf("bench: concurrency must be at least 1, got %d", opts.Concurrency)
+	}
+	client := opts.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = opts.Concurrency
+		client = &http.Client{Transport: transport}
+	}
+	header := opts.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if header.Get("Accept") == "" {
+		header.Set("Accept", "text/html")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		result Result
+		wg     sync.WaitGroup
+	)
+	start := time.Now()
+	for worker := range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local Result
+			for i := worker; ctx.Err() == nil; i++ {
+				latency, err := send(ctx, client, opts.URLs[i%len(opts.URLs)], header)
+				if ctx.Err() != nil {
+					break
+				}
+				local.Requests++
+				if err != nil {
+					local.Failures++
+					continue
+				}
+				local.latencies = append(local.latencies, latency)
+			}
+			mu.Lock()
+			result.Requests += local.Requests
+			result.Failures += local.Failures
+			result.latencies = append(result.latencies, local.latencies...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+	slices.Sort(result.latencies)
+	return result, nil
+}
+
+// Warm requests every URL once, so the server loads each route's SSR bundle
+// before the run, and fails if one does not respond successfully.
+func Warm(ctx context.Context, urls []string) error {
+	header := http.Header{"Accept": {"text/html"}}
+	for _, url := range urls {
+		if _, err := send(ctx, http.DefaultClient, url, header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send requests url and reads the whole response, returning how long that
+// took.
+func send(ctx context.Context, client *http.Client, url string, header http.Header) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header = header.Clone()
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return 0, fmt.Errorf("bench: %s responded %s", url, resp.Status)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var pages, missing, jsonRequests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Accept") != "text/html" {
+			jsonRequests.Add(1)
+		}
+		if req.URL.Path == "/missing" {
+			missing.Add(1)
+			http.NotFound(w, req)
+			return
+		}
+		pages.Add(1)
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer srv.Close()
+
+	result, err := Run(context.Background(), Options{
+		URLs:        []string{srv.URL + "/", srv.URL + "/missing"},
+		Concurrency: 4,
+		Duration:    200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.Positive(t, pages.Load())
+	assert.Positive(t, missing.Load())
+	assert.Zero(t, jsonRequests.Load(), "requests ask for HTML by default")
+	// Requests cut off at the deadline reach the server but go uncounted.
+	assert.InDelta(t, missing.Load(), result.Failures, 4)
+	assert.InDelta(t, pages.Load()+missing.Load(), result.Requests, 4)
+	assert.Len(t, result.latencies, result.Requests-result.Failures)
+	assert.Positive(t, result.RequestsPerSecond())
+	assert.LessOrEqual(t, result.Percentile(50), result.Percentile(99))
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	_, err := Run(context.Background(), Options{Concurrency: 1, Duration: time.Second})
+	assert.EqualError(t, err, "bench: no URLs to request")
+
+	_, err = Run(context.Background(), Options{URLs: []string{"http://localhost"}, Duration: time.Second})
+	assert.EqualError(t, err, "bench: concurrency must be at least 1, got 0")
+}
+
+func TestWarm(t *testing.T) {
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.Path)
+		if req.URL.Path == "/missing" {
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	require.NoError(t, Warm(context.Background(), []string{srv.URL + "/", srv.URL + "/about"}))
+	assert.Equal(t, []string{"/", "/about"}, requested)
+
+	err := Warm(context.Background(), []string{srv.URL + "/missing"})
+	assert.EqualError(t, err, "bench: "+srv.URL+"/missing responded 404 Not Found")
+}
+
+func TestResultPercentile(t *testing.T) {
+	result := Result{}
+	assert.Zero(t, result.Percentile(99))
+
+	for i := 1; i <= 100; i++ {
+		result.latencies = append(result.latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, result.Percentile(50))
+	assert.Equal(t, 99*time.Millisecond, result.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, result.Percentile(100))
+	assert.Equal(t, time.Millisecond, result.Percentile(0))
+}
@@ -0,0 +1,153 @@
+package bundler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MetafileName is the file under rstf/ where BundleEntries saves esbuild's
+// metafile for the client bundles. It stays out of rstf/static so it is never
+// served.
+const MetafileName = "metafile.json"
+
+// metafile is the part of esbuild's metafile the analysis reads.
+type metafile struct {
+	Outputs map[string]struct {
+		Bytes  int `json:"bytes"`
+		Inputs map[string]struct {
+			BytesInOutput int `json:"bytesInOutput"`
+		} `json:"inputs"`
+	} `json:"outputs"`
+}
+
+// BundleAnalysis breaks one client bundle down by the modules in it.
+type BundleAnalysis struct {
+	Bytes     int
+	GzipBytes int
+	// Modules are sorted largest first. A module is an npm package, with
+	// all its files added up, or a source file of the app.
+	Modules []ModuleSize
+}
+
+// ModuleSize is how many bytes of a bundle a module accounts for.
+type ModuleSize struct {
+	Module string
+	Bytes  int
+}
+
+// SharedModule is a module included in more than one bundle.
+type SharedModule struct {
+	Module  string
+	Bytes   int      // bytes in one bundle, the largest of its copies
+	Bundles []string // bundles that include it, sorted
+}
+
+// Analysis is the breakdown of the client bundles of one build.
+type Analysis struct {
+	// Bundles is keyed by the bundle's path under rstf/static, e.g.
+	// "dashboard/bundle.js".
+	Bundles map[string]BundleAnalysis
+	// Shared lists the modules more than one bundle includes, largest first.
+	Shared []SharedModule
+}
+
+// Analyze reads the metafile the last BundleEntries run saved in rstfDir and
+// measures each bundle's gzip size from the files on disk.
+func Analyze(rstfDir string) (Analysis, error) {
+	path := filepath.Join(rstfDir, MetafileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var meta metafile
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Analysis{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	analysis := Analysis{Bundles: map[string]BundleAnalysis{}}
+	shared := map[string]*SharedModule{}
+	for out, output := range meta.Outputs {
+		_, rel, ok := strings.Cut(filepath.ToSlash(out), "rstf/static/")
+		if !ok || !strings.HasSuffix(rel, ".js") {
+			continue
+		}
+		bundle := BundleAnalysis{Bytes: output.Bytes}
+		if bundle.GzipBytes, err = gzipSize(filepath.Join(rstfDir, "static", filepath.FromSlash(rel))); err != nil {
+			return Analysis{}, err
+		}
+		modules := map[string]int{}
+		for input, in := range output.Inputs {
+			if in.BytesInOutput > 0 {
+				modules[moduleName(input)] += in.BytesInOutput
+			}
+		}
+		for module, size := range modules {
+			bundle.Modules = append(bundle.Modules, ModuleSize{Module: module, Bytes: size})
+			s := shared[module]
+			if s == nil {
+				s = &SharedModule{Module: module}
+				shared[module] = s
+			}
+			s.Bytes = max(s.Bytes, size)
+			s.Bundles = append(s.Bundles, rel)
+		}
+		sort.Slice(bundle.Modules, func(i, j int) bool {
+			a, b := bundle.Modules[i], bundle.Modules[j]
+			if a.Bytes != b.Bytes {
+				return a.Bytes > b.Bytes
+			}
+			return a.Module < b.Module
+		})
+		analysis.Bundles[rel] = bundle
+	}
+
+	for _, s := range shared {
+		if len(s.Bundles) > 1 {
+			sort.Strings(s.Bundles)
+			analysis.Shared = append(analysis.Shared, *s)
+		}
+	}
+	sort.Slice(analysis.Shared, func(i, j int) bool {
+		a, b := analysis.Shared[i], analysis.Shared[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Module < b.Module
+	})
+	return analysis, nil
+}
+
+// moduleName groups a metafile input by npm package, e.g.
+// "node_modules/react-dom/cjs/react-dom.production.js" is "react-dom" and
+// "node_modules/@tanstack/query-core/build/index.js" is "@tanstack/query-core".
+// Other inputs are app files and keep their path.
+func moduleName(input string) string {
+	input = filepath.ToSlash(input)
+	i := strings.LastIndex(input, "node_modules/")
+	if i < 0 {
+		return input
+	}
+	parts := strings.SplitN(input[i+len("node_modules/"):], "/", 3)
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+func gzipSize(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	return buf.Len(), nil
+}
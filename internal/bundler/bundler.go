@@ -2,19 +2,158 @@ package bundler
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/evanw/esbuild/pkg/api"
+	"github.com/rafbgarcia/rstf/internal/config"
 )
 
+// Options extends the built-in esbuild settings for both the client and SSR
+// passes. Everything but Plugins comes from rstf.json; Plugins is only
+// reachable from Go, since esbuild runs in-process and cannot load JS plugins.
+//
+// Target, Engines, and Supported only affect client bundles. SSR bundles run
+// in the embedded V8 and always target ES2022.
+//
+// AssetBaseURL is the origin imported assets are linked from. rstf build sets
+// it from build.assetBaseURL; rstf dev leaves it empty.
+type Options struct {
+	Loaders      map[string]api.Loader
+	Alias        map[string]string
+	Define       map[string]string
+	Plugins      []api.Plugin
+	Target       api.Target
+	Engines      []api.Engine
+	Supported    map[string]bool
+	AssetBaseURL string
+}
+
+var esTargets = map[string]api.Target{
+	"esnext": api.ESNext,
+	"es2015": api.ES2015,
+	"es2016": api.ES2016,
+	"es2017": api.ES2017,
+	"es2018": api.ES2018,
+	"es2019": api.ES2019,
+	"es2020": api.ES2020,
+	"es2021": api.ES2021,
+	"es2022": api.ES2022,
+	"es2023": api.ES2023,
+	"es2024": api.ES2024,
+}
+
+var browserEngines = map[string]api.EngineName{
+	"chrome":  api.EngineChrome,
+	"edge":    api.EngineEdge,
+	"firefox": api.EngineFirefox,
+	"ios":     api.EngineIOS,
+	"opera":   api.EngineOpera,
+	"safari":  api.EngineSafari,
+}
+
+// browserTargetRe splits a browser target such as "safari14.1" into its
+// engine name and version.
+var browserTargetRe = regexp.MustCompile(`^([a-z]+)(\d+(?:\.\d+)*)$`)
+
+var loaderNames = map[string]api.Loader{
+	"base64":     api.LoaderBase64,
+	"binary":     api.LoaderBinary,
+	"copy":       api.LoaderCopy,
+	"css":        api.LoaderCSS,
+	"dataurl":    api.LoaderDataURL,
+	"default":    api.LoaderDefault,
+	"empty":      api.LoaderEmpty,
+	"file":       api.LoaderFile,
+	"global-css": api.LoaderGlobalCSS,
+	"js":         api.LoaderJS,
+	"json":       api.LoaderJSON,
+	"jsx":        api.LoaderJSX,
+	"local-css":  api.LoaderLocalCSS,
+	"text":       api.LoaderText,
+	"ts":         api.LoaderTS,
+	"tsx":        api.LoaderTSX,
+}
+
+// NewOptions converts the rstf.json bundler section into Options.
+func NewOptions(cfg config.Bundler) (Options, error) {
+	opts := Options{Alias: cfg.Alias, Define: cfg.Define}
+	if len(cfg.Loaders) > 0 {
+		opts.Loaders = make(map[string]api.Loader, len(cfg.Loaders))
+	}
+	for ext, name := range cfg.Loaders {
+		if !strings.HasPrefix(ext, ".") {
+			return Options{}, fmt.Errorf("bundler loader %q: extension must start with a dot", ext)
+		}
+		loader, ok := loaderNames[name]
+		if !ok {
+			return Options{}, fmt.Errorf("bundler loader %q: unknown loader %q", ext, name)
+		}
+		opts.Loaders[ext] = loader
+	}
+
+	opts.Target = api.ESNext
+	opts.Supported = cfg.Supported
+	hasESTarget := false
+	for _, target := range cfg.Target {
+		target = strings.ToLower(strings.TrimSpace(target))
+		if es, ok := esTargets[target]; ok {
+			if hasESTarget {
+				return Options{}, fmt.Errorf("bundler target %q: only one ES version may be set", target)
+			}
+			hasESTarget = true
+			opts.Target = es
+			continue
+		}
+		m := browserTargetRe.FindStringSubmatch(target)
+		if m == nil {
+			return Options{}, fmt.Errorf("bundler target %q: expected an ES version (es2020) or a browser with version (safari14)", target)
+		}
+		engine, ok := browserEngines[m[1]]
+		if !ok {
+			return Options{}, fmt.Errorf("bundler target %q: unknown browser %q", target, m[1])
+		}
+		opts.Engines = append(opts.Engines, api.Engine{Name: engine, Version: m[2]})
+	}
+	return opts, nil
+}
+
+// assetNames places files emitted by the file loader, such as imported .wasm
+// modules, under rstf/static/assets with content-hashed names.
+const assetNames = "assets/[name]-[hash]"
+
+// assetPublicPath is the URL prefix the file server mounts rstf/static on.
+const assetPublicPath = "/rstf/static/"
+
+// apply copies the user options onto esbuild build options. Imported .wasm
+// files default to the file loader so they resolve to a hashed static URL;
+// both passes use the same public path, so SSR markup references the asset
+// at the same URL the client bundle does.
+func (o Options) apply(build *api.BuildOptions) {
+	build.Loader = map[string]api.Loader{".wasm": api.LoaderFile}
+	for ext, loader := range o.Loaders {
+		build.Loader[ext] = loader
+	}
+	build.AssetNames = assetNames
+	build.PublicPath = o.AssetBaseURL + assetPublicPath
+	build.Alias = o.Alias
+	build.Define = o.Define
+	build.Plugins = o.Plugins
+}
+
 // BundleEntries bundles all hydration entry files into client-side JS bundles
 // using esbuild's Go API (single in-process call, no child processes).
-// Each entry produces rstf/static/{name}/bundle.js.
+// Each entry produces rstf/static/{name}/bundle.js, plus bundle.css when the
+// route imports stylesheets. Every Web Worker constructed via
+// new URL("./file", import.meta.url) gets its own bundle under
+// rstf/static/workers/. esbuild's metafile for the bundles is saved as
+// rstf/metafile.json for Analyze.
 //
 // projectRoot is the path to the project directory (resolved to absolute).
 // entries maps routeDir -> absolute path to .entry.tsx file.
-func BundleEntries(projectRoot string, entries map[string]string) error {
+func BundleEntries(projectRoot string, entries map[string]string, opts Options) error {
 	if len(entries) == 0 {
 		return nil
 	}
@@ -24,44 +163,54 @@ func BundleEntries(projectRoot string, entries map[string]string) error {
 		return fmt.Errorf("resolving project root: %w", err)
 	}
 
+	outdir := filepath.Join(absRoot, "rstf", "static")
 	var entryPoints []api.EntryPoint
 	for _, entryPath := range entries {
 		base := filepath.Base(entryPath)
 		name := base[:len(base)-len(".entry.tsx")]
+		// esbuild only writes bundle.css when the route imports CSS; drop the
+		// previous one so the server stops linking styles a route no longer has.
+		cssPath := filepath.Join(outdir, name, "bundle.css")
+		if err := os.Remove(cssPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", cssPath, err)
+		}
 		entryPoints = append(entryPoints, api.EntryPoint{
 			InputPath:  entryPath,
 			OutputPath: name + "/bundle",
 		})
 	}
 
-	result := api.Build(api.BuildOptions{
+	buildOpts := api.BuildOptions{
 		EntryPointsAdvanced: entryPoints,
 		Bundle:              true,
-		Outdir:              filepath.Join(absRoot, "rstf", "static"),
+		Outdir:              outdir,
 		Platform:            api.PlatformBrowser,
 		JSX:                 api.JSXAutomatic,
 		AbsWorkingDir:       absRoot,
 		Write:               true,
-	})
-
-	if len(result.Errors) > 0 {
-		var msgs []string
-		for _, msg := range result.Errors {
-			text := msg.Text
-			if msg.Location != nil {
-				text = fmt.Sprintf("%s:%d:%d: %s", msg.Location.File, msg.Location.Line, msg.Location.Column, msg.Text)
-			}
-			msgs = append(msgs, text)
-		}
-		return fmt.Errorf("esbuild errors:\n%s", strings.Join(msgs, "\n"))
+		Metafile:            true,
 	}
+	opts.apply(&buildOpts)
+	buildOpts.Target = opts.Target
+	buildOpts.Engines = opts.Engines
+	buildOpts.Supported = opts.Supported
+	workers := newWorkerCollector(absRoot)
+	buildOpts.Plugins = append([]api.Plugin{workers.plugin()}, opts.Plugins...)
 
-	return nil
+	result := api.Build(buildOpts)
+	if err := buildErrors(result); err != nil {
+		return err
+	}
+	metaPath := filepath.Join(absRoot, "rstf", MetafileName)
+	if err := os.WriteFile(metaPath, []byte(result.Metafile), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", metaPath, err)
+	}
+	return bundleWorkers(absRoot, workers, opts)
 }
 
 // BundleSSREntries bundles all SSR entry files into route-scoped JS bundles for
 // the embedded renderer. Each entry produces rstf/ssr/{name}.js.
-func BundleSSREntries(projectRoot string, entries map[string]string) error {
+func BundleSSREntries(projectRoot string, entries map[string]string, opts Options) error {
 	if len(entries) == 0 {
 		return nil
 	}
@@ -81,7 +230,7 @@ func BundleSSREntries(projectRoot string, entries map[string]string) error {
 		})
 	}
 
-	result := api.Build(api.BuildOptions{
+	buildOpts := api.BuildOptions{
 		EntryPointsAdvanced: entryPoints,
 		Bundle:              true,
 		Outdir:              filepath.Join(absRoot, "rstf", "ssr"),
@@ -91,19 +240,27 @@ func BundleSSREntries(projectRoot string, entries map[string]string) error {
 		JSX:                 api.JSXAutomatic,
 		AbsWorkingDir:       absRoot,
 		Write:               true,
-	})
-
-	if len(result.Errors) > 0 {
-		var msgs []string
-		for _, msg := range result.Errors {
-			text := msg.Text
-			if msg.Location != nil {
-				text = fmt.Sprintf("%s:%d:%d: %s", msg.Location.File, msg.Location.Line, msg.Location.Column, msg.Text)
-			}
-			msgs = append(msgs, text)
-		}
-		return fmt.Errorf("esbuild errors:\n%s", strings.Join(msgs, "\n"))
 	}
+	opts.apply(&buildOpts)
+	// Rewrite worker URLs so modules that construct workers still evaluate
+	// without import.meta; the workers themselves are built by BundleEntries.
+	buildOpts.Plugins = append([]api.Plugin{newWorkerCollector(absRoot).plugin()}, opts.Plugins...)
+
+	return buildErrors(api.Build(buildOpts))
+}
 
-	return nil
+// buildErrors formats esbuild errors, or returns nil when the build succeeded.
+func buildErrors(result api.BuildResult) error {
+	if len(result.Errors) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, msg := range result.Errors {
+		text := msg.Text
+		if msg.Location != nil {
+			text = fmt.Sprintf("%s:%d:%d: %s", msg.Location.File, msg.Location.Line, msg.Location.Column, msg.Text)
+		}
+		msgs = append(msgs, text)
+	}
+	return fmt.Errorf("esbuild errors:\n%s", strings.Join(msgs, "\n"))
 }
@@ -0,0 +1,154 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOptions(t *testing.T) {
+	opts, err := NewOptions(config.Bundler{
+		Loaders: map[string]string{".svg": "dataurl", ".graphql": "text"},
+		Alias:   map[string]string{"lodash": "lodash-es"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]api.Loader{".svg": api.LoaderDataURL, ".graphql": api.LoaderText}, opts.Loaders)
+	assert.Equal(t, map[string]string{"lodash": "lodash-es"}, opts.Alias)
+}
+
+func TestNewOptionsRejectsInvalidLoaders(t *testing.T) {
+	_, err := NewOptions(config.Bundler{Loaders: map[string]string{"svg": "dataurl"}})
+	require.ErrorContains(t, err, "extension must start with a dot")
+
+	_, err = NewOptions(config.Bundler{Loaders: map[string]string{".svg": "svgr"}})
+	require.ErrorContains(t, err, `unknown loader "svgr"`)
+}
+
+func TestNewOptionsTarget(t *testing.T) {
+	opts, err := NewOptions(config.Bundler{})
+	require.NoError(t, err)
+	assert.Equal(t, api.ESNext, opts.Target)
+	assert.Empty(t, opts.Engines)
+
+	opts, err = NewOptions(config.Bundler{
+		Target:    []string{"es2020", "safari14.1", "Chrome90"},
+		Supported: map[string]bool{"bigint": false},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, api.ES2020, opts.Target)
+	assert.Equal(t, []api.Engine{
+		{Name: api.EngineSafari, Version: "14.1"},
+		{Name: api.EngineChrome, Version: "90"},
+	}, opts.Engines)
+	assert.Equal(t, map[string]bool{"bigint": false}, opts.Supported)
+}
+
+func TestNewOptionsRejectsInvalidTargets(t *testing.T) {
+	_, err := NewOptions(config.Bundler{Target: []string{"netscape4"}})
+	require.ErrorContains(t, err, `unknown browser "netscape"`)
+
+	_, err = NewOptions(config.Bundler{Target: []string{"last 2 versions"}})
+	require.ErrorContains(t, err, "expected an ES version")
+
+	_, err = NewOptions(config.Bundler{Target: []string{"es2020", "es2022"}})
+	require.ErrorContains(t, err, "only one ES version")
+}
+
+func TestBundleEntriesEmitsWasmAssets(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, filepath.Join(root, "routes", "calc", "add.wasm"), "\x00asm\x01\x00\x00\x00")
+	writeSource(t, filepath.Join(root, "routes", "calc", "index.ts"), `
+import wasmURL from "./add.wasm";
+export const url = wasmURL;
+console.log(url);
+`)
+	entry := filepath.Join(root, "rstf", "entries", "calc.entry.tsx")
+	writeSource(t, entry, `import "../../routes/calc/index";`)
+
+	require.NoError(t, BundleEntries(root, map[string]string{"routes/calc": entry}, Options{}))
+
+	assets, err := filepath.Glob(filepath.Join(root, "rstf", "static", "assets", "add-*.wasm"))
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+
+	bundle, err := os.ReadFile(filepath.Join(root, "rstf", "static", "calc", "bundle.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(bundle), `"/rstf/static/assets/`+filepath.Base(assets[0])+`"`)
+}
+
+func TestBundleEntriesLinksAssetsFromAssetBaseURL(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, filepath.Join(root, "routes", "calc", "add.wasm"), "\x00asm\x01\x00\x00\x00")
+	writeSource(t, filepath.Join(root, "routes", "calc", "index.ts"), `
+import wasmURL from "./add.wasm";
+console.log(wasmURL);
+`)
+	entry := filepath.Join(root, "rstf", "entries", "calc.entry.tsx")
+	writeSource(t, entry, `import "../../routes/calc/index";`)
+
+	opts := Options{AssetBaseURL: "https://cdn.example.com"}
+	require.NoError(t, BundleEntries(root, map[string]string{"routes/calc": entry}, opts))
+
+	bundle, err := os.ReadFile(filepath.Join(root, "rstf", "static", "calc", "bundle.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(bundle), `"https://cdn.example.com/rstf/static/assets/add-`)
+}
+
+func TestBundleEntriesExtractsRouteCSS(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, filepath.Join(root, "routes", "dashboard", "index.css"), `.card { color: red; }`)
+	writeSource(t, filepath.Join(root, "routes", "dashboard", "index.ts"), `import "./index.css";`)
+	entry := filepath.Join(root, "rstf", "entries", "dashboard.entry.tsx")
+	writeSource(t, entry, `import "../../routes/dashboard/index";`)
+	entries := map[string]string{"routes/dashboard": entry}
+
+	require.NoError(t, BundleEntries(root, entries, Options{}))
+	cssPath := filepath.Join(root, "rstf", "static", "dashboard", "bundle.css")
+	css, err := os.ReadFile(cssPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(css), ".card")
+
+	// Dropping the import removes the stale stylesheet on the next build.
+	writeSource(t, filepath.Join(root, "routes", "dashboard", "index.ts"), `export {};`)
+	require.NoError(t, BundleEntries(root, entries, Options{}))
+	assert.NoFileExists(t, cssPath)
+}
+
+func TestAnalyzeBreaksBundlesDownByModule(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, filepath.Join(root, "node_modules", "@acme", "ui", "index.js"), `export const button = "`+strings.Repeat("b", 400)+`";`)
+	writeSource(t, filepath.Join(root, "node_modules", "tiny", "index.js"), `export const tiny = "t";`)
+	writeSource(t, filepath.Join(root, "routes", "home", "index.ts"), `import { button } from "@acme/ui"; console.log(button);`)
+	writeSource(t, filepath.Join(root, "routes", "about", "index.ts"), `import { button } from "@acme/ui"; import { tiny } from "tiny"; console.log(button, tiny);`)
+	home := filepath.Join(root, "rstf", "entries", "home.entry.tsx")
+	about := filepath.Join(root, "rstf", "entries", "about.entry.tsx")
+	writeSource(t, home, `import "../../routes/home/index";`)
+	writeSource(t, about, `import "../../routes/about/index";`)
+
+	require.NoError(t, BundleEntries(root, map[string]string{"routes/home": home, "routes/about": about}, Options{}))
+	analysis, err := Analyze(filepath.Join(root, "rstf"))
+	require.NoError(t, err)
+
+	require.Contains(t, analysis.Bundles, "about/bundle.js")
+	bundle := analysis.Bundles["about/bundle.js"]
+	assert.Positive(t, bundle.GzipBytes)
+	assert.Less(t, bundle.GzipBytes, bundle.Bytes)
+	require.NotEmpty(t, bundle.Modules)
+	assert.Equal(t, "@acme/ui", bundle.Modules[0].Module)
+	var modules []string
+	for _, m := range bundle.Modules {
+		modules = append(modules, m.Module)
+	}
+	assert.Contains(t, modules, "tiny")
+	assert.Contains(t, modules, "routes/about/index.ts")
+
+	require.Len(t, analysis.Shared, 1)
+	assert.Equal(t, "@acme/ui", analysis.Shared[0].Module)
+	assert.Equal(t, []string{"about/bundle.js", "home/bundle.js"}, analysis.Shared[0].Bundles)
+}
@@ -0,0 +1,113 @@
+package bundler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// compressibleExts are the static asset types worth precompressing. Images
+// and fonts are already compressed.
+var compressibleExts = map[string]bool{
+	".js":   true,
+	".mjs":  true,
+	".css":  true,
+	".map":  true,
+	".json": true,
+	".svg":  true,
+	".wasm": true,
+	".txt":  true,
+	".xml":  true,
+	".html": true,
+}
+
+// minCompressSize is the smallest asset worth precompressing. Below it the
+// saving is a few hundred bytes at most.
+const minCompressSize = 1024
+
+// precompressedExts are the suffixes of precompressed variants, by
+// Content-Encoding. The generated server's static handler looks for the same.
+var precompressedExts = map[string]string{"br": ".br", "gzip": ".gz"}
+
+// CompressStatic writes a gzip variant next to every compressible asset under
+// dir, e.g. bundle.js.gz next to bundle.js, and removes variants left over
+// from assets that no longer qualify. It returns the assets it compressed, so
+// the caller can add Brotli variants of the same files.
+func CompressStatic(dir string) ([]string, error) {
+	var compressed []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isPrecompressed(path) {
+			return err
+		}
+		if !compressibleExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < minCompressSize {
+			return removeVariants(path)
+		}
+		written, err := writeGzip(path)
+		if err != nil {
+			return fmt.Errorf("compressing %s: %w", path, err)
+		}
+		if written {
+			compressed = append(compressed, path)
+		} else if err := removeVariants(path); err != nil {
+			return err
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return compressed, err
+}
+
+// writeGzip writes path+".gz" at the highest compression level, reporting
+// false without writing when compression does not make the file smaller.
+func writeGzip(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	var b bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	if err != nil {
+		return false, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return false, err
+	}
+	if err := zw.Close(); err != nil {
+		return false, err
+	}
+	if b.Len() >= len(data) {
+		return false, nil
+	}
+	return true, os.WriteFile(path+precompressedExts["gzip"], b.Bytes(), 0644)
+}
+
+func removeVariants(path string) error {
+	for _, ext := range precompressedExts {
+		if err := os.Remove(path + ext); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isPrecompressed(path string) bool {
+	for _, ext := range precompressedExts {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
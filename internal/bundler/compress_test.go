@@ -0,0 +1,46 @@
+package bundler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressStatic(t *testing.T) {
+	dir := t.TempDir()
+	bundle := strings.Repeat("console.log('hello');\n", 200)
+	writeSource(t, filepath.Join(dir, "routes", "bundle.js"), bundle)
+	writeSource(t, filepath.Join(dir, "main.css"), "body{}")
+	writeSource(t, filepath.Join(dir, "main.css.gz"), "left over from a bigger main.css")
+	writeSource(t, filepath.Join(dir, "logo.png"), strings.Repeat("x", 4096))
+
+	compressed, err := CompressStatic(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "routes", "bundle.js")}, compressed)
+
+	data, err := os.ReadFile(filepath.Join(dir, "routes", "bundle.js.gz"))
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, string(plain))
+
+	assert.NoFileExists(t, filepath.Join(dir, "main.css.gz"), "small assets lose stale variants")
+	assert.NoFileExists(t, filepath.Join(dir, "logo.png.gz"), "images are already compressed")
+
+	again, err := CompressStatic(dir)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, again, "variants are not compressed again")
+
+	compressed, err = CompressStatic(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, compressed)
+}
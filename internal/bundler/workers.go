@@ -0,0 +1,166 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// workerURLRe matches worker constructors that reference a sibling file via
+// import.meta.url:
+//
+//	new Worker(new URL("./worker.ts", import.meta.url))
+//	new SharedWorker(new URL("../shared/sync.ts", import.meta.url), { type: "module" })
+//
+// Group 1 is the constructor prefix and group 2 the relative specifier.
+var workerURLRe = regexp.MustCompile(`(new\s+(?:Shared)?Worker\(\s*)new\s+URL\(\s*['"](\.{1,2}/[^'"]+)['"]\s*,\s*import\.meta\.url\s*\)`)
+
+// workerStaticPrefix is the URL prefix worker bundles are served from.
+const workerStaticPrefix = "/rstf/static/workers/"
+
+var sourceLoaders = map[string]api.Loader{
+	".js":  api.LoaderJS,
+	".jsx": api.LoaderJSX,
+	".ts":  api.LoaderTS,
+	".tsx": api.LoaderTSX,
+}
+
+// workerCollector rewrites worker URLs while esbuild loads sources and
+// remembers which worker files need their own bundle.
+type workerCollector struct {
+	absRoot string
+
+	mu      sync.Mutex
+	workers map[string]string // absolute worker path -> output name
+}
+
+func newWorkerCollector(absRoot string) *workerCollector {
+	return &workerCollector{absRoot: absRoot, workers: map[string]string{}}
+}
+
+// plugin returns an esbuild plugin that rewrites worker URLs in project
+// sources. Files without a worker constructor fall through to esbuild's
+// default loader.
+func (c *workerCollector) plugin() api.Plugin {
+	return api.Plugin{
+		Name: "rstf-workers",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: `\.[jt]sx?$`, Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				if strings.Contains(args.Path, string(filepath.Separator)+"node_modules"+string(filepath.Separator)) {
+					return api.OnLoadResult{}, nil
+				}
+				content, err := os.ReadFile(args.Path)
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				rewritten, ok := c.rewrite(string(content), filepath.Dir(args.Path))
+				if !ok {
+					return api.OnLoadResult{}, nil
+				}
+				loader := sourceLoaders[filepath.Ext(args.Path)]
+				return api.OnLoadResult{Contents: &rewritten, Loader: loader}, nil
+			})
+		},
+	}
+}
+
+// rewrite replaces worker URLs in content with their static bundle URLs.
+// It reports false when content has no worker constructors.
+func (c *workerCollector) rewrite(content, dir string) (string, bool) {
+	if !strings.Contains(content, "import.meta.url") || !workerURLRe.MatchString(content) {
+		return content, false
+	}
+	rewritten := workerURLRe.ReplaceAllStringFunc(content, func(match string) string {
+		m := workerURLRe.FindStringSubmatch(match)
+		name := c.add(filepath.Join(dir, m[2]))
+		return fmt.Sprintf("%s%q", m[1], workerStaticPrefix+name+".js")
+	})
+	return rewritten, true
+}
+
+func (c *workerCollector) add(absPath string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name, ok := c.workers[absPath]; ok {
+		return name
+	}
+	name := workerOutputName(c.absRoot, absPath)
+	c.workers[absPath] = name
+	return name
+}
+
+// pending returns the collected workers not present in done, sorted by path.
+func (c *workerCollector) pending(done map[string]bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var paths []string
+	for path := range c.workers {
+		if !done[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// workerOutputName derives a stable, URL-safe bundle name from the worker's
+// project-relative path: "routes/chat/worker.ts" -> "routes-chat-worker".
+func workerOutputName(absRoot, absPath string) string {
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		rel = filepath.Base(absPath)
+	}
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+	parts := strings.FieldsFunc(rel, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	return strings.Join(parts, "-")
+}
+
+// bundleWorkers builds every collected worker into rstf/static/workers/.
+// Workers may spawn workers of their own, so it repeats until no new ones
+// are discovered.
+func bundleWorkers(absRoot string, collector *workerCollector, opts Options) error {
+	done := map[string]bool{}
+	for {
+		paths := collector.pending(done)
+		if len(paths) == 0 {
+			return nil
+		}
+
+		var entryPoints []api.EntryPoint
+		for _, path := range paths {
+			done[path] = true
+			entryPoints = append(entryPoints, api.EntryPoint{
+				InputPath:  path,
+				OutputPath: "workers/" + collector.add(path),
+			})
+		}
+
+		buildOpts := api.BuildOptions{
+			EntryPointsAdvanced: entryPoints,
+			Bundle:              true,
+			Outdir:              filepath.Join(absRoot, "rstf", "static"),
+			Platform:            api.PlatformBrowser,
+			JSX:                 api.JSXAutomatic,
+			AbsWorkingDir:       absRoot,
+			Write:               true,
+		}
+		opts.apply(&buildOpts)
+		buildOpts.Target = opts.Target
+		buildOpts.Engines = opts.Engines
+		buildOpts.Supported = opts.Supported
+		buildOpts.Plugins = append([]api.Plugin{collector.plugin()}, opts.Plugins...)
+
+		if err := buildErrors(api.Build(buildOpts)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerCollectorRewrite(t *testing.T) {
+	root := t.TempDir()
+	c := newWorkerCollector(root)
+
+	got, ok := c.rewrite(`const w = new Worker(new URL("./worker.ts", import.meta.url), { type: "module" });
+const img = new URL("./logo.png", import.meta.url);`, filepath.Join(root, "routes", "chat"))
+	require.True(t, ok)
+	assert.Contains(t, got, `new Worker("/rstf/static/workers/routes-chat-worker.js", { type: "module" })`)
+	assert.Contains(t, got, `new URL("./logo.png", import.meta.url)`, "non-worker URLs are left alone")
+
+	_, ok = c.rewrite(`export const x = 1;`, root)
+	assert.False(t, ok)
+}
+
+func TestWorkerOutputName(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, "routes-users-_id-worker", workerOutputName(root, filepath.Join(root, "routes", "users._id", "worker.ts")))
+	assert.Equal(t, "shared-sync_worker", workerOutputName(root, filepath.Join(root, "shared", "sync_worker.js")))
+}
+
+func TestBundleEntriesBundlesWorkers(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, filepath.Join(root, "routes", "chat", "worker.ts"), `
+const nested = new Worker(new URL("./nested.ts", import.meta.url));
+self.postMessage("ready");
+`)
+	writeSource(t, filepath.Join(root, "routes", "chat", "nested.ts"), `self.postMessage("nested");`)
+	entry := filepath.Join(root, "rstf", "entries", "chat.entry.tsx")
+	writeSource(t, entry, `
+import "../../routes/chat/start";
+`)
+	writeSource(t, filepath.Join(root, "routes", "chat", "start.ts"), `
+export const worker = new Worker(new URL("./worker.ts", import.meta.url));
+`)
+
+	require.NoError(t, BundleEntries(root, map[string]string{"routes/chat": entry}, Options{}))
+
+	bundle, err := os.ReadFile(filepath.Join(root, "rstf", "static", "chat", "bundle.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(bundle), `"/rstf/static/workers/routes-chat-worker.js"`)
+
+	worker, err := os.ReadFile(filepath.Join(root, "rstf", "static", "workers", "routes-chat-worker.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(worker), `"ready"`)
+	assert.Contains(t, string(worker), `"/rstf/static/workers/routes-chat-nested.js"`)
+
+	assert.FileExists(t, filepath.Join(root, "rstf", "static", "workers", "routes-chat-nested.js"))
+}
+
+func writeSource(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
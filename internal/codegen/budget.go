@@ -0,0 +1,163 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/config"
+)
+
+// AssetSizes are the byte sizes of a route's client bundle and the
+// stylesheet esbuild extracted from its imports.
+type AssetSizes struct {
+	JS  config.Size `json:"js"`
+	CSS config.Size `json:"css"`
+}
+
+// BundleSize compares a route's bundle sizes with the previous build's and
+// with its budget.
+type BundleSize struct {
+	Route    string
+	Current  AssetSizes
+	Previous *AssetSizes // nil when the previous build did not have the route
+	Budget   config.Budget
+}
+
+// OverBudget lists the assets ("js", "css") that exceed the route's budget.
+func (s BundleSize) OverBudget() []string {
+	var over []string
+	if s.Budget.JS > 0 && s.Current.JS > s.Budget.JS {
+		over = append(over, "js")
+	}
+	if s.Budget.CSS > 0 && s.Current.CSS > s.Budget.CSS {
+		over = append(over, "css")
+	}
+	return over
+}
+
+// Describe formats one asset's size with its change since the previous build
+// and its budget, e.g. "152.3 kB (+4.1 kB, budget 150.0 kB)".
+func (s BundleSize) Describe(asset string) string {
+	current, limit := s.Current.JS, s.Budget.JS
+	if asset == "css" {
+		current, limit = s.Current.CSS, s.Budget.CSS
+	}
+	var notes []string
+	if s.Previous == nil {
+		notes = append(notes, "new")
+	} else {
+		previous := s.Previous.JS
+		if asset == "css" {
+			previous = s.Previous.CSS
+		}
+		if delta := current - previous; delta > 0 {
+			notes = append(notes, "+"+delta.String())
+		} else if delta < 0 {
+			notes = append(notes, "-"+(-delta).String())
+		}
+	}
+	if limit > 0 {
+		notes = append(notes, "budget "+limit.String())
+	}
+	if len(notes) == 0 {
+		return current.String()
+	}
+	return current.String() + " (" + strings.Join(notes, ", ") + ")"
+}
+
+// ReadManifest reads the manifest at path. A missing file yields an empty
+// manifest.
+func ReadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// WriteManifest writes manifest to path as indented JSON.
+func WriteManifest(path string, manifest Manifest) error {
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// MeasureBundles records the size of each route's built bundle.js and
+// bundle.css in manifest. rstfDir is the rstf/ directory the bundles were
+// built into.
+func MeasureBundles(manifest *Manifest, rstfDir string) error {
+	for i, route := range manifest.Routes {
+		if route.Bundle == "" {
+			continue
+		}
+		_, rel, found := strings.Cut(route.Bundle, "/rstf/static/")
+		if !found {
+			continue
+		}
+		jsPath := filepath.Join(rstfDir, "static", filepath.FromSlash(rel))
+		js, err := os.Stat(jsPath)
+		if err != nil {
+			return fmt.Errorf("measuring %s bundle: %w", route.Name, err)
+		}
+		sizes := &AssetSizes{JS: config.Size(js.Size())}
+		if css, err := os.Stat(strings.TrimSuffix(jsPath, ".js") + ".css"); err == nil {
+			sizes.CSS = config.Size(css.Size())
+		}
+		manifest.Routes[i].Size = sizes
+	}
+	return nil
+}
+
+// CompareBundles reports the bundle size of every measured route in current
+// against previous and budgets.
+func CompareBundles(current, previous Manifest, build config.Build) []BundleSize {
+	previousSizes := map[string]*AssetSizes{}
+	for _, route := range previous.Routes {
+		if route.Size != nil {
+			previousSizes[route.Name] = route.Size
+		}
+	}
+	var sizes []BundleSize
+	for _, route := range current.Routes {
+		if route.Size == nil {
+			continue
+		}
+		budget, _ := build.BudgetFor(route.Name)
+		sizes = append(sizes, BundleSize{
+			Route:    route.Name,
+			Current:  *route.Size,
+			Previous: previousSizes[route.Name],
+			Budget:   budget,
+		})
+	}
+	return sizes
+}
+
+// CheckBudgets fails with one line per asset over its budget.
+func CheckBudgets(sizes []BundleSize) error {
+	var lines []string
+	for _, size := range sizes {
+		for _, asset := range size.OverBudget() {
+			lines = append(lines, fmt.Sprintf("  %s %s: %s", size.Route, asset, size.Describe(asset)))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return fmt.Errorf("bundle budgets exceeded:\n%s", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,73 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafbgarcia/rstf/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasureBundles(t *testing.T) {
+	rstfDir := t.TempDir()
+	writeFile(t, filepath.Join(rstfDir, "static", "dashboard", "bundle.js"), strings.Repeat("x", 1200))
+	writeFile(t, filepath.Join(rstfDir, "static", "dashboard", "bundle.css"), strings.Repeat("x", 300))
+	writeFile(t, filepath.Join(rstfDir, "static", "about", "bundle.js"), strings.Repeat("x", 50))
+
+	manifest := Manifest{Routes: []ManifestRoute{
+		{Name: "about", Bundle: "/rstf/static/about/bundle.js"},
+		{Name: "api", Bundle: ""},
+		{Name: "dashboard", Bundle: "https://cdn.example.com/rstf/static/dashboard/bundle.js"},
+	}}
+	require.NoError(t, MeasureBundles(&manifest, rstfDir))
+	assert.Equal(t, &AssetSizes{JS: 50}, manifest.Routes[0].Size)
+	assert.Nil(t, manifest.Routes[1].Size)
+	assert.Equal(t, &AssetSizes{JS: 1200, CSS: 300}, manifest.Routes[2].Size)
+
+	manifest.Routes = append(manifest.Routes, ManifestRoute{Name: "missing", Bundle: "/rstf/static/missing/bundle.js"})
+	err := MeasureBundles(&manifest, rstfDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "measuring missing bundle")
+}
+
+func TestCompareBundlesAndCheckBudgets(t *testing.T) {
+	current := Manifest{Routes: []ManifestRoute{
+		{Name: "about", Size: &AssetSizes{JS: 90_000}},
+		{Name: "api"},
+		{Name: "dashboard", Size: &AssetSizes{JS: 152_300, CSS: 25_000}},
+	}}
+	previous := Manifest{Routes: []ManifestRoute{
+		{Name: "dashboard", Size: &AssetSizes{JS: 148_200, CSS: 26_000}},
+	}}
+	build := config.Build{Budgets: map[string]config.Budget{
+		"*":         {JS: 100_000},
+		"dashboard": {JS: 150_000, CSS: 30_000},
+	}}
+
+	sizes := CompareBundles(current, previous, build)
+	require.Len(t, sizes, 2)
+	assert.Empty(t, sizes[0].OverBudget())
+	assert.Equal(t, "90.0 kB (new, budget 100.0 kB)", sizes[0].Describe("js"))
+	assert.Equal(t, []string{"js"}, sizes[1].OverBudget())
+	assert.Equal(t, "25.0 kB (-1.0 kB, budget 30.0 kB)", sizes[1].Describe("css"))
+
+	err := CheckBudgets(sizes)
+	require.Error(t, err)
+	assert.Equal(t, "bundle budgets exceeded:\n  dashboard js: 152.3 kB (+4.1 kB, budget 150.0 kB)", err.Error())
+
+	assert.NoError(t, CheckBudgets(CompareBundles(current, previous, config.Build{})))
+}
+
+func TestReadManifest_Missing(t *testing.T) {
+	manifest, err := ReadManifest(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Routes)
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = ReadManifest(path)
+	assert.Error(t, err)
+}
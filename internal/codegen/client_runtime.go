@@ -1,9 +1,23 @@
 package codegen
 
-func GenerateClientRuntimeTS() string {
-	return `// Code generated by rstf. DO NOT EDIT.
-import { startTransition, useEffect, useRef, useState } from "react";
+import "fmt"
+
+// GenerateClientRuntimeTS generates the client runtime behind @rstf/routes.
+// basePath is the URL prefix a mounted app's live query and RPC endpoints are
+// served under; it is empty for the project itself.
+func GenerateClientRuntimeTS(basePath string) string {
+	return fmt.Sprintf(clientRuntimeHeader, basePath) + clientRuntimeTS
+}
+
+const clientRuntimeHeader = `// Code generated by rstf. DO NOT EDIT.
+import { createElement, startTransition, useEffect, useRef, useState } from "react";
+import type { FormEvent, FormHTMLAttributes } from "react";
+import { revalidate } from "./ssr";
 
+const basePath = %q;
+`
+
+const clientRuntimeTS = `
 export type QueryDef<P, R> = {
   kind: "query";
   route: string;
@@ -14,12 +28,41 @@ export type MutationDef<P, I, R> = {
   kind: "mutation";
   route: string;
   name: string;
+  fieldErrors: boolean;
 };
 
 export type ActionDef<P, I, R> = {
   kind: "action";
   route: string;
   name: string;
+  fieldErrors: boolean;
+};
+
+// RPCOptions describes a mutation or action. fieldErrors is set for
+// functions that return (T, *rstf.FieldErrors), whose result is an
+// ActionResult.
+export type RPCOptions = {
+  fieldErrors?: boolean;
+};
+
+// FieldErrors maps input field names to their error messages.
+export type FieldErrors = Record<string, string>;
+
+export type ActionResult<T> = { ok: true; data: T } | { ok: false; errors: FieldErrors };
+
+export type EventStreamDef<P, E> = {
+  kind: "events";
+  route: string;
+  name: string;
+};
+
+type StreamEvent = {
+  type: string;
+  data: unknown;
+};
+
+export type EventHandlers<E extends StreamEvent> = {
+  [T in E["type"]]?: (data: Extract<E, { type: T }>["data"]) => void;
 };
 
 type QueryStatus = "loading" | "ready" | "error";
@@ -99,7 +142,7 @@ function ensureLiveConnection(): void {
     return;
   }
 
-  source = new EventSource("/__rstf/live?clientId=" + encodeURIComponent(clientId));
+  source = new EventSource(basePath + "/__rstf/live?clientId=" + encodeURIComponent(clientId));
   source.onmessage = (event) => {
     const payload = JSON.parse(event.data) as LiveEvent<unknown>;
     const listener = listeners.get(payload.subscriptionId);
@@ -120,7 +163,10 @@ async function postJSON<T>(url: string, body: unknown): Promise<T> {
     },
     body: JSON.stringify(body),
   });
+  return readResponse<T>(response);
+}
 
+async function readResponse<T>(response: Response): Promise<T> {
   let payload: any = null;
   try {
     payload = await response.json();
@@ -128,7 +174,8 @@ async function postJSON<T>(url: string, body: unknown): Promise<T> {
     payload = null;
   }
 
-  if (!response.ok) {
+  // A call with field errors answers 422 with its result.
+  if (!response.ok && payload?.data === undefined) {
     const err = payload?.error as RPCError | undefined;
     throw err ?? {
       code: "internal_error",
@@ -149,7 +196,7 @@ async function subscribeQuery<P extends Record<string, string>, R>(
   listeners.set(subscriptionId, listener);
 
   try {
-    const response = await postJSON<RPCResponse<R>>("/__rstf/live/subscribe", {
+    const response = await postJSON<RPCResponse<R>>(basePath + "/__rstf/live/subscribe", {
       clientId,
       subscriptionId,
       route: def.route,
@@ -165,7 +212,7 @@ async function subscribeQuery<P extends Record<string, string>, R>(
 
 function unsubscribeQuery(subscriptionId: string): void {
   listeners.delete(subscriptionId);
-  void postJSON<Record<string, boolean>>("/__rstf/live/unsubscribe", {
+  void postJSON<Record<string, boolean>>(basePath + "/__rstf/live/unsubscribe", {
     clientId,
     subscriptionId,
   } satisfies LiveUnsubscribeRequest).catch(() => {
@@ -177,12 +224,26 @@ export function defineQuery<P, R>(route: string, name: string): QueryDef<P, R> {
   return { kind: "query", route, name };
 }
 
-export function defineMutation<P, I, R>(route: string, name: string): MutationDef<P, I, R> {
-  return { kind: "mutation", route, name };
+export function defineMutation<P, I, R>(route: string, name: string, options: RPCOptions = {}): MutationDef<P, I, R> {
+  return { kind: "mutation", route, name, fieldErrors: options.fieldErrors === true };
+}
+
+export function defineAction<P, I, R>(route: string, name: string, options: RPCOptions = {}): ActionDef<P, I, R> {
+  return { kind: "action", route, name, fieldErrors: options.fieldErrors === true };
 }
 
-export function defineAction<P, I, R>(route: string, name: string): ActionDef<P, I, R> {
-  return { kind: "action", route, name };
+// fieldErrorsOf returns the field errors of a failed call to a function that
+// returns (T, *rstf.FieldErrors), or null.
+function fieldErrorsOf(def: { fieldErrors: boolean }, result: unknown): FieldErrors | null {
+  if (!def.fieldErrors) {
+    return null;
+  }
+  const actionResult = result as ActionResult<unknown>;
+  return actionResult.ok ? null : actionResult.errors;
+}
+
+export function defineEventStream<P, E>(route: string, name: string): EventStreamDef<P, E> {
+  return { kind: "events", route, name };
 }
 
 export function useQuery<P extends Record<string, string>, R>(def: QueryDef<P, R>, params: P) {
@@ -268,7 +329,7 @@ export function useMutation<P extends Record<string, string>, I, R>(
   params: P
 ) {
   return async (input: I): Promise<R> => {
-    const response = await postJSON<RPCResponse<R>>("/__rstf/rpc", {
+    const response = await postJSON<RPCResponse<R>>(basePath + "/__rstf/rpc", {
       kind: def.kind,
       route: def.route,
       name: def.name,
@@ -279,27 +340,249 @@ export function useMutation<P extends Record<string, string>, I, R>(
   };
 }
 
+export type ActionOptions<I> = {
+  // optimistic applies the expected outcome of a call before it is sent and
+  // returns a function that undoes it, such as the one updateServerData
+  // returns. The undo runs if the call fails.
+  optimistic?: (input: I) => (() => void) | void;
+  // revalidate refetches the page's server data after a successful call.
+  // Defaults to true.
+  revalidate?: boolean;
+};
+
+export type ActionState<R> = {
+  pending: boolean;
+  error: RPCError | null;
+  // fieldErrors holds the field errors of the last call, for functions that
+  // return (T, *rstf.FieldErrors).
+  fieldErrors: FieldErrors | null;
+  data: R | null;
+};
+
+export type ActionFunction<I, R> = ((input: I) => Promise<R>) & ActionState<R>;
+
 export function useAction<P extends Record<string, string>, I, R>(
   def: ActionDef<P, I, R>,
-  params: P
-) {
-  return async (input: I): Promise<R> => {
-    const response = await postJSON<RPCResponse<R>>("/__rstf/rpc", {
-      kind: def.kind,
-      route: def.route,
-      name: def.name,
-      params,
-      input,
-    } satisfies RPCRequest);
+  params: P,
+  options: ActionOptions<I> = {}
+): ActionFunction<I, R> {
+  const [state, setState] = useState<ActionState<R>>({
+    pending: false,
+    error: null,
+    fieldErrors: null,
+    data: null,
+  });
+
+  const run = async (input: I): Promise<R> => {
+    setState((current) => ({ ...current, pending: true, error: null, fieldErrors: null }));
+    const undo = options.optimistic?.(input);
+
+    let response: RPCResponse<R>;
+    try {
+      response = await postJSON<RPCResponse<R>>(basePath + "/__rstf/rpc", {
+        kind: def.kind,
+        route: def.route,
+        name: def.name,
+        params,
+        input,
+      } satisfies RPCRequest);
+    } catch (error) {
+      undo?.();
+      setState((current) => ({ ...current, pending: false, error: error as RPCError }));
+      throw error;
+    }
+
+    const fieldErrors = fieldErrorsOf(def, response.data);
+    if (fieldErrors) {
+      undo?.();
+      setState({ pending: false, error: null, fieldErrors, data: null });
+      return response.data;
+    }
+    if (options.revalidate !== false) {
+      // The call succeeded; a failed refetch leaves the optimistic data in
+      // place until the next revalidation.
+      await revalidate().catch(() => {});
+    }
+    setState({ pending: false, error: null, fieldErrors: null, data: response.data });
     return response.data;
   };
+
+  return Object.assign(run, state);
 }
-`
+
+export type FormProps<P, I, R> = Omit<FormHTMLAttributes<HTMLFormElement>, "action" | "method" | "onError"> & {
+  action: ActionDef<P, I, R> | MutationDef<P, I, R>;
+  params: P;
+  // Local path to go to after a successful submission. Without JavaScript
+  // the browser returns to the submitting page by default; with it the page
+  // stays and its server data is revalidated in place.
+  redirect?: string;
+  onSuccess?: (result: Exclude<R, { ok: false }>) => void;
+  onError?: (error: RPCError) => void;
+  // onFieldErrors is called when a function that returns
+  // (T, *rstf.FieldErrors) rejects the input. The fields are already marked.
+  onFieldErrors?: (errors: FieldErrors) => void;
+};
+
+// formAction returns the URL a <Form> posts to: the RPC endpoint, with the
+// function and its route params in the query string and input in the body.
+export function formAction<P extends Record<string, string>, I, R>(
+  def: ActionDef<P, I, R> | MutationDef<P, I, R>,
+  params: P,
+  redirect?: string
+): string {
+  let url =
+    basePath +
+    "/__rstf/rpc?kind=" +
+    def.kind +
+    "&route=" +
+    encodeURIComponent(def.route) +
+    "&name=" +
+    encodeURIComponent(def.name) +
+    "&params=" +
+    encodeURIComponent(JSON.stringify(params));
+  if (redirect) {
+    url += "&redirect=" + encodeURIComponent(redirect);
+  }
+  return url;
+}
+
+// markFieldErrors flags the form's fields named in errors as invalid, with
+// their message as the validation message, and clears the others. A flagged
+// field clears once it is edited.
+function markFieldErrors(form: HTMLFormElement, errors: FieldErrors): void {
+  for (const element of Array.from(form.elements)) {
+    const field = element as HTMLInputElement;
+    if (!field.name || typeof field.setCustomValidity !== "function") {
+      continue;
+    }
+    const message = errors[field.name] ?? "";
+    field.setCustomValidity(message);
+    if (!message) {
+      field.removeAttribute("aria-invalid");
+      continue;
+    }
+    field.setAttribute("aria-invalid", "true");
+    field.addEventListener(
+      "input",
+      () => {
+        field.setCustomValidity("");
+        field.removeAttribute("aria-invalid");
+      },
+      { once: true }
+    );
+  }
+  if (Object.keys(errors).length > 0) {
+    form.reportValidity();
+  }
+}
+
+// Form posts its fields to an action or mutation. It works as a regular form
+// before hydration; once hydrated it submits with fetch and revalidates the
+// page's server data instead of reloading. Field errors from a function that
+// returns (T, *rstf.FieldErrors) mark the matching fields invalid.
+export function Form<P extends Record<string, string>, I, R>({
+  action,
+  params,
+  redirect,
+  onSuccess,
+  onError,
+  onFieldErrors,
+  onSubmit,
+  children,
+  ...props
+}: FormProps<P, I, R>) {
+  const [pending, setPending] = useState(false);
+
+  const handleSubmit = async (event: FormEvent<HTMLFormElement>) => {
+    onSubmit?.(event);
+    if (event.defaultPrevented) {
+      return;
+    }
+    event.preventDefault();
+
+    const form = event.currentTarget;
+    const data = new FormData(form, (event.nativeEvent as SubmitEvent).submitter);
+    const body = form.enctype === "multipart/form-data" ? data : new URLSearchParams(data as any);
+    setPending(true);
+    try {
+      const response = await fetch(form.action, {
+        method: "POST",
+        headers: {
+          Accept: "application/json",
+        },
+        body,
+      });
+      const payload = await readResponse<RPCResponse<R>>(response);
+      const fieldErrors = fieldErrorsOf(action, payload.data);
+      markFieldErrors(form, fieldErrors ?? {});
+      if (fieldErrors) {
+        onFieldErrors?.(fieldErrors);
+        return;
+      }
+      if (redirect) {
+        window.location.assign(redirect);
+        return;
+      }
+      await revalidate();
+      onSuccess?.(payload.data as Exclude<R, { ok: false }>);
+    } catch (error) {
+      if (!onError) {
+        throw error;
+      }
+      onError(error as RPCError);
+    } finally {
+      setPending(false);
+    }
+  };
+
+  return createElement(
+    "form",
+    {
+      ...props,
+      method: "post",
+      action: formAction(action, params, redirect),
+      onSubmit: handleSubmit,
+      "aria-busy": pending || undefined,
+    },
+    children
+  );
 }
 
+export function useEventStream<P extends Record<string, string>, E extends StreamEvent>(
+  def: EventStreamDef<P, E>,
+  params: P,
+  handlers: EventHandlers<E>
+): void {
+  const handlersRef = useRef(handlers);
+  handlersRef.current = handlers;
+
+  const paramsKey = stableStringify(params);
+  useEffect(() => {
+    const url =
+      basePath +
+      "/__rstf/live/events?route=" +
+      encodeURIComponent(def.route) +
+      "&name=" +
+      encodeURIComponent(def.name) +
+      "&params=" +
+      encodeURIComponent(JSON.stringify(params));
+    const events = new EventSource(url);
+    events.onmessage = (message) => {
+      const event = JSON.parse(message.data) as StreamEvent;
+      const handlers = handlersRef.current as unknown as Record<string, (data: unknown) => void>;
+      handlers[event.type]?.(event.data);
+    };
+    return () => {
+      events.close();
+    };
+  }, [def, paramsKey]);
+}
+`
+
 func GenerateSSRRuntimeTS() string {
 	return `// Code generated by rstf. DO NOT EDIT.
-import { createContext, createElement, useContext } from "react";
+import { createContext, createElement, useContext, useEffect, useState } from "react";
 import type { ComponentType, PropsWithChildren } from "react";
 
 export type SSRPropsMap = Record<string, Record<string, any>>;
@@ -313,11 +596,40 @@ function currentSSRData(): SSRPropsMap {
   return {};
 }
 
+const dataListeners = new Set<(data: SSRPropsMap) => void>();
+
+function setSSRData(data: SSRPropsMap): void {
+  (window as any).__RSTF_SSR_PROPS__ = data;
+  dataListeners.forEach((listener) => listener(data));
+}
+
+// revalidate refetches the current page's server data and re-renders every
+// component reading it.
+export async function revalidate(): Promise<void> {
+  if (typeof window === "undefined") {
+    return;
+  }
+  const url = new URL(window.location.href);
+  url.searchParams.set("_data", "");
+  const response = await fetch(url, { headers: { Accept: "application/json" } });
+  if (!response.ok) {
+    throw new Error("revalidating server data failed with status " + response.status);
+  }
+  setSSRData((await response.json()) as SSRPropsMap);
+}
+
 export function SSRDataProvider({
   data,
   children,
 }: PropsWithChildren<{ data?: SSRPropsMap }>) {
-  return createElement(SSRDataContext.Provider, { value: data ?? currentSSRData() }, children);
+  const [current, setCurrent] = useState<SSRPropsMap>(() => data ?? currentSSRData());
+  useEffect(() => {
+    dataListeners.add(setCurrent);
+    return () => {
+      dataListeners.delete(setCurrent);
+    };
+  }, []);
+  return createElement(SSRDataContext.Provider, { value: current }, children);
 }
 
 type Simplify<T> = { [K in keyof T]: T[K] } & {};
@@ -336,5 +648,83 @@ export function createSSRWrapper<Injected extends Record<string, any>>(component
     return WrappedComponent;
   };
 }
+
+export function createServerDataHook<Data extends Record<string, any>>(componentPath: string) {
+  return function useServerData(): Data {
+    const allSSRData = useContext(SSRDataContext) ?? currentSSRData();
+    return (allSSRData[componentPath] ?? {}) as Data;
+  };
+}
+
+// createServerDataUpdater returns a function that replaces a component's
+// server data on the client until the next revalidation, e.g. to show an
+// action's expected result before the server confirms it. It returns a
+// function that undoes the update unless the data was revalidated since.
+export function createServerDataUpdater<Data extends Record<string, any>>(componentPath: string) {
+  return function updateServerData(update: (data: Data) => Data): () => void {
+    if (typeof window === "undefined") {
+      return () => {};
+    }
+    const previous = currentSSRData()[componentPath];
+    const next = update((previous ?? {}) as Data);
+    setSSRData({ ...currentSSRData(), [componentPath]: next });
+    return () => {
+      const data = currentSSRData();
+      if (data[componentPath] === next) {
+        setSSRData({ ...data, [componentPath]: previous });
+      }
+    };
+  };
+}
 `
 }
+
+// formatRuntimeTS is @rstf/format, the helpers that display fields tagged with
+// rstf.FormatTag. Their types are declared in rstf/types/rstf.format.d.ts.
+const formatRuntimeTS = `// Code generated by rstf. DO NOT EDIT.
+
+/** Formats a currency field in locale, e.g. "€12.50" for { amount: "12.50", currency: "EUR" }. */
+export function formatMoney(
+  money: Rstf.Money,
+  locale?: string,
+  options?: Intl.NumberFormatOptions,
+): string {
+  return new Intl.NumberFormat(locale, {
+    ...options,
+    style: "currency",
+    currency: money.currency,
+  }).format(Number(money.amount));
+}
+
+/** Parses a datetime field. */
+export function parseDateTime(value: Rstf.DateTime): Date {
+  return new Date(value);
+}
+
+/** Formats a datetime field in locale, in the browser's time zone unless options sets one. */
+export function formatDateTime(
+  value: Rstf.DateTime,
+  locale?: string,
+  options: Intl.DateTimeFormatOptions = { dateStyle: "medium", timeStyle: "short" },
+): string {
+  return parseDateTime(value).toLocaleString(locale, options);
+}
+
+/**
+ * Parses a date field as local midnight of that day. new Date("2026-03-04")
+ * is UTC midnight instead, which is the day before west of UTC.
+ */
+export function parseDate(value: Rstf.DateString): Date {
+  const [year, month, day] = value.split("-").map(Number);
+  return new Date(year, month - 1, day);
+}
+
+/** Formats a date field in locale, e.g. "Mar 4, 2026". */
+export function formatDate(
+  value: Rstf.DateString,
+  locale?: string,
+  options: Intl.DateTimeFormatOptions = { dateStyle: "medium" },
+): string {
+  return parseDate(value).toLocaleDateString(locale, options);
+}
+`
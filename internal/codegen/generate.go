@@ -3,14 +3,19 @@ package codegen
 import (
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 
+	"github.com/rafbgarcia/rstf/internal/config"
 	"github.com/rafbgarcia/rstf/internal/conventions"
+	"github.com/rafbgarcia/rstf/internal/profile"
 )
 
 // GenerateResult holds the output of a codegen run.
@@ -18,6 +23,26 @@ type GenerateResult struct {
 	RouteCount int
 	Entries    map[string]string // routeDir -> absolute path to hydration entry .tsx
 	SSREntries map[string]string // routeDir -> absolute path to SSR entry .tsx
+	// UnusedShared lists the shared/ directories with SSR code that neither
+	// a route nor the layout imports. Their .d.ts and runtime modules are
+	// still generated so the first import type-checks.
+	UnusedShared []string
+	// Mounts holds the output for each app mounted through rstf.json, keyed
+	// by its directory. Route dirs and entries are relative to that directory.
+	Mounts map[string]GenerateResult
+}
+
+// AllUnusedShared returns UnusedShared for the project and every mounted app,
+// relative to the project root.
+func (r GenerateResult) AllUnusedShared() []string {
+	unused := slices.Clone(r.UnusedShared)
+	for dir, m := range r.Mounts {
+		for _, shared := range m.AllUnusedShared() {
+			unused = append(unused, path.Join(dir, shared))
+		}
+	}
+	sort.Strings(unused)
+	return unused
 }
 
 // ChangeEvent describes a single file change for incremental codegen.
@@ -43,10 +68,27 @@ type Generator struct {
 	filesByDir map[string]RouteFile
 	deps       map[string][]string
 	cache      *fsCache
-	entries    map[string]string // routeDir -> absolute hydration entry path
-	ssrEntries map[string]string // routeDir -> absolute SSR entry path
+	entries    map[string]string       // routeDir -> absolute hydration entry path
+	ssrEntries map[string]string       // routeDir -> absolute SSR entry path
+	entryOpts  map[string]EntryOptions // routeDir -> hydration/SSR entry options
+	unused     []string                // shared dirs no route or layout imports
+
+	// A mounted app is generated into its own rstf/ directory by a child
+	// Generator; the project's server_gen.go serves it under prefix.
+	prefix string       // URL prefix of a mounted app, "" for the project itself
+	dir    string       // directory of a mounted app relative to the project root
+	mounts []*Generator // apps mounted through rstf.json
 
 	prevServerCode string
+
+	testIDs bool                  // write rstf/testids.json, set by codegen.testIds in rstf.json
+	ignore  func(dir string) bool // directories left out of parsing, set by ignore in rstf.json
+
+	buildTags     []string // tags route Go files are matched against, see SetBuildTags
+	excludeRoutes []string // route name patterns left out, see ExcludeRoutes
+
+	profile *profile.Span // span the next run records its phases under, nil when not profiling
+	trace   func(string)  // receives codegen decisions, see SetTrace
 }
 
 // NewGenerator creates a Generator for the given project root. It reads go.mod
@@ -65,7 +107,30 @@ func NewGenerator(projectRoot string) (*Generator, error) {
 	if modulePath == "" {
 		return nil, fmt.Errorf("no module directive found in go.mod")
 	}
+	cfg, err := config.Load(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGenerator(absRoot, modulePath)
+	g.testIDs = cfg.Codegen.TestIDs
+	g.ignore = cfg.Ignored
+	for _, m := range cfg.Mounts {
+		dir := filepath.ToSlash(filepath.Clean(m.Dir))
+		if info, err := os.Stat(filepath.Join(absRoot, dir)); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("mount %s: directory %s not found", m.Path, m.Dir)
+		}
+		mount := newGenerator(filepath.Join(absRoot, dir), modulePath+"/"+dir)
+		mount.prefix = m.Path
+		mount.dir = dir
+		mount.testIDs = cfg.Codegen.TestIDs
+		mount.ignore = func(rel string) bool { return cfg.Ignored(dir + "/" + rel) }
+		g.mounts = append(g.mounts, mount)
+	}
+	return g, nil
+}
 
+func newGenerator(absRoot, modulePath string) *Generator {
 	return &Generator{
 		root:       absRoot,
 		rstfDir:    filepath.Join(absRoot, "rstf"),
@@ -74,8 +139,109 @@ func NewGenerator(projectRoot string) (*Generator, error) {
 		deps:       make(map[string][]string),
 		entries:    make(map[string]string),
 		ssrEntries: make(map[string]string),
+		entryOpts:  make(map[string]EntryOptions),
 		cache:      newFSCache(),
-	}, nil
+	}
+}
+
+// SetProfile makes the next Generate or Regenerate run record how long each of
+// its phases takes, per route where the phase works route by route, as spans
+// nested in span. A nil span turns profiling off.
+func (g *Generator) SetProfile(span *profile.Span) {
+	g.profile = span
+}
+
+// SetTrace makes Generate and Regenerate report what they decide about each
+// route to fn, such as a route left out or an entry rewritten, e.g.
+// "routes/dashboard: entries rewritten, deps changed". Mounted apps report
+// through the same fn, with their directory in the path. A nil fn turns
+// tracing off.
+func (g *Generator) SetTrace(fn func(string)) {
+	g.trace = fn
+	for _, m := range g.mounts {
+		m.SetTrace(fn)
+	}
+}
+
+// tracef reports a decision about dir, relative to g's root, to the SetTrace
+// callback.
+func (g *Generator) tracef(dir, format string, args ...any) {
+	if g.trace == nil {
+		return
+	}
+	if g.dir != "" {
+		dir = path.Join(g.dir, dir)
+	}
+	g.trace(dir + ": " + fmt.Sprintf(format, args...))
+}
+
+// Ignored reports whether dir, relative to the project root with forward
+// slashes, is one of the directories ignore in rstf.json leaves out.
+func (g *Generator) Ignored(dir string) bool {
+	return g.ignore != nil && g.ignore(dir)
+}
+
+// SetBuildTags makes codegen match Go files against tags, as go build -tags
+// does, so a file behind //go:build debug only counts with the debug tag. A
+// route whose Go files are all excluded is left out entirely, view included.
+// Mounted apps use the same tags.
+func (g *Generator) SetBuildTags(tags []string) {
+	g.buildTags = tags
+	for _, m := range g.mounts {
+		m.SetBuildTags(tags)
+	}
+}
+
+// ExcludeRoutes leaves the routes whose names match one of patterns out of
+// codegen, and so out of the generated server and the bundles. Patterns use
+// path.Match syntax against route names as in rstf/manifest.json, e.g.
+// "debug" or "admin.*". Mounted apps match their own route names.
+func (g *Generator) ExcludeRoutes(patterns []string) {
+	g.excludeRoutes = patterns
+	for _, m := range g.mounts {
+		m.ExcludeRoutes(patterns)
+	}
+}
+
+// excludedRoute reports why the route in dir is left out: by an
+// ExcludeRoutes pattern, or by the build tags because none of its Go files is
+// part of the build. It returns "" for a route that is kept.
+func (g *Generator) excludedRoute(dir string) (string, error) {
+	name := routeNameForDir(dir)
+	for _, pattern := range g.excludeRoutes {
+		if ok, _ := path.Match(pattern, name); ok {
+			return fmt.Sprintf("excludeRoutes pattern %q", pattern), nil
+		}
+	}
+	excluded, err := excludedByBuildTags(filepath.Join(g.root, dir), g.buildTags)
+	if excluded {
+		return "build tags", err
+	}
+	return "", err
+}
+
+// dropExcluded removes the routes excludedRoute leaves out from the parsed
+// files and the TSX route directories.
+func (g *Generator) dropExcluded(files []RouteFile, tsxDirs []string) ([]RouteFile, []string, error) {
+	var err error
+	reasons := map[string]string{} // a route can have both Go files and a view
+	excluded := func(dir string) bool {
+		if err != nil || !conventions.IsRouteDir(dir) {
+			return false
+		}
+		reason, seen := reasons[dir]
+		if !seen {
+			reason, err = g.excludedRoute(dir)
+			reasons[dir] = reason
+			if reason != "" {
+				g.tracef(dir, "left out by %s", reason)
+			}
+		}
+		return reason != ""
+	}
+	files = slices.DeleteFunc(files, func(f RouteFile) bool { return excluded(f.Dir) })
+	tsxDirs = slices.DeleteFunc(tsxDirs, excluded)
+	return files, tsxDirs, err
 }
 
 // Generate runs the full codegen pipeline — clean slate rebuild. It populates
@@ -91,10 +257,27 @@ func (g *Generator) Generate() (GenerateResult, error) {
 	}
 
 	// 2. Parse all Go route files.
-	files, err := ParseDir(g.root)
+	parseSpan := g.profile.Start("parse")
+	files, err := parseDir(g.root, g.ignore, g.buildTags)
+	parseSpan.End()
 	if err != nil {
 		return GenerateResult{}, fmt.Errorf("parsing project: %w", err)
 	}
+	// Mounted apps are parsed by their own generators.
+	files = slices.DeleteFunc(files, func(f RouteFile) bool {
+		return g.mountOf(f.Dir) != nil
+	})
+	tsxRouteDirs, err := discoverTSXRouteDirs(g.root)
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("discovering TSX routes: %w", err)
+	}
+	files, tsxRouteDirs, err = g.dropExcluded(files, tsxRouteDirs)
+	if err != nil {
+		return GenerateResult{}, err
+	}
+	if err := checkArtifactCollisions(files); err != nil {
+		return GenerateResult{}, err
+	}
 
 	// Create rstf/ directory structure before any parallel writes.
 	for _, dir := range []string{
@@ -131,10 +314,6 @@ func (g *Generator) Generate() (GenerateResult, error) {
 		seenDirs[f.Dir] = true
 	}
 
-	tsxRouteDirs, err := discoverTSXRouteDirs(g.root)
-	if err != nil {
-		return GenerateResult{}, fmt.Errorf("discovering TSX routes: %w", err)
-	}
 	for _, routeDir := range tsxRouteDirs {
 		if seenDirs[routeDir] {
 			continue
@@ -147,6 +326,8 @@ func (g *Generator) Generate() (GenerateResult, error) {
 	var mu sync.Mutex
 	deps := map[string][]string{}
 	g.cache = newFSCache()
+	depsSpan := g.profile.Start("analyze deps")
+	typesSpan := g.profile.Start("write types")
 
 	sem := make(chan struct{}, runtime.NumCPU())
 	var wg sync.WaitGroup
@@ -167,7 +348,12 @@ func (g *Generator) Generate() (GenerateResult, error) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			defer depsSpan.Start(dir).End()
 
+			if err := checkViewExport(g.root, entryPath, g.cache); err != nil {
+				setErr(err)
+				return
+			}
 			d, err := AnalyzeDeps(g.root, entryPath, g.cache)
 			if err != nil {
 				setErr(fmt.Errorf("analyzing deps for %s: %w", dir, err))
@@ -186,6 +372,7 @@ func (g *Generator) Generate() (GenerateResult, error) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			defer typesSpan.Start(rf.Dir).End()
 
 			if err := writeDTSAndRuntime(g.rstfDir, rf); err != nil {
 				setErr(err)
@@ -194,6 +381,8 @@ func (g *Generator) Generate() (GenerateResult, error) {
 	}
 
 	wg.Wait()
+	depsSpan.End()
+	typesSpan.End()
 	if firstErr != nil {
 		return GenerateResult{}, firstErr
 	}
@@ -202,6 +391,8 @@ func (g *Generator) Generate() (GenerateResult, error) {
 
 	entries := map[string]string{}
 	ssrEntries := map[string]string{}
+	entryOpts := map[string]EntryOptions{}
+	entriesSpan := g.profile.Start("write entries")
 	for routeDir, routeDeps := range deps {
 		if !conventions.IsRouteDir(routeDir) {
 			continue
@@ -211,14 +402,16 @@ func (g *Generator) Generate() (GenerateResult, error) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			defer entriesSpan.Start(routeDir).End()
 
-			entryContent := GenerateHydrationEntry(routeDir, routeDeps)
+			opts := resolveEntryOptions(g.root, routeDir, g.cache)
+			entryContent := GenerateHydrationEntry(routeDir, routeDeps, opts)
 			entryPath := filepath.Join(g.rstfDir, "entries", entryFileName(routeDir))
 			if err := os.WriteFile(entryPath, []byte(entryContent), 0644); err != nil {
 				setErr(fmt.Errorf("writing entry %s: %w", entryPath, err))
 				return
 			}
-			ssrContent := GenerateSSREntry(routeDir)
+			ssrContent := GenerateSSREntry(routeDir, opts)
 			ssrEntryPath := filepath.Join(g.rstfDir, "ssr_entries", ssrEntryFileName(routeDir))
 			if err := os.WriteFile(ssrEntryPath, []byte(ssrContent), 0644); err != nil {
 				setErr(fmt.Errorf("writing SSR entry %s: %w", ssrEntryPath, err))
@@ -227,32 +420,41 @@ func (g *Generator) Generate() (GenerateResult, error) {
 			mu.Lock()
 			entries[routeDir] = entryPath
 			ssrEntries[routeDir] = ssrEntryPath
+			entryOpts[routeDir] = opts
 			mu.Unlock()
 		}(routeDir, routeDeps)
 	}
 
 	wg.Wait()
+	if firstErr == nil {
+		firstErr = g.writeTemplateEntries(ssrEntries)
+	}
+	entriesSpan.End()
 	if firstErr != nil {
 		return GenerateResult{}, firstErr
 	}
 
 	// --- Phase 4: sequential finalization ---
 
+	helpersSpan := g.profile.Start("route helpers")
 	routeDefs := BuildRouteDefs(files, deps)
-	if err := writeRouteHelpers(g.rstfDir, routeDefs); err != nil {
+	if err := writeRouteHelpers(g.rstfDir, g.prefix, routeDefs); err != nil {
 		return GenerateResult{}, err
 	}
-
-	serverCode, err := GenerateServer(g.modulePath, files, deps)
-	if err != nil {
-		return GenerateResult{}, fmt.Errorf("generating server: %w", err)
+	if err := writeManifest(g.rstfDir, g.prefix, files, deps); err != nil {
+		return GenerateResult{}, err
 	}
-	serverPath := filepath.Join(g.rstfDir, "server_gen.go")
-	if err := os.WriteFile(serverPath, []byte(serverCode), 0644); err != nil {
-		return GenerateResult{}, fmt.Errorf("writing server_gen.go: %w", err)
+	if g.testIDs {
+		if err := g.writeTestIDs(files, deps, entryOpts); err != nil {
+			return GenerateResult{}, err
+		}
 	}
-
-	if err := ensureDeps(g.root, g.modulePath); err != nil {
+	if err := writeTSConfig(g.root, g.rstfDir, g.mountDirs()...); err != nil {
+		return GenerateResult{}, err
+	}
+	unused, err := g.unusedSharedDirs(files, deps)
+	helpersSpan.End()
+	if err != nil {
 		return GenerateResult{}, err
 	}
 
@@ -265,13 +467,93 @@ func (g *Generator) Generate() (GenerateResult, error) {
 	g.deps = deps
 	g.entries = entries
 	g.ssrEntries = ssrEntries
-	g.prevServerCode = serverCode
+	g.entryOpts = entryOpts
+	g.unused = unused
 
-	return GenerateResult{
-		RouteCount: countRoutes(files, deps),
-		Entries:    entries,
-		SSREntries: ssrEntries,
-	}, nil
+	for _, m := range g.mounts {
+		m.SetProfile(g.profile.Start("mount " + m.prefix))
+		_, err := m.Generate()
+		m.profile.End()
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("mount %s: %w", m.prefix, err)
+		}
+	}
+
+	// Mounted apps are served by the project's server.
+	if g.prefix == "" {
+		if err := g.writeSeeds(); err != nil {
+			return GenerateResult{}, err
+		}
+		serverSpan := g.profile.Start("server gen")
+		defer serverSpan.End()
+		serverCode, err := GenerateServer(g.modulePath, files, deps, entryOpts, g.mountedApps()...)
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("generating server: %w", err)
+		}
+		serverPath := filepath.Join(g.rstfDir, "server_gen.go")
+		if err := os.WriteFile(serverPath, []byte(serverCode), 0644); err != nil {
+			return GenerateResult{}, fmt.Errorf("writing server_gen.go: %w", err)
+		}
+		if err := ensureDeps(g.root, g.modulePath); err != nil {
+			return GenerateResult{}, err
+		}
+		g.prevServerCode = serverCode
+	}
+
+	return g.result(), nil
+}
+
+// result reports the output of the last codegen run, including mounted apps.
+func (g *Generator) result() GenerateResult {
+	result := GenerateResult{
+		RouteCount:   countRoutes(g.files, g.deps),
+		Entries:      g.entries,
+		SSREntries:   g.ssrEntries,
+		UnusedShared: g.unused,
+	}
+	if len(g.mounts) > 0 {
+		result.Mounts = make(map[string]GenerateResult, len(g.mounts))
+		for _, m := range g.mounts {
+			mountResult := m.result()
+			result.Mounts[m.dir] = mountResult
+			result.RouteCount += mountResult.RouteCount
+		}
+	}
+	return result
+}
+
+// mountOf returns the generator of the mounted app that owns relPath, a
+// slash-separated path relative to the project root, or nil.
+func (g *Generator) mountOf(relPath string) *Generator {
+	for _, m := range g.mounts {
+		if relPath == m.dir || strings.HasPrefix(relPath, m.dir+"/") {
+			return m
+		}
+	}
+	return nil
+}
+
+func (g *Generator) mountDirs() []string {
+	dirs := make([]string, 0, len(g.mounts))
+	for _, m := range g.mounts {
+		dirs = append(dirs, m.dir)
+	}
+	return dirs
+}
+
+func (g *Generator) mountedApps() []MountedApp {
+	apps := make([]MountedApp, 0, len(g.mounts))
+	for _, m := range g.mounts {
+		apps = append(apps, MountedApp{
+			Prefix:     m.prefix,
+			Dir:        m.dir,
+			ModulePath: m.modulePath,
+			Files:      m.files,
+			Deps:       m.deps,
+			EntryOpts:  m.entryOpts,
+		})
+	}
+	return apps
 }
 
 // Regenerate performs an incremental codegen based on file change events. It
@@ -279,7 +561,12 @@ func (g *Generator) Generate() (GenerateResult, error) {
 // and only writes files that actually changed. Returns which outputs changed so
 // the caller can decide whether to restart the server.
 func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
-	// 1. Classify events.
+	// 1. Hand changes inside mounted apps to their generators, then classify
+	// the rest.
+	events, err := g.regenerateMounts(events)
+	if err != nil {
+		return RegenerateResult{}, err
+	}
 	goChangedDirs := map[string]bool{} // relative dir -> true
 	var changedPaths []string
 
@@ -297,38 +584,70 @@ func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
 	// 2. Invalidate cache entries for changed paths.
 	g.cache.invalidatePaths(changedPaths)
 
-	// 3. For each Go-changed dir: re-parse and update filesByDir, write DTS + runtime.
+	// 3. For each Go-changed dir: re-parse, check the result against the other
+	// dirs, then update filesByDir and write DTS + runtime.
+	filesByDir := maps.Clone(g.filesByDir)
+	var parsed []RouteFile
+	parseSpan := g.profile.Start("parse")
 	for relDir := range goChangedDirs {
 		absDir := filepath.Join(g.root, relDir)
-		rf, err := ParseSingleDir(g.root, absDir)
+		dirSpan := parseSpan.Start(relDir)
+		rf, err := ParseSingleDir(g.root, absDir, g.buildTags...)
+		dirSpan.End()
 		if err != nil {
 			return RegenerateResult{}, fmt.Errorf("parsing %s: %w", relDir, err)
 		}
 
 		if rf != nil {
-			g.filesByDir[rf.Dir] = *rf
-			if err := writeDTSAndRuntime(g.rstfDir, *rf); err != nil {
-				return RegenerateResult{}, err
-			}
+			filesByDir[rf.Dir] = *rf
+			parsed = append(parsed, *rf)
+			g.tracef(relDir, "Go files re-parsed")
 		} else {
 			// Directory no longer has route functions — remove it.
-			delete(g.filesByDir, relDir)
+			delete(filesByDir, relDir)
+			g.tracef(relDir, "no route functions left, removed")
 		}
 	}
+	parseSpan.End()
 
-	// 4. Rebuild files slice from filesByDir.
-	g.files = make([]RouteFile, 0, len(g.filesByDir))
-	for _, rf := range g.filesByDir {
-		g.files = append(g.files, rf)
-	}
-
-	// 5. Re-discover TSX-only routes.
+	// Re-discover TSX-only routes, and leave out excluded routes.
 	tsxRouteDirs, err := discoverTSXRouteDirs(g.root)
 	if err != nil {
 		return RegenerateResult{}, fmt.Errorf("discovering TSX routes: %w", err)
 	}
+	files := slices.SortedFunc(maps.Values(filesByDir), func(a, b RouteFile) int {
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	files, tsxRouteDirs, err = g.dropExcluded(files, tsxRouteDirs)
+	if err != nil {
+		return RegenerateResult{}, err
+	}
+	if err := checkArtifactCollisions(files); err != nil {
+		return RegenerateResult{}, err
+	}
+	filesByDir = make(map[string]RouteFile, len(files))
+	for _, f := range files {
+		filesByDir[f.Dir] = f
+	}
+	typesSpan := g.profile.Start("write types")
+	for _, rf := range parsed {
+		if _, ok := filesByDir[rf.Dir]; !ok {
+			continue
+		}
+		rfSpan := typesSpan.Start(rf.Dir)
+		err := writeDTSAndRuntime(g.rstfDir, rf)
+		rfSpan.End()
+		if err != nil {
+			return RegenerateResult{}, err
+		}
+	}
+	typesSpan.End()
 
-	// 6. Re-run AnalyzeDeps for all routes (parallel, warm cache).
+	// 4. Commit the updated files.
+	g.filesByDir = filesByDir
+	g.files = files
+
+	// 5. Re-run AnalyzeDeps for all routes (parallel, warm cache).
 	type depJob struct {
 		dir       string
 		entryPath string
@@ -357,6 +676,7 @@ func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
 
 	var mu sync.Mutex
 	newDeps := map[string][]string{}
+	depsSpan := g.profile.Start("analyze deps")
 	sem := make(chan struct{}, runtime.NumCPU())
 	var wg sync.WaitGroup
 	var firstErr error
@@ -375,7 +695,12 @@ func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			defer depsSpan.Start(dir).End()
 
+			if err := checkViewExport(g.root, entryPath, g.cache); err != nil {
+				setErr(err)
+				return
+			}
 			d, err := AnalyzeDeps(g.root, entryPath, g.cache)
 			if err != nil {
 				setErr(fmt.Errorf("analyzing deps for %s: %w", dir, err))
@@ -387,29 +712,44 @@ func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
 		}(job.dir, job.entryPath)
 	}
 	wg.Wait()
+	depsSpan.End()
 	if firstErr != nil {
 		return RegenerateResult{}, firstErr
 	}
 
-	// 7. Diff old vs new deps → only write hydration entries that changed.
+	// 6. Diff old vs new deps → only write hydration entries that changed.
 	newEntries := make(map[string]string, len(g.entries))
 	newSSREntries := make(map[string]string, len(g.ssrEntries))
+	newEntryOpts := make(map[string]EntryOptions, len(g.entryOpts))
+	entriesSpan := g.profile.Start("write entries")
 	for routeDir, routeDeps := range newDeps {
 		if !conventions.IsRouteDir(routeDir) {
 			continue
 		}
 		oldDeps := g.deps[routeDir]
-		if !depsEqual(oldDeps, routeDeps) || g.entries[routeDir] == "" {
-			entryContent := GenerateHydrationEntry(routeDir, routeDeps)
+		opts := resolveEntryOptions(g.root, routeDir, g.cache)
+		newEntryOpts[routeDir] = opts
+		if !depsEqual(oldDeps, routeDeps) || g.entries[routeDir] == "" || g.entryOpts[routeDir] != opts {
+			switch {
+			case g.entries[routeDir] == "":
+				g.tracef(routeDir, "entries written, new route")
+			case !depsEqual(oldDeps, routeDeps):
+				g.tracef(routeDir, "entries rewritten, deps changed")
+			default:
+				g.tracef(routeDir, "entries rewritten, entry options changed")
+			}
+			routeSpan := entriesSpan.Start(routeDir)
+			entryContent := GenerateHydrationEntry(routeDir, routeDeps, opts)
 			entryPath := filepath.Join(g.rstfDir, "entries", entryFileName(routeDir))
 			if err := os.WriteFile(entryPath, []byte(entryContent), 0644); err != nil {
 				return RegenerateResult{}, fmt.Errorf("writing entry %s: %w", entryPath, err)
 			}
-			ssrContent := GenerateSSREntry(routeDir)
+			ssrContent := GenerateSSREntry(routeDir, opts)
 			ssrEntryPath := filepath.Join(g.rstfDir, "ssr_entries", ssrEntryFileName(routeDir))
 			if err := os.WriteFile(ssrEntryPath, []byte(ssrContent), 0644); err != nil {
 				return RegenerateResult{}, fmt.Errorf("writing SSR entry %s: %w", ssrEntryPath, err)
 			}
+			routeSpan.End()
 			newEntries[routeDir] = entryPath
 			newSSREntries[routeDir] = ssrEntryPath
 		} else {
@@ -417,41 +757,101 @@ func (g *Generator) Regenerate(events []ChangeEvent) (RegenerateResult, error) {
 			newSSREntries[routeDir] = g.ssrEntries[routeDir]
 		}
 	}
+	err = g.writeTemplateEntries(newSSREntries)
+	entriesSpan.End()
+	if err != nil {
+		return RegenerateResult{}, err
+	}
 
-	// 8. Generate server_gen.go, compare with previous.
+	// 7. Write route helpers and the manifest.
+	helpersSpan := g.profile.Start("route helpers")
 	routeDefs := BuildRouteDefs(g.files, newDeps)
-	if err := writeRouteHelpers(g.rstfDir, routeDefs); err != nil {
+	if err := writeRouteHelpers(g.rstfDir, g.prefix, routeDefs); err != nil {
 		return RegenerateResult{}, err
 	}
-
-	serverCode, err := GenerateServer(g.modulePath, g.files, newDeps)
+	if err := writeManifest(g.rstfDir, g.prefix, g.files, newDeps); err != nil {
+		return RegenerateResult{}, err
+	}
+	if g.testIDs {
+		if err := g.writeTestIDs(g.files, newDeps, newEntryOpts); err != nil {
+			return RegenerateResult{}, err
+		}
+	}
+	unused, err := g.unusedSharedDirs(g.files, newDeps)
+	helpersSpan.End()
 	if err != nil {
-		return RegenerateResult{}, fmt.Errorf("generating server: %w", err)
+		return RegenerateResult{}, err
 	}
-	serverChanged := serverCode != g.prevServerCode
-	if serverChanged {
-		serverPath := filepath.Join(g.rstfDir, "server_gen.go")
-		if err := os.WriteFile(serverPath, []byte(serverCode), 0644); err != nil {
-			return RegenerateResult{}, fmt.Errorf("writing server_gen.go: %w", err)
+
+	// 8. Generate server_gen.go, compare with previous. Mounted apps are
+	// served by the project's server.
+	serverChanged := false
+	if g.prefix == "" {
+		if err := g.writeSeeds(); err != nil {
+			return RegenerateResult{}, err
+		}
+		serverSpan := g.profile.Start("server gen")
+		defer serverSpan.End()
+		serverCode, err := GenerateServer(g.modulePath, g.files, newDeps, newEntryOpts, g.mountedApps()...)
+		if err != nil {
+			return RegenerateResult{}, fmt.Errorf("generating server: %w", err)
 		}
+		serverChanged = serverCode != g.prevServerCode
+		if serverChanged {
+			g.tracef("rstf/server_gen.go", "rewritten, the server restarts")
+			serverPath := filepath.Join(g.rstfDir, "server_gen.go")
+			if err := os.WriteFile(serverPath, []byte(serverCode), 0644); err != nil {
+				return RegenerateResult{}, fmt.Errorf("writing server_gen.go: %w", err)
+			}
+		} else {
+			g.tracef("rstf/server_gen.go", "unchanged")
+		}
+		g.prevServerCode = serverCode
 	}
 
 	// 9. Update cached state.
 	g.deps = newDeps
 	g.entries = newEntries
 	g.ssrEntries = newSSREntries
-	g.prevServerCode = serverCode
+	g.entryOpts = newEntryOpts
+	g.unused = unused
 
 	return RegenerateResult{
-		GenerateResult: GenerateResult{
-			RouteCount: countRoutes(g.files, newDeps),
-			Entries:    newEntries,
-			SSREntries: newSSREntries,
-		},
-		ServerChanged: serverChanged,
+		GenerateResult: g.result(),
+		ServerChanged:  serverChanged,
 	}, nil
 }
 
+// regenerateMounts runs Regenerate on every mounted app with changes among
+// events and returns the remaining events.
+func (g *Generator) regenerateMounts(events []ChangeEvent) ([]ChangeEvent, error) {
+	if len(g.mounts) == 0 {
+		return events, nil
+	}
+	var own []ChangeEvent
+	mountEvents := map[*Generator][]ChangeEvent{}
+	for _, ev := range events {
+		rel, err := filepath.Rel(g.root, ev.Path)
+		if m := g.mountOf(filepath.ToSlash(rel)); err == nil && m != nil {
+			mountEvents[m] = append(mountEvents[m], ev)
+			continue
+		}
+		own = append(own, ev)
+	}
+	for _, m := range g.mounts {
+		if len(mountEvents[m]) == 0 {
+			continue
+		}
+		m.SetProfile(g.profile.Start("mount " + m.prefix))
+		_, err := m.Regenerate(mountEvents[m])
+		m.profile.End()
+		if err != nil {
+			return nil, fmt.Errorf("mount %s: %w", m.prefix, err)
+		}
+	}
+	return own, nil
+}
+
 // Generate is a standalone wrapper that creates a throwaway Generator and runs
 // the full pipeline. Existing tests and one-shot callers can use this without
 // change.
@@ -488,9 +888,12 @@ func writeDTSAndRuntime(rstfDir string, rf RouteFile) error {
 	return nil
 }
 
-func writeRouteHelpers(rstfDir string, routeDefs []RouteDef) error {
+// writeRouteHelpers writes the client runtime and the route helpers. prefix is
+// the URL prefix of a mounted app, "" for the project itself.
+func writeRouteHelpers(rstfDir, prefix string, routeDefs []RouteDef) error {
+	routeDefs = prefixRouteDefs(routeDefs, prefix)
 	clientPath := filepath.Join(rstfDir, "generated", "client.ts")
-	if err := os.WriteFile(clientPath, []byte(GenerateClientRuntimeTS()), 0644); err != nil {
+	if err := os.WriteFile(clientPath, []byte(GenerateClientRuntimeTS(prefix)), 0644); err != nil {
 		return fmt.Errorf("writing %s: %w", clientPath, err)
 	}
 
@@ -499,6 +902,11 @@ func writeRouteHelpers(rstfDir string, routeDefs []RouteDef) error {
 		return fmt.Errorf("writing %s: %w", ssrPath, err)
 	}
 
+	formatPath := filepath.Join(rstfDir, "generated", "format.ts")
+	if err := os.WriteFile(formatPath, []byte(formatRuntimeTS), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", formatPath, err)
+	}
+
 	tsPath := filepath.Join(rstfDir, "generated", "routes.ts")
 	if err := os.WriteFile(tsPath, []byte(GenerateRoutesTS(routeDefs)), 0644); err != nil {
 		return fmt.Errorf("writing %s: %w", tsPath, err)
@@ -512,6 +920,18 @@ func writeRouteHelpers(rstfDir string, routeDefs []RouteDef) error {
 	return nil
 }
 
+func writeManifest(rstfDir, prefix string, files []RouteFile, deps map[string][]string) error {
+	manifest, err := GenerateManifestJSON(files, deps, prefix)
+	if err != nil {
+		return fmt.Errorf("generating manifest: %w", err)
+	}
+	manifestPath := filepath.Join(rstfDir, "manifest.json")
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
 // countRoutes counts unique route directories across parsed files and deps.
 func countRoutes(files []RouteFile, deps map[string][]string) int {
 	routeSet := map[string]bool{}
@@ -528,6 +948,35 @@ func countRoutes(files []RouteFile, deps map[string][]string) int {
 	return len(routeSet)
 }
 
+// unusedSharedDirs returns the shared/ directories among files with SSR data
+// functions that no route in deps and not the layout's main.tsx imports.
+func (g *Generator) unusedSharedDirs(files []RouteFile, deps map[string][]string) ([]string, error) {
+	reached := map[string]bool{}
+	for _, routeDeps := range deps {
+		for _, dir := range routeDeps {
+			reached[dir] = true
+		}
+	}
+	if _, err := os.Stat(filepath.Join(g.root, "main.tsx")); err == nil {
+		layoutDeps, err := AnalyzeDeps(g.root, "main.tsx", g.cache)
+		if err != nil {
+			return nil, fmt.Errorf("analyzing deps for main.tsx: %w", err)
+		}
+		for _, dir := range layoutDeps {
+			reached[dir] = true
+		}
+	}
+
+	var unused []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Dir, "shared/") && len(f.SSRDataFuncs()) > 0 && !reached[f.Dir] {
+			unused = append(unused, f.Dir)
+		}
+	}
+	sort.Strings(unused)
+	return unused, nil
+}
+
 // depsEqual reports whether two sorted dep slices are identical.
 func depsEqual(a, b []string) bool {
 	if len(a) != len(b) {
@@ -582,6 +1031,34 @@ func discoverTSXRouteDirs(absRoot string) ([]string, error) {
 	return dirs, nil
 }
 
+// checkArtifactCollisions fails when two directories map to the same
+// TypeScript namespace or .d.ts file, since their generated types would
+// silently overwrite each other (e.g. routes/users.$id and routes/users-id).
+func checkArtifactCollisions(files []RouteFile) error {
+	dirs := make([]string, 0, len(files))
+	for _, f := range files {
+		dirs = append(dirs, f.Dir)
+	}
+	sort.Strings(dirs)
+
+	namespaces := map[string]string{}
+	dtsFiles := map[string]string{}
+	for _, dir := range dirs {
+		ns := Namespace(dir)
+		if other, ok := namespaces[ns]; ok {
+			return fmt.Errorf("%s and %s both generate the TypeScript namespace %s; rename one of them", other, dir, ns)
+		}
+		namespaces[ns] = dir
+
+		dts := dtsFileName(dir)
+		if other, ok := dtsFiles[dts]; ok {
+			return fmt.Errorf("%s and %s both generate rstf/types/%s; rename one of them", other, dir, dts)
+		}
+		dtsFiles[dts] = dir
+	}
+	return nil
+}
+
 // dtsFileName returns the .d.ts filename for a given directory path.
 //
 //	"."                       → "main.d.ts"
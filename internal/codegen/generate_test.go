@@ -1,9 +1,14 @@
 package codegen
 
 import (
+	"io/fs"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/rafbgarcia/rstf/internal/profile"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -29,3 +34,261 @@ func TestDiscoverTSXRouteDirsRejectsNestedRouteDirectories(t *testing.T) {
 	require.ErrorContains(t, err, `invalid route directory "routes/admin/users"`)
 	require.ErrorContains(t, err, "use dotted names like routes/admin.users")
 }
+
+func TestCheckArtifactCollisions(t *testing.T) {
+	require.NoError(t, checkArtifactCollisions([]RouteFile{
+		{Dir: "."},
+		{Dir: "routes/users._id"},
+		{Dir: "routes/users"},
+	}))
+
+	err := checkArtifactCollisions([]RouteFile{
+		{Dir: "routes/users-id"},
+		{Dir: "routes/users.$id"},
+	})
+	require.Error(t, err)
+	require.ErrorContains(t, err, "routes/users-id and routes/users.$id")
+	require.ErrorContains(t, err, "namespace RoutesUsersId")
+
+	err = checkArtifactCollisions([]RouteFile{
+		{Dir: "routes/a.b"},
+		{Dir: "routes/a-b"},
+	})
+	require.ErrorContains(t, err, "routes/a-b and routes/a.b")
+}
+
+// writeSameNameRoutesProject writes an app whose routes all share a package
+// name, so the generated server has to number their import aliases.
+func writeSameNameRoutesProject(t testing.TB, root string) {
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "main.go"), `package app
+
+type ServerData struct {
+	AppName string `+"`json:\"appName\"`"+`
+}
+
+func SSR() ServerData { return ServerData{} }
+`)
+	writeFile(t, filepath.Join(root, "main.tsx"), `export function View({ children }: any) { return children; }`)
+	for _, dir := range []string{"admin.users", "users", "teams.users", "users._id", "reports", "settings"} {
+		writeFile(t, filepath.Join(root, "routes", dir, "index.go"), `package users
+
+type Author struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Post struct {
+	Title  string `+"`json:\"title\"`"+`
+	Author Author `+"`json:\"author\"`"+`
+}
+
+type ServerData struct {
+	Posts []Post   `+"`json:\"posts\"`"+`
+	Owner Author   `+"`json:\"owner\"`"+`
+}
+
+func SSR() ServerData { return ServerData{} }
+`)
+		writeFile(t, filepath.Join(root, "routes", dir, "index.tsx"), `export function View() { return null; }`)
+	}
+}
+
+func TestGenerate_StableOutput(t *testing.T) {
+	root := t.TempDir()
+	writeSameNameRoutesProject(t, root)
+
+	snapshot := func() map[string]string {
+		_, err := Generate(root)
+		require.NoError(t, err)
+		files := map[string]string{}
+		rstfDir := filepath.Join(root, "rstf")
+		err = filepath.WalkDir(rstfDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(rstfDir, path)
+			files[filepath.ToSlash(rel)] = string(data)
+			return err
+		})
+		require.NoError(t, err)
+		return files
+	}
+
+	first := snapshot()
+	require.Contains(t, first, "server_gen.go")
+	for range 5 {
+		require.Equal(t, first, snapshot(), "codegen output must be byte-identical across runs")
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	root := b.TempDir()
+	writeSameNameRoutesProject(b, root)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Generate(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerate_Profile(t *testing.T) {
+	root := t.TempDir()
+	writeSameNameRoutesProject(t, root)
+
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	span := profile.Start("codegen")
+	gen.SetProfile(span)
+	_, err = gen.Generate()
+	require.NoError(t, err)
+	span.End()
+
+	var report strings.Builder
+	span.Write(&report)
+	for _, phase := range []string{"parse", "analyze deps", "write types", "write entries", "route helpers", "server gen"} {
+		assert.Contains(t, report.String(), "\n    "+phase+" ")
+	}
+	assert.Contains(t, report.String(), "\n      routes/users._id ")
+
+	users := filepath.Join(root, "routes", "users", "index.go")
+	span = profile.Start("rebuild")
+	gen.SetProfile(span)
+	_, err = gen.Regenerate([]ChangeEvent{{Path: users, Kind: "go"}})
+	require.NoError(t, err)
+	span.End()
+
+	report.Reset()
+	span.Write(&report)
+	assert.Contains(t, report.String(), "\n    parse ")
+	assert.Contains(t, report.String(), "\n      routes/users ")
+}
+
+func TestGenerate_UnusedShared(t *testing.T) {
+	root := t.TempDir()
+	ssrGo := func(pkg string) string {
+		return "package " + pkg + "\n\ntype ServerData struct {\n\tName string `json:\"name\"`\n}\n\nfunc SSR() ServerData { return ServerData{} }\n"
+	}
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "main.tsx"), `import { Badge } from "./shared/ui/badge";
+export function View({ children }: any) { return <Badge>{children}</Badge>; }`)
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `import { Avatar } from "../../shared/ui/avatar";
+export function View() { return <Avatar />; }`)
+	for _, name := range []string{"avatar", "badge", "orphan"} {
+		writeFile(t, filepath.Join(root, "shared", "ui", name, "index.go"), ssrGo(name))
+		writeFile(t, filepath.Join(root, "shared", "ui", name, "index.tsx"), `export function View() { return null; }`)
+	}
+
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	result, err := gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared/ui/orphan"}, result.UnusedShared)
+	assert.FileExists(t, filepath.Join(root, "rstf", "types", "shared-ui-orphan.d.ts"))
+
+	dashboard := filepath.Join(root, "routes", "dashboard", "index.tsx")
+	writeFile(t, dashboard, `import { Avatar } from "../../shared/ui/avatar";
+import { Orphan } from "../../shared/ui/orphan";
+export function View() { return <><Avatar /><Orphan /></>; }`)
+	regen, err := gen.Regenerate([]ChangeEvent{{Path: dashboard, Kind: "tsx"}})
+	require.NoError(t, err)
+	assert.Empty(t, regen.UnusedShared)
+}
+
+func TestGenerateResult_AllUnusedShared(t *testing.T) {
+	result := GenerateResult{
+		UnusedShared: []string{"shared/ui/orphan"},
+		Mounts: map[string]GenerateResult{
+			"admin": {UnusedShared: []string{"shared/ui/chart"}},
+		},
+	}
+	assert.Equal(t, []string{"admin/shared/ui/chart", "shared/ui/orphan"}, result.AllUnusedShared())
+}
+
+func TestGenerate_ExcludedRoutes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "main.tsx"), `export function View({ children }: any) { return children; }`)
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `export function View() { return null; }`)
+	writeFile(t, filepath.Join(root, "routes", "debug", "index.tsx"), `export function View() { return null; }`)
+	writeFile(t, filepath.Join(root, "routes", "debug", "index.go"), "//go:build debug\n\npackage debug\n\ntype ServerData struct {\n\tName string `json:\"name\"`\n}\n\nfunc SSR() ServerData { return ServerData{} }\n")
+	writeFile(t, filepath.Join(root, "routes", "admin.tools", "index.tsx"), `export function View() { return null; }`)
+
+	routes := func(result GenerateResult) []string {
+		var dirs []string
+		for dir := range result.Entries {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		return dirs
+	}
+
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	result, err := gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes/admin.tools", "routes/dashboard"}, routes(result))
+	server, err := os.ReadFile(filepath.Join(root, "rstf", "server_gen.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(server), "example.com/app/routes/debug")
+
+	gen.SetBuildTags([]string{"debug"})
+	gen.ExcludeRoutes([]string{"admin.*"})
+	result, err = gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes/dashboard", "routes/debug"}, routes(result))
+	server, err = os.ReadFile(filepath.Join(root, "rstf", "server_gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(server), "example.com/app/routes/debug")
+
+	// A change under an excluded route keeps it out.
+	admin := filepath.Join(root, "routes", "admin.tools", "index.tsx")
+	regen, err := gen.Regenerate([]ChangeEvent{{Path: admin, Kind: "tsx"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes/dashboard", "routes/debug"}, routes(regen.GenerateResult))
+}
+
+func TestGenerator_Trace(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "main.tsx"), `export function View({ children }: any) { return children; }`)
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `export function View() { return null; }`)
+	dashboard := filepath.Join(root, "routes", "dashboard", "index.go")
+	writeFile(t, dashboard, "package dashboard\n\ntype ServerData struct {\n\tName string `json:\"name\"`\n}\n\nfunc SSR() ServerData { return ServerData{} }\n")
+	writeFile(t, filepath.Join(root, "routes", "debug", "index.tsx"), `export function View() { return null; }`)
+	writeFile(t, filepath.Join(root, "routes", "debug", "index.go"), "//go:build debug\n\npackage debug\n\nfunc SSR() struct{} { return struct{}{} }\n")
+
+	var trace []string
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	gen.SetTrace(func(msg string) { trace = append(trace, msg) })
+	_, err = gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes/debug: left out by build tags"}, trace)
+
+	trace = nil
+	_, err = gen.Regenerate([]ChangeEvent{{Path: dashboard, Kind: "go"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"routes/dashboard: Go files re-parsed",
+		"routes/debug: left out by build tags",
+		"rstf/server_gen.go: unchanged",
+	}, trace)
+
+	trace = nil
+	about := filepath.Join(root, "routes", "about", "index.tsx")
+	writeFile(t, about, `export function View() { return null; }`)
+	gen.ExcludeRoutes([]string{"debug"})
+	_, err = gen.Regenerate([]ChangeEvent{{Path: about, Kind: "tsx"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`routes/debug: left out by excludeRoutes pattern "debug"`,
+		"routes/about: entries written, new route",
+		"rstf/server_gen.go: rewritten, the server restarts",
+	}, trace)
+}
@@ -2,10 +2,35 @@ package codegen
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// hydrationRootRe matches `export const hydrationRoot = "#app"` in main.tsx or
+// a route's index.tsx, capturing the selector (group 1).
+var hydrationRootRe = regexp.MustCompile("(?m)^\\s*export\\s+const\\s+hydrationRoot\\s*=\\s*[\"'`]([^\"'`]+)[\"'`]")
+
+// layoutNoneRe matches `export const layout = "none"` in a route's index.tsx.
+var layoutNoneRe = regexp.MustCompile("(?m)^\\s*export\\s+const\\s+layout\\s*=\\s*[\"'`]none[\"'`]")
+
+// EntryOptions holds per-route settings shared by the hydration and SSR
+// entries. Both entries must agree on them or hydration will mismatch.
+type EntryOptions struct {
+	// RootSelector, when set, hydrates only the route inside that element and
+	// leaves the rest of the layout as static server markup. When empty, the
+	// layout and route hydrate the whole document.
+	RootSelector string
+	// ClientPath is the project-relative path (without extension) of a
+	// client.tsx whose View wraps the rendered tree, e.g. to add providers.
+	ClientPath string
+	// NoLayout renders the route without main.tsx's View, so the route
+	// renders the whole document itself. Set when the route's index.tsx
+	// exports `layout = "none"` or the project has no main.tsx.
+	NoLayout bool
+}
+
 // GenerateHydrationEntry produces the content of a hydration entry file
 // (rstf/entries/{name}.entry.tsx) for a route directory.
 //
@@ -15,37 +40,128 @@ import (
 //
 // The entry file is generated inside rstf/entries/, so relative imports use
 // "../../" to reach the project root.
-func GenerateHydrationEntry(routeDir string, allDeps []string) string {
+func GenerateHydrationEntry(routeDir string, allDeps []string, opts EntryOptions) string {
 	var b strings.Builder
 	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
 	b.WriteString("import { hydrateRoot } from \"react-dom/client\";\n")
 	b.WriteString("import { SSRDataProvider } from \"@rstf/ssr\";\n")
-	b.WriteString("import { View as Layout } from \"../../main\";\n")
+	if opts.RootSelector == "" && !opts.NoLayout {
+		b.WriteString("import { View as Layout } from \"../../main\";\n")
+	}
+	writeClientImport(&b, opts)
 	fmt.Fprintf(&b, "import { View as Route } from \"../../%s\";\n", routeDir)
 	_ = allDeps
 	b.WriteString("\n")
 	b.WriteString("const ssrProps = (window as any).__RSTF_SSR_PROPS__ ?? {};\n\n")
-	b.WriteString("hydrateRoot(document, <SSRDataProvider data={ssrProps}><Layout><Route /></Layout></SSRDataProvider>);\n")
+	if opts.RootSelector == "" {
+		fmt.Fprintf(&b, "hydrateRoot(document, <SSRDataProvider data={ssrProps}>%s</SSRDataProvider>);\n", entryTree(opts))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "const root = document.querySelector(%q);\n", opts.RootSelector)
+	b.WriteString("if (!root) {\n")
+	fmt.Fprintf(&b, "  throw new Error(%q);\n", fmt.Sprintf("rstf: hydration root %q not found", opts.RootSelector))
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "hydrateRoot(root, <SSRDataProvider data={ssrProps}>%s</SSRDataProvider>);\n", wrapClient("<Route />", opts))
 	return b.String()
 }
 
 // GenerateSSREntry produces the content of an SSR entry file
 // (rstf/ssr_entries/{name}.ssr.tsx) for a route directory.
-func GenerateSSREntry(routeDir string) string {
+func GenerateSSREntry(routeDir string, opts EntryOptions) string {
 	var b strings.Builder
 	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
 	b.WriteString("import { renderToString } from \"react-dom/server.browser\";\n")
 	b.WriteString("import { SSRDataProvider } from \"@rstf/ssr\";\n")
-	b.WriteString("import { View as Layout } from \"../../main\";\n")
+	if !opts.NoLayout {
+		b.WriteString("import { View as Layout } from \"../../main\";\n")
+	}
+	writeClientImport(&b, opts)
 	fmt.Fprintf(&b, "import { View as Route } from \"../../%s\";\n", routeDir)
 	b.WriteString("\n")
 	b.WriteString("const render = (ssrProps: Record<string, Record<string, any>>) =>\n")
-	b.WriteString("  renderToString(<SSRDataProvider data={ssrProps}><Layout><Route /></Layout></SSRDataProvider>);\n\n")
+	fmt.Fprintf(&b, "  renderToString(<SSRDataProvider data={ssrProps}>%s</SSRDataProvider>);\n\n", entryTree(opts))
 	b.WriteString("(globalThis as any).__RSTF_RENDERERS__ = (globalThis as any).__RSTF_RENDERERS__ ?? {};\n")
 	fmt.Fprintf(&b, "(globalThis as any).__RSTF_RENDERERS__[%q] = render;\n", routeDir)
 	return b.String()
 }
 
+// entryTree returns the layout+route JSX for the SSR entry and the
+// whole-document hydration entry. The client wrapper goes around the layout,
+// or around the route alone when only the route is hydrated.
+func entryTree(opts EntryOptions) string {
+	if opts.NoLayout {
+		return wrapClient("<Route />", opts)
+	}
+	if opts.RootSelector != "" {
+		return "<Layout>" + wrapClient("<Route />", opts) + "</Layout>"
+	}
+	return wrapClient("<Layout><Route /></Layout>", opts)
+}
+
+func wrapClient(tree string, opts EntryOptions) string {
+	if opts.ClientPath == "" {
+		return tree
+	}
+	return "<Client>" + tree + "</Client>"
+}
+
+func writeClientImport(b *strings.Builder, opts EntryOptions) {
+	if opts.ClientPath != "" {
+		fmt.Fprintf(b, "import { View as Client } from \"../../%s\";\n", opts.ClientPath)
+	}
+}
+
+// resolveEntryOptions reads the per-route entry settings from disk.
+func resolveEntryOptions(projectRoot, routeDir string, cache *fsCache) EntryOptions {
+	return EntryOptions{
+		RootSelector: hydrationRootSelector(projectRoot, routeDir, cache),
+		ClientPath:   clientEntryPath(projectRoot, routeDir),
+		NoLayout:     routeWithoutLayout(projectRoot, routeDir, cache),
+	}
+}
+
+// routeWithoutLayout reports whether a route renders without main.tsx: the
+// route opted out with `export const layout = "none"`, or there is no
+// main.tsx to wrap it in.
+func routeWithoutLayout(projectRoot, routeDir string, cache *fsCache) bool {
+	if _, err := readSource(filepath.Join(projectRoot, "main.tsx"), cache); err != nil {
+		return true
+	}
+	content, err := readSource(filepath.Join(projectRoot, routeDir, "index.tsx"), cache)
+	return err == nil && layoutNoneRe.Match(content)
+}
+
+// clientEntryPath returns the client.tsx that wraps a route, without its
+// extension: the route's own client.tsx wins over the project-root one.
+func clientEntryPath(projectRoot, routeDir string) string {
+	for _, dir := range []string{routeDir, "."} {
+		rel := filepath.ToSlash(filepath.Join(dir, "client"))
+		if info, err := os.Stat(filepath.Join(projectRoot, rel+".tsx")); err == nil && !info.IsDir() {
+			return rel
+		}
+	}
+	return ""
+}
+
+// hydrationRootSelector returns the element selector a route hydrates into.
+// A route's index.tsx overrides the app-wide value in main.tsx; "" means the
+// whole document.
+func hydrationRootSelector(projectRoot, routeDir string, cache *fsCache) string {
+	for _, path := range []string{
+		filepath.Join(projectRoot, routeDir, "index.tsx"),
+		filepath.Join(projectRoot, "main.tsx"),
+	} {
+		content, err := readSource(path, cache)
+		if err != nil {
+			continue
+		}
+		if m := hydrationRootRe.FindSubmatch(content); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
 // entryName returns the entry file basename for a route directory.
 //
 //	"routes/dashboard"       → "dashboard"
@@ -1,13 +1,14 @@
 package codegen
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGenerateHydrationEntry_Dashboard(t *testing.T) {
-	got := GenerateHydrationEntry("routes/dashboard", []string{"routes/dashboard"})
+	got := GenerateHydrationEntry("routes/dashboard", []string{"routes/dashboard"}, EntryOptions{})
 
 	expectations := []string{
 		"// Code generated by rstf. DO NOT EDIT.",
@@ -25,12 +26,100 @@ func TestGenerateHydrationEntry_Dashboard(t *testing.T) {
 }
 
 func TestGenerateHydrationEntry_WithSharedDeps(t *testing.T) {
-	got := GenerateHydrationEntry("routes/dashboard", []string{"routes/dashboard", "shared/ui/user-avatar"})
+	got := GenerateHydrationEntry("routes/dashboard", []string{"routes/dashboard", "shared/ui/user-avatar"}, EntryOptions{})
 
 	assert.NotContains(t, got, `import "@rstf/routes/dashboard";`)
 	assert.NotContains(t, got, `import "@rstf/shared/ui/user-avatar";`)
 }
 
+func TestGenerateHydrationEntry_RootSelector(t *testing.T) {
+	got := GenerateHydrationEntry("routes/dashboard", []string{"routes/dashboard"}, EntryOptions{RootSelector: "#app"})
+
+	expectations := []string{
+		`import { View as Route } from "../../routes/dashboard";`,
+		`const root = document.querySelector("#app");`,
+		`hydrateRoot(root, <SSRDataProvider data={ssrProps}><Route /></SSRDataProvider>);`,
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+	assert.NotContains(t, got, `import { View as Layout } from "../../main";`)
+}
+
+func TestGenerateEntries_ClientWrapper(t *testing.T) {
+	opts := EntryOptions{ClientPath: "client"}
+
+	hydration := GenerateHydrationEntry("routes/dashboard", nil, opts)
+	assert.Contains(t, hydration, `import { View as Client } from "../../client";`)
+	assert.Contains(t, hydration, `hydrateRoot(document, <SSRDataProvider data={ssrProps}><Client><Layout><Route /></Layout></Client></SSRDataProvider>);`)
+
+	ssr := GenerateSSREntry("routes/dashboard", opts)
+	assert.Contains(t, ssr, `import { View as Client } from "../../client";`)
+	assert.Contains(t, ssr, `renderToString(<SSRDataProvider data={ssrProps}><Client><Layout><Route /></Layout></Client></SSRDataProvider>);`)
+}
+
+func TestGenerateEntries_ClientWrapperWithRootSelector(t *testing.T) {
+	opts := EntryOptions{RootSelector: "#app", ClientPath: "routes/dashboard/client"}
+
+	hydration := GenerateHydrationEntry("routes/dashboard", nil, opts)
+	assert.Contains(t, hydration, `hydrateRoot(root, <SSRDataProvider data={ssrProps}><Client><Route /></Client></SSRDataProvider>);`)
+
+	ssr := GenerateSSREntry("routes/dashboard", opts)
+	assert.Contains(t, ssr, `renderToString(<SSRDataProvider data={ssrProps}><Layout><Client><Route /></Client></Layout></SSRDataProvider>);`)
+}
+
+func TestGenerateEntries_NoLayout(t *testing.T) {
+	opts := EntryOptions{NoLayout: true}
+
+	hydration := GenerateHydrationEntry("routes/print", nil, opts)
+	assert.NotContains(t, hydration, `"../../main"`)
+	assert.Contains(t, hydration, `hydrateRoot(document, <SSRDataProvider data={ssrProps}><Route /></SSRDataProvider>);`)
+
+	ssr := GenerateSSREntry("routes/print", opts)
+	assert.NotContains(t, ssr, `"../../main"`)
+	assert.Contains(t, ssr, `renderToString(<SSRDataProvider data={ssrProps}><Route /></SSRDataProvider>);`)
+}
+
+func TestRouteWithoutLayout(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.tsx"), `export function View({ children }) { return <html><body>{children}</body></html>; }`)
+	writeFile(t, filepath.Join(root, "routes", "index", "index.tsx"), `export function View() { return <div />; }`)
+	writeFile(t, filepath.Join(root, "routes", "print", "index.tsx"), `export const layout = "none";
+export function View() { return <html><body /></html>; }`)
+
+	assert.False(t, routeWithoutLayout(root, "routes/index", nil))
+	assert.True(t, routeWithoutLayout(root, "routes/print", nil))
+
+	noMain := t.TempDir()
+	writeFile(t, filepath.Join(noMain, "routes", "index", "index.tsx"), `export function View() { return <html />; }`)
+	assert.True(t, routeWithoutLayout(noMain, "routes/index", nil))
+}
+
+func TestClientEntryPath(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, "", clientEntryPath(root, "routes/index"))
+
+	writeFile(t, filepath.Join(root, "client.tsx"), `export function View({ children }) { return children; }`)
+	assert.Equal(t, "client", clientEntryPath(root, "routes/index"))
+
+	writeFile(t, filepath.Join(root, "routes", "admin", "client.tsx"), `export function View({ children }) { return children; }`)
+	assert.Equal(t, "routes/admin/client", clientEntryPath(root, "routes/admin"))
+	assert.Equal(t, "client", clientEntryPath(root, "routes/index"))
+}
+
+func TestHydrationRootSelector(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.tsx"), `export const hydrationRoot = "#app";
+export function View({ children }) { return <html><body><div id="app">{children}</div></body></html>; }`)
+	writeFile(t, filepath.Join(root, "routes", "index", "index.tsx"), `export function View() { return <div />; }`)
+	writeFile(t, filepath.Join(root, "routes", "admin", "index.tsx"), `export const hydrationRoot = '#admin';
+export function View() { return <div />; }`)
+
+	assert.Equal(t, "#app", hydrationRootSelector(root, "routes/index", nil))
+	assert.Equal(t, "#admin", hydrationRootSelector(root, "routes/admin", nil))
+	assert.Equal(t, "", hydrationRootSelector(t.TempDir(), "routes/index", nil))
+}
+
 func TestEntryName(t *testing.T) {
 	tests := []struct {
 		routeDir string
@@ -1,10 +1,12 @@
 package codegen
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -17,6 +19,21 @@ import (
 // "@rstf/dashboard" don't start with ./ or ../ and are naturally excluded.
 var importRe = regexp.MustCompile(`from\s+['"](\.\.?/[^'"]+)['"]`)
 
+// viewDeclRe matches a View declared inline with an export keyword:
+//
+//	export function View() {}
+//	export const View = SSR(function View() {})
+var viewDeclRe = regexp.MustCompile(`(?m)^\s*export\s+(?:async\s+)?(?:function\*?|const|let|var|class)\s+View\b`)
+
+// exportListRe matches export lists such as `export { Page as View }`,
+// capturing the names between the braces (group 1).
+var exportListRe = regexp.MustCompile(`export\s*\{([^}]*)\}`)
+
+// exportStarRe matches `export * from "./page"` re-exports, capturing the
+// specifier (group 1). `export * as ns from` only exports ns and is not
+// matched.
+var exportStarRe = regexp.MustCompile(`(?m)^\s*export\s*\*\s*from\s*['"]([^'"]+)['"]`)
+
 // fsCache provides thread-safe caching for filesystem operations used during
 // dependency analysis. Shared across all AnalyzeDeps calls to avoid redundant
 // reads when multiple routes import the same TSX files or share directories.
@@ -163,6 +180,78 @@ func walkImports(projectRoot, absFilePath string, visited map[string]bool, goDir
 	return nil
 }
 
+// checkViewExport reports an error when a route entry does not export View.
+// Without this check the route only fails at request time, when the renderer
+// tries to mount an undefined component.
+func checkViewExport(projectRoot, entryPath string, cache *fsCache) error {
+	ok, err := fileExportsView(filepath.Join(projectRoot, entryPath), cache, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%s: route entry must export a View component (e.g. export function View() { ... })", filepath.ToSlash(entryPath))
+}
+
+// readSource reads a source file through cache when one is provided.
+func readSource(absPath string, cache *fsCache) ([]byte, error) {
+	if cache != nil {
+		return cache.readFile(absPath)
+	}
+	return os.ReadFile(absPath)
+}
+
+// fileExportsView reports whether the TSX file at absPath exports View,
+// following its `export * from` re-exports of local .tsx files. A re-export
+// it cannot follow, such as one from a package or a .ts file, counts as
+// exporting View, so a valid route is never rejected.
+func fileExportsView(absPath string, cache *fsCache, visited map[string]bool) (bool, error) {
+	if visited[absPath] {
+		return false, nil
+	}
+	visited[absPath] = true
+	content, err := readSource(absPath, cache)
+	if err != nil {
+		return false, err
+	}
+	if exportsView(content) {
+		return true, nil
+	}
+	for _, m := range exportStarRe.FindAllSubmatch(content, -1) {
+		specifier := string(m[1])
+		if !strings.HasPrefix(specifier, "./") && !strings.HasPrefix(specifier, "../") {
+			return true, nil
+		}
+		resolved := resolveImportPath(filepath.Dir(absPath), specifier)
+		if resolved == "" {
+			return true, nil
+		}
+		ok, err := fileExportsView(resolved, cache, visited)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// exportsView reports whether TSX content exports a binding named View,
+// either declared inline or through an export list.
+func exportsView(content []byte) bool {
+	if viewDeclRe.Match(content) {
+		return true
+	}
+	for _, m := range exportListRe.FindAllSubmatch(content, -1) {
+		for _, item := range strings.Split(string(m[1]), ",") {
+			fields := strings.Fields(item)
+			if len(fields) > 0 && fields[len(fields)-1] == "View" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // extractLocalImports returns relative import specifiers from TSX/TS content.
 // Only imports starting with "./" or "../" are returned.
 func extractLocalImports(content []byte) []string {
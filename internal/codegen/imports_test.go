@@ -281,3 +281,56 @@ func TestDirHasGoFile(t *testing.T) {
 	assert.True(t, dirHasGoFile(withGo), "expected dirHasGoFile=true for directory with .go file")
 	assert.False(t, dirHasGoFile(withoutGo), "expected dirHasGoFile=false for directory without .go file")
 }
+
+func TestExportsView(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"function", "export function View() { return null; }", true},
+		{"async function", "export async function View() { return null; }", true},
+		{"const wrapper", "export const View = SSR(function View() { return null; });", true},
+		{"export list", "function Page() { return null; }\nexport { Page as View };", true},
+		{"export list plain", "function View() { return null; }\nexport { helper, View };", true},
+		{"missing", "export function Page() { return null; }", false},
+		{"not exported", "function View() { return null; }", false},
+		{"prefix only", "export function ViewModel() { return null; }", false},
+		{"renamed away", "function View() { return null; }\nexport { View as Page };", false},
+		{"re-export default", "export { default as View } from \"./page\";", true},
+		{"re-export named", "export { View } from \"../shared/page\";", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exportsView([]byte(tt.content)))
+		})
+	}
+}
+
+func TestCheckViewExport(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `export function Page() { return <div />; }`)
+
+	err := checkViewExport(root, "routes/dashboard/index.tsx", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "routes/dashboard/index.tsx: route entry must export a View component")
+}
+
+func TestCheckViewExport_FollowsExportStar(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `export * from "./page";`)
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "page.tsx"), `export * from "../../shared/dashboard";`)
+	writeFile(t, filepath.Join(root, "shared", "dashboard", "index.tsx"), `export function View() { return <div />; }`)
+	require.NoError(t, checkViewExport(root, "routes/dashboard/index.tsx", nil))
+
+	writeFile(t, filepath.Join(root, "routes", "pkg", "index.tsx"), `export * from "@acme/pages/dashboard";`)
+	require.NoError(t, checkViewExport(root, "routes/pkg/index.tsx", nil))
+
+	writeFile(t, filepath.Join(root, "routes", "helpers", "index.tsx"), `export * from "./a";`)
+	writeFile(t, filepath.Join(root, "routes", "helpers", "a.tsx"), `export * from "./index";
+export function Page() { return <div />; }`)
+	err := checkViewExport(root, "routes/helpers/index.tsx", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route entry must export a View component")
+}
@@ -0,0 +1,123 @@
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Manifest is the machine-readable route table written to rstf/manifest.json
+// for external tools (CDN config generators, e2e suites, docs).
+type Manifest struct {
+	Routes []ManifestRoute `json:"routes"`
+}
+
+// ManifestRoute describes a single route in the manifest.
+type ManifestRoute struct {
+	Name       string            `json:"name"`
+	Dir        string            `json:"dir"`
+	Pattern    string            `json:"pattern"`
+	Params     []string          `json:"params"`
+	Deps       []string          `json:"deps"`
+	Bundle     string            `json:"bundle,omitempty"`
+	HasView    bool              `json:"hasView"`
+	HasSSR     bool              `json:"hasSSR"`
+	HasActions bool              `json:"hasActions"`
+	Methods    []string          `json:"methods"`
+	RPC        []ManifestRPCFunc `json:"rpc"`
+	// Size is recorded by rstf build once the bundles are built.
+	Size *AssetSizes `json:"size,omitempty"`
+}
+
+// ManifestRPCFunc describes a query, mutation, action, or event stream
+// exposed by a route.
+type ManifestRPCFunc struct {
+	Name string        `json:"name"`
+	Kind RouteFuncKind `json:"kind"`
+}
+
+// BuildManifest assembles the route manifest from parsed files and the
+// per-route dependency graph. Routes are sorted by name.
+func BuildManifest(files []RouteFile, deps map[string][]string) Manifest {
+	fileMap := map[string]RouteFile{}
+	for _, rf := range files {
+		fileMap[rf.Dir] = rf
+	}
+
+	routes := make([]ManifestRoute, 0)
+	for _, def := range BuildRouteDefs(files, deps) {
+		routeDeps, hasView := deps[def.Dir]
+		route := ManifestRoute{
+			Name:    def.Name,
+			Dir:     def.Dir,
+			Pattern: def.Pattern,
+			Params:  make([]string, 0, len(def.Params)),
+			Deps:    append([]string{}, routeDeps...),
+			HasView: hasView,
+			Methods: []string{},
+			RPC:     make([]ManifestRPCFunc, 0, len(def.RPCFuncs)),
+		}
+		if hasView {
+			route.Bundle = bundlePath(def.Dir)
+		}
+		for _, param := range def.Params {
+			route.Params = append(route.Params, param.Name)
+		}
+		for _, fn := range fileMap[def.Dir].Funcs {
+			if fn.Name == "SSR" {
+				route.HasSSR = true
+			}
+		}
+		for _, method := range def.Methods {
+			route.Methods = append(route.Methods, method)
+			if method != "GET" {
+				route.HasActions = true
+			}
+		}
+		for _, fn := range def.RPCFuncs {
+			route.RPC = append(route.RPC, ManifestRPCFunc{Name: fn.Name, Kind: fn.Kind})
+			if fn.Kind == RouteFuncKindMutation || fn.Kind == RouteFuncKindAction {
+				route.HasActions = true
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	return Manifest{Routes: routes}
+}
+
+// GenerateManifestJSON renders the manifest as indented JSON. prefix is the
+// URL prefix of a mounted app, which its patterns and bundles are served
+// under.
+func GenerateManifestJSON(files []RouteFile, deps map[string][]string, prefix string) (string, error) {
+	manifest := BuildManifest(files, deps)
+	for i, route := range manifest.Routes {
+		manifest.Routes[i].Pattern = mountedPattern(prefix, route.Pattern)
+		if route.Bundle != "" {
+			manifest.Routes[i].Bundle = prefix + route.Bundle
+		}
+	}
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+// RewriteManifestAssets points the bundle URLs of the manifest at path to
+// baseURL, so tools reading a production build's manifest see the URLs pages
+// actually load. A missing manifest is not an error.
+func RewriteManifestAssets(path, baseURL string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return err
+	}
+	for i, route := range manifest.Routes {
+		if route.Bundle != "" {
+			manifest.Routes[i].Bundle = baseURL + route.Bundle
+		}
+	}
+	return WriteManifest(path, manifest)
+}
@@ -0,0 +1,118 @@
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildManifest(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir: "routes/dashboard",
+			Funcs: []RouteFunc{
+				{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData"},
+				{Name: "DELETE", Kind: RouteFuncKindHTTP, HasContext: true, Method: "DELETE"},
+				{Name: "GET", Kind: RouteFuncKindHTTP, HasContext: true, Method: "GET"},
+			},
+		},
+		{
+			Dir: "routes/live-chat._id",
+			Funcs: []RouteFunc{
+				{Name: "GetMessages", Kind: RouteFuncKindQuery, ReturnType: "Result"},
+				{Name: "SendMessage", Kind: RouteFuncKindMutation, InputType: "Input"},
+			},
+		},
+		{
+			Dir:   "shared/ui/avatar",
+			Funcs: []RouteFunc{{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard":     {"routes/dashboard", "shared/ui/avatar"},
+		"routes/live-chat._id": {"routes/live-chat._id"},
+		"routes/no-server":     {},
+	}
+
+	got := BuildManifest(files, deps)
+
+	require.Len(t, got.Routes, 3)
+	assert.Equal(t, ManifestRoute{
+		Name:       "dashboard",
+		Dir:        "routes/dashboard",
+		Pattern:    "/dashboard",
+		Params:     []string{},
+		Deps:       []string{"routes/dashboard", "shared/ui/avatar"},
+		Bundle:     "/rstf/static/dashboard/bundle.js",
+		HasView:    true,
+		HasSSR:     true,
+		HasActions: true,
+		Methods:    []string{"GET", "DELETE"},
+		RPC:        []ManifestRPCFunc{},
+	}, got.Routes[0])
+	assert.Equal(t, ManifestRoute{
+		Name:       "live-chat._id",
+		Dir:        "routes/live-chat._id",
+		Pattern:    "/live-chat/{id}",
+		Params:     []string{"id"},
+		Deps:       []string{"routes/live-chat._id"},
+		Bundle:     "/rstf/static/live-chat-id/bundle.js",
+		HasView:    true,
+		HasActions: true,
+		Methods:    []string{},
+		RPC: []ManifestRPCFunc{
+			{Name: "SendMessage", Kind: RouteFuncKindMutation},
+			{Name: "GetMessages", Kind: RouteFuncKindQuery},
+		},
+	}, got.Routes[1])
+	assert.Equal(t, "no-server", got.Routes[2].Name)
+	assert.True(t, got.Routes[2].HasView)
+	assert.False(t, got.Routes[2].HasSSR)
+}
+
+func TestGenerateManifestJSON_RouteWithoutView(t *testing.T) {
+	files := []RouteFile{
+		{Dir: "routes/api.health", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, HasContext: true, Method: "GET"}}},
+	}
+
+	out, err := GenerateManifestJSON(files, map[string][]string{}, "")
+	require.NoError(t, err)
+
+	var decoded map[string][]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	require.Len(t, decoded["routes"], 1)
+	route := decoded["routes"][0]
+	assert.Equal(t, "/api/health", route["pattern"])
+	assert.Equal(t, false, route["hasView"])
+	assert.NotContains(t, route, "bundle")
+	assert.Equal(t, []any{}, route["deps"])
+}
+
+func TestRewriteManifestAssets(t *testing.T) {
+	files := []RouteFile{
+		{Dir: "routes/dashboard", Funcs: []RouteFunc{{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData"}}},
+		{Dir: "routes/api.health", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, HasContext: true, Method: "GET"}}},
+	}
+	deps := map[string][]string{"routes/dashboard": {"routes/dashboard"}}
+	out, err := GenerateManifestJSON(files, deps, "")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, os.WriteFile(path, []byte(out), 0644))
+
+	require.NoError(t, RewriteManifestAssets(path, "https://cdn.example.com"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	require.Len(t, manifest.Routes, 2)
+	assert.Equal(t, "", manifest.Routes[0].Bundle)
+	assert.Equal(t, "https://cdn.example.com/rstf/static/dashboard/bundle.js", manifest.Routes[1].Bundle)
+	assert.Equal(t, "/dashboard", manifest.Routes[1].Pattern)
+
+	assert.NoError(t, RewriteManifestAssets(filepath.Join(t.TempDir(), "missing.json"), "https://cdn.example.com"))
+}
@@ -11,12 +11,16 @@ package codegen
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rafbgarcia/rstf/internal/conventions"
@@ -30,23 +34,38 @@ const (
 	RouteFuncKindQuery    RouteFuncKind = "query"
 	RouteFuncKindMutation RouteFuncKind = "mutation"
 	RouteFuncKindAction   RouteFuncKind = "action"
+	RouteFuncKindEvents   RouteFuncKind = "events"
+	RouteFuncKindMeta     RouteFuncKind = "meta"
 )
 
 // RouteFunc represents a parsed route handler function (e.g. SSR, GET, Query).
 type RouteFunc struct {
 	Name          string        // Function name: "SSR", "GET", "POST", etc.
 	Kind          RouteFuncKind // Function kind.
-	ReturnType    string        // Go return type name (e.g. "ServerData" or "string").
+	ReturnType    string        // Go return type name (e.g. "ServerData" or "string"), or the event struct of an events function.
 	ReturnIsSlice bool          // Whether the return type is a slice.
-	ReturnsError  bool          // Whether the function returns an error.
-	InputType     string        // Go input type name for mutations/actions.
-	InputIsSlice  bool          // Whether the input type is a slice.
-	HasContext    bool          // Whether the function accepts a context parameter.
+	// WrapsData is whether SSR returns a slice or a primitive rather than a
+	// struct. The View then receives the value as { data: T }.
+	WrapsData    bool
+	ReturnsError bool   // Whether the function returns an error.
+	InputType    string // Go input type name for mutations/actions.
+	InputIsSlice bool   // Whether the input type is a slice.
+	// InputFirst is whether an RPC function takes its input before the
+	// context, as in func Rename(input string, ctx *rstf.MutationContext).
+	InputFirst bool
+	HasContext bool // Whether the function accepts a context parameter.
+	// Method is the HTTP method a handler serves: its name for GET, POST,
+	// PUT, PATCH, and DELETE, or the method set with //rstf:method.
+	Method string
+	// ReturnsFieldErrors is whether a mutation or action returns
+	// (T, *rstf.FieldErrors), which the client sees as { ok, data | errors }.
+	ReturnsFieldErrors bool
 }
 
 // StructDef represents a parsed Go struct and its fields.
 type StructDef struct {
 	Name   string
+	Doc    string // Go doc comment text, emitted as JSDoc
 	Fields []StructField
 }
 
@@ -55,6 +74,7 @@ type StructField struct {
 	Name     string // Go field name
 	JSONName string // Name from json tag (used in TS output)
 	Type     string // Mapped TypeScript type
+	Doc      string // Go doc or trailing line comment text, emitted as JSDoc
 }
 
 // RouteFile is the result of parsing a single route directory.
@@ -65,6 +85,37 @@ type RouteFile struct {
 	Structs          []StructDef // Struct types referenced by route functions
 	HasOnServerStart bool        // Whether the package exports func OnServerStart(*rstf.App)
 	HasAroundRequest bool        // Whether the package exports func AroundRequest() []rstf.Middleware
+	// RoutePolicies maps route URL patterns to policy names, from the layout
+	// package's var RoutePolicies = rstf.RoutePolicies{...}.
+	RoutePolicies map[string]string
+}
+
+// SSRDataFuncs returns the route's SSR data functions: SSR first, followed by
+// named data functions (e.g. Sidebar, Stats) in alphabetical order.
+func (rf RouteFile) SSRDataFuncs() []RouteFunc {
+	var funcs []RouteFunc
+	for _, fn := range rf.Funcs {
+		if fn.Name == "SSR" || fn.Kind == RouteFuncKindSSR {
+			funcs = append(funcs, fn)
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if (funcs[i].Name == "SSR") != (funcs[j].Name == "SSR") {
+			return funcs[i].Name == "SSR"
+		}
+		return funcs[i].Name < funcs[j].Name
+	})
+	return funcs
+}
+
+// SSRDataKey returns the key a data function's props are stored under in the
+// serialized SSR props map. SSR keeps the bare component path; named data
+// functions are suffixed with "#Name".
+func SSRDataKey(componentPath, funcName string) string {
+	if funcName == "SSR" {
+		return componentPath
+	}
+	return componentPath + "#" + funcName
 }
 
 // routeFuncNames are the exported function names the framework recognizes.
@@ -77,9 +128,37 @@ var httpRouteFuncNames = map[string]bool{
 	"DELETE": true,
 }
 
+// methodDirective marks an exported handler as serving a method the naming
+// convention has no function name for:
+//
+//	//rstf:method PURGE
+//	func Purge(ctx *rstf.Context) error { ... }
+const methodDirective = "//rstf:method"
+
+// conventionalMethods are the methods served by a handler named after them.
+var conventionalMethods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// customMethodRe matches the method names //rstf:method accepts.
+var customMethodRe = regexp.MustCompile(`^[A-Z][A-Z0-9-]*$`)
+
 // ParseDir walks rootDir and parses all Go route files.
 // It returns a RouteFile for each directory that contains route handler functions.
-func ParseDir(rootDir string) ([]RouteFile, error) {
+// Files whose build constraints exclude them from a build with buildTags are
+// skipped, as go build would skip them.
+func ParseDir(rootDir string, buildTags ...string) ([]RouteFile, error) {
+	return parseDir(rootDir, nil, buildTags)
+}
+
+// parseDir is ParseDir that also skips the directories ignore reports true
+// for. ignore receives the path relative to rootDir, with forward slashes.
+func parseDir(rootDir string, ignore func(dir string) bool, buildTags []string) ([]RouteFile, error) {
+	ctx := buildContext(buildTags)
 	dirFiles := map[string][]string{}
 
 	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
@@ -91,12 +170,20 @@ func ParseDir(rootDir string) ([]RouteFile, error) {
 			case "rstf", ".rstf", ".git", "node_modules", "vendor":
 				return filepath.SkipDir
 			}
+			if ignore != nil && path != rootDir {
+				if rel, err := filepath.Rel(rootDir, path); err == nil && ignore(filepath.ToSlash(rel)) {
+					return filepath.SkipDir
+				}
+			}
 			return nil
 		}
 		if filepath.Ext(path) != ".go" {
 			return nil
 		}
 		dir := filepath.Dir(path)
+		if ok, err := ctx.MatchFile(dir, d.Name()); err != nil || !ok {
+			return err
+		}
 		dirFiles[dir] = append(dirFiles[dir], path)
 		return nil
 	})
@@ -114,13 +201,18 @@ func ParseDir(rootDir string) ([]RouteFile, error) {
 			results = append(results, *rf)
 		}
 	}
+	// Map iteration order is random; sort so generated output (and the import
+	// aliases in server_gen.go) is identical across runs.
+	sort.Slice(results, func(i, j int) bool { return results[i].Dir < results[j].Dir })
 	return results, nil
 }
 
 // ParseSingleDir parses a single directory's Go files and returns a RouteFile.
 // Returns nil if the directory doesn't exist or has no .go files with route functions.
 // absDir must be an absolute path; rootDir is the project root (also absolute).
-func ParseSingleDir(rootDir, absDir string) (*RouteFile, error) {
+// Files are matched against buildTags as in ParseDir.
+func ParseSingleDir(rootDir, absDir string, buildTags ...string) (*RouteFile, error) {
+	ctx := buildContext(buildTags)
 	entries, err := os.ReadDir(absDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -131,7 +223,12 @@ func ParseSingleDir(rootDir, absDir string) (*RouteFile, error) {
 
 	var goFiles []string
 	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".go" {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		if ok, err := ctx.MatchFile(absDir, e.Name()); err != nil {
+			return nil, err
+		} else if ok {
 			goFiles = append(goFiles, filepath.Join(absDir, e.Name()))
 		}
 	}
@@ -142,6 +239,38 @@ func ParseSingleDir(rootDir, absDir string) (*RouteFile, error) {
 	return parseRouteDir(rootDir, absDir, goFiles)
 }
 
+// buildContext is the go/build context that decides which Go files are part
+// of a build with buildTags.
+func buildContext(buildTags []string) *build.Context {
+	ctx := build.Default
+	ctx.BuildTags = buildTags
+	return &ctx
+}
+
+// excludedByBuildTags reports whether absDir has Go files but none of them is
+// part of a build with buildTags, so a route in it is left out of the build.
+func excludedByBuildTags(absDir string, buildTags []string) (bool, error) {
+	entries, err := os.ReadDir(absDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	ctx := buildContext(buildTags)
+	hasGo := false
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		hasGo = true
+		if ok, err := ctx.MatchFile(absDir, e.Name()); err != nil || ok {
+			return false, err
+		}
+	}
+	return hasGo, nil
+}
+
 // parseRouteDir parses all Go files in a single route directory.
 func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 	relDir, err := filepath.Rel(rootDir, dir)
@@ -157,7 +286,7 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 	var allFiles []*ast.File
 
 	for _, path := range files {
-		f, err := parser.ParseFile(fset, path, nil, parser.AllErrors)
+		f, err := parser.ParseFile(fset, path, nil, parser.AllErrors|parser.ParseComments)
 		if err != nil {
 			return nil, fmt.Errorf("parsing %s: %w", path, err)
 		}
@@ -181,14 +310,25 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 	referencedStructs := map[string]bool{}
 	hasOnServerStart := false
 	hasAroundRequest := false
+	var routePolicies map[string]string
 
 	for _, f := range allFiles {
+		rstf := frameworkName(f)
+		if relDir == "." {
+			policies, err := parseRoutePolicies(fset, f, rstf)
+			if err != nil {
+				return nil, err
+			}
+			if policies != nil {
+				routePolicies = policies
+			}
+		}
 		for _, decl := range f.Decls {
 			fn, ok := decl.(*ast.FuncDecl)
 			if !ok || fn.Recv != nil {
 				continue
 			}
-			if fn.Name.Name == "OnServerStart" && isOnServerStartFunc(fn) {
+			if fn.Name.Name == "OnServerStart" && isOnServerStartFunc(fn, rstf) {
 				hasOnServerStart = true
 				continue
 			}
@@ -196,7 +336,18 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 				hasAroundRequest = true
 				continue
 			}
-			rf, refs := parseRouteFunc(fn)
+			if method, ok := funcMethodDirective(fn); ok {
+				rf, err := parseCustomMethodFunc(fn, method, rstf)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", relDir, err)
+				}
+				funcs = append(funcs, *rf)
+				continue
+			}
+			if fn.Name.Name == "SSR" && !isSSRParams(fn, rstf) {
+				return nil, fmt.Errorf("%s: SSR must take no parameters or only ctx *rstf.Context", relDir)
+			}
+			rf, refs := parseRouteFunc(fn, rstf)
 			if rf != nil {
 				funcs = append(funcs, *rf)
 				for _, r := range refs {
@@ -206,10 +357,30 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 		}
 	}
 
-	if len(funcs) == 0 && !hasOnServerStart && !hasAroundRequest {
+	if len(funcs) == 0 && !hasOnServerStart && !hasAroundRequest && routePolicies == nil {
 		return nil, nil
 	}
 
+	handlers := map[string]string{}
+	for _, fn := range funcs {
+		if fn.Kind != RouteFuncKindHTTP {
+			continue
+		}
+		if other, ok := handlers[fn.Method]; ok {
+			return nil, fmt.Errorf("%s: %s and %s both handle %s", relDir, other, fn.Name, fn.Method)
+		}
+		handlers[fn.Method] = fn.Name
+	}
+
+	for _, fn := range funcs {
+		if fn.Kind != RouteFuncKindEvents {
+			continue
+		}
+		if _, ok := structDefs[fn.ReturnType]; !ok {
+			return nil, fmt.Errorf("%s: event type %s must be a struct declared in the route package", fn.Name, fn.ReturnType)
+		}
+	}
+
 	// Resolve transitive struct references (e.g. ServerData -> Post, Author).
 	allRefs := resolveTransitiveStructs(referencedStructs, structDefs)
 	var structs []StructDef
@@ -218,6 +389,7 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 			structs = append(structs, sd)
 		}
 	}
+	sort.Slice(structs, func(i, j int) bool { return structs[i].Name < structs[j].Name })
 
 	return &RouteFile{
 		Dir:              relDir,
@@ -226,26 +398,108 @@ func parseRouteDir(rootDir, dir string, files []string) (*RouteFile, error) {
 		Structs:          structs,
 		HasOnServerStart: hasOnServerStart,
 		HasAroundRequest: hasAroundRequest,
+		RoutePolicies:    routePolicies,
 	}, nil
 }
 
+// parseRoutePolicies reads var RoutePolicies = rstf.RoutePolicies{...} from
+// f. The generated checks are resolved at codegen time, so the keys and
+// values must be string literals. It returns nil when f declares none.
+func parseRoutePolicies(fset *token.FileSet, f *ast.File, rstf string) (map[string]string, error) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok || len(vs.Names) != 1 || vs.Names[0].Name != "RoutePolicies" || len(vs.Values) != 1 {
+				continue
+			}
+			lit, ok := vs.Values[0].(*ast.CompositeLit)
+			if !ok || frameworkTypeName(lit.Type, rstf) != "RoutePolicies" {
+				continue
+			}
+			policies := map[string]string{}
+			for _, elt := range lit.Elts {
+				var pattern, name string
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if ok {
+					var keyOK, valueOK bool
+					pattern, keyOK = stringLit(kv.Key)
+					name, valueOK = stringLit(kv.Value)
+					ok = keyOK && valueOK
+				}
+				if !ok {
+					return nil, fmt.Errorf("%s: RoutePolicies entries must be string literals, e.g. \"/admin/*\": \"admin\"", fset.Position(elt.Pos()))
+				}
+				if !strings.HasPrefix(pattern, "/") {
+					return nil, fmt.Errorf("%s: RoutePolicies pattern %q must start with /", fset.Position(elt.Pos()), pattern)
+				}
+				if strings.TrimSpace(name) == "" {
+					return nil, fmt.Errorf("%s: RoutePolicies pattern %q has an empty policy name", fset.Position(elt.Pos()), pattern)
+				}
+				policies[pattern] = name
+			}
+			return policies, nil
+		}
+	}
+	return nil, nil
+}
+
+// stringLit returns the value of a string literal expression.
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
 // parseRouteFunc extracts metadata from recognized route functions.
-// - SSR must return a single named struct type.
-// - GET/POST/PUT/PATCH/DELETE must be func METHOD(ctx *rstf.Context) error.
-func parseRouteFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
+//   - SSR returns a single named struct type, or a slice or primitive that
+//     the View receives as { data: T }.
+//   - GET/POST/PUT/PATCH/DELETE must be func METHOD(ctx *rstf.Context) error.
+//     Handlers for other methods are annotated with //rstf:method instead and
+//     parsed by parseCustomMethodFunc.
+//   - Funcs taking a Query/Mutation/ActionContext are RPC functions.
+//   - func Name(ctx *rstf.Context, stream *rstf.EventStream[E]) error streams events.
+//   - func Meta(ctx *rstf.Context) rstf.PageMeta sets the page's indexing metadata.
+//   - Other func Name(ctx *rstf.Context) Struct are named SSR data functions.
+//
+// rstf is the name the function's file imports the framework under; see
+// frameworkName.
+func parseRouteFunc(fn *ast.FuncDecl, rstf string) (*RouteFunc, []string) {
 	if fn.Name.Name == "SSR" {
-		return parseSSRFunc(fn)
+		return parseSSRFunc(fn, rstf)
+	}
+	if fn.Name.Name == "Meta" {
+		return parseMetaFunc(fn, rstf), nil
 	}
 	if httpRouteFuncNames[fn.Name.Name] {
-		return parseHTTPFunc(fn), nil
+		return parseHTTPFunc(fn, rstf), nil
 	}
 	if !ast.IsExported(fn.Name.Name) {
 		return nil, nil
 	}
-	return parseRPCFunc(fn)
+	if rf, refs := parseRPCFunc(fn, rstf); rf != nil {
+		return rf, refs
+	}
+	if rf, refs := parseEventsFunc(fn, rstf); rf != nil {
+		return rf, refs
+	}
+	return parseNamedSSRFunc(fn, rstf)
+}
+
+// isSSRParams reports whether SSR takes no parameters or only the request
+// context, the only arguments the generated server can pass it.
+func isSSRParams(fn *ast.FuncDecl, rstf string) bool {
+	params := paramTypes(fn.Type.Params)
+	return len(params) == 0 || (len(params) == 1 && isContextParam(params[0], rstf))
 }
 
-func parseSSRFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
+func parseSSRFunc(fn *ast.FuncDecl, rstf string) (*RouteFunc, []string) {
 	results := fn.Type.Results
 	if results == nil || len(results.List) != 1 {
 		return nil, nil
@@ -253,29 +507,73 @@ func parseSSRFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
 
 	field := results.List[0]
 	typeName, isSlice := resolveType(field.Type)
-	if typeName == "" || isSlice || isPrimitiveGoType(typeName) {
+	if typeName == "" {
 		return nil, nil
 	}
 
 	hasContext := false
-	if fn.Type.Params != nil && len(fn.Type.Params.List) > 0 {
-		hasContext = isContextParam(fn.Type.Params.List[0].Type)
+	if params := paramTypes(fn.Type.Params); len(params) > 0 {
+		hasContext = isContextParam(params[0], rstf)
 	}
 
+	var refs []string
+	if !isPrimitiveGoType(typeName) {
+		refs = []string{typeName}
+	}
+	return &RouteFunc{
+		Name:          fn.Name.Name,
+		Kind:          RouteFuncKindSSR,
+		ReturnType:    typeName,
+		ReturnIsSlice: isSlice,
+		WrapsData:     isSlice || isPrimitiveGoType(typeName),
+		HasContext:    hasContext,
+	}, refs
+}
+
+// parseNamedSSRFunc recognizes additional SSR data functions such as
+// func Sidebar(ctx *rstf.Context) SidebarData. Unlike SSR, the context
+// parameter is required and the result must be a struct, so plain exported
+// helpers like func UserID(ctx *rstf.Context) string are never picked up.
+func parseNamedSSRFunc(fn *ast.FuncDecl, rstf string) (*RouteFunc, []string) {
+	params := paramTypes(fn.Type.Params)
+	if len(params) != 1 || !isContextParam(params[0], rstf) {
+		return nil, nil
+	}
+	rf, refs := parseSSRFunc(fn, rstf)
+	if rf == nil || rf.WrapsData {
+		return nil, nil
+	}
+	return rf, refs
+}
+
+// parseMetaFunc recognizes func Meta(ctx *rstf.Context) rstf.PageMeta, with the
+// context parameter optional.
+func parseMetaFunc(fn *ast.FuncDecl, rstf string) *RouteFunc {
+	hasContext := false
+	if params := paramTypes(fn.Type.Params); len(params) > 0 {
+		if len(params) > 1 || !isContextParam(params[0], rstf) {
+			return nil
+		}
+		hasContext = true
+	}
+	if fn.Type.Results == nil || len(fn.Type.Results.List) != 1 {
+		return nil
+	}
+	sel, ok := fn.Type.Results.List[0].Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "PageMeta" {
+		return nil
+	}
 	return &RouteFunc{
 		Name:       fn.Name.Name,
-		Kind:       RouteFuncKindSSR,
-		ReturnType: typeName,
+		Kind:       RouteFuncKindMeta,
 		HasContext: hasContext,
-	}, []string{typeName}
+	}
 }
 
-func parseHTTPFunc(fn *ast.FuncDecl) *RouteFunc {
+func parseHTTPFunc(fn *ast.FuncDecl, rstf string) *RouteFunc {
 	// Must have exactly one *Context parameter.
-	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 {
-		return nil
-	}
-	if !isContextParam(fn.Type.Params.List[0].Type) {
+	params := paramTypes(fn.Type.Params)
+	if len(params) != 1 || !isContextParam(params[0], rstf) {
 		return nil
 	}
 
@@ -292,20 +590,64 @@ func parseHTTPFunc(fn *ast.FuncDecl) *RouteFunc {
 		Name:       fn.Name.Name,
 		Kind:       RouteFuncKindHTTP,
 		HasContext: true,
+		Method:     fn.Name.Name,
 	}
 }
 
-func parseRPCFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
-	if fn.Type.Params == nil || len(fn.Type.Params.List) == 0 || len(fn.Type.Params.List) > 2 {
-		return nil, nil
+// funcMethodDirective returns the method named by fn's //rstf:method
+// directive, if it has one.
+func funcMethodDirective(fn *ast.FuncDecl) (string, bool) {
+	if fn.Doc == nil {
+		return "", false
 	}
+	for _, c := range fn.Doc.List {
+		if rest, ok := strings.CutPrefix(c.Text, methodDirective); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// parseCustomMethodFunc parses a handler annotated with //rstf:method. Unlike
+// the naming convention, a malformed annotated handler is an error rather than
+// a function rstf ignores.
+func parseCustomMethodFunc(fn *ast.FuncDecl, method, rstf string) (*RouteFunc, error) {
+	switch {
+	case !customMethodRe.MatchString(method):
+		return nil, fmt.Errorf("%s: %s needs an uppercase method name, e.g. %s PURGE", fn.Name.Name, methodDirective, methodDirective)
+	case conventionalMethods[method]:
+		return nil, fmt.Errorf("%s: name the handler %s instead of annotating it with %s %s", fn.Name.Name, method, methodDirective, method)
+	case method == "HEAD" || method == "OPTIONS":
+		return nil, fmt.Errorf("%s: rstf answers %s itself; it cannot be handled with %s", fn.Name.Name, method, methodDirective)
+	case !ast.IsExported(fn.Name.Name) || httpRouteFuncNames[fn.Name.Name] || fn.Name.Name == "Meta":
+		return nil, fmt.Errorf("%s: %s handlers must be exported and not use a reserved name", fn.Name.Name, methodDirective)
+	}
+	rf := parseHTTPFunc(fn, rstf)
+	if rf == nil {
+		return nil, fmt.Errorf("%s: %s handlers must be func %s(ctx *rstf.Context) error", fn.Name.Name, methodDirective, fn.Name.Name)
+	}
+	rf.Method = method
+	return rf, nil
+}
 
-	contextType := contextTypeName(fn.Type.Params.List[0].Type)
-	if contextType == "" {
+// parseRPCFunc recognizes query, mutation, and action functions. The context
+// may come before or after the input parameter.
+func parseRPCFunc(fn *ast.FuncDecl, rstf string) (*RouteFunc, []string) {
+	params := paramTypes(fn.Type.Params)
+	if len(params) == 0 || len(params) > 2 {
 		return nil, nil
 	}
 
-	kind := rpcFuncKindForContext(contextType)
+	var kind RouteFuncKind
+	contextIndex := -1
+	for i, param := range params {
+		if k := rpcFuncKindForContext(frameworkType(param, rstf)); k != "" {
+			if kind != "" {
+				return nil, nil
+			}
+			kind, contextIndex = k, i
+		}
+	}
 	if kind == "" {
 		return nil, nil
 	}
@@ -318,21 +660,35 @@ func parseRPCFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
 
 	var refs []string
 
-	if len(fn.Type.Params.List) == 2 {
+	if len(params) == 2 {
 		if kind == RouteFuncKindQuery {
 			return nil, nil
 		}
-		inputName, inputIsSlice := resolveType(fn.Type.Params.List[1].Type)
+		inputName, inputIsSlice := resolveType(params[1-contextIndex])
 		if inputName == "" {
 			return nil, nil
 		}
 		rf.InputType = inputName
 		rf.InputIsSlice = inputIsSlice
+		rf.InputFirst = contextIndex == 1
 		if !isPrimitiveGoType(inputName) {
 			refs = append(refs, inputName)
 		}
 	}
 
+	if returnName, returnIsSlice := parseFieldErrorsResults(fn.Type.Results); returnName != "" {
+		if kind == RouteFuncKindQuery {
+			return nil, nil
+		}
+		rf.ReturnType = returnName
+		rf.ReturnIsSlice = returnIsSlice
+		rf.ReturnsFieldErrors = true
+		if !isPrimitiveGoType(returnName) {
+			refs = append(refs, returnName)
+		}
+		return rf, refs
+	}
+
 	returnName, returnIsSlice, hasError := parseRPCResults(fn.Type.Results)
 	if kind == RouteFuncKindQuery && returnName == "" {
 		return nil, nil
@@ -351,30 +707,124 @@ func parseRPCFunc(fn *ast.FuncDecl) (*RouteFunc, []string) {
 	return rf, refs
 }
 
-// isContextParam checks if a type expression is *<pkg>.Context.
-// Matches any import alias (e.g. *rstf.Context, *fw.Context).
-func isContextParam(expr ast.Expr) bool {
+// parseEventsFunc recognizes func Name(ctx *rstf.Context, stream
+// *rstf.EventStream[E]) error. E is recorded as the return type since it is
+// what subscribers receive.
+func parseEventsFunc(fn *ast.FuncDecl, rstf string) (*RouteFunc, []string) {
+	params := paramTypes(fn.Type.Params)
+	if len(params) != 2 || !isContextParam(params[0], rstf) {
+		return nil, nil
+	}
+	eventType := eventStreamType(params[1])
+	if eventType == "" {
+		return nil, nil
+	}
+	if _, _, hasError := parseRPCResults(fn.Type.Results); !hasError || len(fn.Type.Results.List) != 1 {
+		return nil, nil
+	}
+	return &RouteFunc{
+		Name:         fn.Name.Name,
+		Kind:         RouteFuncKindEvents,
+		ReturnType:   eventType,
+		ReturnsError: true,
+		HasContext:   true,
+	}, []string{eventType}
+}
+
+// eventStreamType returns E for *<pkg>.EventStream[E], qualified when E comes
+// from another package, or "" for any other type expression.
+func eventStreamType(expr ast.Expr) string {
 	star, ok := expr.(*ast.StarExpr)
 	if !ok {
-		return false
+		return ""
 	}
-	sel, ok := star.X.(*ast.SelectorExpr)
+	index, ok := star.X.(*ast.IndexExpr)
 	if !ok {
-		return false
+		return ""
+	}
+	sel, ok := index.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "EventStream" {
+		return ""
+	}
+	switch t := index.Index.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			return pkg.Name + "." + t.Sel.Name
+		}
+	}
+	return ""
+}
+
+// isContextParam checks if a type expression is the framework's *Context,
+// under whatever name the file imports it (e.g. *rstf.Context, *fw.Context).
+func isContextParam(expr ast.Expr, rstf string) bool {
+	return frameworkType(expr, rstf) == "Context"
+}
+
+// frameworkName returns the name f imports the framework package under: its
+// alias, "rstf" when unaliased, or "." for a dot import. It returns "" when f
+// does not import the framework, so no type in f is one of its types.
+func frameworkName(f *ast.File) string {
+	return importName(f, frameworkModule, "rstf")
+}
+
+// importName returns the name f imports importPath under: its alias, name
+// when unaliased, or "." for a dot import. It returns "" when f does not
+// import importPath.
+func importName(f *ast.File, importPath, name string) string {
+	for _, imp := range f.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || path != importPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return name
 	}
-	return sel.Sel.Name == "Context"
+	return ""
 }
 
-func contextTypeName(expr ast.Expr) string {
+// frameworkType returns Name for a type expression *<rstf>.Name, where rstf is
+// the name from frameworkName, or "" for a type from any other package.
+func frameworkType(expr ast.Expr, rstf string) string {
 	star, ok := expr.(*ast.StarExpr)
 	if !ok {
 		return ""
 	}
-	sel, ok := star.X.(*ast.SelectorExpr)
-	if !ok {
-		return ""
+	return frameworkTypeName(star.X, rstf)
+}
+
+// frameworkTypeName returns Name for a type expression <rstf>.Name, as
+// frameworkType does for *<rstf>.Name.
+func frameworkTypeName(expr ast.Expr, rstf string) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if rstf == "." {
+			return t.Name
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && rstf != "" && pkg.Name == rstf {
+			return t.Sel.Name
+		}
 	}
-	return sel.Sel.Name
+	return ""
+}
+
+// paramTypes lists the type of each parameter, repeating a type shared by
+// several names as in func(a, b int).
+func paramTypes(params *ast.FieldList) []ast.Expr {
+	if params == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range params.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, field.Type)
+		}
+	}
+	return types
 }
 
 func rpcFuncKindForContext(name string) RouteFuncKind {
@@ -416,18 +866,25 @@ func parseRPCResults(results *ast.FieldList) (typeName string, isSlice bool, has
 	return typeName, isSlice, true
 }
 
+// parseFieldErrorsResults returns T for results of the form
+// (T, *<pkg>.FieldErrors), or "" for any other results.
+func parseFieldErrorsResults(results *ast.FieldList) (typeName string, isSlice bool) {
+	if results == nil || len(results.List) != 2 || !isStarSelector(results.List[1].Type, "FieldErrors") {
+		return "", false
+	}
+	return resolveType(results.List[0].Type)
+}
+
 // isOnServerStartFunc checks if a function declaration matches func OnServerStart(*<pkg>.App).
 // It must have exactly one parameter of type *<pkg>.App and no return values.
-func isOnServerStartFunc(fn *ast.FuncDecl) bool {
+func isOnServerStartFunc(fn *ast.FuncDecl, rstf string) bool {
 	// Must have no return values.
 	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
 		return false
 	}
 	// Must have exactly one parameter.
-	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 {
-		return false
-	}
-	return isStarSelector(fn.Type.Params.List[0].Type, "App")
+	params := paramTypes(fn.Type.Params)
+	return len(params) == 1 && frameworkType(params[0], rstf) == "App"
 }
 
 // isAroundRequestFunc checks if a function declaration matches
@@ -499,7 +956,11 @@ func extractStructs(f *ast.File) map[string]StructDef {
 			if !ok {
 				continue
 			}
-			sd := StructDef{Name: ts.Name.Name}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			sd := StructDef{Name: ts.Name.Name, Doc: commentText(doc)}
 			for _, field := range st.Fields.List {
 				if len(field.Names) == 0 {
 					continue // Skip embedded fields
@@ -515,13 +976,17 @@ func extractStructs(f *ast.File) map[string]StructDef {
 				if jsonName == "-" {
 					continue
 				}
-				typeName, isSlice := resolveType(field.Type)
-				tsType := goTypeToTS(typeName, isSlice)
+				tsType := fieldTSType(field)
 
+				fieldDoc := field.Doc
+				if fieldDoc == nil {
+					fieldDoc = field.Comment
+				}
 				sd.Fields = append(sd.Fields, StructField{
 					Name:     fieldName,
 					JSONName: jsonName,
 					Type:     tsType,
+					Doc:      commentText(fieldDoc),
 				})
 			}
 			structs[ts.Name.Name] = sd
@@ -530,6 +995,14 @@ func extractStructs(f *ast.File) map[string]StructDef {
 	return structs
 }
 
+// commentText returns the trimmed text of a comment group, or "" for nil.
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cg.Text())
+}
+
 // jsonTagName extracts the field name from a `json:"name"` tag.
 func jsonTagName(field *ast.Field) string {
 	if field.Tag == nil {
@@ -544,6 +1017,57 @@ func jsonTagName(field *ast.Field) string {
 	return name
 }
 
+// fieldTSType maps a struct field's type to its TypeScript equivalent. A
+// field tagged with rstf.FormatTag gets its format's type from the global
+// Rstf namespace, and a time.Time is the RFC 3339 string it encodes as.
+func fieldTSType(field *ast.Field) string {
+	typeName, isSlice := resolveType(field.Type)
+	if isTimeType(field.Type) {
+		typeName = "string"
+	}
+	switch formatTagName(field) {
+	case "currency":
+		typeName = "Rstf.Money"
+	case "datetime":
+		typeName = "Rstf.DateTime"
+	case "date":
+		typeName = "Rstf.DateString"
+	}
+	return goTypeToTS(typeName, isSlice)
+}
+
+// isTimeType reports whether expr is time.Time, *time.Time or a slice of
+// either.
+func isTimeType(expr ast.Expr) bool {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ArrayType:
+			expr = t.Elt
+		case *ast.SelectorExpr:
+			pkg, ok := t.X.(*ast.Ident)
+			return ok && pkg.Name == "time" && t.Sel.Name == "Time"
+		default:
+			return false
+		}
+	}
+}
+
+// formatTagName extracts the format from a `rstf:"format=name,..."` tag.
+func formatTagName(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+	for _, part := range strings.Split(tag.Get("rstf"), ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(part), "format="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
 // goTypeToTS maps a Go type name to its TypeScript equivalent.
 func goTypeToTS(goType string, isSlice bool) string {
 	var tsType string
@@ -121,6 +121,28 @@ func DoSomething() string {
 	assert.Len(t, routes, 0)
 }
 
+func TestParseDirSkipsIgnoredDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "index", "index.go"), `
+package index
+
+func SSR() string {
+	return "hi"
+}
+`)
+	writeFile(t, filepath.Join(dir, "tools", "gen", "main.go"), `package main
+
+func main() {`)
+
+	_, err := ParseDir(dir)
+	require.ErrorContains(t, err, "tools/gen")
+
+	routes, err := parseDir(dir, func(rel string) bool { return rel == "tools/gen" }, nil)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "routes/index", routes[0].Dir)
+}
+
 func TestParseDirRejectsNestedRouteDirectories(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, filepath.Join(dir, "routes", "admin", "users", "index.go"), `
@@ -166,6 +188,68 @@ func SSR() ServerData {
 	assert.Equal(t, "ServerData", fn.ReturnType)
 }
 
+func TestParseDirExtractsDocComments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "page", "page.go"), `
+package page
+
+// ServerData is the page payload.
+type ServerData struct {
+	// Title is shown in the header.
+	Title string `+"`json:\"title\"`"+`
+	Count int    `+"`json:\"count\"`"+` // number of items
+	Plain bool   `+"`json:\"plain\"`"+`
+}
+
+func SSR() ServerData {
+	return ServerData{}
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	require.Len(t, routes[0].Structs, 1)
+	sd := routes[0].Structs[0]
+	assert.Equal(t, "ServerData is the page payload.", sd.Doc)
+	require.Len(t, sd.Fields, 3)
+	assert.Equal(t, "Title is shown in the header.", sd.Fields[0].Doc)
+	assert.Equal(t, "number of items", sd.Fields[1].Doc)
+	assert.Empty(t, sd.Fields[2].Doc)
+}
+
+func TestParseDirMapsFormattedFields(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "page", "page.go"), `
+package page
+
+import "time"
+
+type ServerData struct {
+	Total    int64       `+"`json:\"total\" rstf:\"format=currency,currency=EUR\"`"+`
+	Prices   []int64     `+"`json:\"prices\" rstf:\"format=currency,currency=USD\"`"+`
+	PlacedAt time.Time   `+"`json:\"placedAt\" rstf:\"format=datetime,tz=Europe/Berlin\"`"+`
+	ShipsOn  *time.Time  `+"`json:\"shipsOn\" rstf:\"format=date\"`"+`
+	Updated  time.Time   `+"`json:\"updated\"`"+`
+	History  []time.Time `+"`json:\"history\"`"+`
+}
+
+func SSR() ServerData {
+	return ServerData{}
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	require.Len(t, routes[0].Structs, 1)
+	var types []string
+	for _, f := range routes[0].Structs[0].Fields {
+		types = append(types, f.Type)
+	}
+	assert.Equal(t, []string{"Rstf.Money", "Rstf.Money[]", "Rstf.DateTime", "Rstf.DateString", "string", "string[]"}, types)
+}
+
 func TestParseDirDetectsOnServerStart(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, filepath.Join(dir, "myapp", "main.go"), `
@@ -253,6 +337,265 @@ func NotifySlack(ctx *rstf.ActionContext, input string) (string, error) {
 	assert.Len(t, routes[0].Structs, 3)
 }
 
+func TestParseDirDetectsFieldErrorResults(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "posts", "index.go"), `
+package posts
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type Post struct {
+	Title string `+"`json:\"title\"`"+`
+}
+
+type CreatePostInput struct {
+	Title string `+"`json:\"title\"`"+`
+}
+
+func CreatePost(ctx *rstf.ActionContext, input CreatePostInput) (Post, *rstf.FieldErrors) {
+	return Post{}, nil
+}
+
+func Rename(ctx *rstf.MutationContext, input string) (string, *rstf.FieldErrors) {
+	return input, nil
+}
+
+func Search(ctx *rstf.QueryContext) ([]Post, *rstf.FieldErrors) {
+	return nil, nil
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	// Queries have no input to reject.
+	require.Len(t, routes[0].Funcs, 2)
+
+	assert.Contains(t, routes[0].Funcs, RouteFunc{
+		Name:               "CreatePost",
+		Kind:               RouteFuncKindAction,
+		InputType:          "CreatePostInput",
+		ReturnType:         "Post",
+		HasContext:         true,
+		ReturnsFieldErrors: true,
+	})
+	assert.Contains(t, routes[0].Funcs, RouteFunc{
+		Name:               "Rename",
+		Kind:               RouteFuncKindMutation,
+		InputType:          "string",
+		ReturnType:         "string",
+		HasContext:         true,
+		ReturnsFieldErrors: true,
+	})
+}
+
+func TestParseDirDetectsEventStreamFunctions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "chat._id", "index.go"), `
+package chat
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type MessagePosted struct {
+	Body string `+"`json:\"body\"`"+`
+}
+
+type ChatEvent struct {
+	Message *MessagePosted `+"`json:\"message\"`"+`
+}
+
+func Events(ctx *rstf.Context, stream *rstf.EventStream[ChatEvent]) error {
+	return nil
+}
+
+func Untyped(ctx *rstf.Context, stream *rstf.EventStream[ChatEvent]) {}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, []RouteFunc{{
+		Name:         "Events",
+		Kind:         RouteFuncKindEvents,
+		ReturnType:   "ChatEvent",
+		ReturnsError: true,
+		HasContext:   true,
+	}}, routes[0].Funcs)
+	assert.Len(t, routes[0].Structs, 2)
+}
+
+func TestParseDirRejectsEventTypeFromAnotherPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "chat", "index.go"), `
+package chat
+
+import (
+	rstf "github.com/rafbgarcia/rstf"
+	"example.com/app/events"
+)
+
+func Events(ctx *rstf.Context, stream *rstf.EventStream[events.ChatEvent]) error {
+	return nil
+}
+`)
+
+	_, err := ParseDir(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Events: event type events.ChatEvent must be a struct declared in the route package")
+}
+
+func TestParseDirDetectsMetaFunction(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "drafts", "index.go"), `
+package drafts
+
+import rstf "github.com/rafbgarcia/rstf"
+
+func Meta(ctx *rstf.Context) rstf.PageMeta {
+	return rstf.PageMeta{NoIndex: true}
+}
+`)
+	writeFile(t, filepath.Join(dir, "routes", "about", "index.go"), `
+package about
+
+import rstf "github.com/rafbgarcia/rstf"
+
+func Meta() rstf.PageMeta {
+	return rstf.PageMeta{Canonical: "https://example.com/about"}
+}
+`)
+	writeFile(t, filepath.Join(dir, "routes", "other", "index.go"), `
+package other
+
+import rstf "github.com/rafbgarcia/rstf"
+
+func Meta(ctx *rstf.Context) map[string]string {
+	return nil
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	funcs := map[string][]RouteFunc{}
+	for _, rf := range routes {
+		funcs[rf.Dir] = rf.Funcs
+	}
+	assert.Equal(t, []RouteFunc{{Name: "Meta", Kind: RouteFuncKindMeta, HasContext: true}}, funcs["routes/drafts"])
+	assert.Equal(t, []RouteFunc{{Name: "Meta", Kind: RouteFuncKindMeta}}, funcs["routes/about"])
+}
+
+func TestParseDirDetectsNamedSSRFunctions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "dashboard", "index.go"), `
+package dashboard
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type ServerData struct {
+	Title string `+"`json:\"title\"`"+`
+}
+
+type SidebarData struct {
+	Links []string `+"`json:\"links\"`"+`
+}
+
+func SSR(ctx *rstf.Context) ServerData {
+	return ServerData{}
+}
+
+func Sidebar(ctx *rstf.Context) SidebarData {
+	return SidebarData{}
+}
+
+func NewSidebar() SidebarData {
+	return SidebarData{}
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	require.Len(t, routes[0].Funcs, 2)
+
+	assert.Contains(t, routes[0].Funcs, RouteFunc{
+		Name:       "Sidebar",
+		Kind:       RouteFuncKindSSR,
+		ReturnType: "SidebarData",
+		HasContext: true,
+	})
+
+	dataFuncs := routes[0].SSRDataFuncs()
+	require.Len(t, dataFuncs, 2)
+	assert.Equal(t, "SSR", dataFuncs[0].Name)
+	assert.Equal(t, "Sidebar", dataFuncs[1].Name)
+	assert.Len(t, routes[0].Structs, 2)
+}
+
+// A route directory can mix page data, verb handlers, and RPC functions; each
+// keeps its own types in the generated TypeScript.
+func TestParseDirMixedFunctionKinds(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "posts._id", "index.go"), `
+package posts
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type ServerData struct {
+	Title string `+"`json:\"title\"`"+`
+}
+
+type SidebarData struct {
+	Links []string `+"`json:\"links\"`"+`
+}
+
+type LikeInput struct {
+	Up bool `+"`json:\"up\"`"+`
+}
+
+type LikeResult struct {
+	Likes int `+"`json:\"likes\"`"+`
+}
+
+func SSR(ctx *rstf.Context) ServerData { return ServerData{} }
+
+func Sidebar(ctx *rstf.Context) SidebarData { return SidebarData{} }
+
+func GET(ctx *rstf.Context) error { return nil }
+
+func POST(ctx *rstf.Context) error { return nil }
+
+func Like(ctx *rstf.MutationContext, input LikeInput) (LikeResult, error) {
+	return LikeResult{}, nil
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	rf := routes[0]
+
+	kinds := map[string]RouteFuncKind{}
+	for _, fn := range rf.Funcs {
+		kinds[fn.Name] = fn.Kind
+	}
+	assert.Equal(t, map[string]RouteFuncKind{
+		"SSR":     RouteFuncKindSSR,
+		"Sidebar": RouteFuncKindSSR,
+		"GET":     RouteFuncKindHTTP,
+		"POST":    RouteFuncKindHTTP,
+		"Like":    RouteFuncKindMutation,
+	}, kinds)
+	assert.Len(t, rf.Structs, 4)
+
+	runtimeModule := GenerateRuntimeModule(rf, "routes/posts._id")
+	assert.Contains(t, runtimeModule, "export type RoutesPostsIdSSRProps = RoutesPostsId.ServerData;")
+	assert.Contains(t, runtimeModule, "export type RoutesPostsIdSidebarProps = RoutesPostsId.SidebarData;")
+
+	routesTS := GenerateRoutesTS(BuildRouteDefs(routes, map[string][]string{"routes/posts._id": {"routes/posts._id"}}))
+	assert.Contains(t, routesTS, `Like: defineMutation<{ id: string }, RoutesPostsId.LikeInput, RoutesPostsId.LikeResult>("posts._id", "Like"),`)
+}
+
 func TestParseDirOnServerStartWithAlias(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, filepath.Join(dir, "myapp", "main.go"), `
@@ -476,26 +819,253 @@ func SSR(ctx *rstf.Context) Session {
 	assert.Len(t, routes[0].Funcs, 1)
 }
 
-func TestParseDirSkipsNonStructReturns(t *testing.T) {
+func TestParseDirWrapsNonStructSSRReturns(t *testing.T) {
 	dir := t.TempDir()
-	writeFile(t, filepath.Join(dir, "api", "api.go"), `
-package api
+	writeFile(t, filepath.Join(dir, "routes", "posts", "index.go"), `
+package posts
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type Post struct {
+	Title string `+"`json:\"title\"`"+`
+}
+
+func SSR(ctx *rstf.Context) []Post {
+	return nil
+}
+`)
+	writeFile(t, filepath.Join(dir, "routes", "greeting", "index.go"), `
+package greeting
 
 func SSR() string {
-	return "test"
+	return "hello"
 }
 `)
 
 	routes, err := ParseDir(dir)
 	require.NoError(t, err)
-	// SSR() returns a primitive — should be skipped.
-	assert.Len(t, routes, 0)
+	require.Len(t, routes, 2)
+
+	greeting, posts := routes[0], routes[1]
+	assert.Equal(t, []RouteFunc{{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "string", WrapsData: true}}, greeting.Funcs)
+	assert.Empty(t, greeting.Structs)
+	assert.Equal(t, []RouteFunc{{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "Post", ReturnIsSlice: true, WrapsData: true, HasContext: true}}, posts.Funcs)
+	require.Len(t, posts.Structs, 1)
+	assert.Equal(t, "Post", posts.Structs[0].Name)
+}
+
+func TestParseDirSkipsNamedDataFuncsWithNonStructReturns(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "account", "index.go"), `
+package account
+
+import rstf "github.com/rafbgarcia/rstf"
+
+// UserID is a helper, not a data function.
+func UserID(ctx *rstf.Context) string {
+	return ""
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, routes)
 }
 
-func writeFile(t *testing.T, path, content string) {
+func TestParseDirResolvesFrameworkImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "chat", "index.go"), `
+package chat
+
+import . "github.com/rafbgarcia/rstf"
+
+type Session struct {
+	UserName string
+}
+
+func SSR(ctx *Context) Session {
+	return Session{}
+}
+
+func Rename(name string, ctx *MutationContext) (string, error) {
+	return name, nil
+}
+`)
+	writeFile(t, filepath.Join(dir, "routes", "webhook", "index.go"), `
+package webhook
+
+import rstf "example.com/app/rstf"
+
+func POST(ctx *rstf.Context) error {
+	return nil
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, []RouteFunc{
+		{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "Session", HasContext: true},
+		{Name: "Rename", Kind: RouteFuncKindMutation, InputType: "string", InputFirst: true, ReturnType: "string", ReturnsError: true, HasContext: true},
+	}, routes[0].Funcs)
+}
+
+func TestParseDirRejectsSSRParams(t *testing.T) {
+	for name, params := range map[string]string{
+		"context after another parameter": "id string, ctx *rstf.Context",
+		"context from another package":    "ctx *context.Context",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "routes", "posts", "index.go"), `
+package posts
+
+import (
+	"context"
+
+	rstf "github.com/rafbgarcia/rstf"
+)
+
+var _ context.Context
+var _ rstf.Context
+
+type ServerData struct {
+	Title string
+}
+
+func SSR(`+params+`) ServerData {
+	return ServerData{}
+}
+`)
+
+			_, err := ParseDir(dir)
+			assert.ErrorContains(t, err, "routes/posts: SSR must take no parameters or only ctx *rstf.Context")
+		})
+	}
+}
+
+func writeFile(t testing.TB, path, content string) {
 	t.Helper()
 	err := os.MkdirAll(filepath.Dir(path), 0o755)
 	require.NoError(t, err, "mkdir %s", filepath.Dir(path))
 	err = os.WriteFile(path, []byte(content), 0o644)
 	require.NoError(t, err, "writing %s", path)
 }
+
+func TestParseDirDetectsCustomMethodHandlers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "cache", "index.go"), `
+package cache
+
+import rstf "github.com/rafbgarcia/rstf"
+
+func PATCH(ctx *rstf.Context) error { return nil }
+
+// Purge drops the cached page.
+//
+//rstf:method PURGE
+func Purge(ctx *rstf.Context) error { return nil }
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.ElementsMatch(t, []RouteFunc{
+		{Name: "PATCH", Kind: RouteFuncKindHTTP, HasContext: true, Method: "PATCH"},
+		{Name: "Purge", Kind: RouteFuncKindHTTP, HasContext: true, Method: "PURGE"},
+	}, routes[0].Funcs)
+}
+
+func TestParseDirRejectsInvalidCustomMethodHandlers(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"lowercase method", "//rstf:method purge\nfunc Purge(ctx *rstf.Context) error { return nil }", "needs an uppercase method name"},
+		{"conventional method", "//rstf:method PATCH\nfunc Update(ctx *rstf.Context) error { return nil }", "name the handler PATCH instead"},
+		{"framework method", "//rstf:method OPTIONS\nfunc Options(ctx *rstf.Context) error { return nil }", "rstf answers OPTIONS itself"},
+		{"wrong signature", "//rstf:method PURGE\nfunc Purge() error { return nil }", "must be func Purge(ctx *rstf.Context) error"},
+		{"duplicate method", "//rstf:method PURGE\nfunc Purge(ctx *rstf.Context) error { return nil }\n\n//rstf:method PURGE\nfunc Drop(ctx *rstf.Context) error { return nil }", "Purge and Drop both handle PURGE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "routes", "cache", "index.go"), "package cache\n\nimport rstf \"github.com/rafbgarcia/rstf\"\n\n"+tt.src+"\n")
+
+			_, err := ParseDir(dir)
+			require.ErrorContains(t, err, "routes/cache: ")
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseDirReadsRoutePolicies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "policies.go"), `
+package myapp
+
+import fw "github.com/rafbgarcia/rstf"
+
+var RoutePolicies = fw.RoutePolicies{
+	"/admin/*":  "admin",
+	"/settings": "signedIn",
+}
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, ".", routes[0].Dir)
+	assert.Equal(t, map[string]string{"/admin/*": "admin", "/settings": "signedIn"}, routes[0].RoutePolicies)
+}
+
+func TestParseDirRejectsInvalidRoutePolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries string
+		want    string
+	}{
+		{"non-literal policy", `"/admin/*": adminPolicy`, "entries must be string literals"},
+		{"relative pattern", `"admin": "admin"`, `pattern "admin" must start with /`},
+		{"empty name", `"/admin": ""`, `pattern "/admin" has an empty policy name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "policies.go"), `
+package myapp
+
+import rstf "github.com/rafbgarcia/rstf"
+
+const adminPolicy = "admin"
+
+var RoutePolicies = rstf.RoutePolicies{
+	`+tt.entries+`,
+}
+`)
+			_, err := ParseDir(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "policies.go:")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseDirIgnoresRoutePoliciesOutsideLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "routes", "admin", "index.go"), `
+package admin
+
+import rstf "github.com/rafbgarcia/rstf"
+
+var RoutePolicies = rstf.RoutePolicies{"/admin": "admin"}
+
+func SSR() string { return "" }
+`)
+
+	routes, err := ParseDir(dir)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Nil(t, routes[0].RoutePolicies)
+}
@@ -0,0 +1,92 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/conventions"
+)
+
+// matchPolicies returns the names of the policies guarding the route with URL
+// pattern, from the layout's RoutePolicies. A key matches the route with that
+// pattern, and a key ending in "/*" also every route beneath it. Names come
+// broadest key first, each once. Every key used is recorded in matched.
+func matchPolicies(policies map[string]string, pattern string, matched map[string]bool) []string {
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		bi, wi := policyKeyBase(keys[i])
+		bj, wj := policyKeyBase(keys[j])
+		if len(bi) != len(bj) {
+			return len(bi) < len(bj)
+		}
+		if wi != wj {
+			return wi
+		}
+		return keys[i] < keys[j]
+	})
+
+	var names []string
+	for _, key := range keys {
+		if !policyKeyMatches(key, pattern) {
+			continue
+		}
+		matched[key] = true
+		if name := policies[key]; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// policyKeyBase returns a RoutePolicies key without its "/*" suffix, and
+// whether it had one.
+func policyKeyBase(key string) (string, bool) {
+	base, wildcard := strings.CutSuffix(key, "/*")
+	return base, wildcard
+}
+
+func policyKeyMatches(key, pattern string) bool {
+	if base, wildcard := policyKeyBase(key); wildcard {
+		return pattern == base || strings.HasPrefix(pattern, base+"/")
+	}
+	return pattern == key
+}
+
+// checkRoutePolicies fails for the RoutePolicies keys no route matched, since
+// a mistyped pattern would leave the routes it meant to guard open.
+func checkRoutePolicies(policies map[string]string, matched map[string]bool) error {
+	var unmatched []string
+	for key := range policies {
+		if !matched[key] {
+			unmatched = append(unmatched, fmt.Sprintf("%q", key))
+		}
+	}
+	if len(unmatched) == 0 {
+		return nil
+	}
+	sort.Strings(unmatched)
+	return fmt.Errorf("RoutePolicies patterns match no route: %s", strings.Join(unmatched, ", "))
+}
+
+// policyNames returns the distinct policy names RoutePolicies uses, sorted.
+func policyNames(policies map[string]string) []string {
+	var names []string
+	for _, name := range policies {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// routePatternForDir returns the URL pattern of a route dir within its app,
+// without the URL prefix of a mount.
+func routePatternForDir(dir string) string {
+	return conventions.FolderToURLPattern(routeNameForDir(dir))
+}
@@ -0,0 +1,53 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPolicies(t *testing.T) {
+	policies := map[string]string{
+		"/*":                "signedIn",
+		"/admin/*":          "admin",
+		"/admin":            "staff",
+		"/admin/billing":    "owner",
+		"/users/{id}/edit":  "self",
+		"/reports/*":        "admin",
+		"/reports/{id}/*":   "signedIn",
+		"/administrators/x": "unused",
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/", []string{"signedIn"}},
+		{"/admin", []string{"signedIn", "admin", "staff"}},
+		{"/admin/billing", []string{"signedIn", "admin", "owner"}},
+		{"/admin/users/{id}", []string{"signedIn", "admin"}},
+		{"/administrators", []string{"signedIn"}},
+		{"/users/{id}/edit", []string{"signedIn", "self"}},
+		{"/reports/{id}", []string{"signedIn", "admin"}},
+	}
+	for _, tt := range tests {
+		matched := map[string]bool{}
+		assert.Equal(t, tt.want, matchPolicies(policies, tt.pattern, matched), tt.pattern)
+	}
+}
+
+func TestCheckRoutePolicies(t *testing.T) {
+	policies := map[string]string{"/admin/*": "admin", "/setings": "signedIn", "/": "x"}
+	matched := map[string]bool{}
+	for _, pattern := range []string{"/", "/admin", "/settings"} {
+		matchPolicies(policies, pattern, matched)
+	}
+	assert.EqualError(t, checkRoutePolicies(policies, matched), `RoutePolicies patterns match no route: "/setings"`)
+
+	assert.NoError(t, checkRoutePolicies(nil, map[string]bool{}))
+}
+
+func TestPolicyNames(t *testing.T) {
+	assert.Equal(t, []string{"admin", "signedIn"}, policyNames(map[string]string{"/a/*": "signedIn", "/b": "admin", "/c": "signedIn"}))
+	assert.Empty(t, policyNames(nil))
+}
@@ -15,9 +15,17 @@ import (
 type RouteDef struct {
 	Dir      string
 	Name     string
+	Prefix   string // URL prefix of the mounted app the route belongs to
 	Pattern  string
 	Params   []RouteParamDef
 	RPCFuncs []RPCFuncDef
+	// Methods are the HTTP methods the route's handlers serve, conventional
+	// ones first in GET, POST, PUT, PATCH, DELETE order, then those set with
+	// //rstf:method alphabetically.
+	Methods []string
+	// Policies are the names of the policies RoutePolicies guards the route
+	// with, broadest pattern first.
+	Policies []string
 }
 
 // RouteParamDef describes a single path parameter in a route.
@@ -26,7 +34,8 @@ type RouteParamDef struct {
 	GoField string
 }
 
-// RPCFuncDef describes a generated query, mutation, or action contract.
+// RPCFuncDef describes a generated query, mutation, action, or event stream
+// contract.
 type RPCFuncDef struct {
 	Name          string
 	Kind          RouteFuncKind
@@ -34,6 +43,9 @@ type RPCFuncDef struct {
 	InputIsSlice  bool
 	ReturnType    string
 	ReturnIsSlice bool
+	// ReturnsFieldErrors is whether a mutation or action returns
+	// (T, *rstf.FieldErrors), typed as ActionResult<T> on the client.
+	ReturnsFieldErrors bool
 }
 
 // BuildRouteDefs returns the sorted route contract generated from the file-based
@@ -46,6 +58,7 @@ func BuildRouteDefs(files []RouteFile, deps map[string][]string) []RouteDef {
 
 	seen := map[string]bool{}
 	var routeDefs []RouteDef
+	policies := fileMap["."].RoutePolicies
 
 	addRoute := func(dir string) {
 		if !conventions.IsRouteDir(dir) || seen[dir] {
@@ -61,6 +74,8 @@ func BuildRouteDefs(files []RouteFile, deps map[string][]string) []RouteDef {
 			Pattern:  conventions.FolderToURLPattern(name),
 			Params:   routeParamsForName(name),
 			RPCFuncs: rpcFuncs,
+			Methods:  routeMethods(fileMap[dir]),
+			Policies: matchPolicies(policies, routePatternForDir(dir), map[string]bool{}),
 		})
 	}
 
@@ -77,16 +92,62 @@ func BuildRouteDefs(files []RouteFile, deps map[string][]string) []RouteDef {
 	return routeDefs
 }
 
+// routeMethods returns the methods served by rf's HTTP handlers, in
+// RouteDef.Methods order.
+func routeMethods(rf RouteFile) []string {
+	var methods []string
+	for _, fn := range rf.Funcs {
+		if fn.Kind == RouteFuncKindHTTP {
+			methods = append(methods, fn.Method)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		oi, iConventional := methodOrder[methods[i]]
+		oj, jConventional := methodOrder[methods[j]]
+		if iConventional != jConventional {
+			return iConventional
+		}
+		if iConventional {
+			return oi < oj
+		}
+		return methods[i] < methods[j]
+	})
+	return methods
+}
+
+// methodOrder ranks the conventional methods in RouteDef.Methods.
+var methodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4}
+
+// prefixRouteDefs moves route defs under a mounted app's URL prefix, so their
+// patterns and URLs match what the generated server serves.
+func prefixRouteDefs(routeDefs []RouteDef, prefix string) []RouteDef {
+	if prefix == "" {
+		return routeDefs
+	}
+	prefixed := make([]RouteDef, len(routeDefs))
+	for i, route := range routeDefs {
+		route.Prefix = prefix
+		route.Pattern = mountedPattern(prefix, route.Pattern)
+		prefixed[i] = route
+	}
+	return prefixed
+}
+
 // GenerateRoutesTS generates the @rstf/routes module.
 func GenerateRoutesTS(routeDefs []RouteDef) string {
 	var b strings.Builder
 	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
-	b.WriteString("import { defineAction, defineMutation, defineQuery, useAction, useMutation, useQuery } from \"./client\";\n\n")
+	b.WriteString("import { Form, defineAction, defineEventStream, defineMutation, defineQuery, useAction, useEventStream, useMutation, useQuery } from \"./client\";\n")
+	b.WriteString("import type { ActionResult, FieldErrors } from \"./client\";\n\n")
 
 	if len(routeDefs) == 0 {
 		b.WriteString("export const routes = {} as const;\n\n")
-		b.WriteString("export { useAction, useMutation, useQuery };\n")
+		b.WriteString("export { Form, useAction, useEventStream, useMutation, useQuery };\n")
+		b.WriteString("export type { ActionResult, FieldErrors };\n")
 		b.WriteString("export type RouteName = never;\n")
+		b.WriteString("export type RouteParams = {};\n")
+		writeProtectedRoutesTS(&b, nil)
+		writeHrefTS(&b)
 		return b.String()
 	}
 
@@ -94,9 +155,12 @@ func GenerateRoutesTS(routeDefs []RouteDef) string {
 	for _, route := range routeDefs {
 		fmt.Fprintf(&b, "  %q: {\n", route.Name)
 		fmt.Fprintf(&b, "    pattern: %q,\n", route.Pattern)
+		if len(route.Methods) > 0 {
+			fmt.Fprintf(&b, "    methods: [%s] as const,\n", quotedList(route.Methods))
+		}
 		if len(route.Params) == 0 {
 			b.WriteString("    url(): string {\n")
-			fmt.Fprintf(&b, "      return %q;\n", routeTemplate(route.Name))
+			fmt.Fprintf(&b, "      return %q;\n", mountedPattern(route.Prefix, routeTemplate(route.Name)))
 			b.WriteString("    },\n")
 		} else {
 			b.WriteString("    url(params: { ")
@@ -125,22 +189,34 @@ func GenerateRoutesTS(routeDefs []RouteDef) string {
 			case RouteFuncKindMutation:
 				fmt.Fprintf(
 					&b,
-					"    %s: defineMutation<%s, %s, %s>(%q, %q),\n",
+					"    %s: defineMutation<%s, %s, %s>(%q, %q%s),\n",
 					fn.Name,
 					tsParamsType(route),
 					tsRPCInputType(route.Dir, fn),
-					tsRPCType(route.Dir, fn.ReturnType, fn.ReturnIsSlice, false),
+					tsRPCResultType(route.Dir, fn),
 					route.Name,
 					fn.Name,
+					tsRPCOptions(fn),
 				)
 			case RouteFuncKindAction:
 				fmt.Fprintf(
 					&b,
-					"    %s: defineAction<%s, %s, %s>(%q, %q),\n",
+					"    %s: defineAction<%s, %s, %s>(%q, %q%s),\n",
 					fn.Name,
 					tsParamsType(route),
 					tsRPCInputType(route.Dir, fn),
-					tsRPCType(route.Dir, fn.ReturnType, fn.ReturnIsSlice, false),
+					tsRPCResultType(route.Dir, fn),
+					route.Name,
+					fn.Name,
+					tsRPCOptions(fn),
+				)
+			case RouteFuncKindEvents:
+				fmt.Fprintf(
+					&b,
+					"    %s: defineEventStream<%s, %s>(%q, %q),\n",
+					fn.Name,
+					tsParamsType(route),
+					tsRPCType(route.Dir, fn.ReturnType, false, false),
 					route.Name,
 					fn.Name,
 				)
@@ -149,11 +225,55 @@ func GenerateRoutesTS(routeDefs []RouteDef) string {
 		b.WriteString("  },\n")
 	}
 	b.WriteString("} as const;\n\n")
-	b.WriteString("export { useAction, useMutation, useQuery };\n")
+	b.WriteString("export { Form, useAction, useEventStream, useMutation, useQuery };\n")
+	b.WriteString("export type { ActionResult, FieldErrors };\n")
 	b.WriteString("export type RouteName = keyof typeof routes;\n")
+	b.WriteString("export type RouteParams = {\n")
+	for _, route := range routeDefs {
+		fmt.Fprintf(&b, "  %q: %s;\n", route.Name, tsParamsType(route))
+	}
+	b.WriteString("};\n")
+	writeProtectedRoutesTS(&b, routeDefs)
+	writeHrefTS(&b)
 	return b.String()
 }
 
+// writeProtectedRoutesTS emits protectedRoutes, the policies guarding each
+// route RoutePolicies covers, so the UI can hide links the user would be
+// turned away from. The server enforces the policies either way.
+func writeProtectedRoutesTS(b *strings.Builder, routeDefs []RouteDef) {
+	b.WriteString("\n/** The policies guarding each protected route, from RoutePolicies. The server enforces them; use this for UI hints only. */\n")
+	b.WriteString("export const protectedRoutes: { readonly [N in RouteName]?: readonly string[] } = {")
+	wrote := false
+	for _, route := range routeDefs {
+		if len(route.Policies) == 0 {
+			continue
+		}
+		if !wrote {
+			b.WriteString("\n")
+			wrote = true
+		}
+		fmt.Fprintf(b, "  %q: [%s],\n", route.Name, quotedList(route.Policies))
+	}
+	b.WriteString("};\n")
+}
+
+// writeHrefTS emits href(), a typed link builder keyed by route name. Routes
+// without params take no second argument, so renaming a route folder or its
+// params fails type-checking at every call site.
+func writeHrefTS(b *strings.Builder) {
+	b.WriteString(`
+type HrefArgs<N extends RouteName> = RouteParams[N] extends Record<string, never>
+  ? []
+  : [params: RouteParams[N]];
+
+export function href<N extends RouteName>(name: N, ...args: HrefArgs<N>): string {
+  const route = routes[name] as { url(params?: Record<string, string>): string };
+  return route.url(args[0] as Record<string, string> | undefined);
+}
+`)
+}
+
 // GenerateRoutesGo generates the typed Go routes package.
 func GenerateRoutesGo(routeDefs []RouteDef) string {
 	var b strings.Builder
@@ -269,14 +389,15 @@ func routeRPCFuncs(rf RouteFile) []RPCFuncDef {
 	var funcs []RPCFuncDef
 	for _, fn := range rf.Funcs {
 		switch fn.Kind {
-		case RouteFuncKindQuery, RouteFuncKindMutation, RouteFuncKindAction:
+		case RouteFuncKindQuery, RouteFuncKindMutation, RouteFuncKindAction, RouteFuncKindEvents:
 			funcs = append(funcs, RPCFuncDef{
-				Name:          fn.Name,
-				Kind:          fn.Kind,
-				InputType:     fn.InputType,
-				InputIsSlice:  fn.InputIsSlice,
-				ReturnType:    fn.ReturnType,
-				ReturnIsSlice: fn.ReturnIsSlice,
+				Name:               fn.Name,
+				Kind:               fn.Kind,
+				InputType:          fn.InputType,
+				InputIsSlice:       fn.InputIsSlice,
+				ReturnType:         fn.ReturnType,
+				ReturnIsSlice:      fn.ReturnIsSlice,
+				ReturnsFieldErrors: fn.ReturnsFieldErrors,
 			})
 		}
 	}
@@ -388,11 +509,12 @@ func isTSIdentifier(name string) bool {
 
 func goLocationExpr(route RouteDef) string {
 	if route.Name == "index" {
-		return `Location("/")`
+		return "Location(" + strconv.Quote(mountedPattern(route.Prefix, "/")) + ")"
 	}
 
 	var exprs []string
 	var static strings.Builder
+	static.WriteString(route.Prefix)
 	appendStatic := func() {
 		if static.Len() == 0 {
 			return
@@ -417,11 +539,12 @@ func goLocationExpr(route RouteDef) string {
 
 func tsLocationExpr(route RouteDef) string {
 	if route.Name == "index" {
-		return `"/"`
+		return strconv.Quote(mountedPattern(route.Prefix, "/"))
 	}
 
 	var exprs []string
 	var static strings.Builder
+	static.WriteString(route.Prefix)
 	appendStatic := func() {
 		if static.Len() == 0 {
 			return
@@ -481,6 +604,24 @@ func tsRPCType(routeDir, typeName string, isSlice bool, allowVoid bool) string {
 	return Namespace(routeDir) + "." + tsType
 }
 
+// tsRPCResultType returns the TS result type of a mutation or action.
+func tsRPCResultType(routeDir string, fn RPCFuncDef) string {
+	result := tsRPCType(routeDir, fn.ReturnType, fn.ReturnIsSlice, false)
+	if fn.ReturnsFieldErrors {
+		return "ActionResult<" + result + ">"
+	}
+	return result
+}
+
+// tsRPCOptions returns the options argument of a mutation or action's
+// define call, with its leading comma, or "" when it has none.
+func tsRPCOptions(fn RPCFuncDef) string {
+	if fn.ReturnsFieldErrors {
+		return ", { fieldErrors: true }"
+	}
+	return ""
+}
+
 func goParamsMapExpr(route RouteDef) string {
 	if len(route.Params) == 0 {
 		return "map[string]string{}"
@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,9 @@ func TestBuildRouteDefs(t *testing.T) {
 			Dir: "routes/users._id",
 			Funcs: []RouteFunc{
 				{Name: "SendMessage", Kind: RouteFuncKindMutation, InputType: "SendMessageInput"},
+				{Name: "Purge", Kind: RouteFuncKindHTTP, Method: "PURGE"},
+				{Name: "DELETE", Kind: RouteFuncKindHTTP, Method: "DELETE"},
+				{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"},
 			},
 		},
 	}
@@ -48,6 +52,7 @@ func TestBuildRouteDefs(t *testing.T) {
 				{Name: "id", GoField: "Id"},
 			},
 			RPCFuncs: []RPCFuncDef{{Name: "SendMessage", Kind: RouteFuncKindMutation, InputType: "SendMessageInput"}},
+			Methods:  []string{"GET", "DELETE", "PURGE"},
 		},
 	}, got)
 }
@@ -64,16 +69,20 @@ func TestGenerateRoutesTS(t *testing.T) {
 			Params: []RouteParamDef{
 				{Name: "id", GoField: "Id"},
 			},
-			Dir: "routes/users._id",
+			Dir:     "routes/users._id",
+			Methods: []string{"POST", "PURGE"},
 			RPCFuncs: []RPCFuncDef{
 				{Name: "GetMessages", Kind: RouteFuncKindQuery, ReturnType: "GetMessagesResult"},
 				{Name: "SendMessage", Kind: RouteFuncKindMutation, InputType: "SendMessageInput"},
+				{Name: "EditMessage", Kind: RouteFuncKindAction, InputType: "EditMessageInput", ReturnType: "Message", ReturnsFieldErrors: true},
+				{Name: "Events", Kind: RouteFuncKindEvents, ReturnType: "ChatEvent"},
 			},
 		},
 	})
 
 	for _, expected := range []string{
-		`import { defineAction, defineMutation, defineQuery, useAction, useMutation, useQuery } from "./client";`,
+		`import { Form, defineAction, defineEventStream, defineMutation, defineQuery, useAction, useEventStream, useMutation, useQuery } from "./client";`,
+		`import type { ActionResult, FieldErrors } from "./client";`,
 		`export const routes = {`,
 		`"index": {`,
 		`pattern: "/",`,
@@ -81,15 +90,24 @@ func TestGenerateRoutesTS(t *testing.T) {
 		`return "/";`,
 		`"users._id": {`,
 		`pattern: "/users/{id}",`,
+		`methods: ["POST", "PURGE"] as const,`,
 		`url(params: { id: string }): string {`,
 		`return "/users/" + encodeURIComponent(params.id);`,
 		`GetMessages: defineQuery<{ id: string }, RoutesUsersId.GetMessagesResult>("users._id", "GetMessages"),`,
 		`SendMessage: defineMutation<{ id: string }, RoutesUsersId.SendMessageInput, void>("users._id", "SendMessage"),`,
-		`export { useAction, useMutation, useQuery };`,
+		`EditMessage: defineAction<{ id: string }, RoutesUsersId.EditMessageInput, ActionResult<RoutesUsersId.Message>>("users._id", "EditMessage", { fieldErrors: true }),`,
+		`Events: defineEventStream<{ id: string }, RoutesUsersId.ChatEvent>("users._id", "Events"),`,
+		`export { Form, useAction, useEventStream, useMutation, useQuery };`,
+		`export type { ActionResult, FieldErrors };`,
 		`export type RouteName = keyof typeof routes;`,
+		`export type RouteParams = {`,
+		`"index": Record<string, never>;`,
+		`"users._id": { id: string };`,
+		`export function href<N extends RouteName>(name: N, ...args: HrefArgs<N>): string {`,
 	} {
 		assert.Contains(t, got, expected, "missing %q\n\n%s", expected, got)
 	}
+	assert.Equal(t, 1, strings.Count(got, "methods:"), "routes without handlers list no methods")
 }
 
 func TestGenerateRoutesGo(t *testing.T) {
@@ -133,3 +151,59 @@ func TestGenerateRoutesGo(t *testing.T) {
 		assert.Contains(t, got, expected, "missing %q\n\n%s", expected, got)
 	}
 }
+
+func TestGenerateRoutesTS_ProtectedRoutes(t *testing.T) {
+	files := []RouteFile{
+		{Dir: ".", Package: "myapp", RoutePolicies: map[string]string{"/admin/*": "admin", "/users/{id}": "signedIn"}},
+		{Dir: "routes/admin.users", Package: "users", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}}},
+		{Dir: "routes/users._id", Package: "users", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}}},
+		{Dir: "routes/about", Package: "about", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}}},
+	}
+
+	got := GenerateRoutesTS(BuildRouteDefs(files, nil))
+
+	assert.Contains(t, got, `export const protectedRoutes: { readonly [N in RouteName]?: readonly string[] } = {
+  "admin.users": ["admin"],
+  "users._id": ["signedIn"],
+};`)
+}
+
+func TestGenerateRoutesTS_EmptyStillExportsHref(t *testing.T) {
+	got := GenerateRoutesTS(nil)
+
+	assert.Contains(t, got, "export type RouteName = never;")
+	assert.Contains(t, got, "export type RouteParams = {};")
+	assert.Contains(t, got, "export function href<N extends RouteName>(name: N, ...args: HrefArgs<N>): string {")
+}
+
+func TestGenerateRoutes_MountPrefix(t *testing.T) {
+	defs := prefixRouteDefs([]RouteDef{
+		{Name: "index", Pattern: "/"},
+		{
+			Name:    "users._id",
+			Pattern: "/users/{id}",
+			Params:  []RouteParamDef{{Name: "id", GoField: "Id"}},
+		},
+	}, "/admin")
+
+	assert.Equal(t, "/admin", defs[0].Pattern)
+	assert.Equal(t, "/admin/users/{id}", defs[1].Pattern)
+
+	goSrc := GenerateRoutesGo(defs)
+	for _, expected := range []string{
+		`func (IndexRoute) Pattern() string { return "/admin" }`,
+		`return Location("/admin")`,
+		`return Location("/admin/users/" + url.PathEscape(params.Id))`,
+	} {
+		assert.Contains(t, goSrc, expected, "missing %q\n\n%s", expected, goSrc)
+	}
+
+	tsSrc := GenerateRoutesTS(defs)
+	for _, expected := range []string{
+		`pattern: "/admin",`,
+		`return "/admin";`,
+		`return "/admin/users/" + encodeURIComponent(params.id);`,
+	} {
+		assert.Contains(t, tsSrc, expected, "missing %q\n\n%s", expected, tsSrc)
+	}
+}
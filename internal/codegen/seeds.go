@@ -0,0 +1,154 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// seedsDir holds the project's seed files. They run in file name order, so
+// prefix them with a number: 001_users.sql, 002_posts.go.
+const seedsDir = "seeds"
+
+// SeedDef is one seed codegen found in seeds/: a .sql file, or an exported
+// func(context.Context, *sql.Tx) error declared in a .go file.
+type SeedDef struct {
+	Name string // "001_users.sql" or "002_posts.go:Posts"
+	SQL  string // statements of a .sql seed
+	Func string // function name of a Go seed
+}
+
+// discoverSeeds returns the seeds in seeds/, ordered by file name and, within
+// a Go file, by declaration order. Exported functions with any other
+// signature are helpers and are skipped.
+func discoverSeeds(absRoot string) ([]SeedDef, error) {
+	dir := filepath.Join(absRoot, seedsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext == ".sql" || ext == ".go" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var seeds []SeedDef
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if filepath.Ext(name) == ".sql" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			seeds = append(seeds, SeedDef{Name: name, SQL: string(data)})
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		context, sql := importName(f, "context", "context"), importName(f, "database/sql", "sql")
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !fn.Name.IsExported() || !isSeedFunc(fn, context, sql) {
+				continue
+			}
+			seeds = append(seeds, SeedDef{Name: name + ":" + fn.Name.Name, Func: fn.Name.Name})
+		}
+	}
+	return seeds, nil
+}
+
+// isSeedFunc checks if a function declaration matches
+// func(context.Context, *sql.Tx) error, where context and sql are the names
+// its file imports those packages under, as from importName.
+func isSeedFunc(fn *ast.FuncDecl, context, sql string) bool {
+	params := paramTypes(fn.Type.Params)
+	if len(params) != 2 {
+		return false
+	}
+	if frameworkTypeName(params[0], context) != "Context" || frameworkType(params[1], sql) != "Tx" {
+		return false
+	}
+	if fn.Type.Results == nil || len(fn.Type.Results.List) != 1 {
+		return false
+	}
+	result, ok := fn.Type.Results.List[0].Type.(*ast.Ident)
+	return ok && result.Name == "error"
+}
+
+// GenerateSeedsGo produces rstf/seeds/seeds_gen.go, which lists the project's
+// seeds for the generated server's --seed flag and for tests.
+func GenerateSeedsGo(modulePath string, seeds []SeedDef) (string, error) {
+	hasGo := false
+	for _, seed := range seeds {
+		hasGo = hasGo || seed.Func != ""
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
+	b.WriteString("package seeds\n\n")
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n\n")
+	fmt.Fprintf(&b, "\trstf %q\n", frameworkModule)
+	if hasGo {
+		fmt.Fprintf(&b, "\tappseeds %q\n", modulePath+"/"+seedsDir)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// All lists the seeds in seeds/ in the order they run.\n")
+	b.WriteString("var All = []rstf.Seed{\n")
+	for _, seed := range seeds {
+		if seed.Func != "" {
+			fmt.Fprintf(&b, "\t{Name: %q, Func: appseeds.%s},\n", seed.Name, seed.Func)
+		} else {
+			fmt.Fprintf(&b, "\t{Name: %q, SQL: %q},\n", seed.Name, seed.SQL)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Run runs All against app's database. Call it from tests after\n")
+	b.WriteString("// configuring the database to start from the same data as rstf db seed.\n")
+	b.WriteString("func Run(ctx context.Context, app *rstf.App) error {\n")
+	b.WriteString("\treturn app.RunSeeds(ctx, All)\n")
+	b.WriteString("}\n")
+	return formatGoSource("seeds_gen.go", b.String())
+}
+
+// writeSeeds writes rstf/seeds/seeds_gen.go. The package is written even
+// without a seeds/ directory because the generated server imports it.
+func (g *Generator) writeSeeds() error {
+	seeds, err := discoverSeeds(g.root)
+	if err != nil {
+		return fmt.Errorf("discovering seeds: %w", err)
+	}
+	src, err := GenerateSeedsGo(g.modulePath, seeds)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(g.rstfDir, seedsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, "seeds_gen.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
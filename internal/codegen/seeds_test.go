@@ -0,0 +1,92 @@
+package codegen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverSeeds(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "seeds", "002_posts.go"), `package seeds
+
+import (
+	"context"
+	"database/sql"
+)
+
+func Posts(ctx context.Context, tx *sql.Tx) error { return nil }
+
+func Comments(ctx context.Context, tx *sql.Tx) error { return nil }
+
+func Helper(tx *sql.Tx) error { return nil }
+
+func drafts(ctx context.Context, tx *sql.Tx) error { return nil }
+`)
+	writeFile(t, filepath.Join(root, "seeds", "001_users.sql"), "INSERT INTO users (name) VALUES ('ada');\n")
+	writeFile(t, filepath.Join(root, "seeds", "003_tags.sql"), "INSERT INTO tags (name) VALUES ('go');\n")
+	writeFile(t, filepath.Join(root, "seeds", "seeds_test.go"), "package seeds\n")
+	writeFile(t, filepath.Join(root, "seeds", "README.md"), "# Seeds\n")
+
+	seeds, err := discoverSeeds(root)
+	require.NoError(t, err)
+	assert.Equal(t, []SeedDef{
+		{Name: "001_users.sql", SQL: "INSERT INTO users (name) VALUES ('ada');\n"},
+		{Name: "002_posts.go:Posts", Func: "Posts"},
+		{Name: "002_posts.go:Comments", Func: "Comments"},
+		{Name: "003_tags.sql", SQL: "INSERT INTO tags (name) VALUES ('go');\n"},
+	}, seeds)
+}
+
+func TestDiscoverSeeds_ResolvesImportNames(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "seeds", "001_posts.go"), `package seeds
+
+import (
+	stdctx "context"
+	db "database/sql"
+
+	"example.com/app/fake/sql"
+)
+
+func Posts(ctx stdctx.Context, tx *db.Tx) error { return nil }
+
+func Fake(ctx stdctx.Context, tx *sql.Tx) error { return nil }
+`)
+
+	seeds, err := discoverSeeds(root)
+	require.NoError(t, err)
+	assert.Equal(t, []SeedDef{{Name: "001_posts.go:Posts", Func: "Posts"}}, seeds)
+}
+
+func TestDiscoverSeeds_NoDir(t *testing.T) {
+	seeds, err := discoverSeeds(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, seeds)
+}
+
+func TestGenerateSeedsGo(t *testing.T) {
+	got, err := GenerateSeedsGo("example.com/app", []SeedDef{
+		{Name: "001_users.sql", SQL: "INSERT INTO users (name) VALUES ('ada');\n"},
+		{Name: "002_posts.go:Posts", Func: "Posts"},
+	})
+	require.NoError(t, err)
+
+	for _, expected := range []string{
+		"package seeds",
+		`appseeds "example.com/app/seeds"`,
+		`{Name: "001_users.sql", SQL: "INSERT INTO users (name) VALUES ('ada');\n"},`,
+		`{Name: "002_posts.go:Posts", Func: appseeds.Posts},`,
+		"func Run(ctx context.Context, app *rstf.App) error {",
+		"return app.RunSeeds(ctx, All)",
+	} {
+		assert.Contains(t, got, expected, "missing %q\n\n%s", expected, got)
+	}
+
+	got, err = GenerateSeedsGo("example.com/app", nil)
+	require.NoError(t, err)
+	assert.NotContains(t, got, "appseeds", "SQL-only projects must not import seeds/")
+	assert.Contains(t, got, "var All = []rstf.Seed{}")
+}
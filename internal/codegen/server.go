@@ -1,11 +1,18 @@
 package codegen
 
 import (
+	"errors"
 	"fmt"
+	"go/format"
+	"go/scanner"
+	"path"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rafbgarcia/rstf/internal/conventions"
+	"github.com/rafbgarcia/rstf/internal/release"
 )
 
 // frameworkModule is the import path of the rstf framework itself.
@@ -13,32 +20,119 @@ const frameworkModule = "github.com/rafbgarcia/rstf"
 
 // serverImport tracks a user-package import for the generated server file.
 type serverImport struct {
-	Alias      string // Go import alias (e.g. "app", "dashboard")
-	ImportPath string // full import path
-	Dir        string // project-relative dir (e.g. ".", "routes/dashboard")
-	HasContext bool   // whether SSR() takes *rstf.Context
-	HasSSR     bool   // whether the package exports SSR.
+	Alias      string      // Go import alias (e.g. "app", "dashboard")
+	ImportPath string      // full import path
+	Dir        string      // project-relative dir (e.g. ".", "routes/dashboard")
+	DataFuncs  []RouteFunc // SSR data functions, SSR first (see RouteFile.SSRDataFuncs).
 }
 
 // routeEntry pairs a route directory with its computed URL pattern and handlers.
 type routeEntry struct {
-	dir           string
-	urlPattern    string
-	hasComponent  bool
-	hasSSR        bool
-	hasGET        bool
-	hasPOST       bool
-	hasPUT        bool
-	hasPATCH      bool
-	hasDELETE     bool
-	ssrHasContext bool
-	rpcFuncs      []RouteFunc
+	dir            string
+	urlPattern     string
+	bundlePath     string
+	hasComponent   bool
+	hasSSR         bool
+	hasGET         bool
+	hasPOST        bool
+	hasPUT         bool
+	hasPATCH       bool
+	hasDELETE      bool
+	customMethods  []RouteFunc // handlers annotated with //rstf:method
+	ssrHasContext  bool
+	hasMeta        bool
+	metaHasContext bool
+	noLayout       bool
+	rpcFuncs       []RouteFunc
+	policies       []string // names of the policies guarding the route, see matchPolicies
+}
+
+// MountedApp is a project the generated server serves under a URL prefix, as
+// configured by the mounts in rstf.json. Its file and route dirs are relative
+// to Dir.
+type MountedApp struct {
+	Prefix     string // URL prefix, e.g. "/admin"
+	Dir        string // project directory relative to the host project root
+	ModulePath string // Go import path of Dir
+	Files      []RouteFile
+	Deps       map[string][]string
+	EntryOpts  map[string]EntryOptions
+}
+
+// serverApp is one app the generated server serves: the project itself, or a
+// project mounted under a URL prefix.
+type serverApp struct {
+	prefix    string // URL prefix, "" for the project itself
+	dir       string // app directory relative to the project root
+	ident     string // suffix of the app's generated identifiers, "" for the project itself
+	layout    RouteFile
+	hasLayout bool
+	routes    []routeEntry
+	aliasMap  map[string]serverImport
+	deps      map[string][]string
 }
 
 // GenerateServer produces the content of rstf/server_gen.go — the Go entry
 // point that wires routes to handlers, calls route functions, and renders via
-// the embedded JavaScript runtime.
-func GenerateServer(modulePath string, files []RouteFile, deps map[string][]string) (string, error) {
+// the embedded JavaScript runtime. entryOpts holds the per-route entry options
+// the hydration and SSR entries were generated with. Each mount is served
+// under its prefix with its own App, renderer, and live query hub.
+func GenerateServer(modulePath string, files []RouteFile, deps map[string][]string, entryOpts map[string]EntryOptions, mounts ...MountedApp) (string, error) {
+	usedAliases := map[string]int{}
+	app, imports, err := newServerApp(modulePath, "", ".", files, deps, entryOpts, usedAliases)
+	if err != nil {
+		return "", err
+	}
+
+	var mounted []serverApp
+	idents := map[string]string{}
+	for _, m := range mounts {
+		mountedApp, mountImports, err := newServerApp(m.ModulePath, m.Prefix, m.Dir, m.Files, m.Deps, m.EntryOpts, usedAliases)
+		if err != nil {
+			return "", err
+		}
+		if other, ok := idents[mountedApp.ident]; ok {
+			return "", fmt.Errorf("mounts %s and %s both generate the Go identifier %s; rename one of them", other, m.Prefix, mountedApp.ident)
+		}
+		idents[mountedApp.ident] = m.Prefix
+		mounted = append(mounted, mountedApp)
+		imports = append(imports, mountImports...)
+	}
+
+	imports = append(imports, serverImport{Alias: "rstfseeds", ImportPath: modulePath + "/rstf/" + seedsDir})
+
+	var b strings.Builder
+	writeHeader(&b)
+	writeImports(&b, imports)
+	writeAcceptHelpers(&b)
+	writeServerDataProps(&b)
+	writeAssemblePage(&b)
+	writeRequestHelpers(&b)
+	writeRPCHelpers(&b)
+	writeRPCDispatchers(&b, app)
+	for _, mountedApp := range mounted {
+		writeRPCDispatchers(&b, mountedApp)
+	}
+	writeResponseHelpers(&b)
+	if len(mounted) > 0 {
+		writeMountHelpers(&b)
+	}
+	for _, mountedApp := range mounted {
+		writeMountedApp(&b, mountedApp)
+	}
+	writeMain(&b, app, mounted)
+	return formatGoSource("server_gen.go", b.String())
+}
+
+// newServerApp collects the routes and imports of the app in dir. Import
+// aliases are unique across all apps sharing usedAliases.
+func newServerApp(
+	modulePath, prefix, dir string,
+	files []RouteFile,
+	deps map[string][]string,
+	entryOpts map[string]EntryOptions,
+	usedAliases map[string]int,
+) (serverApp, []serverImport, error) {
 	fileMap := map[string]RouteFile{}
 	for _, f := range files {
 		fileMap[f.Dir] = f
@@ -47,8 +141,9 @@ func GenerateServer(modulePath string, files []RouteFile, deps map[string][]stri
 	layout, hasLayout := fileMap["."]
 
 	if hasLayout && layout.Package == "main" {
-		return "", fmt.Errorf(
-			"main.go: package main is reserved for rstf, please use a different package name (e.g. your app name)",
+		return serverApp{}, nil, fmt.Errorf(
+			"%s: package main is reserved for rstf, please use a different package name (e.g. your app name)",
+			path.Join(dir, "main.go"),
 		)
 	}
 
@@ -60,26 +155,34 @@ func GenerateServer(modulePath string, files []RouteFile, deps map[string][]stri
 		folder := strings.TrimPrefix(f.Dir, "routes/")
 		e := routeEntry{
 			dir:        f.Dir,
-			urlPattern: conventions.FolderToURLPattern(folder),
+			urlPattern: mountedPattern(prefix, conventions.FolderToURLPattern(folder)),
 		}
 		for _, fn := range f.Funcs {
-			switch fn.Name {
-			case "SSR":
-				e.hasSSR = true
-				e.ssrHasContext = fn.HasContext
-			case "GET":
-				e.hasGET = true
-			case "POST":
-				e.hasPOST = true
-			case "PUT":
-				e.hasPUT = true
-			case "PATCH":
-				e.hasPATCH = true
-			case "DELETE":
-				e.hasDELETE = true
-			}
 			switch fn.Kind {
-			case RouteFuncKindQuery, RouteFuncKindMutation, RouteFuncKindAction:
+			case RouteFuncKindSSR:
+				if fn.Name == "SSR" {
+					e.hasSSR = true
+					e.ssrHasContext = fn.HasContext
+				}
+			case RouteFuncKindHTTP:
+				switch fn.Method {
+				case "GET":
+					e.hasGET = true
+				case "POST":
+					e.hasPOST = true
+				case "PUT":
+					e.hasPUT = true
+				case "PATCH":
+					e.hasPATCH = true
+				case "DELETE":
+					e.hasDELETE = true
+				default:
+					e.customMethods = append(e.customMethods, fn)
+				}
+			case RouteFuncKindMeta:
+				e.hasMeta = true
+				e.metaHasContext = fn.HasContext
+			case RouteFuncKindQuery, RouteFuncKindMutation, RouteFuncKindAction, RouteFuncKindEvents:
 				e.rpcFuncs = append(e.rpcFuncs, fn)
 			}
 		}
@@ -93,53 +196,104 @@ func GenerateServer(modulePath string, files []RouteFile, deps map[string][]stri
 		e := routeMap[routeDir]
 		if e.dir == "" {
 			folder := strings.TrimPrefix(routeDir, "routes/")
-			e = routeEntry{dir: routeDir, urlPattern: conventions.FolderToURLPattern(folder)}
+			e = routeEntry{dir: routeDir, urlPattern: mountedPattern(prefix, conventions.FolderToURLPattern(folder))}
 		}
 		e.hasComponent = true
 		routeMap[routeDir] = e
 	}
 
 	var routes []routeEntry
+	matchedPolicies := map[string]bool{}
 	for _, e := range routeMap {
+		e.bundlePath = prefix + bundlePath(e.dir)
+		e.noLayout = entryOpts[e.dir].NoLayout
+		e.policies = matchPolicies(layout.RoutePolicies, routePatternForDir(e.dir), matchedPolicies)
 		routes = append(routes, e)
 	}
+	if err := checkRoutePolicies(layout.RoutePolicies, matchedPolicies); err != nil {
+		return serverApp{}, nil, fmt.Errorf("%s: %w", dir, err)
+	}
 	sort.Slice(routes, func(i, j int) bool {
-		return routes[i].urlPattern < routes[j].urlPattern
+		if routes[i].urlPattern != routes[j].urlPattern {
+			return routes[i].urlPattern < routes[j].urlPattern
+		}
+		return routes[i].dir < routes[j].dir
 	})
 
-	imports := collectImports(modulePath, layout, hasLayout, routes, deps, fileMap)
+	layoutAlias := "app"
+	if prefix != "" {
+		layoutAlias = layout.Package
+	}
+	imports := collectImports(modulePath, layoutAlias, layout, hasLayout, routes, deps, fileMap, usedAliases)
 
 	aliasMap := map[string]serverImport{}
 	for _, imp := range imports {
 		aliasMap[imp.Dir] = imp
 	}
 
-	var b strings.Builder
-	writeHeader(&b)
-	writeImports(&b, imports)
-	writeAcceptHelpers(&b)
-	writeStructToMap(&b)
-	writeAssemblePage(&b)
-	writeRequestHelpers(&b)
-	writeRPCHelpers(&b)
-	writeRPCDispatchers(&b, routes, aliasMap)
-	writeResponseHelpers(&b)
-	writeMain(&b, routes, layout, hasLayout, aliasMap, deps)
-	return b.String(), nil
+	var ident string
+	if prefix != "" {
+		ident = goExportedName(prefix)
+	}
+	return serverApp{
+		prefix:    prefix,
+		dir:       dir,
+		ident:     ident,
+		layout:    layout,
+		hasLayout: hasLayout,
+		routes:    routes,
+		aliasMap:  aliasMap,
+		deps:      deps,
+	}, imports, nil
+}
+
+// mountedPattern prefixes a route pattern with a mount's URL prefix. The index
+// route of a mounted app is served at the bare prefix.
+//
+//	"", "/users"       → "/users"
+//	"/admin", "/users" → "/admin/users"
+//	"/admin", "/"      → "/admin"
+func mountedPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+	if pattern == "/" {
+		return prefix
+	}
+	return prefix + pattern
+}
+
+// formatGoSource gofmts generated Go code. It fails when the generator emitted
+// invalid Go, quoting the offending line, so the bug surfaces at codegen time
+// instead of when server_gen.go is compiled.
+func formatGoSource(name, src string) (string, error) {
+	out, err := format.Source([]byte(src))
+	if err == nil {
+		return string(out), nil
+	}
+	var errs scanner.ErrorList
+	if errors.As(err, &errs) && len(errs) > 0 {
+		lines := strings.Split(src, "\n")
+		if line := errs[0].Pos.Line; line > 0 && line <= len(lines) {
+			return "", fmt.Errorf("generated %s is not valid Go: %w\n\t%d: %s", name, err, line, strings.TrimSpace(lines[line-1]))
+		}
+	}
+	return "", fmt.Errorf("generated %s is not valid Go: %w", name, err)
 }
 
 // collectImports gathers all unique user-package imports across the layout and
 // all routes, assigning collision-free aliases.
 func collectImports(
 	modulePath string,
+	layoutAlias string,
 	layout RouteFile,
 	hasLayout bool,
 	routes []routeEntry,
 	deps map[string][]string,
 	fileMap map[string]RouteFile,
+	usedAliases map[string]int,
 ) []serverImport {
 	seen := map[string]bool{}
-	usedAliases := map[string]int{}
 	var imports []serverImport
 
 	addImport := func(dir string) {
@@ -157,7 +311,7 @@ func collectImports(
 		var baseAlias string
 		if dir == "." {
 			importPath = modulePath
-			baseAlias = "app"
+			baseAlias = layoutAlias
 		} else {
 			importPath = modulePath + "/" + dir
 			baseAlias = rf.Package
@@ -169,22 +323,11 @@ func collectImports(
 		}
 		usedAliases[baseAlias]++
 
-		hasCtx := false
-		hasSSR := false
-		for _, fn := range rf.Funcs {
-			if fn.Name == "SSR" {
-				hasSSR = true
-				hasCtx = fn.HasContext
-				break
-			}
-		}
-
 		imports = append(imports, serverImport{
 			Alias:      alias,
 			ImportPath: importPath,
 			Dir:        dir,
-			HasContext: hasCtx,
-			HasSSR:     hasSSR,
+			DataFuncs:  rf.SSRDataFuncs(),
 		})
 	}
 
@@ -210,17 +353,20 @@ func writeHeader(b *strings.Builder) {
 func writeImports(b *strings.Builder, imports []serverImport) {
 	b.WriteString("import (\n")
 	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"crypto/sha256\"\n")
 	b.WriteString("\t\"encoding/json\"\n")
 	b.WriteString("\t\"flag\"\n")
 	b.WriteString("\t\"fmt\"\n")
 	b.WriteString("\t\"io\"\n")
 	b.WriteString("\t\"mime\"\n")
+	b.WriteString("\t\"net\"\n")
 	b.WriteString("\t\"net/http\"\n")
 	b.WriteString("\t\"os\"\n")
 	b.WriteString("\t\"os/signal\"\n")
 	b.WriteString("\t\"strconv\"\n")
 	b.WriteString("\t\"strings\"\n")
 	b.WriteString("\t\"syscall\"\n")
+	b.WriteString("\t\"time\"\n")
 	b.WriteString("\n")
 	fmt.Fprintf(b, "\trstf %q\n", frameworkModule)
 	fmt.Fprintf(b, "\t%q\n", frameworkModule+"/renderer")
@@ -277,6 +423,24 @@ func writeAcceptHelpers(b *strings.Builder) {
 	return hasWildcard
 }
 
+// acceptsJSON reports whether accept lists application/json with a nonzero
+// quality.
+func acceptsJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.EqualFold(mediaType, "application/json") {
+			continue
+		}
+		if qRaw, ok := params["q"]; ok {
+			if q, err := strconv.ParseFloat(qRaw, 64); err == nil && q <= 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func allowHeader(methods []string) string {
 	return strings.Join(methods, ", ")
 }
@@ -289,52 +453,169 @@ func methodNotAllowed(w http.ResponseWriter, methods []string) {
 	b.WriteString("\n")
 }
 
-func writeStructToMap(b *strings.Builder) {
-	b.WriteString(`func structToMap(v any) map[string]any {
-	b, err := json.Marshal(v)
+func writeServerDataProps(b *strings.Builder) {
+	b.WriteString(`// serverDataProps converts a data function's result for sd, recording a
+// result that cannot be serialized in errs.
+func serverDataProps(errs *[]error, key string, v any) map[string]any {
+	props, err := rstf.ServerDataProps(v)
 	if err != nil {
-		return map[string]any{}
-	}
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
-		return map[string]any{}
-	}
-	if m == nil {
-		return map[string]any{}
+		*errs = append(*errs, &rstf.ServerDataError{Key: key, Err: err})
 	}
-	return m
+	return props
 }`)
 	b.WriteString("\n\n")
 }
 
 func writeAssemblePage(b *strings.Builder) {
-	b.WriteString(`func assemblePage(html string, ssrProps map[string]map[string]any, bundlePath string, cssPath string) string {
+	b.WriteString(`// inlineCriticalCSS is set to "true" by rstf build (-ldflags -X) when
+// rstf.json enables build.inlineCriticalCSS.
+var inlineCriticalCSS string
+
+// assetBaseURL is set by rstf build (-ldflags -X) to rstf.json's
+// build.assetBaseURL, the CDN origin pages link scripts and stylesheets from.
+// It is empty in dev, where assets load from this server.
+var assetBaseURL string
+
+// pageStyles lists the stylesheets a page can link: main.css and the CSS
+// esbuild extracts from each route's imports, keyed by bundle path.
+type pageStyles struct {
+	global   string
+	routes   map[string]string
+	all      []string
+	critical map[string]*rstf.CriticalCSS
+}
+
+// loadPageStyles records which stylesheets were built into staticDir, which
+// is served at staticURL. Route stylesheets sit next to their bundle
+// (rstf/static/{name}/bundle.css).
+func loadPageStyles(staticDir, staticURL string, bundlePaths []string) pageStyles {
+	styles := pageStyles{routes: map[string]string{}, critical: map[string]*rstf.CriticalCSS{}}
+	file := func(url string) string {
+		return staticDir + strings.TrimPrefix(url, staticURL)
+	}
+	if _, err := os.Stat(staticDir + "/main.css"); err == nil {
+		styles.global = staticURL + "/main.css"
+	}
+	for _, bundlePath := range bundlePaths {
+		cssPath := strings.TrimSuffix(bundlePath, ".js") + ".css"
+		if _, err := os.Stat(file(cssPath)); err == nil {
+			styles.routes[bundlePath] = cssPath
+			styles.all = append(styles.all, cssPath)
+		}
+	}
+	if inlineCriticalCSS == "true" {
+		for _, cssPath := range append([]string{styles.global}, styles.all...) {
+			if cssPath == "" {
+				continue
+			}
+			if css, err := os.ReadFile(file(cssPath)); err == nil {
+				styles.critical[cssPath] = rstf.ParseCriticalCSS(string(css))
+			}
+		}
+	}
+	return styles
+}
+
+// stylesheetTag links cssPath. With critical CSS enabled it inlines the rules
+// the page uses instead and loads the full stylesheet without blocking render.
+func (s pageStyles) stylesheetTag(cssPath string, html string) string {
+	href := assetBaseURL + cssPath
+	critical, ok := s.critical[cssPath]
+	if !ok {
+		return "<link rel=\"stylesheet\" href=\"" + href + "\">\n"
+	}
+	return "<style>" + critical.Extract(html) + "</style>\n" +
+		"<link rel=\"preload\" as=\"style\" href=\"" + href + "\" onload=\"this.onload=null;this.rel='stylesheet'\">\n" +
+		"<noscript><link rel=\"stylesheet\" href=\"" + href + "\"></noscript>\n"
+}
+
+// assemblePage links main.css and the rendered route's stylesheet, and
+// prefetches the other routes' stylesheets so the next page load finds them
+// cached. The App's PageShell places them in the document.
+func assemblePage(rstfApp *rstf.App, req *http.Request, html string, ssrProps map[string]map[string]any, bundlePath string, styles pageStyles) string {
 	sdJSON, err := json.Marshal(ssrProps)
 	if err != nil {
 		sdJSON = []byte("{}")
 	}
 	dataScript := "<script>window.__RSTF_SSR_PROPS__ = " + string(sdJSON) + "</script>"
-	bundleScript := "<script src=\"" + bundlePath + "\"></script>"
-	page := "<!DOCTYPE html>" + html
-	if cssPath != "" {
-		page = strings.Replace(page, "</head>", "<link rel=\"stylesheet\" href=\""+cssPath+"\">\n</head>", 1)
+	bundleScript := "<script src=\"" + assetBaseURL + bundlePath + "\"></script>"
+
+	var links strings.Builder
+	if styles.global != "" {
+		links.WriteString(styles.stylesheetTag(styles.global, html))
+	}
+	routeCSS := styles.routes[bundlePath]
+	if routeCSS != "" {
+		links.WriteString(styles.stylesheetTag(routeCSS, html))
 	}
-	page = strings.Replace(page, "</body>", dataScript+bundleScript+"</body>", 1)
-	return page
+	for _, cssPath := range styles.all {
+		if cssPath != routeCSS {
+			links.WriteString("<link rel=\"prefetch\" as=\"style\" href=\"" + assetBaseURL + cssPath + "\">\n")
+		}
+	}
+	return rstfApp.AssemblePage(rstf.PageParts{
+		HTML:    html,
+		Styles:  links.String(),
+		Scripts: dataScript + bundleScript,
+		Request: req,
+	})
 }`)
 	b.WriteString("\n\n")
 }
 
 func writeRequestHelpers(b *strings.Builder) {
-	b.WriteString(`func newRequestContext(req *http.Request, rstfApp *rstf.App) (*rstf.Context, error) {
-	ctx := rstf.NewContext(req)
-	ctx.DB = rstfApp.DB()
+	b.WriteString(`// reportServerError passes err to the app's OnError handlers with the
+// request's Context and answers the request with a 500.
+func reportServerError(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, err error) {
+	rstfApp.ReportError(rstf.ContextFromRequest(req), err, nil)
+	rstfApp.WriteServerError(w, req, err, nil)
+}
+
+// newRequestContext returns the Context for req. The one the route's
+// lifecycle handler created is reused, so the route's policies, handlers, and
+// hooks all see what the others set on it.
+func newRequestContext(req *http.Request, rstfApp *rstf.App) (*rstf.Context, error) {
+	ctx := rstf.ContextFromRequest(req)
+	if ctx == nil {
+		ctx = rstf.NewContext(req)
+	}
+	db, err := rstfApp.TenantDB(ctx.Tenant())
+	if err != nil {
+		return nil, err
+	}
+	ctx.DB = db
+	ctx.App = rstfApp
 	if err := ctx.SetRequestBodyLimitBytes(rstfApp.RequestBodyLimitBytes()); err != nil {
 		return nil, err
 	}
 	return ctx, nil
 }
 
+// authorizeRoute runs a route's policies before its handler and answers the
+// request when one rejects it. It returns the request the handler goes on
+// with, which a policy may have replaced on the Context, or nil if rejected.
+func authorizeRoute(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, policies ...string) *http.Request {
+	ctx, err := newRequestContext(req, rstfApp)
+	if err != nil {
+		reportServerError(w, req, rstfApp, err)
+		return nil
+	}
+	if !rstfApp.AuthorizeRequest(w, ctx, policies...) {
+		return nil
+	}
+	return ctx.Request
+}
+
+// authorizeRPC runs a route's policies before one of its RPC functions, with
+// params as the request's path values.
+func authorizeRPC(req *http.Request, rstfApp *rstf.App, params map[string]string, policies ...string) error {
+	ctx, err := newRequestContext(cloneRequestWithParams(req, params), rstfApp)
+	if err != nil {
+		return err
+	}
+	return rstfApp.Authorize(ctx, policies...)
+}
+
 func invokeRouteAction(
 	w http.ResponseWriter,
 	req *http.Request,
@@ -346,7 +627,7 @@ func invokeRouteAction(
 	ctx, err := newRequestContext(req, rstfApp)
 	if err != nil {
 		if !tracker.Written() {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			reportServerError(w, req, rstfApp, err)
 		}
 		return
 	}
@@ -414,7 +695,7 @@ func decodeJSONBody(req *http.Request, target any) error {
 }
 
 func cloneRequestWithParams(req *http.Request, params map[string]string) *http.Request {
-	cloned := req.Clone(context.Background())
+	cloned := req.Clone(context.WithoutCancel(req.Context()))
 	cloned.Body = http.NoBody
 	for key, value := range params {
 		cloned.SetPathValue(key, value)
@@ -423,8 +704,14 @@ func cloneRequestWithParams(req *http.Request, params map[string]string) *http.R
 }
 
 func writeRPCSuccess(w http.ResponseWriter, payload any) {
+	data, err := rstf.FormatFields(payload)
+	if err != nil {
+		rstf.WriteErrorEnvelope(w, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"data": payload})
+	w.WriteHeader(rstf.RPCResultStatus(payload))
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
 }
 
 func writeSSE(w http.ResponseWriter, event rstf.LiveEvent) error {
@@ -454,7 +741,23 @@ func writeNotAcceptable(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotAcceptable)
 }
 
+func serverTimingHeader(ssrData, render, assemble time.Duration) string {
+	return fmt.Sprintf(
+		"ssr-data;dur=%.3f, render;dur=%.3f, assemble;dur=%.3f",
+		float64(ssrData)/float64(time.Millisecond),
+		float64(render)/float64(time.Millisecond),
+		float64(assemble)/float64(time.Millisecond),
+	)
+}
+
+// logRenderTiming prints how long a page spent loading data and rendering,
+// so a slow route shows which of the two to look at.
+func logRenderTiming(req *http.Request, ssrData, render time.Duration) {
+	fmt.Fprintf(os.Stderr, "  %s %s  data %s  render %s\n", req.Method, req.URL.Path, ssrData.Round(time.Microsecond), render.Round(time.Microsecond))
+}
+
 func writeHTMLResponse(w http.ResponseWriter, page string, head bool) {
+	w.Header().Add("Vary", "Accept")
 	if head {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.Header().Set("Content-Length", strconv.Itoa(len(page)))
@@ -463,30 +766,67 @@ func writeHTMLResponse(w http.ResponseWriter, page string, head bool) {
 	}
 	fmt.Fprint(w, page)
 }
+
+// writeServerData responds with a route's server data instead of its HTML.
+// The ETag lets clients revalidate cheaply; unless a data function set a
+// cache policy, private, no-cache keeps shared caches out since the data is
+// per request.
+func writeServerData(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, sd map[string]map[string]any, head bool) {
+	body, err := json.Marshal(sd)
+	if err != nil {
+		reportServerError(w, req, rstfApp, err)
+		return
+	}
+	etag := fmt.Sprintf("\"%x\"", sha256.Sum256(body))
+	w.Header().Set("Content-Type", "application/json")
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if head {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Write(body)
+}
 `)
 	b.WriteString("\n")
 }
 
-func writeRPCDispatchers(b *strings.Builder, routes []routeEntry, aliasMap map[string]serverImport) {
-	writeExecuteQuery(b, routes, aliasMap)
-	writeExecuteMutationOrAction(b, routes, aliasMap)
+func writeRPCDispatchers(b *strings.Builder, app serverApp) {
+	writeExecuteQuery(b, app)
+	writeExecuteMutationOrAction(b, app)
+	writeStreamEvents(b, app)
 }
 
-func writeExecuteQuery(b *strings.Builder, routes []routeEntry, aliasMap map[string]serverImport) {
-	b.WriteString(`func executeQuery(req *http.Request, rstfApp *rstf.App, routeName string, fnName string, params map[string]string) (any, error) {
+func writeExecuteQuery(b *strings.Builder, app serverApp) {
+	aliasMap := app.aliasMap
+	fmt.Fprintf(b, `func executeQuery%s(req *http.Request, rstfApp *rstf.App, routeName string, fnName string, params map[string]string) (any, error) {
 	switch routeName {
-`)
-	for _, route := range routes {
+`, app.ident)
+	for _, route := range app.routes {
 		queryFuncs := rpcFuncsByKind(route.rpcFuncs, RouteFuncKindQuery)
 		if len(queryFuncs) == 0 {
 			continue
 		}
 		alias := aliasMap[route.dir].Alias
 		fmt.Fprintf(b, "\tcase %q:\n", routeNameForDir(route.dir))
+		writeRPCPolicyCheck(b, route, "nil, err")
 		b.WriteString("\t\tswitch fnName {\n")
 		for _, fn := range queryFuncs {
 			fmt.Fprintf(b, "\t\tcase %q:\n", fn.Name)
-			b.WriteString("\t\t\tctx := rstf.NewQueryContext(cloneRequestWithParams(req, params), rstfApp.DB(), rstfApp.RequestBodyLimitBytes())\n")
+			b.WriteString("\t\t\tdb, err := rstfApp.TenantDB(rstf.TenantFromRequest(req))\n")
+			b.WriteString("\t\t\tif err != nil {\n")
+			b.WriteString("\t\t\t\treturn nil, err\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString("\t\t\tctx := rstf.NewQueryContext(cloneRequestWithParams(req, params), db, rstfApp.RequestBodyLimitBytes())\n")
+			b.WriteString("\t\t\tctx.App = rstfApp\n")
 			if returnsErrorOnly(fn) {
 				fmt.Fprintf(b, "\t\t\tif err := %s.%s(ctx); err != nil {\n", alias, fn.Name)
 				b.WriteString("\t\t\t\treturn nil, err\n")
@@ -511,26 +851,29 @@ func writeExecuteQuery(b *strings.Builder, routes []routeEntry, aliasMap map[str
 `)
 }
 
-func writeExecuteMutationOrAction(b *strings.Builder, routes []routeEntry, aliasMap map[string]serverImport) {
-	b.WriteString(`func executeMutationOrAction(
+func writeExecuteMutationOrAction(b *strings.Builder, app serverApp) {
+	aliasMap := app.aliasMap
+	fmt.Fprintf(b, `func executeMutationOrAction%s(
+	w http.ResponseWriter,
 	req *http.Request,
 	rstfApp *rstf.App,
 	routeName string,
 	fnName string,
 	kind string,
 	params map[string]string,
-	input json.RawMessage,
+	input rstf.RPCInput,
 	liveHub *rstf.LiveHub,
 ) (any, error) {
 	switch routeName {
-`)
-	for _, route := range routes {
+`, app.ident)
+	for _, route := range app.routes {
 		rpcFuncs := rpcFuncsByKinds(route.rpcFuncs, RouteFuncKindMutation, RouteFuncKindAction)
 		if len(rpcFuncs) == 0 {
 			continue
 		}
 		alias := aliasMap[route.dir].Alias
 		fmt.Fprintf(b, "\tcase %q:\n", routeNameForDir(route.dir))
+		writeRPCPolicyCheck(b, route, "nil, err")
 		b.WriteString("\t\tswitch fnName {\n")
 		for _, fn := range rpcFuncs {
 			fmt.Fprintf(b, "\t\tcase %q:\n", fn.Name)
@@ -538,21 +881,31 @@ func writeExecuteMutationOrAction(b *strings.Builder, routes []routeEntry, alias
 			b.WriteString("\t\t\t\treturn nil, &rstf.RequestError{Code: rstf.ErrorCodeInvalidPayload, Message: \"rpc kind mismatch\", Status: http.StatusBadRequest}\n")
 			b.WriteString("\t\t\t}\n")
 			if fn.Kind == RouteFuncKindMutation {
-				b.WriteString("\t\t\tctx := rstf.NewMutationContext(cloneRequestWithParams(req, params), rstfApp.DB(), rstfApp.RequestBodyLimitBytes(), liveHub.Invalidate)\n")
+				b.WriteString("\t\t\tdb, err := rstfApp.TenantDB(rstf.TenantFromRequest(req))\n")
+				b.WriteString("\t\t\tif err != nil {\n")
+				b.WriteString("\t\t\t\treturn nil, err\n")
+				b.WriteString("\t\t\t}\n")
+				b.WriteString("\t\t\tctx := rstf.NewMutationContext(cloneRequestWithParams(req, params), db, rstfApp.RequestBodyLimitBytes(), liveHub.Invalidate)\n")
+				b.WriteString("\t\t\tctx.App = rstfApp\n")
 			} else {
 				b.WriteString("\t\t\tctx := rstf.NewActionContext(cloneRequestWithParams(req, params), rstfApp.RequestBodyLimitBytes())\n")
+				b.WriteString("\t\t\tctx.App = rstfApp\n")
 			}
+			b.WriteString("\t\t\tdefer ctx.WriteHeaders(w)\n")
 			writeInputDecodeBlock(b, fn, alias)
 			switch {
+			case fn.ReturnsFieldErrors:
+				fmt.Fprintf(b, "\t\t\tresult, fieldErrors := %s.%s(%s)\n", alias, fn.Name, rpcCallArgs(fn))
+				b.WriteString("\t\t\treturn rstf.NewActionResult(result, fieldErrors), nil\n")
 			case returnsErrorOnly(fn):
-				fmt.Fprintf(b, "\t\t\tif err := %s.%s(ctx%s); err != nil {\n", alias, fn.Name, callInputSuffix(fn))
+				fmt.Fprintf(b, "\t\t\tif err := %s.%s(%s); err != nil {\n", alias, fn.Name, rpcCallArgs(fn))
 				b.WriteString("\t\t\t\treturn nil, err\n")
 				b.WriteString("\t\t\t}\n")
 				b.WriteString("\t\t\treturn nil, nil\n")
 			case returnsDataAndError(fn):
-				fmt.Fprintf(b, "\t\t\treturn %s.%s(ctx%s)\n", alias, fn.Name, callInputSuffix(fn))
+				fmt.Fprintf(b, "\t\t\treturn %s.%s(%s)\n", alias, fn.Name, rpcCallArgs(fn))
 			default:
-				fmt.Fprintf(b, "\t\t\treturn %s.%s(ctx%s), nil\n", alias, fn.Name, callInputSuffix(fn))
+				fmt.Fprintf(b, "\t\t\treturn %s.%s(%s), nil\n", alias, fn.Name, rpcCallArgs(fn))
 			}
 		}
 		b.WriteString("\t\t}\n")
@@ -568,24 +921,80 @@ func writeExecuteMutationOrAction(b *strings.Builder, routes []routeEntry, alias
 `)
 }
 
+// writeStreamEvents writes the dispatcher for event stream functions. params
+// become the request's path values, like for RPC calls, but the request keeps
+// its context so the stream ends when the client disconnects.
+func writeStreamEvents(b *strings.Builder, app serverApp) {
+	aliasMap := app.aliasMap
+	fmt.Fprintf(b, `func streamEvents%s(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, routeName string, fnName string, params map[string]string) error {
+	for key, value := range params {
+		req.SetPathValue(key, value)
+	}
+	switch routeName {
+`, app.ident)
+	for _, route := range app.routes {
+		eventFuncs := rpcFuncsByKind(route.rpcFuncs, RouteFuncKindEvents)
+		if len(eventFuncs) == 0 {
+			continue
+		}
+		alias := aliasMap[route.dir].Alias
+		fmt.Fprintf(b, "\tcase %q:\n", routeNameForDir(route.dir))
+		writeRPCPolicyCheck(b, route, "err")
+		b.WriteString("\t\tswitch fnName {\n")
+		for _, fn := range eventFuncs {
+			fmt.Fprintf(b, "\t\tcase %q:\n", fn.Name)
+			b.WriteString("\t\t\tctx, err := newRequestContext(req, rstfApp)\n")
+			b.WriteString("\t\t\tif err != nil {\n")
+			b.WriteString("\t\t\t\treturn err\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString("\t\t\tctx.Writer = w\n")
+			fmt.Fprintf(b, "\t\t\treturn rstf.ServeEvents(ctx, %s.%s)\n", alias, fn.Name)
+		}
+		b.WriteString("\t\t}\n")
+	}
+	b.WriteString(`	}
+	return &rstf.RequestError{
+		Code:    rstf.ErrorCodeInvalidPayload,
+		Message: "unknown event stream",
+		Status:  http.StatusNotFound,
+	}
+}
+
+`)
+}
+
+// writeRPCPolicyCheck runs the route's policies at the top of its case in an
+// RPC dispatcher, returning ret when one rejects the call.
+func writeRPCPolicyCheck(b *strings.Builder, route routeEntry, ret string) {
+	if len(route.policies) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\t\tif err := authorizeRPC(req, rstfApp, params, %s); err != nil {\n", quotedList(route.policies))
+	fmt.Fprintf(b, "\t\t\treturn %s\n", ret)
+	b.WriteString("\t\t}\n")
+}
+
 func writeInputDecodeBlock(b *strings.Builder, fn RouteFunc, alias string) {
 	if fn.InputType == "" {
 		return
 	}
 	fmt.Fprintf(b, "\t\t\tvar inputValue %s\n", fnInputGoType(fn, alias))
-	b.WriteString("\t\t\tif len(input) == 0 {\n")
-	b.WriteString("\t\t\t\treturn nil, &rstf.RequestError{Code: rstf.ErrorCodeInvalidPayload, Message: \"input is required\", Status: http.StatusBadRequest}\n")
-	b.WriteString("\t\t\t}\n")
-	b.WriteString("\t\t\tif err := json.Unmarshal(input, &inputValue); err != nil {\n")
-	b.WriteString("\t\t\t\treturn nil, &rstf.RequestError{Code: rstf.ErrorCodeInvalidPayload, Message: \"invalid rpc input\", Status: http.StatusBadRequest}\n")
+	b.WriteString("\t\t\tif err := input.Decode(&inputValue); err != nil {\n")
+	b.WriteString("\t\t\t\treturn nil, err\n")
 	b.WriteString("\t\t\t}\n")
 }
 
-func callInputSuffix(fn RouteFunc) string {
-	if fn.InputType == "" {
-		return ""
+// rpcCallArgs returns the arguments of an RPC function call, in the order the
+// function declares its parameters.
+func rpcCallArgs(fn RouteFunc) string {
+	switch {
+	case fn.InputType == "":
+		return "ctx"
+	case fn.InputFirst:
+		return "inputValue, ctx"
+	default:
+		return "ctx, inputValue"
 	}
-	return ", inputValue"
 }
 
 func fnInputGoType(fn RouteFunc, alias string) string {
@@ -633,58 +1042,194 @@ func rpcFuncsByKinds(funcs []RouteFunc, kinds ...RouteFuncKind) []RouteFunc {
 	return result
 }
 
-func writeMain(
-	b *strings.Builder,
-	routes []routeEntry,
-	layout RouteFile,
-	hasLayout bool,
-	aliasMap map[string]serverImport,
-	deps map[string][]string,
-) {
-	hasOnServerStart := hasLayout && layout.HasOnServerStart
-	hasAroundRequest := hasLayout && layout.HasAroundRequest
-	hasLayoutSSR := false
-	if hasLayout {
-		for _, fn := range layout.Funcs {
-			if fn.Name == "SSR" {
-				hasLayoutSSR = true
-				break
-			}
-		}
-	}
-
+// writeMain writes main, which serves the project and every mounted app from
+// one HTTP server.
+func writeMain(b *strings.Builder, app serverApp, mounted []serverApp) {
 	b.WriteString(`func main() {
 	port := flag.String("port", "3000", "HTTP server port")
+	host := flag.String("host", "", "Address to bind, e.g. 127.0.0.1 (default: all interfaces)")
+	seed := flag.Bool("seed", false, "Run the seeds in seeds/ against the database and exit")
 	flag.Parse()
 
-	rstfApp := rstf.NewApp()
-	defer rstfApp.Close()
 `)
+	writeAppSetup(b, app)
+
+	if len(mounted) > 0 {
+		b.WriteString("\n")
+		for _, m := range mounted {
+			name := mountVarName(m)
+			fmt.Fprintf(b, "\t%sRouter, %sLiveHub, close%s := new%sApp()\n", name, name, m.ident, m.ident)
+			fmt.Fprintf(b, "\tdefer close%s()\n", m.ident)
+		}
+		// Longer prefixes first, so /admin/reports is not served by /admin.
+		byPrefix := slices.Clone(mounted)
+		sort.SliceStable(byPrefix, func(i, j int) bool {
+			return len(byPrefix[i].prefix) > len(byPrefix[j].prefix)
+		})
+		b.WriteString(`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+`)
+		for _, m := range byPrefix {
+			fmt.Fprintf(b, "\t\tcase hasPathPrefix(req.URL.Path, %q):\n", m.prefix)
+			fmt.Fprintf(b, "\t\t\t%sRouter.ServeHTTP(w, req)\n", mountVarName(m))
+		}
+		b.WriteString(`		default:
+			rt.ServeHTTP(w, req)
+		}
+	})
+`)
+	}
 
-	if hasOnServerStart {
-		imp := aliasMap["."]
-		fmt.Fprintf(b, `
-	%s.OnServerStart(rstfApp)
-`, imp.Alias)
+	handler := "rt"
+	if len(mounted) > 0 {
+		handler = "handler"
+	}
+	fmt.Fprintf(b, `
+	srv := &http.Server{
+		Handler:           %s,
+		ReadHeaderTimeout: rstfApp.ReadHeaderTimeout(),
+		ReadTimeout:       rstfApp.ReadTimeout(),
+		WriteTimeout:      rstfApp.WriteTimeout(),
+		IdleTimeout:       rstfApp.IdleTimeout(),
+	}
+	srv.RegisterOnShutdown(liveHub.Close)
+`, handler)
+	for _, m := range mounted {
+		fmt.Fprintf(b, "\tsrv.RegisterOnShutdown(%sLiveHub.Close)\n", mountVarName(m))
 	}
 
 	b.WriteString(`
-	r := renderer.New()
-	if err := r.Start("."); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to start renderer: %s\n", err)
+	ln, err := rstf.Listen(net.JoinHostPort(*host, *port))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "server error: %s\n", err)
 		os.Exit(1)
 	}
-	defer r.Stop()
 
+	// On SIGTERM or interrupt, stop accepting and let in-flight requests
+	// finish before the deferred cleanup runs.
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		<-c
-		r.Stop()
-		os.Exit(0)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
 	}()
 
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		fmt.Fprintf(os.Stderr, "server error: %s\n", err)
+		os.Exit(1)
+	}
+	<-shutdownDone
+}
+`)
+}
+
+// writeMountedApp writes the function that builds a mounted app's router. It
+// hands the cleanup main would otherwise defer back to main.
+func writeMountedApp(b *strings.Builder, app serverApp) {
+	fmt.Fprintf(b, "// new%sApp serves the app in %s/ under %s. The returned func releases it\n", app.ident, app.dir, app.prefix)
+	b.WriteString("// once the server has shut down.\n")
+	fmt.Fprintf(b, "func new%sApp() (*router.Router, *rstf.LiveHub, func()) {\n", app.ident)
+	writeAppSetup(b, app)
+	b.WriteString(`
+	return rt, liveHub, func() {
+		r.Stop()
+		rstfApp.Close()
+	}
+}
+
+`)
+}
+
+// writeMountHelpers writes the helpers main uses to dispatch requests to
+// mounted apps.
+func writeMountHelpers(b *strings.Builder) {
+	b.WriteString(`// hasPathPrefix reports whether urlPath is prefix or lies below it.
+func hasPathPrefix(urlPath, prefix string) bool {
+	return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+}
+
+`)
+}
+
+func mountVarName(app serverApp) string {
+	return strings.ToLower(app.ident[:1]) + app.ident[1:]
+}
+
+// writeAppSetup writes the statements that build an app's router: its App,
+// renderer, middleware, static files, live query and RPC endpoints, and
+// routes. The project's own app is set up in main and defers its cleanup.
+func writeAppSetup(b *strings.Builder, app serverApp) {
+	routes := app.routes
+	aliasMap := app.aliasMap
+	deps := app.deps
+	mounted := app.prefix != ""
+	hasOnServerStart := app.hasLayout && app.layout.HasOnServerStart
+	hasAroundRequest := app.hasLayout && app.layout.HasAroundRequest
+	hasLayoutSSR := app.hasLayout && len(app.layout.SSRDataFuncs()) > 0
+
+	b.WriteString("\trstfApp := rstf.NewApp()\n")
+	// Stamp the versions that generated and bundled the app next to what the
+	// binary records about itself.
+	fmt.Fprintf(b, `	build := rstf.ReadBuildInfo()
+	build.CLI, build.Esbuild = %q, %q
+	rstfApp.SetBuildInfo(build)
+`, release.Version, release.EsbuildVersion())
+	if !mounted {
+		b.WriteString("\tdefer rstfApp.Close()\n")
+	}
+
+	if hasOnServerStart {
+		imp := aliasMap["."]
+		fmt.Fprintf(b, `
+	%s.OnServerStart(rstfApp)
+`, imp.Alias)
+	}
+	if names := policyNames(app.layout.RoutePolicies); len(names) > 0 {
+		fmt.Fprintf(b, `	if err := rstfApp.CheckPolicies(%s); err != nil {
+		fmt.Fprintf(os.Stderr, "%%s\n", err)
+		os.Exit(1)
+	}
+`, quotedList(names))
+	}
+	if !mounted {
+		b.WriteString(`
+	if *seed {
+		if err := rstfseeds.Run(context.Background(), rstfApp); err != nil {
+			fmt.Fprintf(os.Stderr, "seed error: %s\n", err)
+			rstfApp.Close()
+			os.Exit(1)
+		}
+		return
+	}
+`)
+	}
+
+	fmt.Fprintf(b, `
+	r := renderer.New()
+	if err := r.Start(%q); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start renderer: %%s\n", err)
+		os.Exit(1)
+	}
+	rstfApp.SetTemplateRenderer(r)
+	if err := rstfApp.AddMetrics("renderer", func() any { return r.Stats() }); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to publish renderer metrics: %%s\n", err)
+		os.Exit(1)
+	}
+`, app.dir)
+	if !mounted {
+		b.WriteString("\tdefer r.Stop()\n")
+	}
+	fmt.Fprintf(b, `
 	rt := router.New()
+	rt.Use(rstf.NewAccessLogMiddleware(rstfApp))
+	rt.Use(rstf.NewRecoveryMiddleware(rstfApp))
+	rt.Use(rstf.NewIPFilterMiddleware(rstfApp))
+	rt.Use(rstf.NewTenantMiddleware(rstfApp))
 	admissionMiddleware := rstf.NewAdmissionMiddleware(rstf.AdmissionControlConfig{
 		MaxConcurrentRequests: rstfApp.MaxConcurrentRequests(),
 		MaxQueuedRequests:     rstfApp.MaxQueuedRequests(),
@@ -693,14 +1238,15 @@ func writeMain(
 	rt.Use(func(next http.Handler) http.Handler {
 		admitted := admissionMiddleware(next)
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			if strings.HasPrefix(req.URL.Path, "/__rstf/live") {
+			if strings.HasPrefix(req.URL.Path, %q) {
 				next.ServeHTTP(w, req)
 				return
 			}
 			admitted.ServeHTTP(w, req)
 		})
 	})
-`)
+	rt.Use(rstf.NewMethodOverrideMiddleware(rstfApp))
+`, app.prefix+"/__rstf/live")
 
 	if hasAroundRequest {
 		imp := aliasMap["."]
@@ -711,17 +1257,35 @@ func writeMain(
 `, imp.Alias)
 	}
 
-	b.WriteString(`
-	rt.Handle("/rstf/static/*", http.StripPrefix("/rstf/static/", http.FileServer(http.Dir("rstf/static"))))
+	staticURL := app.prefix + "/rstf/static"
+	staticDir := path.Join(app.dir, "rstf/static")
+	b.WriteString("\n")
+	if !mounted {
+		b.WriteString(`	// Imported .wasm assets must be served as application/wasm for
+	// WebAssembly.instantiateStreaming, whatever the host's mime.types says.
+	mime.AddExtensionType(".wasm", "application/wasm")
+`)
+	}
+	fmt.Fprintf(b, "\trt.Handle(%q, http.StripPrefix(%q, rstf.NewStaticHandler(%q)))\n\n", staticURL+"/*", staticURL+"/", staticDir)
 
-	var cssPath string
-	if _, err := os.Stat("rstf/static/main.css"); err == nil {
-		cssPath = "/rstf/static/main.css"
+	fmt.Fprintf(b, "\tstyles := loadPageStyles(%q, %q, []string{\n", staticDir, staticURL)
+	for _, route := range routes {
+		if route.hasComponent {
+			fmt.Fprintf(b, "\t\t%q,\n", route.bundlePath)
+		}
 	}
+	b.WriteString("\t})\n")
 
+	revalidatePath := "rstf.RevalidatePath"
+	metricsPath := "rstf.MetricsPath"
+	if mounted {
+		revalidatePath = strconv.Quote(app.prefix) + " + rstf.RevalidatePath"
+		metricsPath = strconv.Quote(app.prefix) + " + rstf.MetricsPath"
+	}
+	fmt.Fprintf(b, `
 	liveHub := rstf.NewLiveHub()
 
-	rt.Handle("/__rstf/live", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	rt.Handle(%[1]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodGet {
 			methodNotAllowed(w, []string{http.MethodGet})
 			return
@@ -759,7 +1323,7 @@ func writeMain(
 		}
 	}))
 
-	rt.Handle("/__rstf/live/subscribe", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	rt.Handle(%[2]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			methodNotAllowed(w, []string{http.MethodPost})
 			return
@@ -769,16 +1333,16 @@ func writeMain(
 			rstf.WriteErrorEnvelope(w, err)
 			return
 		}
-		key := rstf.NewSubscriptionKey(payload.Route, payload.Name, payload.Params)
+		key := rstf.NewSubscriptionKey(payload.Route, payload.Name, payload.Params).ForTenant(rstf.TenantFromRequest(req))
 		liveHub.Register(&rstf.LiveSubscription{
 			ClientID:       payload.ClientID,
 			SubscriptionID: payload.SubscriptionID,
 			Key:            key,
 			Execute: func() (any, error) {
-				return executeQuery(req, rstfApp, payload.Route, payload.Name, payload.Params)
+				return executeQuery%[5]s(req, rstfApp, payload.Route, payload.Name, payload.Params)
 			},
 		})
-		result, err := executeQuery(req, rstfApp, payload.Route, payload.Name, payload.Params)
+		result, err := executeQuery%[5]s(req, rstfApp, payload.Route, payload.Name, payload.Params)
 		if err != nil {
 			rstf.WriteErrorEnvelope(w, err)
 			return
@@ -786,7 +1350,7 @@ func writeMain(
 		writeRPCSuccess(w, result)
 	}))
 
-	rt.Handle("/__rstf/live/unsubscribe", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	rt.Handle(%[3]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			methodNotAllowed(w, []string{http.MethodPost})
 			return
@@ -800,24 +1364,65 @@ func writeMain(
 		writeRPCSuccess(w, map[string]bool{"ok": true})
 	}))
 
-	rt.Handle("/__rstf/rpc", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	rt.Handle(%[7]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			methodNotAllowed(w, []string{http.MethodGet})
+			return
+		}
+		query := req.URL.Query()
+		params := map[string]string{}
+		if raw := query.Get("params"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &params); err != nil {
+				rstf.WriteErrorEnvelope(w, &rstf.RequestError{Code: rstf.ErrorCodeInvalidPayload, Message: "params must be a JSON object of strings", Status: http.StatusBadRequest})
+				return
+			}
+		}
+		tracker := rstf.NewResponseTracker(w)
+		if err := streamEvents%[5]s(tracker, req, rstfApp, query.Get("route"), query.Get("name"), params); err != nil && !tracker.Written() {
+			rstf.WriteErrorEnvelope(tracker, err)
+		}
+	}))
+
+	rt.Handle(%[4]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			methodNotAllowed(w, []string{http.MethodPost})
 			return
 		}
+		tracker := rstf.NewResponseTracker(w)
+		if rstf.IsFormSubmission(req) {
+			form, err := rstf.ParseFormSubmission(tracker, req, rstfApp.RequestBodyLimitBytes())
+			var result any
+			if err == nil {
+				result, err = executeMutationOrAction%[5]s(tracker, req, rstfApp, form.Route, form.Name, form.Kind, form.Params, form.Input, liveHub)
+			}
+			form.WriteResult(tracker, req, rstfApp, result, err)
+			if form.Route != "" {
+				rstfApp.AuditAction(req, form.Route, form.Name, form.Params, tracker.StatusCode())
+			}
+			return
+		}
 		var payload rpcRequest
 		if err := decodeJSONBody(req, &payload); err != nil {
 			rstf.WriteErrorEnvelope(w, err)
 			return
 		}
-		result, err := executeMutationOrAction(req, rstfApp, payload.Route, payload.Name, payload.Kind, payload.Params, payload.Input, liveHub)
+		result, err := executeMutationOrAction%[5]s(tracker, req, rstfApp, payload.Route, payload.Name, payload.Kind, payload.Params, rstf.RPCInput{JSON: payload.Input}, liveHub)
 		if err != nil {
-			rstf.WriteErrorEnvelope(w, err)
-			return
+			rstf.WriteErrorEnvelope(tracker, err)
+		} else {
+			writeRPCSuccess(tracker, result)
 		}
-		writeRPCSuccess(w, result)
+		rstfApp.AuditAction(req, payload.Route, payload.Name, payload.Params, tracker.StatusCode())
 	}))
-`)
+
+	rt.Handle(%[8]s, rstf.NewMetricsHandler(rstfApp))
+
+	rt.Handle(%[6]s, rstf.NewRevalidateHandler(rstfApp, liveHub, map[string]string{
+`, app.prefix+"/__rstf/live", app.prefix+"/__rstf/live/subscribe", app.prefix+"/__rstf/live/unsubscribe", app.prefix+"/__rstf/rpc", app.ident, revalidatePath, app.prefix+"/__rstf/live/events", metricsPath)
+	for _, route := range routes {
+		fmt.Fprintf(b, "\t\t%q: %q,\n", route.urlPattern, routeNameForDir(route.dir))
+	}
+	b.WriteString("\t}))\n")
 
 	for _, route := range routes {
 		allowedMethods := []string{"OPTIONS"}
@@ -836,8 +1441,16 @@ func writeMain(
 		if route.hasDELETE {
 			allowedMethods = append(allowedMethods, "DELETE")
 		}
+		for _, fn := range route.customMethods {
+			allowedMethods = append(allowedMethods, fn.Method)
+		}
 
-		fmt.Fprintf(b, "\n\trt.Handle(%q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {\n", route.urlPattern)
+		fmt.Fprintf(b, "\n\trt.Handle(%[1]q, rstf.NewLifecycleHandler(rstfApp, %[1]q, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {\n", route.urlPattern)
+		if len(route.policies) > 0 {
+			b.WriteString("\t\tif req.Method != http.MethodOptions {\n")
+			fmt.Fprintf(b, "\t\t\tif req = authorizeRoute(w, req, rstfApp, %s); req == nil {\n", quotedList(route.policies))
+			b.WriteString("\t\t\t\treturn\n\t\t\t}\n\t\t}\n")
+		}
 		fmt.Fprintf(b, "\t\tallowed := []string{%s}\n", quotedList(allowedMethods))
 		b.WriteString(`		switch req.Method {
 		case http.MethodOptions:
@@ -847,6 +1460,17 @@ func writeMain(
 `)
 		if route.hasComponent || route.hasGET {
 			b.WriteString("\t\t\thead := req.Method == http.MethodHead\n")
+			if route.hasComponent {
+				// ?_data always returns the server data. Accept: application/json
+				// does too, unless the route has its own GET handler.
+				if route.hasGET {
+					b.WriteString("\t\t\tif req.URL.Query().Has(\"_data\") {\n")
+				} else {
+					b.WriteString("\t\t\tif req.URL.Query().Has(\"_data\") || (!prefersHTML(req.Header.Get(\"Accept\")) && acceptsJSON(req.Header.Get(\"Accept\"))) {\n")
+				}
+				writeServerDataBlock(b, route, hasLayoutSSR, aliasMap, deps)
+				b.WriteString("\t\t\t}\n")
+			}
 			b.WriteString(`			isHTML := prefersHTML(req.Header.Get("Accept"))
 			if isHTML {
 `)
@@ -881,29 +1505,32 @@ func writeMain(
 			b.WriteString("\t\tcase http.MethodDelete:\n")
 			writeMethodCallBlock(b, route, aliasMap, "DELETE", false)
 		}
+		for _, fn := range route.customMethods {
+			fmt.Fprintf(b, "\t\tcase %q:\n", fn.Method)
+			writeMethodCallBlock(b, route, aliasMap, fn.Name, false)
+		}
 		b.WriteString(`		default:
 			methodNotAllowed(w, allowed)
 		}
-	}))
+	})))
 `)
 	}
 
-	b.WriteString(`
-	srv := &http.Server{
-		Addr:              ":" + *port,
-		Handler:           rt,
-		ReadHeaderTimeout: rstfApp.ReadHeaderTimeout(),
-		ReadTimeout:       rstfApp.ReadTimeout(),
-		WriteTimeout:      rstfApp.WriteTimeout(),
-		IdleTimeout:       rstfApp.IdleTimeout(),
+	customPattern := "route.Pattern"
+	if mounted {
+		customPattern = strconv.Quote(app.prefix) + " + route.Pattern"
 	}
-
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		fmt.Fprintf(os.Stderr, "server error: %s\n", err)
-		os.Exit(1)
+	fmt.Fprintf(b, `
+	for _, route := range rstfApp.Routes() {
+		pattern := %s
+		handler := rstf.NewLifecycleHandler(rstfApp, pattern, route.Handler)
+		if route.Method == "" {
+			rt.Handle(pattern, handler)
+		} else {
+			rt.Method(route.Method, pattern, handler)
+		}
 	}
-}
-`)
+`, customPattern)
 }
 
 func writeHTMLRenderBlock(
@@ -913,36 +1540,138 @@ func writeHTMLRenderBlock(
 	aliasMap map[string]serverImport,
 	deps map[string][]string,
 ) {
+	layout := "main"
+	if route.noLayout {
+		layout = ""
+	}
+	writeRequestContextBlock(b)
+	b.WriteString("\t\t\t\tssrDataStart := time.Now()\n")
+	writeServerDataMap(b, route, hasLayoutSSR, aliasMap, deps)
+	b.WriteString("\t\t\t\tssrDataDur := time.Since(ssrDataStart)\n")
+	b.WriteString("\t\t\t\trenderStart := time.Now()\n")
+	fmt.Fprintf(b, "\t\t\t\thtml, err := r.Render(renderer.RenderRequest{Component: %q, Layout: %q, SSRProps: sd})\n", route.dir, layout)
+	b.WriteString("\t\t\t\tif err != nil {\n")
+	b.WriteString("\t\t\t\t\tstack := renderer.Stack(err)\n")
+	b.WriteString("\t\t\t\t\trstfApp.ReportError(ctx, err, stack)\n")
+	b.WriteString("\t\t\t\t\trstfApp.WriteServerError(w, req, err, stack)\n")
+	b.WriteString("\t\t\t\t\treturn\n")
+	b.WriteString("\t\t\t\t}\n")
+	b.WriteString("\t\t\t\trenderDur := time.Since(renderStart)\n")
+	b.WriteString("\t\t\t\tassembleStart := time.Now()\n")
+	fmt.Fprintf(b, "\t\t\t\tpage := assemblePage(rstfApp, req, html, sd, %q, styles)\n", route.bundlePath)
+	if route.hasMeta {
+		alias := aliasMap[route.dir].Alias
+		if route.metaHasContext {
+			fmt.Fprintf(b, "\t\t\t\tmeta := %s.Meta(ctx)\n", alias)
+		} else {
+			fmt.Fprintf(b, "\t\t\t\tmeta := %s.Meta()\n", alias)
+		}
+		b.WriteString("\t\t\t\tmeta.SetHeaders(w)\n")
+		b.WriteString("\t\t\t\tpage, err = meta.Apply(page)\n")
+		b.WriteString("\t\t\t\tif err != nil {\n")
+		b.WriteString("\t\t\t\t\trstfApp.ReportError(ctx, err, nil)\n")
+		b.WriteString("\t\t\t\t\trstfApp.WriteServerError(w, req, err, nil)\n")
+		b.WriteString("\t\t\t\t\treturn\n")
+		b.WriteString("\t\t\t\t}\n")
+	}
+	b.WriteString("\t\t\t\tctx.WriteHeaders(w)\n")
+	b.WriteString("\t\t\t\tw.Header().Set(\"Server-Timing\", serverTimingHeader(ssrDataDur, renderDur, time.Since(assembleStart)))\n")
+	b.WriteString("\t\t\t\tif rstfApp.RenderTiming() {\n")
+	b.WriteString("\t\t\t\t\tlogRenderTiming(req, ssrDataDur, renderDur)\n")
+	b.WriteString("\t\t\t\t}\n")
+	b.WriteString("\t\t\t\twriteHTMLResponse(w, page, head)\n")
+	b.WriteString("\t\t\t\treturn\n")
+}
+
+// writeServerDataBlock responds with the route's server data as JSON.
+func writeServerDataBlock(
+	b *strings.Builder,
+	route routeEntry,
+	hasLayoutSSR bool,
+	aliasMap map[string]serverImport,
+	deps map[string][]string,
+) {
+	usesContext := serverDataUsesContext(route, hasLayoutSSR, aliasMap, deps)
+	if usesContext {
+		writeRequestContextBlock(b)
+	}
+	writeServerDataMap(b, route, hasLayoutSSR, aliasMap, deps)
+	if usesContext {
+		b.WriteString("\t\t\t\tctx.WriteHeaders(w)\n")
+	}
+	b.WriteString("\t\t\t\twriteServerData(w, req, rstfApp, sd, head)\n")
+	b.WriteString("\t\t\t\treturn\n")
+}
+
+func writeRequestContextBlock(b *strings.Builder) {
 	b.WriteString("\t\t\t\tctx, err := newRequestContext(req, rstfApp)\n")
 	b.WriteString("\t\t\t\tif err != nil {\n")
-	b.WriteString("\t\t\t\t\thttp.Error(w, err.Error(), http.StatusInternalServerError)\n")
+	b.WriteString("\t\t\t\t\treportServerError(w, req, rstfApp, err)\n")
 	b.WriteString("\t\t\t\t\treturn\n")
 	b.WriteString("\t\t\t\t}\n")
+}
+
+// serverDataUsesContext reports whether any data function writeServerDataMap
+// calls takes a *rstf.Context.
+func serverDataUsesContext(
+	route routeEntry,
+	hasLayoutSSR bool,
+	aliasMap map[string]serverImport,
+	deps map[string][]string,
+) bool {
+	var imports []serverImport
+	if hasLayoutSSR && !route.noLayout {
+		imports = append(imports, aliasMap["."])
+	}
+	for _, depDir := range deps[route.dir] {
+		if imp, ok := aliasMap[depDir]; ok && depDir != "." {
+			imports = append(imports, imp)
+		}
+	}
+	for _, imp := range imports {
+		for _, fn := range imp.DataFuncs {
+			if fn.HasContext {
+				return true
+			}
+		}
+	}
+	return false
+}
 
+// writeServerDataMap builds sd from the layout's and every dependency's data
+// functions. Routes rendered without the layout skip its data functions. In dev
+// mode a result that cannot be serialized fails the request.
+func writeServerDataMap(
+	b *strings.Builder,
+	route routeEntry,
+	hasLayoutSSR bool,
+	aliasMap map[string]serverImport,
+	deps map[string][]string,
+) {
 	b.WriteString("\t\t\t\tsd := map[string]map[string]any{}\n")
-	if hasLayoutSSR {
-		imp := aliasMap["."]
-		fmt.Fprintf(b, "\t\t\t\tsd[\"main\"] = %s\n", ssrCall(imp.Alias, imp.HasContext))
+	var calls strings.Builder
+	if hasLayoutSSR && !route.noLayout {
+		writeSSRDataCalls(&calls, aliasMap["."], "main")
 	}
 	for _, depDir := range deps[route.dir] {
 		if depDir == "." {
 			continue
 		}
 		imp, ok := aliasMap[depDir]
-		if !ok || !imp.HasSSR {
+		if !ok {
 			continue
 		}
-		fmt.Fprintf(b, "\t\t\t\tsd[%q] = %s\n", depDir, ssrCall(imp.Alias, imp.HasContext))
+		writeSSRDataCalls(&calls, imp, depDir)
 	}
-
-	fmt.Fprintf(b, "\t\t\t\thtml, err := r.Render(renderer.RenderRequest{Component: %q, Layout: \"main\", SSRProps: sd})\n", route.dir)
-	b.WriteString("\t\t\t\tif err != nil {\n")
-	b.WriteString("\t\t\t\t\thttp.Error(w, err.Error(), 500)\n")
+	if calls.Len() == 0 {
+		return
+	}
+	b.WriteString("\t\t\t\tvar sdErrs []error\n")
+	b.WriteString(calls.String())
+	b.WriteString("\t\t\t\tif err := rstfApp.CheckServerData(sdErrs); err != nil {\n")
+	b.WriteString("\t\t\t\t\treportServerError(w, req, rstfApp, err)\n")
 	b.WriteString("\t\t\t\t\treturn\n")
 	b.WriteString("\t\t\t\t}\n")
-	fmt.Fprintf(b, "\t\t\t\tpage := assemblePage(html, sd, %q, cssPath)\n", bundlePath(route.dir))
-	b.WriteString("\t\t\t\twriteHTMLResponse(w, page, head)\n")
-	b.WriteString("\t\t\t\treturn\n")
 }
 
 func writeMethodCallBlock(
@@ -961,11 +1690,25 @@ func writeMethodCallBlock(
 	b.WriteString("\t\t\t\treturn\n")
 }
 
-func ssrCall(alias string, hasContext bool) string {
-	if hasContext {
-		return fmt.Sprintf("structToMap(%s.SSR(ctx))", alias)
+// writeSSRDataCalls stores each data function's result under its SSRDataKey.
+func writeSSRDataCalls(b *strings.Builder, imp serverImport, componentPath string) {
+	for _, fn := range imp.DataFuncs {
+		key := SSRDataKey(componentPath, fn.Name)
+		fmt.Fprintf(b, "\t\t\t\tsd[%q] = serverDataProps(&sdErrs, %q, %s)\n", key, key, ssrCall(imp.Alias, fn))
+	}
+}
+
+// ssrCall calls a data function. A slice or primitive result is stored
+// under "data".
+func ssrCall(alias string, fn RouteFunc) string {
+	call := fmt.Sprintf("%s.%s()", alias, fn.Name)
+	if fn.HasContext {
+		call = fmt.Sprintf("%s.%s(ctx)", alias, fn.Name)
+	}
+	if fn.WrapsData {
+		return fmt.Sprintf(`map[string]any{"data": %s}`, call)
 	}
-	return fmt.Sprintf("structToMap(%s.SSR())", alias)
+	return call
 }
 
 func quotedList(items []string) string {
@@ -2,33 +2,71 @@ package codegen
 
 import (
 	"encoding/json"
+	"go/format"
+	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/rafbgarcia/rstf"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// assemblePage mirrors the generated assemblePage function from writeAssemblePage
-// so we can unit-test the CSS link injection logic directly.
-func assemblePage(html string, ssrProps map[string]map[string]any, bundlePath string, cssPath string) string {
+// assetBaseURL, pageStyles, stylesheetTag, and assemblePage mirror the
+// generated code from writeAssemblePage so we can unit-test the CSS link
+// injection logic directly.
+var assetBaseURL string
+
+type pageStyles struct {
+	global   string
+	routes   map[string]string
+	all      []string
+	critical map[string]*rstf.CriticalCSS
+}
+
+func (s pageStyles) stylesheetTag(cssPath string, html string) string {
+	href := assetBaseURL + cssPath
+	critical, ok := s.critical[cssPath]
+	if !ok {
+		return "<link rel=\"stylesheet\" href=\"" + href + "\">\n"
+	}
+	return "<style>" + critical.Extract(html) + "</style>\n" +
+		"<link rel=\"preload\" as=\"style\" href=\"" + href + "\" onload=\"this.onload=null;this.rel='stylesheet'\">\n" +
+		"<noscript><link rel=\"stylesheet\" href=\"" + href + "\"></noscript>\n"
+}
+
+func assemblePage(rstfApp *rstf.App, req *http.Request, html string, ssrProps map[string]map[string]any, bundlePath string, styles pageStyles) string {
 	sdJSON, _ := json.Marshal(ssrProps)
 	dataScript := "<script>window.__RSTF_SSR_PROPS__ = " + string(sdJSON) + "</script>"
-	bundleScript := "<script src=\"" + bundlePath + "\"></script>"
-	page := "<!DOCTYPE html>" + html
-	if cssPath != "" {
-		page = strings.Replace(page, "</head>", "<link rel=\"stylesheet\" href=\""+cssPath+"\">\n</head>", 1)
+	bundleScript := "<script src=\"" + assetBaseURL + bundlePath + "\"></script>"
+
+	var links strings.Builder
+	if styles.global != "" {
+		links.WriteString(styles.stylesheetTag(styles.global, html))
+	}
+	routeCSS := styles.routes[bundlePath]
+	if routeCSS != "" {
+		links.WriteString(styles.stylesheetTag(routeCSS, html))
+	}
+	for _, cssPath := range styles.all {
+		if cssPath != routeCSS {
+			links.WriteString("<link rel=\"prefetch\" as=\"style\" href=\"" + assetBaseURL + cssPath + "\">\n")
+		}
 	}
-	page = strings.Replace(page, "</body>", dataScript+bundleScript+"</body>", 1)
-	return page
+	return rstfApp.AssemblePage(rstf.PageParts{
+		HTML:    html,
+		Styles:  links.String(),
+		Scripts: dataScript + bundleScript,
+		Request: req,
+	})
 }
 
 func TestAssemblePage_WithCSS(t *testing.T) {
 	html := "<html><head><title>Test</title></head><body><h1>Hello</h1></body></html>"
 	sd := map[string]map[string]any{"main": {"key": "val"}}
-	cssPath := "/rstf/static/main.css"
+	styles := pageStyles{global: "/rstf/static/main.css"}
 
-	got := assemblePage(html, sd, "/rstf/static/dashboard/bundle.js", cssPath)
+	got := assemblePage(rstf.NewApp(), nil, html, sd, "/rstf/static/dashboard/bundle.js", styles)
 
 	checks := []struct {
 		desc string
@@ -56,9 +94,9 @@ func TestAssemblePage_WithoutCSS(t *testing.T) {
 	html := "<html><head><title>Test</title></head><body><h1>Hello</h1></body></html>"
 	sd := map[string]map[string]any{"main": {"key": "val"}}
 
-	got := assemblePage(html, sd, "/rstf/static/dashboard/bundle.js", "")
+	got := assemblePage(rstf.NewApp(), nil, html, sd, "/rstf/static/dashboard/bundle.js", pageStyles{})
 
-	assert.NotContains(t, got, "<link", "should not contain <link> tag when cssPath is empty\n\nFull output:\n%s", got)
+	assert.NotContains(t, got, "<link", "should not contain <link> tag when there are no stylesheets\n\nFull output:\n%s", got)
 
 	// Should still have doctype and scripts.
 	for _, want := range []string{"<!DOCTYPE html>", "window.__RSTF_SSR_PROPS__", `<script src="`} {
@@ -66,6 +104,336 @@ func TestAssemblePage_WithoutCSS(t *testing.T) {
 	}
 }
 
+func TestAssemblePage_RouteCSS(t *testing.T) {
+	html := "<html><head></head><body></body></html>"
+	styles := pageStyles{
+		global: "/rstf/static/main.css",
+		routes: map[string]string{
+			"/rstf/static/dashboard/bundle.js": "/rstf/static/dashboard/bundle.css",
+			"/rstf/static/settings/bundle.js":  "/rstf/static/settings/bundle.css",
+		},
+		all: []string{"/rstf/static/dashboard/bundle.css", "/rstf/static/settings/bundle.css"},
+	}
+
+	got := assemblePage(rstf.NewApp(), nil, html, nil, "/rstf/static/dashboard/bundle.js", styles)
+
+	assert.Contains(t, got, `<link rel="stylesheet" href="/rstf/static/main.css">`+"\n"+`<link rel="stylesheet" href="/rstf/static/dashboard/bundle.css">`,
+		"route CSS should follow main.css so it can override it")
+	assert.Contains(t, got, `<link rel="prefetch" as="style" href="/rstf/static/settings/bundle.css">`)
+	assert.NotContains(t, got, `<link rel="prefetch" as="style" href="/rstf/static/dashboard/bundle.css">`)
+}
+
+func TestAssemblePage_InlinesCriticalCSS(t *testing.T) {
+	html := `<html><head></head><body><div class="card"></div></body></html>`
+	styles := pageStyles{
+		global: "/rstf/static/main.css",
+		critical: map[string]*rstf.CriticalCSS{
+			"/rstf/static/main.css": rstf.ParseCriticalCSS(`.card { padding: 1rem; } .modal { display: none; }`),
+		},
+	}
+
+	got := assemblePage(rstf.NewApp(), nil, html, nil, "/rstf/static/dashboard/bundle.js", styles)
+
+	assert.Contains(t, got, `<style>.card{padding: 1rem;}</style>`)
+	assert.NotContains(t, got, ".modal")
+	assert.Contains(t, got, `<link rel="preload" as="style" href="/rstf/static/main.css" onload="this.onload=null;this.rel='stylesheet'">`)
+	assert.Contains(t, got, `<noscript><link rel="stylesheet" href="/rstf/static/main.css"></noscript>`)
+}
+
+func TestAssemblePage_AssetBaseURL(t *testing.T) {
+	assetBaseURL = "https://cdn.example.com"
+	t.Cleanup(func() { assetBaseURL = "" })
+
+	html := "<html><head></head><body></body></html>"
+	styles := pageStyles{
+		global: "/rstf/static/main.css",
+		routes: map[string]string{"/rstf/static/dashboard/bundle.js": "/rstf/static/dashboard/bundle.css"},
+		all:    []string{"/rstf/static/dashboard/bundle.css", "/rstf/static/settings/bundle.css"},
+	}
+
+	got := assemblePage(rstf.NewApp(), nil, html, nil, "/rstf/static/dashboard/bundle.js", styles)
+
+	assert.Contains(t, got, `<link rel="stylesheet" href="https://cdn.example.com/rstf/static/main.css">`)
+	assert.Contains(t, got, `<link rel="stylesheet" href="https://cdn.example.com/rstf/static/dashboard/bundle.css">`)
+	assert.Contains(t, got, `<link rel="prefetch" as="style" href="https://cdn.example.com/rstf/static/settings/bundle.css">`)
+	assert.Contains(t, got, `<script src="https://cdn.example.com/rstf/static/dashboard/bundle.js"></script>`)
+}
+
+func TestGenerateServer_RouteWithoutLayout(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     ".",
+			Package: "myapp",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "Session", HasContext: true}},
+			Structs: []StructDef{{Name: "Session"}},
+		},
+		{
+			Dir:     "routes/print",
+			Package: "print",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData"}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/print": {"routes/print"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, map[string]EntryOptions{
+		"routes/print": {NoLayout: true},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, got, `renderer.RenderRequest{Component: "routes/print", Layout: "", SSRProps: sd}`)
+	assert.Contains(t, got, `sd["routes/print"] = serverDataProps(&sdErrs, "routes/print", print.SSR())`)
+	assert.NotContains(t, got, `sd["main"]`)
+}
+
+func TestGenerateServer_WrapsNonStructSSRData(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/posts",
+			Package: "posts",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "Post", ReturnIsSlice: true, WrapsData: true, HasContext: true}},
+			Structs: []StructDef{{Name: "Post"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/posts": {"routes/posts"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, `sd["routes/posts"] = serverDataProps(&sdErrs, "routes/posts", map[string]any{"data": posts.SSR(ctx)})`)
+}
+
+func TestGenerateServer_PageMeta(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/drafts",
+			Package: "drafts",
+			Funcs:   []RouteFunc{{Name: "Meta", Kind: RouteFuncKindMeta, HasContext: true}},
+		},
+		{
+			Dir:     "routes/about",
+			Package: "about",
+			Funcs:   []RouteFunc{{Name: "Meta", Kind: RouteFuncKindMeta}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/drafts": {"routes/drafts"},
+		"routes/about":  {"routes/about"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, "meta := drafts.Meta(ctx)")
+	assert.Contains(t, got, "meta := about.Meta()")
+	assert.Contains(t, got, "meta.SetHeaders(w)")
+	assert.Contains(t, got, "page, err = meta.Apply(page)")
+}
+
+func TestGenerateServer_MountedApp(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+	}
+	admin := MountedApp{
+		Prefix:     "/admin",
+		Dir:        "admin",
+		ModulePath: "github.com/user/myapp/admin",
+		Files: []RouteFile{
+			{
+				Dir:     "routes/users",
+				Package: "users",
+				Funcs: []RouteFunc{
+					{Name: "SSR", ReturnType: "ServerData", HasContext: true},
+					{Name: "ListUsers", Kind: RouteFuncKindQuery, ReturnType: "ServerData", HasContext: true},
+				},
+				Structs: []StructDef{{Name: "ServerData"}},
+			},
+		},
+		Deps: map[string][]string{
+			"routes/users": {"routes/users"},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil, admin)
+	require.NoError(t, err)
+
+	expectations := []string{
+		`users "github.com/user/myapp/admin/routes/users"`,
+		"func newAdminApp() (*router.Router, *rstf.LiveHub, func()) {",
+		`r.Start("admin")`,
+		`rt.Handle("/admin/rstf/static/*", http.StripPrefix("/admin/rstf/static/", rstf.NewStaticHandler("admin/rstf/static")))`,
+		`styles := loadPageStyles("admin/rstf/static", "/admin/rstf/static", []string{`,
+		`rt.Handle("/admin/__rstf/live", `,
+		`rt.Handle("/admin/__rstf/rpc", `,
+		`rt.Handle("/admin"+rstf.RevalidatePath, `,
+		`rt.Handle("/admin/users", `,
+		`assemblePage(rstfApp, req, html, sd, "/admin/rstf/static/users/bundle.js", styles)`,
+		"func executeQueryAdmin(",
+		"adminRouter, adminLiveHub, closeAdmin := newAdminApp()",
+		`case hasPathPrefix(req.URL.Path, "/admin"):`,
+		"srv.RegisterOnShutdown(adminLiveHub.Close)",
+		// The host app keeps its own routes and dispatchers.
+		`rt.Handle("/dashboard", `,
+		"func executeQuery(",
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+	assert.Equal(t, 1, strings.Count(got, "rstfseeds.Run("), "only the host app runs seeds")
+}
+
+func TestGenerateServer_NoMountsOmitsPrefixDispatch(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	assert.NotContains(t, got, "hasPathPrefix")
+	assert.NotContains(t, got, "handler := http.HandlerFunc")
+}
+
+func TestGenerateServer_EventStream(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/chat._id",
+			Package: "chat",
+			Funcs:   []RouteFunc{{Name: "Events", Kind: RouteFuncKindEvents, ReturnType: "ChatEvent", ReturnsError: true, HasContext: true}},
+			Structs: []StructDef{{Name: "ChatEvent"}},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	require.NoError(t, err)
+
+	expectations := []string{
+		"func streamEvents(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, routeName string, fnName string, params map[string]string) error {",
+		"req.SetPathValue(key, value)",
+		"\tcase \"chat._id\":\n\t\tswitch fnName {\n\t\tcase \"Events\":",
+		"return rstf.ServeEvents(ctx, chat.Events)",
+		`rt.Handle("/__rstf/live/events", `,
+		`if err := streamEvents(tracker, req, rstfApp, query.Get("route"), query.Get("name"), params); err != nil && !tracker.Written() {`,
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+	// Event streams are not callable as queries or RPCs.
+	assert.NotContains(t, got, "chat.Events(ctx)")
+}
+
+func TestGenerateServer_FieldErrorResults(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/posts",
+			Package: "posts",
+			Funcs:   []RouteFunc{{Name: "CreatePost", Kind: RouteFuncKindAction, InputType: "CreatePostInput", ReturnType: "Post", HasContext: true, ReturnsFieldErrors: true}},
+			Structs: []StructDef{{Name: "CreatePostInput"}, {Name: "Post"}},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, `result, fieldErrors := posts.CreatePost(ctx, inputValue)
+			return rstf.NewActionResult(result, fieldErrors), nil`)
+	// Field errors answer 422 Unprocessable Entity.
+	assert.Contains(t, got, `w.WriteHeader(rstf.RPCResultStatus(payload))`)
+	assert.Contains(t, got, `data, err := rstf.FormatFields(payload)`)
+}
+
+func TestGenerateServer_InputBeforeContext(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/posts",
+			Package: "posts",
+			Funcs:   []RouteFunc{{Name: "Rename", Kind: RouteFuncKindMutation, InputType: "string", InputFirst: true, ReturnsError: true, HasContext: true}},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, "if err := posts.Rename(inputValue, ctx); err != nil {")
+}
+
+func TestGenerateServer_FormSubmission(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/posts",
+			Package: "posts",
+			Funcs:   []RouteFunc{{Name: "CreatePost", Kind: RouteFuncKindAction, InputType: "CreatePostInput", ReturnsError: true, HasContext: true}},
+			Structs: []StructDef{{Name: "CreatePostInput"}},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	require.NoError(t, err)
+
+	expectations := []string{
+		"input rstf.RPCInput,",
+		"var inputValue posts.CreatePostInput\n\t\t\tif err := input.Decode(&inputValue); err != nil {",
+		"if rstf.IsFormSubmission(req) {",
+		"form, err := rstf.ParseFormSubmission(tracker, req, rstfApp.RequestBodyLimitBytes())",
+		"result, err = executeMutationOrAction(tracker, req, rstfApp, form.Route, form.Name, form.Kind, form.Params, form.Input, liveHub)",
+		"form.WriteResult(tracker, req, rstfApp, result, err)",
+		"rstf.RPCInput{JSON: payload.Input}",
+		// Both submission kinds are audited once they respond.
+		"rstfApp.AuditAction(req, form.Route, form.Name, form.Params, tracker.StatusCode())",
+		"rstfApp.AuditAction(req, payload.Route, payload.Name, payload.Params, tracker.StatusCode())",
+		// Headers and cookies the action sets reach the response.
+		"ctx := rstf.NewActionContext(cloneRequestWithParams(req, params), rstfApp.RequestBodyLimitBytes())\n\t\t\tctx.App = rstfApp\n\t\t\tdefer ctx.WriteHeaders(w)",
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+}
+
+func TestGenerateServer_CustomMethod(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/cache",
+			Package: "cache",
+			Funcs: []RouteFunc{
+				{Name: "PATCH", Kind: RouteFuncKindHTTP, HasContext: true, Method: "PATCH"},
+				{Name: "Purge", Kind: RouteFuncKindHTTP, HasContext: true, Method: "PURGE"},
+			},
+		},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	require.NoError(t, err)
+
+	expectations := []string{
+		`allowed := []string{"OPTIONS", "PATCH", "PURGE"}`,
+		"case http.MethodPatch:\n\t\t\tinvokeRouteAction(w, req, rstfApp, false, cache.PATCH)",
+		"case \"PURGE\":\n\t\t\tinvokeRouteAction(w, req, rstfApp, false, cache.Purge)",
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+}
+
 func TestGenerateServer_SingleRoute(t *testing.T) {
 	files := []RouteFile{
 		{
@@ -85,7 +453,7 @@ func TestGenerateServer_SingleRoute(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	expectations := []string{
@@ -104,8 +472,10 @@ func TestGenerateServer_SingleRoute(t *testing.T) {
 		`"github.com/rafbgarcia/rstf/router"`,
 		`app "github.com/user/myapp"`,
 		`dashboard "github.com/user/myapp/routes/dashboard"`,
-		"func structToMap(v any) map[string]any {",
-		"func assemblePage(html string, ssrProps map[string]map[string]any, bundlePath string, cssPath string) string {",
+		"func serverDataProps(errs *[]error, key string, v any) map[string]any {",
+		"func assemblePage(rstfApp *rstf.App, req *http.Request, html string, ssrProps map[string]map[string]any, bundlePath string, styles pageStyles) string {",
+		"var assetBaseURL string",
+		`bundleScript := "<script src=\"" + assetBaseURL + bundlePath + "\"></script>"`,
 		"window.__RSTF_SSR_PROPS__",
 		"func main() {",
 		"r := renderer.New()",
@@ -113,31 +483,58 @@ func TestGenerateServer_SingleRoute(t *testing.T) {
 		`defer r.Stop()`,
 		`signal.Notify(c, os.Interrupt, syscall.SIGTERM)`,
 		`rt := router.New()`,
+		`rt.Use(rstf.NewAccessLogMiddleware(rstfApp))`,
+		`rt.Use(rstf.NewRecoveryMiddleware(rstfApp))`,
+		`rt.Use(rstf.NewIPFilterMiddleware(rstfApp))`,
+		`rt.Use(rstf.NewTenantMiddleware(rstfApp))`,
+		`rt.Use(rstf.NewMethodOverrideMiddleware(rstfApp))`,
+		`rstfApp.ReportError(ctx, err, stack)`,
+		`mime.AddExtensionType(".wasm", "application/wasm")`,
 		`rt.Handle("/rstf/static/*"`,
 		`rt.Handle("/dashboard"`,
-		"ctx := rstf.NewContext(req)",
-		`sd["main"] = structToMap(app.SSR(ctx))`,
-		`sd["routes/dashboard"] = structToMap(dashboard.SSR(ctx))`,
+		"ctx := rstf.ContextFromRequest(req)",
+		"ctx = rstf.NewContext(req)",
+		`sd["main"] = serverDataProps(&sdErrs, "main", app.SSR(ctx))`,
+		`sd["routes/dashboard"] = serverDataProps(&sdErrs, "routes/dashboard", dashboard.SSR(ctx))`,
 		"allowed := []string{\"OPTIONS\", \"GET\", \"HEAD\"}",
 		`w.WriteHeader(http.StatusNotAcceptable)`,
 		`Component: "routes/dashboard"`,
 		`Layout: "main"`,
-		`http.Error(w, err.Error(), 500)`,
-		`assemblePage(html, sd, "/rstf/static/dashboard/bundle.js", cssPath)`,
-		`os.Stat("rstf/static/main.css")`,
+		`rstfApp.WriteServerError(w, req, err, stack)`,
+		`assemblePage(rstfApp, req, html, sd, "/rstf/static/dashboard/bundle.js", styles)`,
+		`os.Stat(staticDir + "/main.css")`,
+		"styles := loadPageStyles(\"rstf/static\", \"/rstf/static\", []string{\n\t\t\"/rstf/static/dashboard/bundle.js\",\n\t})",
 		`flag.String("port", "3000", "HTTP server port")`,
 		`flag.Parse()`,
+		`for _, route := range rstfApp.Routes() {`,
+		`handler := rstf.NewLifecycleHandler(rstfApp, pattern, route.Handler)`,
+		`rt.Method(route.Method, pattern, handler)`,
+		`rt.Handle("/dashboard", rstf.NewLifecycleHandler(rstfApp, "/dashboard", http.HandlerFunc(`,
 		`srv := &http.Server{`,
 		`ReadHeaderTimeout: rstfApp.ReadHeaderTimeout()`,
 		`ReadTimeout:       rstfApp.ReadTimeout()`,
 		`WriteTimeout:      rstfApp.WriteTimeout()`,
 		`IdleTimeout:       rstfApp.IdleTimeout()`,
-		`srv.ListenAndServe()`,
+		"rt.Handle(rstf.RevalidatePath, rstf.NewRevalidateHandler(rstfApp, liveHub, map[string]string{\n\t\t\"/dashboard\": \"dashboard\",\n\t}))",
+		`srv.RegisterOnShutdown(liveHub.Close)`,
+		`host := flag.String("host", "", "Address to bind, e.g. 127.0.0.1 (default: all interfaces)")`,
+		`ln, err := rstf.Listen(net.JoinHostPort(*host, *port))`,
+		`srv.Shutdown(ctx)`,
+		`srv.Serve(ln)`,
 	}
 
 	for _, exp := range expectations {
 		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
 	}
+	// Error details reach the client only through WriteServerError.
+	assert.NotContains(t, got, "err.Error()")
+	// Every 500 is reported to OnError first.
+	lines := strings.Split(got, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "rstfApp.WriteServerError(") {
+			assert.Contains(t, lines[i-1], "rstfApp.ReportError(", "unreported 500 at line %d", i+1)
+		}
+	}
 }
 
 func TestGenerateServer_MultipleRoutes(t *testing.T) {
@@ -173,7 +570,7 @@ func TestGenerateServer_MultipleRoutes(t *testing.T) {
 		"routes/users._id.edit": {"routes/users._id.edit"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// Verify all three handlers exist.
@@ -230,12 +627,12 @@ func TestGenerateServer_SharedDeps(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard", "shared/ui/user-avatar"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	expectations := []string{
 		`useravatar "github.com/user/myapp/shared/ui/user-avatar"`,
-		`sd["shared/ui/user-avatar"] = structToMap(useravatar.SSR(ctx))`,
+		`sd["shared/ui/user-avatar"] = serverDataProps(&sdErrs, "shared/ui/user-avatar", useravatar.SSR(ctx))`,
 	}
 	for _, exp := range expectations {
 		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
@@ -257,17 +654,40 @@ func TestGenerateServer_RouteWithoutGoFile(t *testing.T) {
 		"routes/about": {}, // no deps — the route has no .go, no shared deps with .go
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// Should still have a handler for /about.
 	assert.Contains(t, got, `rt.Handle("/about",`, "output missing handler for /about\n\nFull output:\n%s", got)
 
 	// Should have layout SSR but NOT a route SSR call.
-	assert.Contains(t, got, `sd["main"] = structToMap(app.SSR(ctx))`, "output missing layout SSR call\n\nFull output:\n%s", got)
+	assert.Contains(t, got, `sd["main"] = serverDataProps(&sdErrs, "main", app.SSR(ctx))`, "output missing layout SSR call\n\nFull output:\n%s", got)
 
 	// Should not contain "routes/about" as a ServerData key (it appears in Component, which is fine).
-	assert.NotContains(t, got, `sd["routes/about"] = structToMap`, "output should not contain routes/about ServerData entry\n\nFull output:\n%s", got)
+	assert.NotContains(t, got, `sd["routes/about"] = serverDataProps`, "output should not contain routes/about ServerData entry\n\nFull output:\n%s", got)
+}
+
+func TestGenerateServer_NamedSSRFunctions(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs: []RouteFunc{
+				{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData", HasContext: true},
+				{Name: "Sidebar", Kind: RouteFuncKindSSR, ReturnType: "SidebarData", HasContext: true},
+			},
+			Structs: []StructDef{{Name: "ServerData"}, {Name: "SidebarData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, `sd["routes/dashboard"] = serverDataProps(&sdErrs, "routes/dashboard", dashboard.SSR(ctx))`)
+	assert.Contains(t, got, `sd["routes/dashboard#Sidebar"] = serverDataProps(&sdErrs, "routes/dashboard#Sidebar", dashboard.Sidebar(ctx))`)
 }
 
 func TestGenerateServer_SSRWithoutContext(t *testing.T) {
@@ -289,12 +709,73 @@ func TestGenerateServer_SSRWithoutContext(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// SSR calls should not pass ctx.
-	assert.Contains(t, got, "structToMap(app.SSR())", "expected app.SSR() without ctx\n\nFull output:\n%s", got)
-	assert.Contains(t, got, "structToMap(dashboard.SSR())", "expected dashboard.SSR() without ctx\n\nFull output:\n%s", got)
+	assert.Contains(t, got, `"main", app.SSR())`, "expected app.SSR() without ctx\n\nFull output:\n%s", got)
+	assert.Contains(t, got, `"routes/dashboard", dashboard.SSR())`, "expected dashboard.SSR() without ctx\n\nFull output:\n%s", got)
+}
+
+func TestGenerateServer_ServerDataJSON(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+		{
+			Dir:     "routes/users",
+			Package: "users",
+			Funcs: []RouteFunc{
+				{Name: "SSR", ReturnType: "ServerData"},
+				{Name: "GET", Kind: RouteFuncKindHTTP, ReturnsError: true, HasContext: true, Method: "GET"},
+			},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+		"routes/users":     {"routes/users"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, got, "func writeServerData(w http.ResponseWriter, req *http.Request, rstfApp *rstf.App, sd map[string]map[string]any, head bool) {")
+	assert.Contains(t, got, `w.Header().Set("Cache-Control", "private, no-cache")`)
+
+	dashboard := got[strings.Index(got, `rt.Handle("/dashboard"`):]
+	assert.Contains(t, dashboard, `if req.URL.Query().Has("_data") || (!prefersHTML(req.Header.Get("Accept")) && acceptsJSON(req.Header.Get("Accept"))) {
+				ctx, err := newRequestContext(req, rstfApp)`)
+	// Headers, cookies, and cache policies set through ctx apply to both the
+	// JSON and the HTML.
+	assert.Contains(t, dashboard, `sd["routes/dashboard"] = serverDataProps(&sdErrs, "routes/dashboard", dashboard.SSR(ctx))
+				if err := rstfApp.CheckServerData(sdErrs); err != nil {
+					reportServerError(w, req, rstfApp, err)
+					return
+				}
+				ctx.WriteHeaders(w)
+				writeServerData(w, req, rstfApp, sd, head)`)
+	assert.Contains(t, dashboard, `ctx.WriteHeaders(w)
+				w.Header().Set("Server-Timing", `)
+	assert.Contains(t, got, `if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}`)
+
+	// A GET handler keeps owning Accept: application/json; only ?_data
+	// returns server data. Data functions without ctx skip the request context.
+	users := got[strings.Index(got, `rt.Handle("/users"`):]
+	assert.Contains(t, users, `if req.URL.Query().Has("_data") {
+				sd := map[string]map[string]any{}
+				var sdErrs []error
+				sd["routes/users"] = serverDataProps(&sdErrs, "routes/users", users.SSR())
+				if err := rstfApp.CheckServerData(sdErrs); err != nil {
+					reportServerError(w, req, rstfApp, err)
+					return
+				}
+				writeServerData(w, req, rstfApp, sd, head)`)
 }
 
 func TestGenerateServer_AliasCollision(t *testing.T) {
@@ -324,7 +805,7 @@ func TestGenerateServer_AliasCollision(t *testing.T) {
 		"routes/admin.index": {"routes/admin.index"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// One should be "index", the other "index2".
@@ -346,13 +827,13 @@ func TestGenerateServer_NoLayout(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	assert.Contains(t, got, `rt.Handle("/dashboard",`, "output missing handler\n\nFull output:\n%s", got)
 	// Should NOT have layout import or SSR call.
 	assert.NotContains(t, got, `app "github.com/user/myapp"`, "should not have layout import\n\nFull output:\n%s", got)
-	assert.NotContains(t, got, `"main": structToMap`, "should not have layout ServerData entry\n\nFull output:\n%s", got)
+	assert.NotContains(t, got, `"main", app.SSR`, "should not have layout ServerData entry\n\nFull output:\n%s", got)
 }
 
 func TestGenerateServer_WithOnServerStart(t *testing.T) {
@@ -375,7 +856,7 @@ func TestGenerateServer_WithOnServerStart(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	expectations := []string{
@@ -383,10 +864,18 @@ func TestGenerateServer_WithOnServerStart(t *testing.T) {
 		"rstfApp := rstf.NewApp()",
 		"app.OnServerStart(rstfApp)",
 		"defer rstfApp.Close()",
+		// Build metadata for the metrics endpoint.
+		"build := rstf.ReadBuildInfo()",
+		"rstfApp.SetBuildInfo(build)",
+		// --seed runs seeds/ once OnServerStart has configured the database.
+		`rstfseeds "github.com/user/myapp/rstf/seeds"`,
+		`seed := flag.Bool("seed", false,`,
+		"if err := rstfseeds.Run(context.Background(), rstfApp); err != nil {",
 		"admissionMiddleware := rstf.NewAdmissionMiddleware",
 		`strings.HasPrefix(req.URL.Path, "/__rstf/live")`,
-		// DB wiring in handler.
-		"ctx.DB = rstfApp.DB()",
+		// DB and App wiring in handler.
+		"db, err := rstfApp.TenantDB(ctx.Tenant())",
+		"ctx.App = rstfApp",
 		"ctx.SetRequestBodyLimitBytes(rstfApp.RequestBodyLimitBytes())",
 	}
 	for _, exp := range expectations {
@@ -414,7 +903,7 @@ func TestGenerateServer_WithoutOnServerStart(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// Should NOT have OnServerStart call, but app/runtime defaults still wire context.
@@ -429,7 +918,7 @@ func TestGenerateServer_WithoutOnServerStart(t *testing.T) {
 		"defer rstfApp.Close()",
 		"admissionMiddleware := rstf.NewAdmissionMiddleware",
 		`strings.HasPrefix(req.URL.Path, "/__rstf/live")`,
-		"ctx.DB = rstfApp.DB()",
+		"db, err := rstfApp.TenantDB(ctx.Tenant())",
 		"ctx.SetRequestBodyLimitBytes(rstfApp.RequestBodyLimitBytes())",
 	}
 	for _, s := range required {
@@ -457,7 +946,7 @@ func TestGenerateServer_OnServerStartOnlyLayout(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	// Should have OnServerStart initialization.
@@ -465,7 +954,7 @@ func TestGenerateServer_OnServerStartOnlyLayout(t *testing.T) {
 	assert.Contains(t, got, "app.OnServerStart(rstfApp)", "output missing app.OnServerStart call\n\nFull output:\n%s", got)
 
 	// Should NOT have layout SSR call (no SSR function).
-	assert.NotContains(t, got, `"main": structToMap`, "should not have layout SSR entry when layout has no SSR\n\nFull output:\n%s", got)
+	assert.NotContains(t, got, `"main", app.SSR`, "should not have layout SSR entry when layout has no SSR\n\nFull output:\n%s", got)
 }
 
 func TestGenerateServer_WithAroundRequest(t *testing.T) {
@@ -488,7 +977,7 @@ func TestGenerateServer_WithAroundRequest(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	expectations := []string{
@@ -527,7 +1016,7 @@ func TestGenerateServer_WithBothConventions(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	got, err := GenerateServer("github.com/user/myapp", files, deps)
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.NoError(t, err)
 
 	expectations := []string{
@@ -537,7 +1026,7 @@ func TestGenerateServer_WithBothConventions(t *testing.T) {
 		"defer rstfApp.Close()",
 		"admissionMiddleware := rstf.NewAdmissionMiddleware",
 		`strings.HasPrefix(req.URL.Path, "/__rstf/live")`,
-		"ctx.DB = rstfApp.DB()",
+		"db, err := rstfApp.TenantDB(ctx.Tenant())",
 		"ctx.SetRequestBodyLimitBytes(rstfApp.RequestBodyLimitBytes())",
 		// AroundRequest
 		"app.AroundRequest()",
@@ -610,7 +1099,145 @@ func TestGenerateServer_RejectsPackageMain(t *testing.T) {
 		"routes/dashboard": {"routes/dashboard"},
 	}
 
-	_, err := GenerateServer("github.com/user/myapp", files, deps)
+	_, err := GenerateServer("github.com/user/myapp", files, deps, nil)
 	require.Error(t, err, "expected error for package main in layout, got nil")
 	assert.Contains(t, err.Error(), "reserved for rstf", "error should mention package main, got: %s", err)
 }
+
+func TestGenerateServer_ServerTimingHeader(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+	}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	expectations := []string{
+		`"time"`,
+		"func serverTimingHeader(ssrData, render, assemble time.Duration) string {",
+		`"ssr-data;dur=%.3f, render;dur=%.3f, assemble;dur=%.3f"`,
+		"ssrDataStart := time.Now()",
+		"renderStart := time.Now()",
+		"assembleStart := time.Now()",
+		`w.Header().Set("Server-Timing", serverTimingHeader(ssrDataDur, renderDur, time.Since(assembleStart)))`,
+		"if rstfApp.RenderTiming() {\n\t\t\t\t\tlogRenderTiming(req, ssrDataDur, renderDur)\n\t\t\t\t}",
+		"func logRenderTiming(req *http.Request, ssrData, render time.Duration) {",
+		`rstfApp.AddMetrics("renderer", func() any { return r.Stats() })`,
+		"rt.Handle(rstf.MetricsPath, rstf.NewMetricsHandler(rstfApp))",
+	}
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+
+	// The header must be set before the response is written.
+	headerIdx := strings.Index(got, `w.Header().Set("Server-Timing"`)
+	writeIdx := strings.Index(got, "writeHTMLResponse(w, page, head)")
+	assert.Less(t, headerIdx, writeIdx, "Server-Timing should be set before writing the page")
+}
+
+func TestFormatGoSource(t *testing.T) {
+	got, err := formatGoSource("server_gen.go", "package main\nfunc main() {\nx:=1\n_ = x\n}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n", got)
+
+	_, err = formatGoSource("server_gen.go", "package main\n\nfunc main() {\n\tif ok {\n}\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "generated server_gen.go is not valid Go")
+}
+
+func TestGenerateServer_OutputIsFormatted(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:     "routes/dashboard",
+			Package: "dashboard",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+		{
+			Dir:     "routes/users._id",
+			Package: "usersid",
+			Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "ServerData", HasContext: true}},
+			Structs: []StructDef{{Name: "ServerData"}},
+		},
+	}
+	deps := map[string][]string{
+		"routes/dashboard": {"routes/dashboard"},
+		"routes/users._id": {"routes/users._id"},
+	}
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	formatted, err := format.Source([]byte(got))
+	require.NoError(t, err)
+	assert.Equal(t, string(formatted), got)
+}
+
+func TestGenerateServer_RoutePolicies(t *testing.T) {
+	files := []RouteFile{
+		{
+			Dir:              ".",
+			Package:          "myapp",
+			HasOnServerStart: true,
+			RoutePolicies:    map[string]string{"/admin/*": "admin", "/admin/billing": "owner"},
+		},
+		{
+			Dir:     "routes/admin",
+			Package: "admin",
+			Funcs: []RouteFunc{
+				{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData", HasContext: true},
+				{Name: "Users", Kind: RouteFuncKindQuery, ReturnType: "UsersResult"},
+				{Name: "Ban", Kind: RouteFuncKindMutation},
+				{Name: "Events", Kind: RouteFuncKindEvents, ReturnType: "AdminEvent"},
+			},
+			Structs: []StructDef{{Name: "ServerData"}, {Name: "UsersResult"}, {Name: "AdminEvent"}},
+		},
+		{
+			Dir:     "routes/admin.billing",
+			Package: "billing",
+			Funcs:   []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}},
+		},
+		{
+			Dir:     "routes/about",
+			Package: "about",
+			Funcs:   []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}},
+		},
+	}
+	deps := map[string][]string{"routes/admin": {"routes/admin"}}
+
+	got, err := GenerateServer("github.com/user/myapp", files, deps, nil)
+	require.NoError(t, err)
+
+	for _, exp := range []string{
+		// A missing SetPolicy fails at startup.
+		"app.OnServerStart(rstfApp)\n\tif err := rstfApp.CheckPolicies(\"admin\", \"owner\"); err != nil {",
+		// Pages and handlers check before anything else; preflights pass.
+		// The handler goes on with the request the policies leave on the Context.
+		"rt.Handle(\"/admin\", rstf.NewLifecycleHandler(rstfApp, \"/admin\", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {\n\t\tif req.Method != http.MethodOptions {\n\t\t\tif req = authorizeRoute(w, req, rstfApp, \"admin\"); req == nil {\n\t\t\t\treturn\n\t\t\t}\n\t\t}",
+		"if req = authorizeRoute(w, req, rstfApp, \"admin\", \"owner\"); req == nil {",
+		// Queries, mutations, and event streams of the route check too.
+		"case \"admin\":\n\t\tif err := authorizeRPC(req, rstfApp, params, \"admin\"); err != nil {\n\t\t\treturn nil, err\n\t\t}",
+		"if err := authorizeRPC(req, rstfApp, params, \"admin\"); err != nil {\n\t\t\treturn err\n\t\t}",
+	} {
+		assert.Contains(t, got, exp)
+	}
+	assert.Equal(t, 2, strings.Count(got, "return nil, err\n\t\t}\n\t\tswitch fnName {"), "query and mutation dispatchers check")
+	assert.Equal(t, 2, strings.Count(got, "req = authorizeRoute(w, req, rstfApp,"), "only the admin routes are guarded")
+}
+
+func TestGenerateServer_RejectsUnmatchedRoutePolicies(t *testing.T) {
+	files := []RouteFile{
+		{Dir: ".", Package: "myapp", HasOnServerStart: true, RoutePolicies: map[string]string{"/admn/*": "admin"}},
+		{Dir: "routes/admin", Package: "admin", Funcs: []RouteFunc{{Name: "GET", Kind: RouteFuncKindHTTP, Method: "GET"}}},
+	}
+
+	_, err := GenerateServer("github.com/user/myapp", files, map[string][]string{}, nil)
+	assert.EqualError(t, err, `.: RoutePolicies patterns match no route: "/admn/*"`)
+}
@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// templatesDir holds TSX templates rendered on demand with App.RenderTemplate
+// rather than served as routes, such as transactional email bodies.
+const templatesDir = "templates"
+
+// GenerateTemplateEntry produces the content of the SSR entry for a template
+// (rstf/ssr_entries/templates-{name}.ssr.tsx). name is the template path
+// relative to templates/ without its extension, e.g. "emails/welcome".
+//
+// Templates render with renderToStaticMarkup: their output is never
+// hydrated, so it carries no React markers, and the props passed from Go are
+// spread onto View directly instead of going through SSRDataProvider.
+func GenerateTemplateEntry(name string) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
+	b.WriteString("import { renderToStaticMarkup } from \"react-dom/server.browser\";\n")
+	fmt.Fprintf(&b, "import { View } from \"../../%s/%s\";\n", templatesDir, name)
+	b.WriteString("\n")
+	b.WriteString("const render = (props: Record<string, any>) => renderToStaticMarkup(<View {...props} />);\n\n")
+	b.WriteString("(globalThis as any).__RSTF_RENDERERS__ = (globalThis as any).__RSTF_RENDERERS__ ?? {};\n")
+	fmt.Fprintf(&b, "(globalThis as any).__RSTF_RENDERERS__[%q] = render;\n", templatesDir+"/"+name)
+	return b.String()
+}
+
+// discoverTemplates returns the names of the .tsx templates under templates/,
+// relative to it and without extension, sorted.
+func discoverTemplates(absRoot string) ([]string, error) {
+	dir := filepath.Join(absRoot, templatesDir)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var names []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".tsx" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".tsx"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeTemplateEntries writes an SSR entry for every template under
+// templates/ and records it in ssrEntries under the template's component
+// path, so the SSR pass bundles templates alongside routes.
+func (g *Generator) writeTemplateEntries(ssrEntries map[string]string) error {
+	names, err := discoverTemplates(g.root)
+	if err != nil {
+		return fmt.Errorf("discovering templates: %w", err)
+	}
+	for _, name := range names {
+		component := templatesDir + "/" + name
+		ok, err := fileExportsView(filepath.Join(g.root, component+".tsx"), g.cache, map[string]bool{})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s.tsx: template must export a View component (e.g. export function View(props) { ... })", component)
+		}
+		entryPath := filepath.Join(g.rstfDir, "ssr_entries", ssrEntryFileName(component))
+		if err := os.WriteFile(entryPath, []byte(GenerateTemplateEntry(name)), 0644); err != nil {
+			return fmt.Errorf("writing template entry %s: %w", entryPath, err)
+		}
+		ssrEntries[component] = entryPath
+	}
+	return nil
+}
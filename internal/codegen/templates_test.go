@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTemplateEntry(t *testing.T) {
+	got := GenerateTemplateEntry("emails/welcome")
+
+	assert.Contains(t, got, `import { renderToStaticMarkup } from "react-dom/server.browser";`)
+	assert.Contains(t, got, `import { View } from "../../templates/emails/welcome";`)
+	assert.Contains(t, got, "renderToStaticMarkup(<View {...props} />)")
+	assert.Contains(t, got, `__RSTF_RENDERERS__["templates/emails/welcome"] = render;`)
+	assert.NotContains(t, got, "SSRDataProvider")
+}
+
+func TestGenerate_Templates(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "routes", "index", "index.tsx"), `export function View() { return <div />; }`)
+	writeFile(t, filepath.Join(root, "templates", "emails", "welcome.tsx"), `export function View({ name }: { name: string }) { return <p>Hi {name}</p>; }`)
+
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	result, err := gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.RouteCount, "templates are not routes")
+	assert.NotContains(t, result.Entries, "templates/emails/welcome", "templates are never hydrated")
+
+	entry := filepath.Join(root, "rstf", "ssr_entries", "templates-emails-welcome.ssr.tsx")
+	assert.Equal(t, entry, result.SSREntries["templates/emails/welcome"])
+	content, err := os.ReadFile(entry)
+	require.NoError(t, err)
+	assert.Equal(t, GenerateTemplateEntry("emails/welcome"), string(content))
+
+	receipt := filepath.Join(root, "templates", "emails", "receipt.tsx")
+	writeFile(t, receipt, `export function View() { return <p>Thanks</p>; }`)
+	regen, err := gen.Regenerate([]ChangeEvent{{Path: receipt, Kind: "tsx"}})
+	require.NoError(t, err)
+	assert.Contains(t, regen.SSREntries, "templates/emails/receipt")
+	assert.Contains(t, regen.SSREntries, "templates/emails/welcome")
+}
+
+func TestGenerate_TemplateWithoutView(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "templates", "welcome.tsx"), `export function Email() { return <p />; }`)
+
+	_, err := Generate(root)
+	require.ErrorContains(t, err, "templates/welcome.tsx: template must export a View component")
+}
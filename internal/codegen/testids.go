@@ -0,0 +1,136 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// testIDRe matches static data-testid attributes in TSX, capturing the id
+// (group 1 or 2): data-testid="save", data-testid='save', or
+// data-testid={"save"}. Ids built at runtime are not discoverable.
+var testIDRe = regexp.MustCompile(`data-testid=(?:["']([^"']+)["']|\{\s*["'` + "`" + `]([^"'` + "`" + `$]+)["'` + "`" + `]\s*\})`)
+
+// TestIDManifest maps every route to the data-testid attributes of the TSX it
+// renders. It is written to rstf/testids.json when rstf.json sets
+// codegen.testIds, so e2e suites can be generated from or checked against the
+// actual UI.
+type TestIDManifest struct {
+	Routes []TestIDRoute `json:"routes"`
+}
+
+// TestIDRoute lists the test ids a route's page can contain, sorted by id and
+// file. The layout's ids are included unless the route renders without it.
+type TestIDRoute struct {
+	Name    string   `json:"name"`
+	Pattern string   `json:"pattern"`
+	TestIDs []TestID `json:"testIds"`
+}
+
+// TestID is one data-testid attribute and the project-relative file that
+// declares it.
+type TestID struct {
+	ID   string `json:"id"`
+	File string `json:"file"`
+}
+
+// BuildTestIDManifest collects the test ids of each route in routeDefs by
+// following the local imports of its index.tsx, and of main.tsx unless
+// entryOpts marks the route as rendering without the layout.
+func BuildTestIDManifest(projectRoot string, routeDefs []RouteDef, entryOpts map[string]EntryOptions, cache *fsCache) (TestIDManifest, error) {
+	manifest := TestIDManifest{Routes: make([]TestIDRoute, 0, len(routeDefs))}
+	for _, def := range routeDefs {
+		entry := filepath.Join(projectRoot, def.Dir, "index.tsx")
+		if _, err := os.Stat(entry); err != nil {
+			continue
+		}
+		entries := []string{entry}
+		layout := filepath.Join(projectRoot, "main.tsx")
+		if _, err := os.Stat(layout); err == nil && !entryOpts[def.Dir].NoLayout {
+			entries = append(entries, layout)
+		}
+
+		route := TestIDRoute{Name: def.Name, Pattern: def.Pattern, TestIDs: []TestID{}}
+		visited := map[string]bool{}
+		seen := map[TestID]bool{}
+		for _, entry := range entries {
+			err := walkTestIDs(projectRoot, entry, visited, cache, func(id TestID) {
+				if !seen[id] {
+					seen[id] = true
+					route.TestIDs = append(route.TestIDs, id)
+				}
+			})
+			if err != nil {
+				return TestIDManifest{}, fmt.Errorf("collecting test ids for %s: %w", def.Dir, err)
+			}
+		}
+		sort.Slice(route.TestIDs, func(i, j int) bool {
+			a, b := route.TestIDs[i], route.TestIDs[j]
+			if a.ID != b.ID {
+				return a.ID < b.ID
+			}
+			return a.File < b.File
+		})
+		manifest.Routes = append(manifest.Routes, route)
+	}
+	return manifest, nil
+}
+
+// walkTestIDs reports the test ids declared in absFilePath and in the local
+// TSX files it imports, transitively.
+func walkTestIDs(projectRoot, absFilePath string, visited map[string]bool, cache *fsCache, report func(TestID)) error {
+	if visited[absFilePath] {
+		return nil
+	}
+	visited[absFilePath] = true
+
+	content, err := readSource(absFilePath, cache)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(projectRoot, absFilePath)
+	if err != nil {
+		return err
+	}
+	for _, m := range testIDRe.FindAllSubmatch(content, -1) {
+		id := string(m[1])
+		if id == "" {
+			id = string(m[2])
+		}
+		report(TestID{ID: id, File: filepath.ToSlash(rel)})
+	}
+
+	dir := filepath.Dir(absFilePath)
+	for _, spec := range extractLocalImports(content) {
+		resolved := resolveImportPath(dir, spec)
+		if resolved == "" {
+			continue
+		}
+		if err := walkTestIDs(projectRoot, resolved, visited, cache, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTestIDs writes rstf/testids.json, with a mounted app's patterns under
+// its URL prefix.
+func (g *Generator) writeTestIDs(files []RouteFile, deps map[string][]string, entryOpts map[string]EntryOptions) error {
+	routeDefs := prefixRouteDefs(BuildRouteDefs(files, deps), g.prefix)
+	manifest, err := BuildTestIDManifest(g.root, routeDefs, entryOpts, g.cache)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding test id manifest: %w", err)
+	}
+	path := filepath.Join(g.rstfDir, "testids.json")
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_TestIDs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "rstf.json"), `{"codegen": {"testIds": true}}`)
+	writeFile(t, filepath.Join(root, "main.tsx"), `export function View({ children }: any) { return <nav data-testid="nav">{children}</nav>; }`)
+	writeFile(t, filepath.Join(root, "shared", "ui", "button.tsx"), `export function Button() { return <button data-testid={"save"} />; }`)
+	writeFile(t, filepath.Join(root, "routes", "users._id", "index.tsx"), `import { Button } from "../../shared/ui/button";
+export function View() { return <form data-testid='user-form'><Button /><span data-testid={`+"`row-${id}`"+`} /></form>; }`)
+	writeFile(t, filepath.Join(root, "routes", "login", "index.tsx"), `export const layout = "none";
+export function View() { return <input data-testid="email" />; }`)
+
+	gen, err := NewGenerator(root)
+	require.NoError(t, err)
+	_, err = gen.Generate()
+	require.NoError(t, err)
+
+	read := func() TestIDManifest {
+		data, err := os.ReadFile(filepath.Join(root, "rstf", "testids.json"))
+		require.NoError(t, err)
+		var manifest TestIDManifest
+		require.NoError(t, json.Unmarshal(data, &manifest))
+		return manifest
+	}
+	assert.Equal(t, TestIDManifest{Routes: []TestIDRoute{
+		{Name: "login", Pattern: "/login", TestIDs: []TestID{{ID: "email", File: "routes/login/index.tsx"}}},
+		{Name: "users._id", Pattern: "/users/{id}", TestIDs: []TestID{
+			{ID: "nav", File: "main.tsx"},
+			{ID: "save", File: "shared/ui/button.tsx"},
+			{ID: "user-form", File: "routes/users._id/index.tsx"},
+		}},
+	}}, read())
+
+	login := filepath.Join(root, "routes", "login", "index.tsx")
+	writeFile(t, login, `export const layout = "none";
+export function View() { return <input data-testid="login-email" />; }`)
+	_, err = gen.Regenerate([]ChangeEvent{{Path: login, Kind: "tsx"}})
+	require.NoError(t, err)
+	assert.Equal(t, []TestID{{ID: "login-email", File: "routes/login/index.tsx"}}, read().Routes[0].TestIDs)
+}
+
+func TestGenerate_TestIDsOff(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "routes", "index", "index.tsx"), `export function View() { return <div data-testid="home" />; }`)
+
+	_, err := Generate(root)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(root, "rstf", "testids.json"))
+}
@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GenerateTSConfig produces rstf/tsconfig.json, the base config that maps the
+// @rstf/* aliases to rstf/generated and pulls in the generated declarations.
+// Paths and includes are relative to rstf/, so a project tsconfig only needs
+// to extend it. Mounted apps in mountDirs have their own config and are
+// excluded.
+func GenerateTSConfig(mountDirs ...string) string {
+	exclude := `"../node_modules", "../dist", "./static"`
+	for _, dir := range mountDirs {
+		exclude += `, "../` + dir + `"`
+	}
+	return `{
+  "compilerOptions": {
+    "jsx": "react-jsx",
+    "target": "ES2022",
+    "module": "ESNext",
+    "moduleResolution": "node",
+    "lib": ["DOM", "DOM.Iterable", "ES2022"],
+    "paths": {
+      "@rstf/*": ["./generated/*"]
+    }
+  },
+  "include": ["./types", "./generated/**/*.ts", "../**/*.ts", "../**/*.tsx"],
+  "exclude": [` + exclude + `]
+}
+`
+}
+
+// assetDeclarations types the asset imports the bundler handles out of the
+// box; it is written to rstf/types/rstf.assets.d.ts, a name no route declaration
+// can take since route artifact names never contain dots.
+const assetDeclarations = `// Code generated by rstf. DO NOT EDIT.
+
+declare module "*.wasm" {
+  const url: string;
+  export default url;
+}
+`
+
+// formatDeclarations types the fields tagged with rstf.FormatTag, as they are
+// sent to the browser; it is written to rstf/types/rstf.format.d.ts. The
+// helpers in @rstf/format display them.
+const formatDeclarations = `// Code generated by rstf. DO NOT EDIT.
+
+declare namespace Rstf {
+  /** A field tagged rstf:"format=currency": a decimal amount and its ISO 4217 code. */
+  interface Money {
+    amount: string;
+    currency: string;
+  }
+  /** A field tagged rstf:"format=datetime": an RFC 3339 timestamp. */
+  type DateTime = string;
+  /** A field tagged rstf:"format=date": a calendar date, "YYYY-MM-DD". */
+  type DateString = string;
+}
+`
+
+// projectTSConfigTemplate is written to the project root when no tsconfig.json
+// exists yet. Existing project configs are never touched.
+const projectTSConfigTemplate = `{
+  "extends": "./rstf/tsconfig.json",
+  "compilerOptions": {
+    "strict": true,
+    "noEmit": true
+  }
+}
+`
+
+func writeTSConfig(root, rstfDir string, mountDirs ...string) error {
+	basePath := filepath.Join(rstfDir, "tsconfig.json")
+	if err := os.WriteFile(basePath, []byte(GenerateTSConfig(mountDirs...)), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", basePath, err)
+	}
+
+	assetsPath := filepath.Join(rstfDir, "types", "rstf.assets.d.ts")
+	if err := os.MkdirAll(filepath.Dir(assetsPath), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(assetsPath), err)
+	}
+	if err := os.WriteFile(assetsPath, []byte(assetDeclarations), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", assetsPath, err)
+	}
+	formatPath := filepath.Join(rstfDir, "types", "rstf.format.d.ts")
+	if err := os.WriteFile(formatPath, []byte(formatDeclarations), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", formatPath, err)
+	}
+
+	projectPath := filepath.Join(root, "tsconfig.json")
+	if _, err := os.Stat(projectPath); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("checking %s: %w", projectPath, err)
+	}
+	if err := os.WriteFile(projectPath, []byte(projectTSConfigTemplate), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", projectPath, err)
+	}
+	return nil
+}
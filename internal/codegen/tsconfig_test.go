@@ -0,0 +1,63 @@
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTSConfig(t *testing.T) {
+	var cfg struct {
+		CompilerOptions struct {
+			Paths map[string][]string `json:"paths"`
+		} `json:"compilerOptions"`
+		Include []string `json:"include"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(GenerateTSConfig()), &cfg))
+
+	assert.Equal(t, []string{"./generated/*"}, cfg.CompilerOptions.Paths["@rstf/*"])
+	assert.Contains(t, cfg.Include, "./types")
+	assert.Contains(t, cfg.Include, "../**/*.tsx")
+}
+
+func TestWriteTSConfig_CreatesProjectConfigWhenMissing(t *testing.T) {
+	root := t.TempDir()
+	rstfDir := filepath.Join(root, "rstf")
+	require.NoError(t, os.MkdirAll(rstfDir, 0755))
+
+	require.NoError(t, writeTSConfig(root, rstfDir))
+
+	base, err := os.ReadFile(filepath.Join(rstfDir, "tsconfig.json"))
+	require.NoError(t, err)
+	assert.Equal(t, GenerateTSConfig(), string(base))
+
+	assets, err := os.ReadFile(filepath.Join(rstfDir, "types", "rstf.assets.d.ts"))
+	require.NoError(t, err)
+	assert.Contains(t, string(assets), `declare module "*.wasm"`)
+
+	format, err := os.ReadFile(filepath.Join(rstfDir, "types", "rstf.format.d.ts"))
+	require.NoError(t, err)
+	assert.Contains(t, string(format), "declare namespace Rstf {")
+
+	project, err := os.ReadFile(filepath.Join(root, "tsconfig.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(project), `"extends": "./rstf/tsconfig.json"`)
+}
+
+func TestWriteTSConfig_KeepsExistingProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	rstfDir := filepath.Join(root, "rstf")
+	require.NoError(t, os.MkdirAll(rstfDir, 0755))
+	writeFile(t, filepath.Join(root, "tsconfig.json"), `{"compilerOptions":{}}`)
+
+	require.NoError(t, writeTSConfig(root, rstfDir))
+
+	project, err := os.ReadFile(filepath.Join(root, "tsconfig.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"compilerOptions":{}}`, string(project))
+	assert.FileExists(t, filepath.Join(rstfDir, "tsconfig.json"))
+}
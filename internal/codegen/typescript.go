@@ -37,10 +37,26 @@ func GenerateDTS(rf RouteFile) string {
 	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n\n")
 	fmt.Fprintf(&b, "declare namespace %s {\n", ns)
 
+	eventTypes := map[string]bool{}
+	for _, fn := range rf.Funcs {
+		if fn.Kind == RouteFuncKindEvents {
+			eventTypes[fn.ReturnType] = true
+		}
+	}
+
 	// Write interfaces for each struct (including the ServerData return type).
 	for i, sd := range rf.Structs {
+		writeJSDoc(&b, "  ", sd.Doc)
+		if eventTypes[sd.Name] {
+			writeEventUnion(&b, sd)
+			if i < len(rf.Structs)-1 {
+				b.WriteString("\n")
+			}
+			continue
+		}
 		fmt.Fprintf(&b, "  interface %s {\n", sd.Name)
 		for _, f := range sd.Fields {
+			writeJSDoc(&b, "    ", f.Doc)
 			fmt.Fprintf(&b, "    %s: %s;\n", f.JSONName, f.Type)
 		}
 		b.WriteString("  }\n")
@@ -53,49 +69,100 @@ func GenerateDTS(rf RouteFile) string {
 	return b.String()
 }
 
-// GenerateRuntimeModule produces the rstf/generated/{path}.ts module for an
-// SSR-backed component. The module exports a typed SSR wrapper bound to the
-// component's generated path so user code never needs to author component IDs.
-func GenerateRuntimeModule(rf RouteFile, componentPath string) string {
-	var fn *RouteFunc
-	for i := range rf.Funcs {
-		if rf.Funcs[i].Name == "SSR" {
-			fn = &rf.Funcs[i]
-			break
-		}
+// writeEventUnion writes an event stream's struct as a discriminated union
+// with one member per field, matching what rstf.EventStream.Send emits.
+func writeEventUnion(b *strings.Builder, sd StructDef) {
+	if len(sd.Fields) == 0 {
+		fmt.Fprintf(b, "  type %s = never;\n", sd.Name)
+		return
 	}
-	if fn == nil {
-		return "// Code generated by rstf. DO NOT EDIT.\n"
+	fmt.Fprintf(b, "  type %s =\n", sd.Name)
+	for i, f := range sd.Fields {
+		writeJSDoc(b, "    ", f.Doc)
+		fmt.Fprintf(b, "    | { type: %q; data: %s }", f.JSONName, f.Type)
+		if i == len(sd.Fields)-1 {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
 	}
+}
 
-	ns := Namespace(rf.Dir)
+// writeJSDoc writes doc as a JSDoc block at the given indent. Single-line docs
+// stay on one line; nothing is written for an empty doc.
+func writeJSDoc(b *strings.Builder, indent, doc string) {
+	if doc == "" {
+		return
+	}
+	lines := strings.Split(strings.ReplaceAll(doc, "*/", "*\\/"), "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
 
-	// Verify the return struct exists.
-	found := false
+// GenerateRuntimeModule produces the rstf/generated/{path}.ts module for an
+// SSR-backed component. For SSR the module exports a typed SSR wrapper and a
+// useServerData hook; each named data function (e.g. Sidebar) gets its own
+// wrapper and use<Name> hook. All are bound to the component's generated path
+// so user code never needs to author component IDs.
+func GenerateRuntimeModule(rf RouteFile, componentPath string) string {
+	structs := map[string]bool{}
 	for _, s := range rf.Structs {
-		if s.Name == fn.ReturnType {
-			found = true
-			break
+		structs[s.Name] = true
+	}
+
+	// Only emit accessors whose return struct exists.
+	var funcs []RouteFunc
+	for _, fn := range rf.SSRDataFuncs() {
+		if structs[fn.ReturnType] || (fn.WrapsData && isPrimitiveGoType(fn.ReturnType)) {
+			funcs = append(funcs, fn)
 		}
 	}
-	if !found {
+	if len(funcs) == 0 {
 		return "// Code generated by rstf. DO NOT EDIT.\n"
 	}
 
+	ns := Namespace(rf.Dir)
+
 	var b strings.Builder
 	b.WriteString("// Code generated by rstf. DO NOT EDIT.\n")
-	if rf.Dir == "." {
+	if rf.Dir == "." && funcs[0].Name == "SSR" {
 		b.WriteString("import type { PropsWithChildren } from \"react\";\n")
 	}
-	b.WriteString("import { createSSRWrapper } from \"@rstf/ssr\";\n\n")
+	b.WriteString("import { createSSRWrapper, createServerDataHook, createServerDataUpdater } from \"@rstf/ssr\";\n")
 
-	ssrPropsType := SSRPropsTypeName(rf.Dir)
-	if rf.Dir == "." {
-		fmt.Fprintf(&b, "export type %s = PropsWithChildren<%s.%s>;\n", ssrPropsType, ns, fn.ReturnType)
-		fmt.Fprintf(&b, "export const SSR = createSSRWrapper<%s.%s>(%q);\n", ns, fn.ReturnType, componentPath)
-	} else {
-		fmt.Fprintf(&b, "export type %s = %s.%s;\n", ssrPropsType, ns, fn.ReturnType)
-		fmt.Fprintf(&b, "export const SSR = createSSRWrapper<%s.%s>(%q);\n", ns, fn.ReturnType, componentPath)
+	for _, fn := range funcs {
+		b.WriteString("\n")
+		dataType := ns + "." + fn.ReturnType
+		if fn.WrapsData {
+			dataType = "{ data: " + tsRPCType(rf.Dir, fn.ReturnType, fn.ReturnIsSlice, false) + " }"
+		}
+		key := SSRDataKey(componentPath, fn.Name)
+		if fn.Name == "SSR" {
+			ssrPropsType := SSRPropsTypeName(rf.Dir)
+			if rf.Dir == "." {
+				fmt.Fprintf(&b, "export type %s = PropsWithChildren<%s>;\n", ssrPropsType, dataType)
+			} else {
+				fmt.Fprintf(&b, "export type %s = %s;\n", ssrPropsType, dataType)
+			}
+			fmt.Fprintf(&b, "export const SSR = createSSRWrapper<%s>(%q);\n", dataType, key)
+			fmt.Fprintf(&b, "export const useServerData = createServerDataHook<%s>(%q);\n", dataType, key)
+			fmt.Fprintf(&b, "export const updateServerData = createServerDataUpdater<%s>(%q);\n", dataType, key)
+			continue
+		}
+		fmt.Fprintf(&b, "export type %s%sProps = %s;\n", ns, fn.Name, dataType)
+		fmt.Fprintf(&b, "export const %s = createSSRWrapper<%s>(%q);\n", fn.Name, dataType, key)
+		fmt.Fprintf(&b, "export const use%s = createServerDataHook<%s>(%q);\n", fn.Name, dataType, key)
+		fmt.Fprintf(&b, "export const update%s = createServerDataUpdater<%s>(%q);\n", fn.Name, dataType, key)
 	}
 
 	return b.String()
@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -136,6 +137,71 @@ func TestGenerateDTS_NestedRoute(t *testing.T) {
 	assert.Contains(t, got, "declare namespace UsersProfile {", "expected namespace UsersProfile, got:\n%s", got)
 }
 
+func TestGenerateDTS_JSDoc(t *testing.T) {
+	rf := RouteFile{
+		Dir:     "dashboard",
+		Package: "dashboard",
+		Structs: []StructDef{
+			{
+				Name: "ServerData",
+				Doc:  "ServerData is the dashboard payload.\n\nIt is cached per user.",
+				Fields: []StructField{
+					{Name: "Title", JSONName: "title", Type: "string", Doc: "Title shown in the header."},
+					{Name: "Count", JSONName: "count", Type: "number"},
+				},
+			},
+		},
+	}
+
+	got := GenerateDTS(rf)
+
+	expected := `  /**
+   * ServerData is the dashboard payload.
+   *
+   * It is cached per user.
+   */
+  interface ServerData {
+    /** Title shown in the header. */
+    title: string;
+    count: number;
+  }`
+	assert.Contains(t, got, expected, "Full output:\n%s", got)
+}
+
+func TestGenerateDTS_EventUnion(t *testing.T) {
+	rf := RouteFile{
+		Dir:     "routes/chat",
+		Package: "chat",
+		Funcs:   []RouteFunc{{Name: "Events", Kind: RouteFuncKindEvents, ReturnType: "ChatEvent", ReturnsError: true, HasContext: true}},
+		Structs: []StructDef{
+			{
+				Name: "ChatEvent",
+				Fields: []StructField{
+					{Name: "Message", JSONName: "message", Type: "MessagePosted", Doc: "A new message."},
+					{Name: "Typing", JSONName: "typing", Type: "UserTyping"},
+				},
+			},
+			{
+				Name:   "MessagePosted",
+				Fields: []StructField{{Name: "Body", JSONName: "body", Type: "string"}},
+			},
+		},
+	}
+
+	got := GenerateDTS(rf)
+
+	expected := `  type ChatEvent =
+    /** A new message. */
+    | { type: "message"; data: MessagePosted }
+    | { type: "typing"; data: UserTyping };
+
+  interface MessagePosted {
+    body: string;
+  }`
+	assert.Contains(t, got, expected, "Full output:\n%s", got)
+	assert.NotContains(t, got, "interface ChatEvent")
+}
+
 func TestGenerateRuntimeModule(t *testing.T) {
 	rf := RouteFile{
 		Dir:     "routes/dashboard",
@@ -161,9 +227,11 @@ func TestGenerateRuntimeModule(t *testing.T) {
 
 	expectations := []string{
 		"// Code generated by rstf. DO NOT EDIT.",
-		`import { createSSRWrapper } from "@rstf/ssr";`,
+		`import { createSSRWrapper, createServerDataHook, createServerDataUpdater } from "@rstf/ssr";`,
 		"export type RoutesDashboardSSRProps = RoutesDashboard.ServerData;",
 		`export const SSR = createSSRWrapper<RoutesDashboard.ServerData>("routes/dashboard");`,
+		`export const useServerData = createServerDataHook<RoutesDashboard.ServerData>("routes/dashboard");`,
+		`export const updateServerData = createServerDataUpdater<RoutesDashboard.ServerData>("routes/dashboard");`,
 	}
 
 	for _, exp := range expectations {
@@ -197,11 +265,56 @@ func TestGenerateRuntimeModule_Layout(t *testing.T) {
 		`import type { PropsWithChildren } from "react";`,
 		"export type MainSSRProps = PropsWithChildren<Main.Session>;",
 		`export const SSR = createSSRWrapper<Main.Session>("main");`,
+		`export const useServerData = createServerDataHook<Main.Session>("main");`,
+	}
+
+	for _, exp := range expectations {
+		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
+	}
+}
+
+func TestGenerateRuntimeModule_WrappedData(t *testing.T) {
+	posts := GenerateRuntimeModule(RouteFile{
+		Dir:     "routes/posts",
+		Package: "posts",
+		Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "Post", ReturnIsSlice: true, WrapsData: true}},
+		Structs: []StructDef{{Name: "Post"}},
+	}, "routes/posts")
+	assert.Contains(t, posts, "export type RoutesPostsSSRProps = { data: RoutesPosts.Post[] };")
+	assert.Contains(t, posts, `export const useServerData = createServerDataHook<{ data: RoutesPosts.Post[] }>("routes/posts");`)
+
+	greeting := GenerateRuntimeModule(RouteFile{
+		Dir:     "routes/greeting",
+		Package: "greeting",
+		Funcs:   []RouteFunc{{Name: "SSR", ReturnType: "string", WrapsData: true}},
+	}, "routes/greeting")
+	assert.Contains(t, greeting, "export type RoutesGreetingSSRProps = { data: string };")
+}
+
+func TestGenerateRuntimeModule_NamedSSRFunctions(t *testing.T) {
+	rf := RouteFile{
+		Dir:     "routes/dashboard",
+		Package: "dashboard",
+		Funcs: []RouteFunc{
+			{Name: "Stats", Kind: RouteFuncKindSSR, ReturnType: "StatsData", HasContext: true},
+			{Name: "SSR", Kind: RouteFuncKindSSR, ReturnType: "ServerData", HasContext: true},
+		},
+		Structs: []StructDef{{Name: "ServerData"}, {Name: "StatsData"}},
 	}
 
+	got := GenerateRuntimeModule(rf, "routes/dashboard")
+
+	expectations := []string{
+		`export const SSR = createSSRWrapper<RoutesDashboard.ServerData>("routes/dashboard");`,
+		"export type RoutesDashboardStatsProps = RoutesDashboard.StatsData;",
+		`export const Stats = createSSRWrapper<RoutesDashboard.StatsData>("routes/dashboard#Stats");`,
+		`export const useStats = createServerDataHook<RoutesDashboard.StatsData>("routes/dashboard#Stats");`,
+		`export const updateStats = createServerDataUpdater<RoutesDashboard.StatsData>("routes/dashboard#Stats");`,
+	}
 	for _, exp := range expectations {
 		assert.Contains(t, got, exp, "output missing %q\n\nFull output:\n%s", exp, got)
 	}
+	assert.Less(t, strings.Index(got, "export const SSR"), strings.Index(got, "export const Stats"))
 }
 
 func TestNamespace(t *testing.T) {
@@ -0,0 +1,309 @@
+// Package config loads the optional rstf.json project configuration.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the project configuration file, read from the project root.
+const FileName = "rstf.json"
+
+// Config is the parsed rstf.json. Every section is optional; a project
+// without the file gets the zero Config.
+type Config struct {
+	// Port is the HTTP port rstf dev and rstf start serve on when --port is
+	// not given. Zero means the default, 3000.
+	Port int `json:"port"`
+	// Ignore lists directories codegen and the rstf dev watcher skip, as
+	// paths relative to rstf.json or path.Match patterns, e.g. "scripts" or
+	// "tools/*".
+	Ignore  []string `json:"ignore"`
+	CSS     CSS      `json:"css"`
+	Bundler Bundler  `json:"bundler"`
+	Build   Build    `json:"build"`
+	Codegen Codegen  `json:"codegen"`
+	Export  Export   `json:"export"`
+	Mounts  []Mount  `json:"mounts"`
+}
+
+// DefaultPort is the HTTP port used when neither --port nor rstf.json sets one.
+const DefaultPort = 3000
+
+// PortOrDefault returns Port, or DefaultPort when it is not set.
+func (c Config) PortOrDefault() int {
+	if c.Port == 0 {
+		return DefaultPort
+	}
+	return c.Port
+}
+
+// Ignored reports whether dir, relative to the project root with forward
+// slashes, is one of the Ignore directories.
+func (c Config) Ignored(dir string) bool {
+	for _, pattern := range c.Ignore {
+		if ok, _ := path.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CSS configures the project stylesheet build.
+type CSS struct {
+	// Entry is the stylesheet entrypoint relative to rstf.json, e.g.
+	// "styles/app.scss". Without it the first of main.css, main.scss, and
+	// main.sass is used. It is always built to rstf/static/main.css.
+	Entry string `json:"entry"`
+	// Node is the command that runs the CSS worker, the node process that
+	// compiles Sass and runs PostCSS, e.g. "/opt/node22/bin/node". Defaults
+	// to "node" from PATH.
+	Node string `json:"node"`
+}
+
+// Codegen enables optional codegen output.
+type Codegen struct {
+	// TestIDs writes rstf/testids.json, mapping each route to the static
+	// data-testid attributes in the TSX it renders, for e2e suites.
+	TestIDs bool `json:"testIds"`
+}
+
+// Mount serves another rstf project from this one under a URL prefix. The
+// mounted project has its own routes/, main.go and main.tsx, and shares this
+// project's go.mod.
+type Mount struct {
+	// Path is the URL prefix the project is served under, e.g. "/admin".
+	Path string `json:"path"`
+	// Dir is the project directory relative to rstf.json, e.g. "admin".
+	Dir string `json:"dir"`
+}
+
+// Build configures what rstf build bakes into the production binary.
+type Build struct {
+	// InlineCriticalCSS inlines the stylesheet rules each page uses into its
+	// <head> and loads the full stylesheets asynchronously.
+	InlineCriticalCSS bool `json:"inlineCriticalCSS"`
+	// AssetBaseURL is the origin pages load bundles, stylesheets, and
+	// imported assets from, e.g. "https://cdn.example.com". The CDN is
+	// expected to pull /rstf/static/ from the app server.
+	AssetBaseURL string `json:"assetBaseURL"`
+	// Budgets caps the size of route bundles, keyed by route name as in
+	// rstf/manifest.json ("dashboard", "users._id"). The "*" budget applies
+	// to routes without their own.
+	Budgets map[string]Budget `json:"budgets"`
+	// FailOnUnusedShared fails the build when a shared/ directory has SSR
+	// code that no route or layout imports. Without it the build only
+	// reports them.
+	FailOnUnusedShared bool `json:"failOnUnusedShared"`
+	// ExcludeRoutes leaves routes out of the production build, e.g. debug
+	// pages rstf dev still serves. Entries are route names as in
+	// rstf/manifest.json, or path.Match patterns such as "admin.*".
+	ExcludeRoutes []string `json:"excludeRoutes"`
+}
+
+// Budget is the largest a route's client bundle and stylesheet may be. A zero
+// size is not checked.
+type Budget struct {
+	JS  Size `json:"js"`
+	CSS Size `json:"css"`
+}
+
+// BudgetFor returns the budget for the route named name, falling back to the
+// "*" budget.
+func (b Build) BudgetFor(name string) (Budget, bool) {
+	if budget, ok := b.Budgets[name]; ok {
+		return budget, true
+	}
+	budget, ok := b.Budgets["*"]
+	return budget, ok
+}
+
+// Export configures rstf export, which prerenders pages to static HTML.
+type Export struct {
+	// Routes limits the prerendered routes to those whose names match, as
+	// route names in rstf/manifest.json or path.Match patterns such as
+	// "docs.*". Defaults to every route with a View and no URL parameters.
+	// Routes with URL parameters are only exported through Paths.
+	Routes []string `json:"routes"`
+	// Paths lists more URL paths to prerender, such as the pages of routes
+	// with URL parameters, e.g. "/posts/hello-world".
+	Paths []string `json:"paths"`
+}
+
+// Bundler configures the esbuild passes that produce client and SSR bundles.
+type Bundler struct {
+	// Loaders maps file extensions to esbuild loader names, e.g. ".svg": "dataurl".
+	Loaders map[string]string `json:"loaders"`
+	// Alias rewrites import paths before resolution, e.g. "lodash": "lodash-es".
+	Alias map[string]string `json:"alias"`
+	// Define replaces global identifiers with constant expressions.
+	Define map[string]string `json:"define"`
+	// Target lists the browsers (and optionally one ES version) client
+	// bundles must run in, e.g. ["es2020", "safari14", "chrome90"].
+	// Defaults to esnext.
+	Target []string `json:"target"`
+	// Supported overrides individual esbuild syntax features, e.g.
+	// "bigint": false.
+	Supported map[string]bool `json:"supported"`
+}
+
+// Load reads rstf.json from projectRoot. A missing file is not an error.
+func Load(projectRoot string) (Config, error) {
+	path := filepath.Join(projectRoot, FileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", FileName, err)
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", FileName, err)
+	}
+	if err := validateMounts(cfg.Mounts); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateAssetBaseURL(cfg.Build.AssetBaseURL); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateRoutePatterns("build.excludeRoutes", cfg.Build.ExcludeRoutes); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateRoutePatterns("export.routes", cfg.Export.Routes); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateExportPaths(cfg.Export.Paths); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validatePort(cfg.Port); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateIgnore(cfg.Ignore); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := validateCSSEntry(cfg.CSS.Entry); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	for i, pattern := range cfg.Ignore {
+		cfg.Ignore[i] = filepath.ToSlash(filepath.Clean(pattern))
+	}
+	cfg.Build.AssetBaseURL = strings.TrimSuffix(cfg.Build.AssetBaseURL, "/")
+	return cfg, nil
+}
+
+func validateAssetBaseURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("build.assetBaseURL %q must be an absolute http(s) URL", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("build.assetBaseURL %q must not have a query or fragment", raw)
+	}
+	return nil
+}
+
+func validatePort(port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("port %d must be between 1 and 65535", port)
+	}
+	return nil
+}
+
+func validateIgnore(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("ignore %q: %w", pattern, err)
+		}
+		clean := path.Clean(pattern)
+		if pattern == "" || !filepath.IsLocal(clean) || clean == "." {
+			return fmt.Errorf("ignore %q must be a directory inside the project", pattern)
+		}
+		for _, dir := range []string{"routes", "rstf"} {
+			if ok, _ := path.Match(strings.Split(clean, "/")[0], dir); ok {
+				return fmt.Errorf("ignore %q must not match %s/ or a directory in it", pattern, dir)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCSSEntry(entry string) error {
+	if entry == "" {
+		return nil
+	}
+	if !filepath.IsLocal(entry) {
+		return fmt.Errorf("css.entry %q must be a file inside the project", entry)
+	}
+	switch path.Ext(entry) {
+	case ".css", ".scss", ".sass":
+		return nil
+	}
+	return fmt.Errorf("css.entry %q must be a .css, .scss, or .sass file", entry)
+}
+
+func validateRoutePatterns(key string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s %q: %w", key, pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateExportPaths(paths []string) error {
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") || path.Clean(p) != p || strings.ContainsAny(p, "?#") {
+			return fmt.Errorf("export.paths %q must be a clean URL path starting with /, without a query", p)
+		}
+		if p == "/rstf" || strings.HasPrefix(p, "/rstf/") || strings.HasPrefix(p, "/__rstf") {
+			return fmt.Errorf("export.paths %q is reserved for rstf", p)
+		}
+	}
+	return nil
+}
+
+func validateMounts(mounts []Mount) error {
+	paths := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, m := range mounts {
+		if !strings.HasPrefix(m.Path, "/") || m.Path == "/" || strings.HasSuffix(m.Path, "/") {
+			return fmt.Errorf("mount path %q must start with / and not end with one", m.Path)
+		}
+		if strings.ContainsAny(m.Path, "{}*") || strings.Contains(m.Path, "//") {
+			return fmt.Errorf("mount path %q must be a static path", m.Path)
+		}
+		if strings.HasPrefix(m.Path, "/rstf/") || strings.HasPrefix(m.Path, "/__rstf") || m.Path == "/rstf" {
+			return fmt.Errorf("mount path %q is reserved for rstf", m.Path)
+		}
+		dir := filepath.ToSlash(filepath.Clean(m.Dir))
+		if m.Dir == "" || !filepath.IsLocal(m.Dir) || dir == "." {
+			return fmt.Errorf("mount dir %q must be a subdirectory of the project", m.Dir)
+		}
+		switch strings.Split(dir, "/")[0] {
+		case "routes", "rstf", "node_modules", "dist":
+			return fmt.Errorf("mount dir %q must not be inside %s/", m.Dir, strings.Split(dir, "/")[0])
+		}
+		if paths[m.Path] {
+			return fmt.Errorf("mount path %q is used twice", m.Path)
+		}
+		if dirs[dir] {
+			return fmt.Errorf("mount dir %q is used twice", m.Dir)
+		}
+		paths[m.Path] = true
+		dirs[dir] = true
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, cfg)
+}
+
+func TestLoad_Bundler(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{
+  "bundler": {
+    "loaders": { ".svg": "dataurl" },
+    "alias": { "lodash": "lodash-es" },
+    "define": { "process.env.NODE_ENV": "\"production\"" },
+    "target": ["es2020", "safari14"],
+    "supported": { "bigint": false }
+  }
+}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{".svg": "dataurl"}, cfg.Bundler.Loaders)
+	assert.Equal(t, map[string]string{"lodash": "lodash-es"}, cfg.Bundler.Alias)
+	assert.Equal(t, `"production"`, cfg.Bundler.Define["process.env.NODE_ENV"])
+	assert.Equal(t, []string{"es2020", "safari14"}, cfg.Bundler.Target)
+	assert.Equal(t, map[string]bool{"bigint": false}, cfg.Bundler.Supported)
+}
+
+func TestLoad_Build(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"build": {"inlineCriticalCSS": true}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.True(t, cfg.Build.InlineCriticalCSS)
+}
+
+func TestLoad_AssetBaseURL(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"build": {"assetBaseURL": "https://cdn.example.com/app/"}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/app", cfg.Build.AssetBaseURL)
+
+	for _, raw := range []string{"cdn.example.com", "/static", "ftp://cdn.example.com", "https://cdn.example.com/?v=1"} {
+		writeConfig(t, root, `{"build": {"assetBaseURL": "`+raw+`"}}`)
+		_, err := Load(root)
+		require.Error(t, err, raw)
+		assert.Contains(t, err.Error(), "build.assetBaseURL")
+	}
+}
+
+func TestLoad_ExcludeRoutes(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"build": {"excludeRoutes": ["debug", "admin.*"]}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"debug", "admin.*"}, cfg.Build.ExcludeRoutes)
+
+	writeConfig(t, root, `{"build": {"excludeRoutes": ["admin.["]}}`)
+	_, err = Load(root)
+	assert.ErrorContains(t, err, `build.excludeRoutes "admin.["`)
+}
+
+func TestLoad_Budgets(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"build": {"budgets": {
+		"*": {"js": "150kB"},
+		"dashboard": {"js": "1.5MB", "css": 20000}
+	}}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	budget, ok := cfg.Build.BudgetFor("dashboard")
+	require.True(t, ok)
+	assert.Equal(t, Budget{JS: 1500000, CSS: 20000}, budget)
+	budget, ok = cfg.Build.BudgetFor("about")
+	require.True(t, ok)
+	assert.Equal(t, Budget{JS: 150000}, budget)
+
+	for _, raw := range []string{`"150 parsecs"`, `"-1kB"`, `-5`, `true`} {
+		writeConfig(t, root, `{"build": {"budgets": {"*": {"js": `+raw+`}}}}`)
+		_, err := Load(root)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestSize(t *testing.T) {
+	for raw, want := range map[string]Size{"512B": 512, "2.3kB": 2300, "150 KB": 150000, "1.5MB": 1500000, "42": 42} {
+		got, err := ParseSize(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	assert.Equal(t, "512 B", Size(512).String())
+	assert.Equal(t, "152.3 kB", Size(152300).String())
+	assert.Equal(t, "1.50 MB", Size(1500000).String())
+}
+
+func TestLoad_Mounts(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"mounts": [{"path": "/admin", "dir": "admin"}]}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, []Mount{{Path: "/admin", Dir: "admin"}}, cfg.Mounts)
+}
+
+func TestLoad_RejectsInvalidMounts(t *testing.T) {
+	tests := []struct {
+		mounts string
+		want   string
+	}{
+		{`[{"path": "admin", "dir": "admin"}]`, `mount path "admin" must start with /`},
+		{`[{"path": "/admin/", "dir": "admin"}]`, `mount path "/admin/" must start with /`},
+		{`[{"path": "/orgs/{id}", "dir": "admin"}]`, `mount path "/orgs/{id}" must be a static path`},
+		{`[{"path": "/__rstf", "dir": "admin"}]`, `mount path "/__rstf" is reserved`},
+		{`[{"path": "/admin", "dir": "../admin"}]`, `mount dir "../admin" must be a subdirectory`},
+		{`[{"path": "/admin", "dir": "routes/admin"}]`, `mount dir "routes/admin" must not be inside routes/`},
+		{`[{"path": "/a", "dir": "admin"}, {"path": "/b", "dir": "admin"}]`, `mount dir "admin" is used twice`},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeConfig(t, root, `{"mounts": `+tt.mounts+`}`)
+
+		_, err := Load(root)
+		require.Error(t, err, tt.mounts)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestLoad_Export(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"export": {"routes": ["index", "docs.*"], "paths": ["/posts/hello-world"]}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, Export{Routes: []string{"index", "docs.*"}, Paths: []string{"/posts/hello-world"}}, cfg.Export)
+
+	tests := []struct {
+		export string
+		want   string
+	}{
+		{`{"routes": ["docs.["]}`, `export.routes "docs.["`},
+		{`{"paths": ["posts/a"]}`, `export.paths "posts/a" must be a clean URL path`},
+		{`{"paths": ["/posts/"]}`, `export.paths "/posts/" must be a clean URL path`},
+		{`{"paths": ["/posts/../a"]}`, `export.paths "/posts/../a" must be a clean URL path`},
+		{`{"paths": ["/search?q=a"]}`, `export.paths "/search?q=a" must be a clean URL path`},
+		{`{"paths": ["/rstf/static/a"]}`, `export.paths "/rstf/static/a" is reserved`},
+	}
+	for _, tt := range tests {
+		writeConfig(t, root, `{"export": `+tt.export+`}`)
+		_, err := Load(root)
+		require.Error(t, err, tt.export)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestLoad_Port(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, DefaultPort, Config{}.PortOrDefault())
+
+	writeConfig(t, root, `{"port": 8080}`)
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.PortOrDefault())
+
+	writeConfig(t, root, `{"port": 70000}`)
+	_, err = Load(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "port 70000 must be between 1 and 65535")
+}
+
+func TestLoad_Ignore(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"ignore": ["scripts/", "tools/*"]}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scripts", "tools/*"}, cfg.Ignore)
+	assert.True(t, cfg.Ignored("scripts"))
+	assert.True(t, cfg.Ignored("tools/gen"))
+	assert.False(t, cfg.Ignored("tools"))
+	assert.False(t, cfg.Ignored("scripts/db"))
+	assert.False(t, cfg.Ignored("shared"))
+}
+
+func TestLoad_RejectsInvalidIgnore(t *testing.T) {
+	tests := []struct {
+		ignore string
+		want   string
+	}{
+		{`["[a-"]`, `ignore "[a-"`},
+		{`["../other"]`, `ignore "../other" must be a directory inside the project`},
+		{`["."]`, `ignore "." must be a directory inside the project`},
+		{`["routes/admin"]`, `ignore "routes/admin" must not match routes/`},
+		{`["*"]`, `ignore "*" must not match routes/`},
+		{`["rstf"]`, `ignore "rstf" must not match rstf/`},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeConfig(t, root, `{"ignore": `+tt.ignore+`}`)
+
+		_, err := Load(root)
+		require.Error(t, err, tt.ignore)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestLoad_CSS(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"css": {"entry": "styles/app.scss", "node": "/opt/node/bin/node"}}`)
+
+	cfg, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, CSS{Entry: "styles/app.scss", Node: "/opt/node/bin/node"}, cfg.CSS)
+
+	for _, entry := range []string{"../app.css", "/abs/app.css", "styles/app.less"} {
+		writeConfig(t, root, `{"css": {"entry": "`+entry+`"}}`)
+		_, err := Load(root)
+		require.Error(t, err, entry)
+		assert.Contains(t, err.Error(), "css.entry")
+	}
+}
+
+func TestLoad_RejectsUnknownFields(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, `{"bundlr": {}}`)
+
+	_, err := Load(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parsing rstf.json")
+}
+
+func writeConfig(t *testing.T, root, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte(content), 0o644))
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size is a byte count. In rstf.json it is a number of bytes or a string
+// with a unit: "512B", "150kB", "1.5MB". Units are decimal, 1kB is 1000 bytes.
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"kb", 1e3},
+	{"mb", 1e6},
+	{"b", 1},
+}
+
+// UnmarshalJSON accepts a byte count or a size string.
+func (s *Size) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		if n < 0 {
+			return fmt.Errorf("size %d must not be negative", n)
+		}
+		*s = Size(n)
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("size must be a number of bytes or a string like \"150kB\"")
+	}
+	size, err := ParseSize(raw)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// ParseSize parses a size string such as "150kB".
+func ParseSize(raw string) (Size, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	multiplier := 1.0
+	for _, unit := range sizeUnits {
+		if trimmed, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(trimmed), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a value like \"150kB\"", raw)
+	}
+	return Size(math.Round(n * multiplier)), nil
+}
+
+// String formats the size in the largest unit that keeps it at least 1.
+func (s Size) String() string {
+	switch {
+	case s >= 1e6:
+		return strconv.FormatFloat(float64(s)/1e6, 'f', 2, 64) + " MB"
+	case s >= 1e3:
+		return strconv.FormatFloat(float64(s)/1e3, 'f', 1, 64) + " kB"
+	default:
+		return strconv.FormatInt(int64(s), 10) + " B"
+	}
+}
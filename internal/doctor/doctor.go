@@ -0,0 +1,242 @@
+// Package doctor checks that the machine and the project have what rstf dev
+// and rstf build need, and says how to fix what is missing.
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/release"
+)
+
+// minGoMinor is the oldest Go 1.x release the framework builds with.
+const minGoMinor = 24
+
+// Status is the outcome of one check.
+type Status int
+
+const (
+	OK Status = iota
+	// Warn is a problem that only breaks some workflows.
+	Warn
+	// Fail is a problem rstf dev or rstf build will run into.
+	Fail
+)
+
+// Result is one check's outcome. Detail describes what was found; Fix, set
+// for warnings and failures, says what to do about it.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Fix    string
+}
+
+// Env is how checks look at the machine, so tests can fake it.
+type Env struct {
+	LookPath func(file string) (string, error)
+	// Output runs a command and returns its trimmed standard output.
+	Output func(name string, args ...string) (string, error)
+}
+
+// SystemEnv looks at the real machine.
+func SystemEnv() Env {
+	return Env{
+		LookPath: exec.LookPath,
+		Output: func(name string, args ...string) (string, error) {
+			out, err := exec.Command(name, args...).Output()
+			return strings.TrimSpace(string(out)), err
+		},
+	}
+}
+
+// Run checks the project at root, in the order rstf dev needs things.
+func Run(root string, env Env) []Result {
+	return []Result{
+		checkGo(env),
+		checkCgo(env),
+		checkGoMod(root),
+		checkNode(env),
+		checkNpm(env),
+		checkNodeModules(root),
+		checkWritable(root),
+	}
+}
+
+// Failed reports how many results are failures.
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == Fail {
+			n++
+		}
+	}
+	return n
+}
+
+func checkGo(env Env) Result {
+	r := Result{Name: "Go"}
+	if _, err := env.LookPath("go"); err != nil {
+		r.Status, r.Detail = Fail, "go not found on PATH"
+		r.Fix = fmt.Sprintf("install Go %s or newer from https://go.dev/dl/", release.GoVersion)
+		return r
+	}
+	out, err := env.Output("go", "env", "GOVERSION")
+	if err != nil {
+		r.Status, r.Detail = Fail, fmt.Sprintf("go env GOVERSION failed: %v", err)
+		r.Fix = "reinstall Go from https://go.dev/dl/"
+		return r
+	}
+	r.Detail = out
+	if minor, ok := goMinor(out); ok && minor < minGoMinor {
+		r.Status = Fail
+		r.Fix = fmt.Sprintf("upgrade to Go 1.%d or newer from https://go.dev/dl/", minGoMinor)
+	}
+	return r
+}
+
+// goMinor returns the minor version of a "go1.24.6" style version.
+func goMinor(version string) (int, bool) {
+	rest, ok := strings.CutPrefix(version, "go1.")
+	if !ok {
+		return 0, false
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		rest = rest[:end]
+	}
+	minor, err := strconv.Atoi(rest)
+	return minor, err == nil
+}
+
+// checkCgo checks what the embedded V8 renderer needs to compile: cgo and a
+// C++ compiler.
+func checkCgo(env Env) Result {
+	r := Result{Name: "cgo"}
+	if _, err := env.LookPath("go"); err != nil {
+		r.Status, r.Detail, r.Fix = Warn, "skipped, go not found", "install Go first"
+		return r
+	}
+	enabled, _ := env.Output("go", "env", "CGO_ENABLED")
+	if enabled != "1" {
+		r.Status, r.Detail = Fail, "CGO_ENABLED="+enabled
+		r.Fix = "unset CGO_ENABLED or set it to 1; the renderer embeds V8 through cgo"
+		return r
+	}
+	cxx, _ := env.Output("go", "env", "CXX")
+	if cxx == "" {
+		cxx = "g++"
+	}
+	compiler := strings.Fields(cxx)[0]
+	if _, err := env.LookPath(compiler); err != nil {
+		r.Status, r.Detail = Fail, compiler+" not found on PATH"
+		r.Fix = "install a C++ compiler (Xcode Command Line Tools on macOS, build-essential on Debian/Ubuntu)"
+		return r
+	}
+	r.Detail = "enabled, " + compiler
+	return r
+}
+
+func checkGoMod(root string) Result {
+	r := Result{Name: "go.mod"}
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		r.Status, r.Detail = Fail, "no go.mod in "+root
+		r.Fix = "run rstf from the app root, or create an app with `rstf init <name>`"
+		return r
+	}
+	module := codegen.ParseModulePath(data)
+	if module == "" {
+		r.Status, r.Detail = Fail, "go.mod has no module directive"
+		r.Fix = "add `module <path>` as the first line of go.mod"
+		return r
+	}
+	r.Detail = module
+	if !strings.Contains(string(data), release.FrameworkModule) {
+		r.Status = Fail
+		r.Fix = fmt.Sprintf("run `go get %s@%s`", release.FrameworkModule, release.ModuleVersion)
+		r.Detail += ", does not require " + release.FrameworkModule
+	}
+	return r
+}
+
+func checkNode(env Env) Result {
+	r := Result{Name: "Node.js"}
+	if _, err := env.LookPath("node"); err != nil {
+		r.Status, r.Detail = Fail, "node not found on PATH"
+		r.Fix = fmt.Sprintf("install Node.js %s from https://nodejs.org; CSS builds and compression run on it", release.NodeVersion)
+		return r
+	}
+	out, err := env.Output("node", "--version")
+	if err != nil {
+		r.Status, r.Detail = Fail, fmt.Sprintf("node --version failed: %v", err)
+		r.Fix = "reinstall Node.js from https://nodejs.org"
+		return r
+	}
+	r.Detail = out
+	want, _ := strconv.Atoi(release.NodeVersion)
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(out, "v"), ".", 2)[0])
+	if err == nil && major < want {
+		r.Status = Warn
+		r.Fix = fmt.Sprintf("rstf is tested on Node.js %s; upgrade if builds misbehave", release.NodeVersion)
+	}
+	return r
+}
+
+func checkNpm(env Env) Result {
+	r := Result{Name: "npm"}
+	if _, err := env.LookPath("npm"); err != nil {
+		r.Status, r.Detail = Warn, "npm not found on PATH"
+		r.Fix = "install npm (it ships with Node.js) to install the app's dependencies"
+		return r
+	}
+	r.Detail = "found"
+	return r
+}
+
+func checkNodeModules(root string) Result {
+	r := Result{Name: "node_modules"}
+	if _, err := os.Stat(filepath.Join(root, "package.json")); err != nil {
+		r.Status, r.Detail = Fail, "no package.json"
+		r.Fix = "create an app with `rstf init <name>`, or add a package.json that depends on react and react-dom"
+		return r
+	}
+	var missing []string
+	for _, pkg := range []string{"react", "react-dom"} {
+		if _, err := os.Stat(filepath.Join(root, "node_modules", pkg, "package.json")); err != nil {
+			missing = append(missing, pkg)
+		}
+	}
+	if len(missing) > 0 {
+		r.Status, r.Detail = Fail, "missing "+strings.Join(missing, ", ")
+		r.Fix = "run `npm install`"
+		return r
+	}
+	r.Detail = "react, react-dom installed"
+	return r
+}
+
+// checkWritable checks that codegen can write rstf/.
+func checkWritable(root string) Result {
+	r := Result{Name: "rstf/"}
+	// Before the first codegen run, rstf/ is created in the app root.
+	dir := filepath.Join(root, "rstf")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = root
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		r.Fix = "make rstf/ writable by your user, or delete it; codegen recreates it"
+		return r
+	}
+	f.Close()
+	os.Remove(f.Name())
+	r.Detail = "writable"
+	return r
+}
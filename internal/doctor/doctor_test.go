@@ -0,0 +1,116 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeEnv(onPath []string, outputs map[string]string) Env {
+	return Env{
+		LookPath: func(file string) (string, error) {
+			for _, p := range onPath {
+				if p == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		Output: func(name string, args ...string) (string, error) {
+			out, ok := outputs[name+" "+strings.Join(args, " ")]
+			if !ok {
+				return "", errors.New("unexpected command")
+			}
+			return out, nil
+		},
+	}
+}
+
+func writeProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                              "module example.com/app\n\ngo 1.24\n\nrequire github.com/rafbgarcia/rstf v0.1.0\n",
+		"package.json":                        `{"dependencies": {"react": "19", "react-dom": "19"}}`,
+		"node_modules/react/package.json":     "{}",
+		"node_modules/react-dom/package.json": "{}",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+var healthyOutputs = map[string]string{
+	"go env GOVERSION":   "go1.24.6",
+	"go env CGO_ENABLED": "1",
+	"go env CXX":         "clang++",
+	"node --version":     "v24.1.0",
+}
+
+func TestRun_Healthy(t *testing.T) {
+	root := writeProject(t)
+	results := Run(root, fakeEnv([]string{"go", "clang++", "node", "npm"}, healthyOutputs))
+
+	for _, r := range results {
+		assert.Equal(t, OK, r.Status, "%s: %s", r.Name, r.Detail)
+	}
+	assert.Zero(t, Failed(results))
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.NotContains(t, e.Name(), ".doctor-", "the write probe is removed")
+	}
+}
+
+func TestRun_ReportsFixes(t *testing.T) {
+	root := writeProject(t)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "node_modules", "react-dom")))
+	outputs := map[string]string{
+		"go env GOVERSION":   "go1.22.1",
+		"go env CGO_ENABLED": "0",
+	}
+	results := Run(root, fakeEnv([]string{"go"}, outputs))
+
+	byName := map[string]Result{}
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+	assert.Equal(t, Fail, byName["Go"].Status)
+	assert.Contains(t, byName["Go"].Fix, "Go 1.24")
+	assert.Equal(t, Fail, byName["cgo"].Status)
+	assert.Equal(t, "CGO_ENABLED=0", byName["cgo"].Detail)
+	assert.Equal(t, Fail, byName["Node.js"].Status)
+	assert.Equal(t, Warn, byName["npm"].Status)
+	assert.Equal(t, Fail, byName["node_modules"].Status)
+	assert.Equal(t, "missing react-dom", byName["node_modules"].Detail)
+	assert.Equal(t, "run `npm install`", byName["node_modules"].Fix)
+	assert.Equal(t, 4, Failed(results))
+}
+
+func TestCheckGoMod(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, Fail, checkGoMod(root).Status)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644))
+	r := checkGoMod(root)
+	assert.Equal(t, Fail, r.Status)
+	assert.Contains(t, r.Fix, "go get github.com/rafbgarcia/rstf@")
+}
+
+func TestGoMinor(t *testing.T) {
+	for version, want := range map[string]int{"go1.24.6": 24, "go1.25rc1": 25, "go1.23": 23} {
+		got, ok := goMinor(version)
+		assert.True(t, ok, version)
+		assert.Equal(t, want, got, version)
+	}
+	_, ok := goMinor("devel +abc")
+	assert.False(t, ok)
+}
@@ -0,0 +1,117 @@
+// Package export prerenders the pages of a built rstf app to static HTML
+// files, so the app can be hosted without its server.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+)
+
+// Pages returns the URL paths of the routes to prerender, from the manifests
+// of the project and its mounted apps. A route is a page when it has a View
+// and no URL parameters. With patterns, only the pages whose names match one
+// are returned, and every pattern must match one; patterns use path.Match
+// syntax against route names as in rstf/manifest.json.
+func Pages(manifests []codegen.Manifest, patterns []string) ([]string, error) {
+	matched := map[string]bool{}
+	var pages []string
+	for _, manifest := range manifests {
+		for _, route := range manifest.Routes {
+			if !route.HasView || len(route.Params) > 0 {
+				continue
+			}
+			if len(patterns) == 0 {
+				pages = append(pages, route.Pattern)
+				continue
+			}
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, route.Name); ok {
+					matched[pattern] = true
+					pages = append(pages, route.Pattern)
+					break
+				}
+			}
+		}
+	}
+	for _, pattern := range patterns {
+		if !matched[pattern] {
+			return nil, fmt.Errorf("export.routes pattern %q matches no route with a View and no URL parameters", pattern)
+		}
+	}
+	slices.Sort(pages)
+	return slices.Compact(pages), nil
+}
+
+// File returns the file a page is written to, relative to the output
+// directory: "/" is index.html and "/docs/intro" is docs/intro/index.html, so
+// static hosts serve it at the page's URL.
+func File(urlPath string) string {
+	return filepath.Join(filepath.FromSlash(strings.TrimPrefix(path.Clean(urlPath), "/")), "index.html")
+}
+
+// Prerender requests each path from the server at baseURL as a browser
+// navigation does, and writes the HTML to its File in outDir. A page that
+// does not answer 200 with HTML, e.g. one that redirects, fails the export.
+func Prerender(ctx context.Context, baseURL string, paths []string, outDir string) error {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	for _, p := range paths {
+		html, err := fetch(ctx, client, base.JoinPath(p).String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		file := filepath.Join(outDir, File(p))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(file), err)
+		}
+		if err := os.WriteFile(file, html, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if location := resp.Header.Get("Location"); location != "" {
+			return nil, fmt.Errorf("responded %s to %s; only pages that render can be exported", resp.Status, location)
+		}
+		return nil, fmt.Errorf("responded %s", resp.Status)
+	}
+	if media, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); media != "text/html" {
+		return nil, fmt.Errorf("responded with %q, not an HTML page", resp.Header.Get("Content-Type"))
+	}
+	return body, nil
+}
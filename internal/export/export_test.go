@@ -0,0 +1,79 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPages(t *testing.T) {
+	manifests := []codegen.Manifest{
+		{Routes: []codegen.ManifestRoute{
+			{Name: "index", Pattern: "/", HasView: true},
+			{Name: "docs.intro", Pattern: "/docs/intro", HasView: true},
+			{Name: "posts._slug", Pattern: "/posts/{slug}", Params: []string{"slug"}, HasView: true},
+			{Name: "health", Pattern: "/health", Methods: []string{"GET"}},
+		}},
+		{Routes: []codegen.ManifestRoute{
+			{Name: "index", Pattern: "/admin", HasView: true},
+		}},
+	}
+
+	pages, err := Pages(manifests, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/admin", "/docs/intro"}, pages)
+
+	pages, err = Pages(manifests, []string{"docs.*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/docs/intro"}, pages)
+
+	_, err = Pages(manifests, []string{"index", "posts.*"})
+	assert.EqualError(t, err, `export.routes pattern "posts.*" matches no route with a View and no URL parameters`)
+}
+
+func TestFile(t *testing.T) {
+	assert.Equal(t, "index.html", File("/"))
+	assert.Equal(t, filepath.Join("docs", "intro", "index.html"), File("/docs/intro"))
+}
+
+func TestPrerender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/", "/docs/intro":
+			assert.Equal(t, "text/html", req.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<h1>" + req.URL.Path + "</h1>"))
+		case "/account":
+			http.Redirect(w, req, "/login", http.StatusSeeOther)
+		case "/data":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	out := t.TempDir()
+	require.NoError(t, Prerender(context.Background(), server.URL, []string{"/", "/docs/intro"}, out))
+	html, err := os.ReadFile(filepath.Join(out, "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>/</h1>", string(html))
+	html, err = os.ReadFile(filepath.Join(out, "docs", "intro", "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>/docs/intro</h1>", string(html))
+
+	err = Prerender(context.Background(), server.URL, []string{"/account"}, out)
+	assert.EqualError(t, err, "/account: responded 303 See Other to /login; only pages that render can be exported")
+	err = Prerender(context.Background(), server.URL, []string{"/data"}, out)
+	assert.EqualError(t, err, `/data: responded with "application/json", not an HTML page`)
+	err = Prerender(context.Background(), server.URL, []string{"/missing"}, out)
+	assert.EqualError(t, err, "/missing: responded 404 Not Found")
+}
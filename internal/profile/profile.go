@@ -0,0 +1,137 @@
+// Package profile times the phases of rstf dev and rstf build for --profile
+// and prints them as a flame-style report.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// barWidth is the width of the timeline in the report, in characters.
+const barWidth = 40
+
+// Span times one phase and the phases nested in it. Nested phases may run in
+// parallel. The methods of a nil *Span do nothing, so code can be timed
+// unconditionally and only records spans when profiling is on.
+type Span struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+
+	mu       sync.Mutex
+	children []*Span
+}
+
+// Start starts timing a top-level phase.
+func Start(name string) *Span {
+	return &Span{name: name, start: time.Now()}
+}
+
+// Start starts timing a phase nested in s. It is safe to call from several
+// goroutines.
+func (s *Span) Start(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := Start(name)
+	s.mu.Lock()
+	s.children = append(s.children, child)
+	s.mu.Unlock()
+	return child
+}
+
+// End stops timing s.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.duration = time.Since(s.start)
+}
+
+// MarshalJSON encodes s as its name, duration, start offset from its parent,
+// and nested phases, for rstf's --json output.
+func (s *Span) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.report(s.start))
+}
+
+type spanReport struct {
+	Name       string       `json:"name"`
+	StartMs    float64      `json:"startMs"`
+	DurationMs float64      `json:"durationMs"`
+	Children   []spanReport `json:"children,omitempty"`
+}
+
+func (s *Span) report(parent time.Time) spanReport {
+	r := spanReport{
+		Name:       s.name,
+		StartMs:    float64(s.start.Sub(parent).Microseconds()) / 1000,
+		DurationMs: float64(s.duration.Microseconds()) / 1000,
+	}
+	s.mu.Lock()
+	children := slices.Clone(s.children)
+	s.mu.Unlock()
+	slices.SortStableFunc(children, func(a, b *Span) int { return a.start.Compare(b.start) })
+	for _, child := range children {
+		r.Children = append(r.Children, child.report(s.start))
+	}
+	return r
+}
+
+// Write prints s and its nested phases, one per line, with how long each took
+// and a bar placing it on s's timeline, so phases that ran in parallel show as
+// overlapping bars:
+//
+//	build                170.0ms  ████████████████████████████████████████
+//	  codegen             61.2ms  ███████████████
+//	    analyze deps      40.3ms   ██████████
+//	      routes/users    22.9ms   ██████
+func (s *Span) Write(w io.Writer) {
+	if s == nil {
+		return
+	}
+	nameWidth := s.nameWidth(0)
+	s.write(w, 0, nameWidth, s.start, s.duration)
+}
+
+func (s *Span) nameWidth(depth int) int {
+	width := 2*depth + len(s.name)
+	for _, child := range s.children {
+		width = max(width, child.nameWidth(depth+1))
+	}
+	return width
+}
+
+func (s *Span) write(w io.Writer, depth, nameWidth int, origin time.Time, total time.Duration) {
+	label := strings.Repeat("  ", depth) + s.name
+	fmt.Fprintf(w, "  %-*s  %8s  %s\n", nameWidth, label, formatDuration(s.duration), bar(s.start.Sub(origin), s.duration, total))
+
+	children := slices.Clone(s.children)
+	slices.SortStableFunc(children, func(a, b *Span) int { return a.start.Compare(b.start) })
+	for _, child := range children {
+		child.write(w, depth+1, nameWidth, origin, total)
+	}
+}
+
+// bar draws a phase that started offset into a timeline of length total.
+func bar(offset, duration, total time.Duration) string {
+	if total <= 0 {
+		return ""
+	}
+	from := int(int64(offset) * barWidth / int64(total))
+	to := int((int64(offset+duration)*barWidth + int64(total) - 1) / int64(total))
+	from = min(max(from, 0), barWidth-1)
+	to = min(max(to, from+1), barWidth)
+	return strings.Repeat(" ", from) + strings.Repeat("█", to-from)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
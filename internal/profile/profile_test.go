@@ -0,0 +1,77 @@
+package profile
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanWrite(t *testing.T) {
+	origin := time.Now()
+	root := &Span{name: "build", start: origin, duration: 400 * time.Millisecond}
+	codegen := &Span{name: "codegen", start: origin, duration: 100 * time.Millisecond}
+	root.children = []*Span{
+		{name: "bundles", start: origin.Add(100 * time.Millisecond), duration: 300 * time.Millisecond},
+		codegen,
+	}
+	codegen.children = []*Span{
+		{name: "routes/users", start: origin.Add(50 * time.Millisecond), duration: 50 * time.Millisecond},
+		{name: "routes/dashboard", start: origin.Add(50 * time.Millisecond), duration: 1500 * time.Microsecond},
+	}
+
+	var b strings.Builder
+	root.Write(&b)
+	assert.Equal(t, strings.Join([]string{
+		"  build                  400.0ms  " + strings.Repeat("█", 40),
+		"    codegen              100.0ms  " + strings.Repeat("█", 10),
+		"      routes/users        50.0ms       " + strings.Repeat("█", 5),
+		"      routes/dashboard     1.5ms       █",
+		"    bundles              300.0ms            " + strings.Repeat("█", 30),
+		"",
+	}, "\n"), b.String())
+}
+
+func TestSpanMarshalJSON(t *testing.T) {
+	origin := time.Now()
+	root := &Span{name: "build", start: origin, duration: 400 * time.Millisecond}
+	root.children = []*Span{
+		{name: "bundles", start: origin.Add(100 * time.Millisecond), duration: 300 * time.Millisecond},
+		{name: "codegen", start: origin, duration: 1500 * time.Microsecond},
+	}
+
+	data, err := json.Marshal(root)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "build", "startMs": 0, "durationMs": 400,
+		"children": [
+			{"name": "codegen", "startMs": 0, "durationMs": 1.5},
+			{"name": "bundles", "startMs": 100, "durationMs": 300}
+		]
+	}`, string(data))
+}
+
+func TestSpanNil(t *testing.T) {
+	var s *Span
+	child := s.Start("parse")
+	child.End()
+	s.End()
+	assert.Nil(t, child)
+
+	var b strings.Builder
+	s.Write(&b)
+	assert.Empty(t, b.String())
+}
+
+func TestSpanStartEnd(t *testing.T) {
+	root := Start("build")
+	child := root.Start("codegen")
+	child.End()
+	root.End()
+	assert.Equal(t, []*Span{child}, root.children)
+	assert.LessOrEqual(t, child.duration, root.duration)
+	assert.Equal(t, "2.50s", formatDuration(2500*time.Millisecond))
+}
@@ -0,0 +1,21 @@
+package release
+
+import "runtime/debug"
+
+// EsbuildVersion returns the version of esbuild linked into the running
+// binary, or "" when it is not linked or the binary has no build info.
+func EsbuildVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == "github.com/evanw/esbuild" {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/rafbgarcia/rstf/internal/codegen"
+)
+
+var (
+	staticSegmentPattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	dynamicSegmentPattern = regexp.MustCompile(`^[$_][a-z][a-z0-9_]*$`)
+)
+
+type routeParam struct {
+	Name    string // URL param name, e.g. "id"
+	GoField string // ServerData field, e.g. "ID"
+}
+
+type routeTemplateData struct {
+	Dir         string // e.g. "routes/users._id.edit"
+	Package     string
+	Title       string
+	PropsType   string
+	Params      []routeParam
+	PropsFields string // destructured View props, e.g. "title, id"
+}
+
+type componentTemplateData struct {
+	Dir       string // e.g. "shared/ui/app-badge"
+	Package   string
+	Component string // exported component name, e.g. "AppBadge"
+	Title     string
+	PropsType string
+}
+
+// RouteDir converts a route name as typed on the command line to its
+// directory. Dynamic segments may be written $name or _name:
+//
+//	"users.$id.edit" → "routes/users._id.edit"
+func RouteDir(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "routes/")
+	if name == "" {
+		return "", fmt.Errorf("route name is required")
+	}
+	segments := strings.Split(name, ".")
+	for i, seg := range segments {
+		switch {
+		case staticSegmentPattern.MatchString(seg):
+		case dynamicSegmentPattern.MatchString(seg):
+			segments[i] = "_" + seg[1:]
+		default:
+			return "", fmt.Errorf("invalid route name %q: segment %q must be lowercase letters, digits and dashes, or a $param", name, seg)
+		}
+	}
+	return "routes/" + strings.Join(segments, "."), nil
+}
+
+// GenerateRoute creates the route directory for name under projectRoot with
+// an index.go returning typed ServerData and an index.tsx View rendering it.
+// It returns the paths it created, relative to projectRoot, and never
+// overwrites an existing route.
+func GenerateRoute(projectRoot, name string) ([]string, error) {
+	dir, err := RouteDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return generateFiles(projectRoot, dir, newRouteTemplateData(dir), []fileTemplate{
+		{path: dir + "/index.go", contents: routeGoTemplate},
+		{path: dir + "/index.tsx", contents: routeTSXTemplate},
+	})
+}
+
+// GenerateComponent creates a shared component under shared/ui. With ssr it
+// also writes an index.go with an SSR function and a starter test for it.
+//
+//	"app-badge"        → shared/ui/app-badge, exported as AppBadge
+//	"forms/text-field" → shared/ui/forms/text-field, exported as TextField
+func GenerateComponent(projectRoot, name string, ssr bool) ([]string, error) {
+	name = strings.Trim(strings.TrimPrefix(strings.TrimSpace(name), "shared/ui/"), "/")
+	if name == "" {
+		return nil, fmt.Errorf("component name is required")
+	}
+	segments := strings.Split(name, "/")
+	for _, seg := range segments {
+		if !staticSegmentPattern.MatchString(seg) {
+			return nil, fmt.Errorf("invalid component name %q: segment %q must be lowercase letters, digits and dashes", name, seg)
+		}
+	}
+	last := segments[len(segments)-1]
+	if last[0] < 'a' || last[0] > 'z' {
+		return nil, fmt.Errorf("invalid component name %q: it must start with a letter", name)
+	}
+	dir := "shared/ui/" + name
+	data := componentTemplateData{
+		Dir:       dir,
+		Package:   sanitizePackageName(last),
+		Component: codegen.Namespace(last),
+		Title:     humanizeName(last),
+		PropsType: codegen.SSRPropsTypeName(dir),
+	}
+	if !ssr {
+		return generateFiles(projectRoot, dir, data, []fileTemplate{
+			{path: dir + "/index.tsx", contents: componentTSXTemplate},
+		})
+	}
+	return generateFiles(projectRoot, dir, data, []fileTemplate{
+		{path: dir + "/index.go", contents: componentGoTemplate},
+		{path: dir + "/index_test.go", contents: componentGoTestTemplate},
+		{path: dir + "/index.tsx", contents: componentSSRTSXTemplate},
+	})
+}
+
+// generateFiles renders files into dir, which must not exist yet, and returns
+// their paths.
+func generateFiles(projectRoot, dir string, data any, files []fileTemplate) ([]string, error) {
+	target := filepath.Join(projectRoot, filepath.FromSlash(dir))
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists", dir)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("stat %s: %w", dir, err)
+	}
+
+	var created []string
+	for _, file := range files {
+		if err := writeTemplate(projectRoot, file, data); err != nil {
+			return created, err
+		}
+		created = append(created, file.path)
+	}
+	return created, nil
+}
+
+func newRouteTemplateData(dir string) routeTemplateData {
+	folder := strings.TrimPrefix(dir, "routes/")
+	var static []string
+	var params []routeParam
+	props := []string{"title"}
+	for _, seg := range strings.Split(folder, ".") {
+		if !strings.HasPrefix(seg, "_") {
+			static = append(static, seg)
+			continue
+		}
+		param := seg[1:]
+		params = append(params, routeParam{Name: param, GoField: goFieldName(param)})
+		props = append(props, lowerCamel(param))
+	}
+	title := humanizeName(strings.Join(static, " "))
+	if len(static) == 0 {
+		title = "Index"
+	}
+	return routeTemplateData{
+		Dir:         dir,
+		Package:     sanitizePackageName(strings.Join(static, "")),
+		Title:       title,
+		PropsType:   codegen.SSRPropsTypeName(dir),
+		Params:      params,
+		PropsFields: strings.Join(props, ", "),
+	}
+}
+
+// goFieldName turns a URL param into an exported Go field: "id" → "ID",
+// "post_slug" → "PostSlug".
+func goFieldName(param string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(param, "_") {
+		if part == "" {
+			continue
+		}
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// lowerCamel turns a URL param into the JSON name of its ServerData field:
+// "post_slug" → "postSlug".
+func lowerCamel(param string) string {
+	parts := strings.Split(param, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// writeTemplate renders file into root, gofmt'ing Go sources.
+func writeTemplate(root string, file fileTemplate, data any) error {
+	targetPath := filepath.Join(root, filepath.FromSlash(file.path))
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("creating parent dir for %s: %w", targetPath, err)
+	}
+
+	tmpl, err := template.New(file.path).Funcs(template.FuncMap{"lowerCamel": lowerCamel}).Parse(file.contents)
+	if err != nil {
+		return fmt.Errorf("parsing template %s: %w", file.path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", file.path, err)
+	}
+	out := buf.Bytes()
+	if strings.HasSuffix(file.path, ".go") {
+		if out, err = format.Source(out); err != nil {
+			return fmt.Errorf("formatting %s: %w", file.path, err)
+		}
+	}
+
+	f, err := os.OpenFile(targetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", targetPath, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(out); err != nil {
+		return fmt.Errorf("writing %s: %w", targetPath, err)
+	}
+	return nil
+}
+
+const routeGoTemplate = `package {{ .Package }}
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type ServerData struct {
+	Title string ` + "`json:\"title\"`" + `
+{{- range .Params }}
+	{{ .GoField }} string ` + "`json:\"{{ lowerCamel .Name }}\"`" + `
+{{- end }}
+}
+
+func SSR(ctx *rstf.Context) ServerData {
+	return ServerData{
+		Title: {{ printf "%q" .Title }},
+{{- range .Params }}
+		{{ .GoField }}: ctx.Param({{ printf "%q" .Name }}),
+{{- end }}
+	}
+}
+`
+
+const routeTSXTemplate = `import { SSR, type {{ .PropsType }} } from "@rstf/{{ .Dir }}";
+
+export const View = SSR(function View({ {{ .PropsFields }} }: {{ .PropsType }}) {
+  return (
+    <section>
+      <h1>{title}</h1>
+{{- range .Params }}
+      <p>{{ .Name }}: { {{- lowerCamel .Name -}} }</p>
+{{- end }}
+    </section>
+  );
+});
+`
+
+const componentTSXTemplate = `export function {{ .Component }}() {
+  return <div>{{ .Title }}</div>;
+}
+`
+
+const componentGoTemplate = `package {{ .Package }}
+
+import rstf "github.com/rafbgarcia/rstf"
+
+type ServerData struct {
+	Title string ` + "`json:\"title\"`" + `
+}
+
+func SSR(ctx *rstf.Context) ServerData {
+	return ServerData{Title: {{ printf "%q" .Title }}}
+}
+`
+
+const componentGoTestTemplate = `package {{ .Package }}
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	rstf "github.com/rafbgarcia/rstf"
+)
+
+func TestSSR(t *testing.T) {
+	ctx := rstf.NewContext(httptest.NewRequest("GET", "/", nil))
+	if got := SSR(ctx); got.Title == "" {
+		t.Errorf("SSR() = %+v, want a title", got)
+	}
+}
+`
+
+const componentSSRTSXTemplate = `import { SSR, type {{ .PropsType }} } from "@rstf/{{ .Dir }}";
+
+export const {{ .Component }} = SSR(function {{ .Component }}({ title }: {{ .PropsType }}) {
+  return <div>{title}</div>;
+});
+`
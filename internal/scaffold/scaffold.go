@@ -7,7 +7,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"text/template"
 	"unicode"
 
 	"github.com/rafbgarcia/rstf/internal/codegen"
@@ -158,27 +157,7 @@ func validateTargetDir(targetDir string) error {
 }
 
 func writeTemplateFile(cfg Config, file fileTemplate) error {
-	targetPath := filepath.Join(cfg.TargetDir, file.path)
-	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-		return fmt.Errorf("creating parent dir for %s: %w", targetPath, err)
-	}
-
-	tmpl, err := template.New(file.path).Parse(file.contents)
-	if err != nil {
-		return fmt.Errorf("parsing template %s: %w", file.path, err)
-	}
-
-	f, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return fmt.Errorf("opening %s: %w", targetPath, err)
-	}
-	defer f.Close()
-
-	if err := tmpl.Execute(f, cfg); err != nil {
-		return fmt.Errorf("writing %s: %w", targetPath, err)
-	}
-
-	return nil
+	return writeTemplate(cfg.TargetDir, file, cfg)
 }
 
 func runCommand(dir string, name string, args ...string) error {
@@ -249,7 +228,7 @@ const packageJSONTemplate = `{
   "scripts": {
     "dev": "rstf dev",
     "build": "rstf build",
-    "typecheck": "tsc --noEmit"
+    "typecheck": "rstf typecheck"
   },
   "dependencies": {
     "react": "^19.1.0",
@@ -268,19 +247,11 @@ const packageJSONTemplate = `{
 `
 
 const tsconfigTemplate = `{
+  "extends": "./rstf/tsconfig.json",
   "compilerOptions": {
-    "jsx": "react-jsx",
-    "target": "ES2022",
-    "module": "ESNext",
-    "moduleResolution": "node",
-    "lib": ["DOM", "DOM.Iterable", "ES2022"],
     "strict": true,
-    "noEmit": true,
-    "paths": {
-      "@rstf/*": ["./rstf/generated/*"]
-    }
-  },
-  "include": ["rstf/types", "rstf/generated/**/*.ts", "**/*.ts", "**/*.tsx"]
+    "noEmit": true
+  }
 }
 `
 
@@ -1,9 +1,11 @@
 package scaffold
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/rafbgarcia/rstf/internal/codegen"
 	"github.com/rafbgarcia/rstf/internal/release"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,3 +35,86 @@ func TestDeriveConfigHonorsLocalOverrides(t *testing.T) {
 	assert.Equal(t, filepath.Clean("/tmp/rstf"), filepath.Clean(cfg.FrameworkReplace))
 	assert.Equal(t, "file:/tmp/rstf/packages/cli", cfg.CLIRef)
 }
+
+func TestRouteDir(t *testing.T) {
+	cases := map[string]string{
+		"users.$id.edit":        "routes/users._id.edit",
+		"users._id":             "routes/users._id",
+		"routes/admin.settings": "routes/admin.settings",
+		"index":                 "routes/index",
+	}
+	for name, want := range cases {
+		got, err := RouteDir(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+	for _, name := range []string{"", "Users", "users/edit", "users..edit", "users.$"} {
+		_, err := RouteDir(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestGenerateRoute(t *testing.T) {
+	root := t.TempDir()
+
+	created, err := GenerateRoute(root, "users.$id.edit")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes/users._id.edit/index.go", "routes/users._id.edit/index.tsx"}, created)
+
+	tsx, err := os.ReadFile(filepath.Join(root, "routes", "users._id.edit", "index.tsx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(tsx), `import { SSR, type RoutesUsersIdEditSSRProps } from "@rstf/routes/users._id.edit";`)
+	assert.Contains(t, string(tsx), `SSR(function View({ title, id }: RoutesUsersIdEditSSRProps)`)
+
+	routes, err := codegen.ParseDir(root)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "usersedit", routes[0].Package)
+	assert.Equal(t, []codegen.RouteFunc{{Name: "SSR", Kind: codegen.RouteFuncKindSSR, ReturnType: "ServerData", HasContext: true}}, routes[0].Funcs)
+	require.Len(t, routes[0].Structs, 1)
+	var fields []string
+	for _, f := range routes[0].Structs[0].Fields {
+		fields = append(fields, f.JSONName)
+	}
+	assert.Equal(t, []string{"title", "id"}, fields)
+
+	_, err = GenerateRoute(root, "users._id.edit")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "routes/users._id.edit already exists")
+}
+
+func TestGenerateComponent(t *testing.T) {
+	root := t.TempDir()
+
+	created, err := GenerateComponent(root, "price-tag", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared/ui/price-tag/index.tsx"}, created)
+	tsx, err := os.ReadFile(filepath.Join(root, "shared", "ui", "price-tag", "index.tsx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(tsx), "export function PriceTag()")
+
+	created, err = GenerateComponent(root, "forms/text-field", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"shared/ui/forms/text-field/index.go",
+		"shared/ui/forms/text-field/index_test.go",
+		"shared/ui/forms/text-field/index.tsx",
+	}, created)
+	tsx, err = os.ReadFile(filepath.Join(root, "shared", "ui", "forms", "text-field", "index.tsx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(tsx), `import { SSR, type SharedUiFormsTextFieldSSRProps } from "@rstf/shared/ui/forms/text-field";`)
+	assert.Contains(t, string(tsx), "export const TextField = SSR(function TextField({ title }: SharedUiFormsTextFieldSSRProps)")
+
+	dirs, err := codegen.ParseDir(root)
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	assert.Equal(t, "textfield", dirs[0].Package)
+	assert.Equal(t, []codegen.RouteFunc{{Name: "SSR", Kind: codegen.RouteFuncKindSSR, ReturnType: "ServerData", HasContext: true}}, dirs[0].Funcs)
+
+	for _, name := range []string{"", "PriceTag", "forms/2fa"} {
+		_, err := GenerateComponent(root, name, false)
+		assert.Error(t, err, name)
+	}
+	_, err = GenerateComponent(root, "price-tag", true)
+	assert.ErrorContains(t, err, "shared/ui/price-tag already exists")
+}
@@ -0,0 +1,91 @@
+package upgrade
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	rstfNamedImportRe     = regexp.MustCompile(`import\s*(?:type\s+)?\{([^}]*)\}\s*from\s*["']@rstf/[^"']+["']`)
+	rstfNamespaceImportRe = regexp.MustCompile(`import\s*\*\s*as\s+([\w$]+)\s+from\s*["']@rstf/[^"']+["']`)
+	serverDataSpecifierRe = regexp.MustCompile(`\bserverData\b(\s+as\s+[\w$]+)?`)
+	serverDataCallRe      = regexp.MustCompile(`\bserverData(\s*\()`)
+	serverDataRe          = regexp.MustCompile(`\bserverData\b`)
+)
+
+// serverDataHookCodemod replaces serverData() from the generated @rstf
+// modules with the useServerData() hook they export.
+var serverDataHookCodemod = Codemod{
+	Name: "useServerData",
+	Match: func(path string) bool {
+		return strings.HasSuffix(path, ".tsx") || strings.HasSuffix(path, ".ts")
+	},
+	Apply: rewriteServerData,
+}
+
+func rewriteServerData(src string) (string, []string) {
+	imported, aliased := false, false
+	out := rstfNamedImportRe.ReplaceAllStringFunc(src, func(stmt string) string {
+		return serverDataSpecifierRe.ReplaceAllStringFunc(stmt, func(spec string) string {
+			imported = true
+			if strings.TrimSpace(strings.TrimPrefix(spec, "serverData")) != "" {
+				aliased = true
+			}
+			return "useServerData" + strings.TrimPrefix(spec, "serverData")
+		})
+	})
+	if imported && !aliased {
+		out = serverDataCallRe.ReplaceAllString(out, "useServerData${1}")
+	}
+	for _, m := range rstfNamespaceImportRe.FindAllStringSubmatch(out, -1) {
+		ns := regexp.QuoteMeta(m[1])
+		call := regexp.MustCompile(`(^|[^\w$.])` + ns + `\.serverData\b`)
+		if call.MatchString(out) {
+			imported = true
+			out = call.ReplaceAllString(out, "${1}"+strings.ReplaceAll(m[1], "$", "$$")+".useServerData")
+		}
+	}
+	if !imported {
+		return src, nil
+	}
+
+	var issues []string
+	for i, line := range strings.Split(out, "\n") {
+		if serverDataRe.MatchString(line) && !rstfNamedImportRe.MatchString(line) {
+			issues = append(issues, fmt.Sprintf("line %d still references serverData; use the useServerData() hook inside a component", i+1))
+		}
+	}
+	return out, issues
+}
+
+var generatedImportRe = regexp.MustCompile(`(\bfrom\s*|\bimport\s*\(?\s*|\brequire\s*\(\s*)(["'])((?:\.\.?/)*)\.?rstf/generated/([^"']+)(["'])`)
+
+// generatedImportCodemod replaces relative imports of generated modules, in
+// rstf/generated or the .rstf/generated directory older versions wrote, with
+// the @rstf/* aliases that keep working when the generated layout changes.
+var generatedImportCodemod = Codemod{
+	Name: "rstfImports",
+	Match: func(path string) bool {
+		return strings.HasSuffix(path, ".tsx") || strings.HasSuffix(path, ".ts")
+	},
+	Apply: rewriteGeneratedImports,
+}
+
+func rewriteGeneratedImports(src string) (string, []string) {
+	out := generatedImportRe.ReplaceAllStringFunc(src, func(stmt string) string {
+		m := generatedImportRe.FindStringSubmatch(stmt)
+		if m[2] != m[5] {
+			return stmt
+		}
+		module := m[4]
+		for _, ext := range []string{".d.ts", ".ts", ".tsx", ".js"} {
+			if strings.HasSuffix(module, ext) {
+				module = strings.TrimSuffix(module, ext)
+				break
+			}
+		}
+		return m[1] + m[2] + "@rstf/" + module + m[5]
+	})
+	return out, nil
+}
@@ -0,0 +1,169 @@
+// Package upgrade moves an app to the framework version of the running CLI:
+// it bumps the Go module requirement and rewrites user code for breaking
+// convention changes.
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rafbgarcia/rstf/internal/gotool"
+	"github.com/rafbgarcia/rstf/internal/release"
+)
+
+// Codemod rewrites user code for one breaking change. Apply must be
+// idempotent: upgrade runs every codemod on every file it matches, whatever
+// version the app comes from.
+type Codemod struct {
+	Name string
+	// Match reports whether the codemod applies to the file at path.
+	Match func(path string) bool
+	// Apply returns the rewritten source and a description of each
+	// occurrence it could not fix.
+	Apply func(src string) (string, []string)
+}
+
+// Codemods are the registered codemods, oldest first.
+var Codemods = []Codemod{generatedImportCodemod, serverDataHookCodemod}
+
+// Report lists what RunCodemods changed and what needs a manual fix. Paths
+// are relative to the project root.
+type Report struct {
+	Changed []Change
+	Manual  []ManualFix
+}
+
+// Change is a file a codemod rewrote.
+type Change struct {
+	Path    string
+	Codemod string
+}
+
+// ManualFix is an occurrence a codemod found but could not rewrite.
+type ManualFix struct {
+	Path    string
+	Codemod string
+	Reason  string
+}
+
+// RunCodemods applies Codemods to the app's source files under root, skipping
+// generated and third-party directories. With write false it only reports
+// what would change.
+func RunCodemods(root string, write bool) (Report, error) {
+	var report Report
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case "rstf", ".rstf", ".git", "node_modules", "vendor", "dist":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		var matched []Codemod
+		for _, codemod := range Codemods {
+			if codemod.Match(rel) {
+				matched = append(matched, codemod)
+			}
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", rel, err)
+		}
+		src := string(data)
+		out := src
+		for _, codemod := range matched {
+			next, issues := codemod.Apply(out)
+			if next != out {
+				report.Changed = append(report.Changed, Change{Path: rel, Codemod: codemod.Name})
+			}
+			for _, issue := range issues {
+				report.Manual = append(report.Manual, ManualFix{Path: rel, Codemod: codemod.Name, Reason: issue})
+			}
+			out = next
+		}
+		if write && out != src {
+			if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", rel, err)
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// FrameworkVersion returns the framework version the app at root requires,
+// and the replacement path when go.mod replaces the framework module.
+func FrameworkVersion(root string) (version, replacedBy string, err error) {
+	cmd := exec.Command("go", "mod", "edit", "-json")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	return frameworkRequirement(out)
+}
+
+type goModJSON struct {
+	Require []struct {
+		Path    string
+		Version string
+	}
+	Replace []struct {
+		Old struct{ Path string }
+		New struct {
+			Path    string
+			Version string
+		}
+	}
+}
+
+func frameworkRequirement(modJSON []byte) (version, replacedBy string, err error) {
+	var mod goModJSON
+	if err := json.Unmarshal(modJSON, &mod); err != nil {
+		return "", "", fmt.Errorf("parsing go.mod: %w", err)
+	}
+	for _, req := range mod.Require {
+		if req.Path == release.FrameworkModule {
+			version = req.Version
+		}
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("go.mod does not require %s", release.FrameworkModule)
+	}
+	for _, rep := range mod.Replace {
+		if rep.Old.Path == release.FrameworkModule {
+			replacedBy = strings.TrimSpace(rep.New.Path + " " + rep.New.Version)
+		}
+	}
+	return version, replacedBy, nil
+}
+
+// BumpFramework requires version of the framework module in the app at root.
+func BumpFramework(root, version string) error {
+	cmd := exec.Command("go", "get", release.FrameworkModule+"@"+version)
+	cmd.Dir = root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	gotool.Prepare(cmd)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go get %s@%s: %w", release.FrameworkModule, version, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestRewriteServerData(t *testing.T) {
+	out, issues := rewriteServerData(`import { serverData, href } from "@rstf/routes/dashboard";
+
+export function View() {
+  const data = serverData();
+  return <a href={href("/")}>{data.title}</a>;
+}
+`)
+	assert.Empty(t, issues)
+	assert.Equal(t, `import { useServerData, href } from "@rstf/routes/dashboard";
+
+export function View() {
+  const data = useServerData();
+  return <a href={href("/")}>{data.title}</a>;
+}
+`, out)
+
+	again, issues := rewriteServerData(out)
+	assert.Equal(t, out, again, "codemods are idempotent")
+	assert.Empty(t, issues)
+}
+
+func TestRewriteServerData_AliasAndNamespace(t *testing.T) {
+	out, issues := rewriteServerData(`import { serverData as dashboardData } from "@rstf/routes/dashboard";
+import * as badge from "@rstf/shared/ui/badge";
+
+export function View() {
+  const data = dashboardData();
+  const b = badge.serverData();
+  return null;
+}
+`)
+	assert.Empty(t, issues)
+	assert.Contains(t, out, `import { useServerData as dashboardData } from "@rstf/routes/dashboard";`)
+	assert.Contains(t, out, "const b = badge.useServerData();")
+}
+
+func TestRewriteServerData_ReportsLeftovers(t *testing.T) {
+	out, issues := rewriteServerData(`import { serverData } from "@rstf/routes/dashboard";
+
+const load = serverData;
+`)
+	assert.Contains(t, out, `import { useServerData } from "@rstf/routes/dashboard";`)
+	assert.Equal(t, []string{"line 3 still references serverData; use the useServerData() hook inside a component"}, issues)
+}
+
+func TestRewriteServerData_IgnoresOtherModules(t *testing.T) {
+	src := `import { serverData } from "./local";
+
+const data = serverData();
+`
+	out, issues := rewriteServerData(src)
+	assert.Equal(t, src, out)
+	assert.Empty(t, issues)
+}
+
+func TestRewriteGeneratedImports(t *testing.T) {
+	out, issues := rewriteGeneratedImports(`import { SSR } from "../../rstf/generated/routes/dashboard";
+import { routes } from '../../.rstf/generated/routes.ts';
+import "../rstf/generated/client";
+const chart = await import("../../rstf/generated/shared/ui/chart");
+import { format } from "../../lib/rstf/generated/format";
+`)
+	assert.Empty(t, issues)
+	assert.Equal(t, `import { SSR } from "@rstf/routes/dashboard";
+import { routes } from '@rstf/routes';
+import "@rstf/client";
+const chart = await import("@rstf/shared/ui/chart");
+import { format } from "../../lib/rstf/generated/format";
+`, out)
+
+	again, _ := rewriteGeneratedImports(out)
+	assert.Equal(t, out, again, "codemods are idempotent")
+}
+
+func TestRunCodemods_GeneratedImportsBeforeServerData(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "routes", "dashboard", "index.tsx")
+	writeFile(t, path, `import { serverData } from "../../.rstf/generated/routes/dashboard";
+export const View = () => <p>{serverData().title}</p>;
+`)
+
+	report, err := RunCodemods(root, true)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "routes/dashboard/index.tsx", Codemod: "rstfImports"},
+		{Path: "routes/dashboard/index.tsx", Codemod: "useServerData"},
+	}, report.Changed)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `import { useServerData } from "@rstf/routes/dashboard";
+export const View = () => <p>{useServerData().title}</p>;
+`, string(data))
+}
+
+func TestRunCodemods(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "routes", "dashboard", "index.tsx"), `import { serverData } from "@rstf/routes/dashboard";
+export const View = () => <p>{serverData().title}</p>;
+`)
+	writeFile(t, filepath.Join(root, "routes", "about", "index.tsx"), `export const View = () => <p>About</p>;
+`)
+	writeFile(t, filepath.Join(root, "rstf", "generated.ts"), `import { serverData } from "@rstf/routes/dashboard";
+`)
+
+	report, err := RunCodemods(root, false)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "routes/dashboard/index.tsx", Codemod: "useServerData"}}, report.Changed)
+	data, err := os.ReadFile(filepath.Join(root, "routes", "dashboard", "index.tsx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "serverData()", "dry run leaves files alone")
+
+	_, err = RunCodemods(root, true)
+	require.NoError(t, err)
+	data, err = os.ReadFile(filepath.Join(root, "routes", "dashboard", "index.tsx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "useServerData().title")
+}
+
+func TestFrameworkRequirement(t *testing.T) {
+	version, replacedBy, err := frameworkRequirement([]byte(`{
+		"Require": [{"Path": "github.com/rafbgarcia/rstf", "Version": "v0.1.0-alpha.5"}],
+		"Replace": [{"Old": {"Path": "github.com/rafbgarcia/rstf"}, "New": {"Path": "../rstf"}}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0-alpha.5", version)
+	assert.Equal(t, "../rstf", replacedBy)
+
+	_, _, err = frameworkRequirement([]byte(`{"Require": []}`))
+	assert.ErrorContains(t, err, "go.mod does not require github.com/rafbgarcia/rstf")
+}
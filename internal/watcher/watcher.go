@@ -13,12 +13,14 @@ import (
 type Event struct {
 	Path string // Absolute path of the changed file
 	Kind string // "go" or "tsx"
+	Op   string // "create" if the batch saw the file created, else "write" or "rename"
 }
 
-// Watcher monitors an app directory for .go, .tsx, and .css file changes.
+// Watcher monitors an app directory for .go, .tsx, and stylesheet changes.
 type Watcher struct {
 	appRoot  string
 	onChange func([]Event)
+	ignore   func(dir string) bool
 	fsw      *fsnotify.Watcher
 	done     chan struct{}
 }
@@ -33,6 +35,13 @@ func New(appRoot string, onChange func([]Event)) *Watcher {
 	}
 }
 
+// SetIgnore makes the watcher skip the directories ignore reports true for,
+// besides the hidden ones, node_modules, and rstf. ignore receives the path
+// relative to appRoot, with forward slashes. Call it before Start.
+func (w *Watcher) SetIgnore(ignore func(dir string) bool) {
+	w.ignore = ignore
+}
+
 // Start begins watching the directory tree. It walks appRoot to add all
 // non-ignored directories, then starts a goroutine to process events.
 func (w *Watcher) Start() error {
@@ -47,7 +56,7 @@ func (w *Watcher) Start() error {
 		if err != nil {
 			return nil // skip unreadable dirs
 		}
-		if d.IsDir() && shouldIgnoreDir(w.appRoot, path) {
+		if d.IsDir() && w.shouldIgnoreDir(path) {
 			return filepath.SkipDir
 		}
 		if d.IsDir() {
@@ -88,6 +97,9 @@ func (w *Watcher) loop() {
 				return
 			}
 			if e, ok := w.toEvent(ev); ok {
+				if prev, ok := pending[e.Path]; ok && prev.Op == "create" {
+					e.Op = prev.Op
+				}
 				pending[e.Path] = e
 				timer.Reset(debounce)
 			}
@@ -124,7 +136,7 @@ func (w *Watcher) toEvent(ev fsnotify.Event) (Event, bool) {
 				if err != nil {
 					return nil
 				}
-				if d.IsDir() && shouldIgnoreDir(w.appRoot, path) {
+				if d.IsDir() && w.shouldIgnoreDir(path) {
 					return filepath.SkipDir
 				}
 				if d.IsDir() {
@@ -141,10 +153,18 @@ func (w *Watcher) toEvent(ev fsnotify.Event) (Event, bool) {
 		return Event{}, false
 	}
 
-	return Event{Path: ev.Name, Kind: kind}, true
+	op := "write"
+	switch {
+	case ev.Op&fsnotify.Create != 0:
+		op = "create"
+	case ev.Op&fsnotify.Rename != 0:
+		op = "rename"
+	}
+	return Event{Path: ev.Name, Kind: kind, Op: op}, true
 }
 
 // fileKind returns "go", "tsx", or "css" for watched extensions, "" otherwise.
+// Sass sources count as "css" so edits to partials rebuild the stylesheet.
 func fileKind(path string) string {
 	if strings.HasSuffix(path, ".go") {
 		return "go"
@@ -152,18 +172,18 @@ func fileKind(path string) string {
 	if strings.HasSuffix(path, ".tsx") {
 		return "tsx"
 	}
-	if strings.HasSuffix(path, ".css") {
+	if strings.HasSuffix(path, ".css") || strings.HasSuffix(path, ".scss") || strings.HasSuffix(path, ".sass") {
 		return "css"
 	}
 	return ""
 }
 
 // shouldIgnoreDir returns true if the directory should not be watched.
-func shouldIgnoreDir(appRoot, path string) bool {
+func (w *Watcher) shouldIgnoreDir(path string) bool {
 	name := filepath.Base(path)
 
 	// Hidden directories (.git, .rstf, .DS_Store, etc.)
-	if strings.HasPrefix(name, ".") && path != appRoot {
+	if strings.HasPrefix(name, ".") && path != w.appRoot {
 		return true
 	}
 
@@ -171,5 +191,10 @@ func shouldIgnoreDir(appRoot, path string) bool {
 		return true
 	}
 
+	if w.ignore != nil {
+		if rel, err := filepath.Rel(w.appRoot, path); err == nil && rel != "." {
+			return w.ignore(filepath.ToSlash(rel))
+		}
+	}
 	return false
 }
@@ -36,6 +36,12 @@ func TestGoFileChange(t *testing.T) {
 	batch, ok := waitBatch(events, 2*time.Second)
 	require.True(t, ok, "expected event for .go file, got none")
 	assert.Equal(t, "go", batch[0].Kind)
+	assert.Equal(t, "create", batch[0].Op)
+
+	os.WriteFile(path, []byte("package main\n"), 0644)
+	batch, ok = waitBatch(events, 2*time.Second)
+	require.True(t, ok, "expected event for the .go file's second write, got none")
+	assert.Equal(t, "write", batch[0].Op)
 }
 
 func TestTsxFileChange(t *testing.T) {
@@ -70,6 +76,23 @@ func TestCssFileChange(t *testing.T) {
 	assert.Equal(t, "css", batch[0].Kind)
 }
 
+func TestScssPartialChange(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "styles"), 0755))
+
+	events := make(chan []Event, 10)
+	w := New(dir, func(batch []Event) { events <- batch })
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	path := filepath.Join(dir, "styles", "_vars.scss")
+	os.WriteFile(path, []byte("$brand: red;"), 0644)
+
+	batch, ok := waitBatch(events, 2*time.Second)
+	require.True(t, ok, "expected event for .scss file, got none")
+	assert.Equal(t, "css", batch[0].Kind)
+}
+
 func TestTsFileIgnored(t *testing.T) {
 	dir := t.TempDir()
 
@@ -109,6 +132,35 @@ func TestIgnoredDirectories(t *testing.T) {
 	assert.False(t, ok, "expected no event for files in ignored directories, but got one")
 }
 
+func TestSetIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"scripts", "tools/gen", "routes"} {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+	}
+
+	events := make(chan []Event, 10)
+	w := New(dir, func(batch []Event) { events <- batch })
+	var seen []string
+	w.SetIgnore(func(rel string) bool {
+		seen = append(seen, rel)
+		return rel == "scripts" || rel == "tools/gen"
+	})
+	require.NoError(t, w.Start())
+	defer w.Stop()
+	assert.ElementsMatch(t, []string{"routes", "scripts", "tools", "tools/gen"}, seen)
+
+	for _, name := range []string{"scripts", "tools/gen"} {
+		os.WriteFile(filepath.Join(dir, name, "main.go"), []byte("package x"), 0644)
+	}
+	_, ok := waitBatch(events, 500*time.Millisecond)
+	assert.False(t, ok, "expected no event for files in ignored directories, but got one")
+
+	os.WriteFile(filepath.Join(dir, "routes", "index.go"), []byte("package routes"), 0644)
+	batch, ok := waitBatch(events, 2*time.Second)
+	require.True(t, ok, "expected event for .go file outside ignored directories, got none")
+	assert.Equal(t, filepath.Join(dir, "routes", "index.go"), batch[0].Path)
+}
+
 func TestNewSubdirectoryWatched(t *testing.T) {
 	dir := t.TempDir()
 
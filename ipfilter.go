@@ -0,0 +1,170 @@
+package rstf
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DevClientAddrHeader carries the client's address from the rstf dev proxy
+// to the app server, whose connections all come from the proxy. The proxy
+// overwrites it on every request, and the app only reads it in dev mode.
+const DevClientAddrHeader = "X-Rstf-Dev-Client-Addr"
+
+// IPFilter restricts which client addresses may reach the paths under
+// PathPrefix, or every path when PathPrefix is empty. Entries are CIDR
+// ranges such as "10.0.0.0/8" or single addresses.
+//
+// A client matching Deny is rejected. When Allow is set, a client must also
+// match one of its entries.
+type IPFilter struct {
+	PathPrefix string
+	Allow      []string
+	Deny       []string
+}
+
+type ipFilter struct {
+	pathPrefix string
+	allow      []netip.Prefix
+	deny       []netip.Prefix
+}
+
+// AddIPFilter registers filter. Every filter whose PathPrefix matches a
+// request must admit its client; rejected requests get a 403 before any
+// route code or SSR runs.
+func (a *App) AddIPFilter(filter IPFilter) error {
+	if len(filter.Allow) == 0 && len(filter.Deny) == 0 {
+		return fmt.Errorf("ip filter must allow or deny at least one address")
+	}
+	allow, err := parseIPPrefixes(filter.Allow)
+	if err != nil {
+		return err
+	}
+	deny, err := parseIPPrefixes(filter.Deny)
+	if err != nil {
+		return err
+	}
+	a.ipFilters = append(a.ipFilters, ipFilter{
+		pathPrefix: strings.TrimSuffix(filter.PathPrefix, "/"),
+		allow:      allow,
+		deny:       deny,
+	})
+	return nil
+}
+
+// SetClientIPHeader makes IP filters read the client address from header
+// instead of the connection's remote address. Use it only behind a proxy that
+// sets the header, such as "X-Real-IP". For comma-separated headers like
+// "X-Forwarded-For" the last entry, added by the nearest proxy, is used.
+func (a *App) SetClientIPHeader(header string) error {
+	if strings.TrimSpace(header) == "" {
+		return fmt.Errorf("client IP header must not be empty")
+	}
+	a.clientIPHeader = http.CanonicalHeaderKey(header)
+	return nil
+}
+
+// NewIPFilterMiddleware returns middleware enforcing the App's IP filters. It
+// passes requests through unchanged when no filter is configured.
+func NewIPFilterMiddleware(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if len(app.ipFilters) == 0 || app.clientAllowed(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			WriteErrorEnvelope(w, &RequestError{
+				Code:    ErrorCodeForbidden,
+				Message: "client address is not allowed",
+			})
+		})
+	}
+}
+
+func (a *App) clientAllowed(req *http.Request) bool {
+	var addr netip.Addr
+	resolved := false
+	for _, filter := range a.ipFilters {
+		if !hasPathPrefix(req.URL.Path, filter.pathPrefix) {
+			continue
+		}
+		if !resolved {
+			addr, resolved = a.clientAddr(req), true
+		}
+		if !addr.IsValid() || matchesIPPrefix(filter.deny, addr) {
+			return false
+		}
+		if len(filter.allow) > 0 && !matchesIPPrefix(filter.allow, addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// clientAddr returns the request's client address, or the zero Addr when it
+// cannot be parsed. Under rstf dev, the remote address is the dev proxy's, so
+// the client's comes from DevClientAddrHeader instead.
+func (a *App) clientAddr(req *http.Request) netip.Addr {
+	raw := req.RemoteAddr
+	if a.clientIPHeader != "" {
+		raw = req.Header.Get(a.clientIPHeader)
+		if i := strings.LastIndexByte(raw, ','); i >= 0 {
+			raw = raw[i+1:]
+		}
+		raw = strings.TrimSpace(raw)
+	} else {
+		if dev := req.Header.Get(DevClientAddrHeader); dev != "" && a.devMode {
+			raw = dev
+		}
+		if host, _, err := net.SplitHostPort(raw); err == nil {
+			raw = host
+		}
+	}
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func parseIPPrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("ip filter: invalid address %q", entry)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("ip filter: invalid CIDR %q", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func matchesIPPrefix(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix reports whether path is prefix or lies under it.
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	rest, found := strings.CutPrefix(path, prefix)
+	return found && (rest == "" || rest[0] == '/')
+}
@@ -0,0 +1,105 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serveFromIP(h http.Handler, remoteAddr, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.AddIPFilter(IPFilter{Deny: []string{"203.0.113.7"}}))
+	require.NoError(t, app.AddIPFilter(IPFilter{PathPrefix: "/admin", Allow: []string{"10.0.0.0/8", "::1"}}))
+
+	h := NewIPFilterMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		remoteAddr string
+		path       string
+		status     int
+	}{
+		{"198.51.100.1:5000", "/", http.StatusNoContent},
+		{"203.0.113.7:5000", "/", http.StatusForbidden},
+		{"198.51.100.1:5000", "/admin/users", http.StatusForbidden},
+		{"198.51.100.1:5000", "/administrators", http.StatusNoContent},
+		{"10.1.2.3:5000", "/admin", http.StatusNoContent},
+		{"[::1]:5000", "/admin/users", http.StatusNoContent},
+		{"[::ffff:10.1.2.3]:5000", "/admin", http.StatusNoContent},
+		{"garbage", "/admin", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		rec := serveFromIP(h, tc.remoteAddr, tc.path)
+		require.Equal(t, tc.status, rec.Code, "%s %s", tc.remoteAddr, tc.path)
+		if tc.status == http.StatusForbidden {
+			require.Contains(t, rec.Body.String(), `"code":"forbidden"`)
+		}
+	}
+}
+
+func TestIPFilterMiddleware_ClientIPHeader(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.SetClientIPHeader(" "))
+	require.NoError(t, app.SetClientIPHeader("x-forwarded-for"))
+	require.NoError(t, app.AddIPFilter(IPFilter{Allow: []string{"192.0.2.0/24"}}))
+
+	h := NewIPFilterMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 192.0.2.9")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	req.Header.Set("X-Forwarded-For", "192.0.2.9, 10.0.0.1")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code, "only the entry added by the nearest proxy is trusted")
+}
+
+func TestIPFilterMiddleware_DevProxyAddr(t *testing.T) {
+	serve := func(dev bool) int {
+		t.Setenv(DevModeEnv, "")
+		if dev {
+			t.Setenv(DevModeEnv, "1")
+		}
+		app := NewApp()
+		require.NoError(t, app.AddIPFilter(IPFilter{Deny: []string{"127.0.0.1"}}))
+		h := NewIPFilterMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		req.Header.Set(DevClientAddrHeader, "198.51.100.1:6000")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(true), "rstf dev passes the client's address on")
+	require.Equal(t, http.StatusForbidden, serve(false), "the header is ignored outside dev mode")
+}
+
+func TestAddIPFilter_Invalid(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.AddIPFilter(IPFilter{PathPrefix: "/admin"}))
+	require.ErrorContains(t, app.AddIPFilter(IPFilter{Allow: []string{"10.0.0.0/33"}}), `invalid CIDR "10.0.0.0/33"`)
+	require.ErrorContains(t, app.AddIPFilter(IPFilter{Deny: []string{"localhost"}}), `invalid address "localhost"`)
+
+	called := false
+	h := NewIPFilterMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+	serveFromIP(h, "garbage", "/admin")
+	require.True(t, called, "without filters every request passes")
+}
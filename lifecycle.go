@@ -0,0 +1,109 @@
+package rstf
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestHook is called when a request reaches a route, before its handler
+// runs. route is the URL pattern the request matched, such as "/posts/{slug}".
+type RequestHook func(ctx *Context, route string)
+
+// ResponseHook is called after a route's handler returns, with the status it
+// responded with and how long it took.
+type ResponseHook func(ctx *Context, route string, status int, duration time.Duration)
+
+// OnRequest registers a hook invoked for every request to a route: the
+// convention routes and those registered with Route. Hooks run in
+// registration order.
+func (a *App) OnRequest(hook RequestHook) {
+	if hook == nil {
+		return
+	}
+	a.requestHooks = append(a.requestHooks, hook)
+}
+
+// OnResponse registers a hook invoked once a route has responded. A handler
+// that panics before writing is reported with status 500.
+func (a *App) OnResponse(hook ResponseHook) {
+	if hook == nil {
+		return
+	}
+	a.responseHooks = append(a.responseHooks, hook)
+}
+
+// NewLifecycleHandler wraps the handler the generated server registers at
+// route so it calls the App's OnRequest and OnResponse hooks and writes its
+// audit log. The hooks, the audit log, the route's policies, and its handler
+// share one Context, which the handler gets with ContextFromRequest, even when
+// the App has no hooks.
+func NewLifecycleHandler(app *App, route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		slot, req := withContextSlot(req)
+		ctx := NewContext(req)
+		ctx.App = app
+		slot.ctx = ctx
+		if len(app.requestHooks) == 0 && len(app.responseHooks) == 0 && app.auditLog == nil {
+			ctx.Writer = w
+			next.ServeHTTP(w, ctx.Request)
+			return
+		}
+
+		tracker := NewResponseTracker(w)
+		ctx.Writer = tracker
+		for _, hook := range app.requestHooks {
+			hook(ctx, route)
+		}
+
+		start := time.Now()
+		completed := false
+		defer func() {
+			status := tracker.StatusCode()
+			if !completed && !tracker.Written() {
+				status = http.StatusInternalServerError
+			}
+			duration := time.Since(start)
+			for _, hook := range app.responseHooks {
+				hook(ctx, route, status, duration)
+			}
+			if app.auditLog != nil {
+				app.auditRoute(ctx, route, status)
+			}
+		}()
+		next.ServeHTTP(tracker, ctx.Request)
+		completed = true
+	})
+}
+
+type requestContextKey struct{}
+
+// contextSlot holds a request's Context once its route's lifecycle handler
+// creates it. The recovery middleware attaches the slot before the route is
+// known, so it can report a panic with that Context.
+type contextSlot struct {
+	ctx *Context
+}
+
+// withContextSlot returns the slot req carries, attaching an empty one to the
+// returned request if it has none.
+func withContextSlot(req *http.Request) (*contextSlot, *http.Request) {
+	if slot, ok := req.Context().Value(requestContextKey{}).(*contextSlot); ok {
+		return slot, req
+	}
+	slot := &contextSlot{}
+	return slot, req.WithContext(context.WithValue(req.Context(), requestContextKey{}, slot))
+}
+
+// ContextFromRequest returns the Context NewLifecycleHandler created for req,
+// or nil if req did not reach a route.
+func ContextFromRequest(req *http.Request) *Context {
+	if req == nil {
+		return nil
+	}
+	slot, _ := req.Context().Value(requestContextKey{}).(*contextSlot)
+	if slot == nil {
+		return nil
+	}
+	return slot.ctx
+}
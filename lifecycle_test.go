@@ -0,0 +1,97 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleHandler_CallsHooksWithRouteAndStatus(t *testing.T) {
+	app := NewApp()
+	var events []string
+	var gotStatus int
+	var gotDuration time.Duration
+	app.OnRequest(func(ctx *Context, route string) {
+		events = append(events, "request "+route+" "+ctx.Request.URL.Path)
+	})
+	app.OnResponse(func(ctx *Context, route string, status int, duration time.Duration) {
+		events = append(events, "response "+route)
+		gotStatus = status
+		gotDuration = duration
+	})
+
+	handler := NewLifecycleHandler(app, "/posts/{slug}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		events = append(events, "handler")
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts/hello", nil))
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, []string{"request /posts/{slug} /posts/hello", "handler", "response /posts/{slug}"}, events)
+	require.Equal(t, http.StatusCreated, gotStatus)
+	require.GreaterOrEqual(t, gotDuration, time.Millisecond)
+}
+
+func TestLifecycleHandler_DefaultsToOK(t *testing.T) {
+	app := NewApp()
+	var gotStatus int
+	app.OnResponse(func(ctx *Context, route string, status int, duration time.Duration) {
+		gotStatus = status
+	})
+
+	handler := NewLifecycleHandler(app, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusOK, gotStatus)
+}
+
+func TestLifecycleHandler_ReportsPanicAs500(t *testing.T) {
+	app := NewApp()
+	var gotStatus int
+	app.OnResponse(func(ctx *Context, route string, status int, duration time.Duration) {
+		gotStatus = status
+	})
+
+	handler := NewRecoveryMiddleware(app)(NewLifecycleHandler(app, "/boom", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, http.StatusInternalServerError, gotStatus)
+}
+
+func TestLifecycleHandler_SharesContextWithHandler(t *testing.T) {
+	app := NewApp()
+	var hookCtx, handlerCtx *Context
+	app.OnResponse(func(ctx *Context, route string, status int, duration time.Duration) {
+		hookCtx = ctx
+	})
+
+	handler := NewLifecycleHandler(app, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handlerCtx = ContextFromRequest(req)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.NotNil(t, handlerCtx)
+	require.Same(t, hookCtx, handlerCtx)
+	require.Nil(t, ContextFromRequest(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestLifecycleHandler_AttachesContextWithoutHooks(t *testing.T) {
+	app := NewApp()
+	var handlerCtx *Context
+	handler := NewLifecycleHandler(app, "/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handlerCtx = ContextFromRequest(req)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.NotNil(t, handlerCtx)
+	require.Same(t, app, handlerCtx.App)
+}
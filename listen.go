@@ -0,0 +1,41 @@
+package rstf
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// ListenFDEnv names the environment variable through which rstf dev and rstf
+// bench hand the app server an inherited listening socket, as a file
+// descriptor number.
+const ListenFDEnv = "RSTF_LISTEN_FD"
+
+// TestURLEnv names the environment variable through which rstf test hands
+// the tests it runs the base URL of the app server it started for them.
+const TestURLEnv = "RSTF_TEST_URL"
+
+// Listen returns the listener the app server accepts connections on. Under
+// rstf dev this is the socket inherited through ListenFDEnv: the dev process
+// keeps it open across restarts, so connections made while the server rebuilds
+// wait in the accept queue instead of being refused. Otherwise it listens on
+// addr.
+func Listen(addr string) (net.Listener, error) {
+	value := os.Getenv(ListenFDEnv)
+	if value == "" {
+		return net.Listen("tcp", addr)
+	}
+
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return nil, fmt.Errorf("invalid %s %q", ListenFDEnv, value)
+	}
+	f := os.NewFile(uintptr(fd), "rstf-listener")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited listener: %w", err)
+	}
+	return ln, nil
+}
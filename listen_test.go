@@ -0,0 +1,40 @@
+package rstf
+
+import (
+	"net"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_InheritedFD(t *testing.T) {
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer parent.Close()
+	f, err := parent.(*net.TCPListener).File()
+	require.NoError(t, err)
+	// Listen takes ownership of the descriptor, as the server does after exec.
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	f.Close()
+
+	t.Setenv(ListenFDEnv, strconv.Itoa(fd))
+	ln, err := Listen(":0")
+	require.NoError(t, err)
+	defer ln.Close()
+	require.Equal(t, parent.Addr().String(), ln.Addr().String())
+
+	// The parent's socket outlives the child's listener.
+	require.NoError(t, ln.Close())
+	conn, err := net.Dial("tcp", parent.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestListen_InvalidFD(t *testing.T) {
+	t.Setenv(ListenFDEnv, "stdin")
+	_, err := Listen(":0")
+	require.ErrorContains(t, err, "invalid "+ListenFDEnv)
+}
@@ -218,12 +218,46 @@ func (h *LiveHub) Invalidate(keys ...SubscriptionKey) {
 	}
 }
 
+// InvalidateRoute reruns every subscription to the route's queries. A tenant
+// limits it to that tenant's subscriptions; "" reruns them for all tenants.
+func (h *LiveHub) InvalidateRoute(routeName, tenant string) {
+	if h == nil || routeName == "" {
+		return
+	}
+	prefix := "query:" + routeName + ":"
+
+	h.mu.RLock()
+	var keys []SubscriptionKey
+	for key := range h.subsByKey {
+		k := string(key)
+		if tenant == "" {
+			if rest, ok := strings.CutPrefix(k, "tenant:"); ok {
+				_, k, _ = strings.Cut(rest, ":")
+			}
+		} else {
+			var ok bool
+			if k, ok = strings.CutPrefix(k, "tenant:"+tenant+":"); !ok {
+				continue
+			}
+		}
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	h.mu.RUnlock()
+
+	h.Invalidate(keys...)
+}
+
 func (h *LiveHub) refresh(sub *LiveSubscription) {
 	if sub == nil || sub.Execute == nil {
 		return
 	}
 
 	data, err := sub.Execute()
+	if err == nil {
+		data, err = FormatFields(data)
+	}
 	if err != nil {
 		re := requestErrorFrom(err)
 		h.publish(sub.ClientID, LiveEvent{
@@ -257,3 +291,18 @@ func (h *LiveHub) publish(clientID string, event LiveEvent) {
 	default:
 	}
 }
+
+// Close ends every live connection. Clients reconnect on their own, so the
+// server calls it on shutdown to let open event streams drain.
+func (h *LiveHub) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for clientID, client := range h.clients {
+		close(client.ch)
+		delete(h.clients, clientID)
+	}
+}
@@ -0,0 +1,108 @@
+package rstf
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PageMeta describes a route's page to browsers, search engines, and link
+// previews. A route declares it by exporting func Meta(ctx *rstf.Context)
+// rstf.PageMeta, or Meta() without the context when it does not depend on the
+// request.
+type PageMeta struct {
+	Title       string // replaces the page's <title>
+	Description string // <meta name="description">
+
+	// OpenGraph holds og: properties for link previews, keyed without the
+	// prefix, e.g. {"title": "...", "image": "https://..."}.
+	OpenGraph map[string]string
+
+	// StructuredData is marshaled to JSON into a JSON-LD script, e.g. a
+	// schema.org Article.
+	StructuredData any
+
+	NoIndex   bool   // keep the page out of search results
+	NoFollow  bool   // don't follow the page's links
+	Canonical string // absolute URL search engines should index instead of this one
+
+	// RobotsHeader also sends the robots directives as an X-Robots-Tag
+	// header, which crawlers honor without parsing the page.
+	RobotsHeader bool
+}
+
+// Robots returns the robots directives, such as "noindex, nofollow", or ""
+// when the page can be indexed and followed.
+func (m PageMeta) Robots() string {
+	var directives []string
+	if m.NoIndex {
+		directives = append(directives, "noindex")
+	}
+	if m.NoFollow {
+		directives = append(directives, "nofollow")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// HeadTags returns the tags to insert into the page's <head>, other than the
+// title.
+func (m PageMeta) HeadTags() (string, error) {
+	var b strings.Builder
+	if m.Description != "" {
+		b.WriteString("<meta name=\"description\" content=\"" + html.EscapeString(m.Description) + "\">\n")
+	}
+	for _, property := range slices.Sorted(maps.Keys(m.OpenGraph)) {
+		b.WriteString("<meta property=\"og:" + html.EscapeString(property) + "\" content=\"" + html.EscapeString(m.OpenGraph[property]) + "\">\n")
+	}
+	if robots := m.Robots(); robots != "" {
+		b.WriteString("<meta name=\"robots\" content=\"" + robots + "\">\n")
+	}
+	if m.Canonical != "" {
+		b.WriteString("<link rel=\"canonical\" href=\"" + html.EscapeString(m.Canonical) + "\">\n")
+	}
+	if m.StructuredData != nil {
+		// json.Marshal escapes <, >, and &, so the data cannot close the script.
+		data, err := json.Marshal(m.StructuredData)
+		if err != nil {
+			return "", fmt.Errorf("rstf: marshal structured data: %w", err)
+		}
+		b.WriteString("<script type=\"application/ld+json\">" + string(data) + "</script>\n")
+	}
+	return b.String(), nil
+}
+
+var titleTagRe = regexp.MustCompile(`(?is)<title\b[^>]*>.*?</title>`)
+
+// Apply adds the metadata to a rendered page: it replaces the <title> the
+// layout rendered, or adds one, and inserts the other tags before </head>.
+func (m PageMeta) Apply(page string) (string, error) {
+	tags, err := m.HeadTags()
+	if err != nil {
+		return "", err
+	}
+	head, rest, ok := strings.Cut(page, "</head>")
+	if !ok {
+		return page, nil
+	}
+	if m.Title != "" {
+		title := "<title>" + html.EscapeString(m.Title) + "</title>"
+		if loc := titleTagRe.FindStringIndex(head); loc != nil {
+			head = head[:loc[0]] + title + head[loc[1]:]
+		} else {
+			tags = title + "\n" + tags
+		}
+	}
+	return head + tags + "</head>" + rest, nil
+}
+
+// SetHeaders sets X-Robots-Tag on w when RobotsHeader is enabled.
+func (m PageMeta) SetHeaders(w http.ResponseWriter) {
+	if robots := m.Robots(); m.RobotsHeader && robots != "" {
+		w.Header().Set("X-Robots-Tag", robots)
+	}
+}
@@ -0,0 +1,76 @@
+package rstf
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func headTags(t *testing.T, meta PageMeta) string {
+	t.Helper()
+	tags, err := meta.HeadTags()
+	require.NoError(t, err)
+	return tags
+}
+
+func TestPageMeta(t *testing.T) {
+	require.Equal(t, "", headTags(t, PageMeta{}))
+
+	meta := PageMeta{NoIndex: true, NoFollow: true, Canonical: "https://example.com/posts?a=1&b=2"}
+	require.Equal(t, "noindex, nofollow", meta.Robots())
+	require.Equal(t,
+		"<meta name=\"robots\" content=\"noindex, nofollow\">\n"+
+			"<link rel=\"canonical\" href=\"https://example.com/posts?a=1&amp;b=2\">\n",
+		headTags(t, meta))
+
+	rec := httptest.NewRecorder()
+	meta.SetHeaders(rec)
+	require.Empty(t, rec.Header().Get("X-Robots-Tag"), "header is opt-in")
+
+	meta.RobotsHeader = true
+	meta.SetHeaders(rec)
+	require.Equal(t, "noindex, nofollow", rec.Header().Get("X-Robots-Tag"))
+
+	rec = httptest.NewRecorder()
+	PageMeta{Canonical: "https://example.com/", RobotsHeader: true}.SetHeaders(rec)
+	require.Empty(t, rec.Header().Get("X-Robots-Tag"))
+}
+
+func TestPageMeta_SEOTags(t *testing.T) {
+	meta := PageMeta{
+		Title:       "Tom & Jerry",
+		Description: `A "classic"`,
+		OpenGraph:   map[string]string{"title": "Tom & Jerry", "image": "https://example.com/a.png"},
+		StructuredData: map[string]any{
+			"@type":    "Article",
+			"headline": "</script><script>alert(1)</script>",
+		},
+	}
+	require.Equal(t,
+		"<meta name=\"description\" content=\"A &#34;classic&#34;\">\n"+
+			"<meta property=\"og:image\" content=\"https://example.com/a.png\">\n"+
+			"<meta property=\"og:title\" content=\"Tom &amp; Jerry\">\n"+
+			`<script type="application/ld+json">{"@type":"Article","headline":"\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e"}</script>`+"\n",
+		headTags(t, meta))
+
+	_, err := PageMeta{StructuredData: func() {}}.HeadTags()
+	require.ErrorContains(t, err, "rstf: marshal structured data")
+}
+
+func TestPageMetaApply(t *testing.T) {
+	page := "<html><head><meta charset=\"utf-8\"><title>App</title></head><body><title>svg</title></body></html>"
+
+	got, err := PageMeta{Title: "Posts <1>", NoIndex: true}.Apply(page)
+	require.NoError(t, err)
+	require.Equal(t, "<html><head><meta charset=\"utf-8\"><title>Posts &lt;1&gt;</title>"+
+		"<meta name=\"robots\" content=\"noindex\">\n</head><body><title>svg</title></body></html>", got)
+
+	got, err = PageMeta{Title: "Posts"}.Apply("<html><head></head><body></body></html>")
+	require.NoError(t, err)
+	require.Equal(t, "<html><head><title>Posts</title>\n</head><body></body></html>", got)
+
+	got, err = PageMeta{}.Apply(page)
+	require.NoError(t, err)
+	require.Equal(t, page, got, "an empty PageMeta leaves the page alone")
+}
@@ -0,0 +1,47 @@
+package rstf
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// MethodOverrideField is the form field through which an HTML form,
+	// which can only send GET and POST, asks for PUT, PATCH, or DELETE:
+	// <input type="hidden" name="_method" value="DELETE">.
+	MethodOverrideField = "_method"
+
+	// MethodOverrideHeader is the header through which a client that cannot
+	// send other methods asks for PUT, PATCH, or DELETE.
+	MethodOverrideHeader = "X-HTTP-Method-Override"
+)
+
+// NewMethodOverrideMiddleware routes a POST to the route's PUT, PATCH, or
+// DELETE handler when it names that method in MethodOverrideHeader or, for a
+// form post, in the MethodOverrideField field. Other methods and values are
+// left alone. Reading the field parses the form, limited to the app's request
+// body limit, so handlers read it from Request.PostForm.
+func NewMethodOverrideMiddleware(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				next.ServeHTTP(w, req)
+				return
+			}
+			method := req.Header.Get(MethodOverrideHeader)
+			if method == "" && IsFormSubmission(req) {
+				if err := parseFormBody(w, req, app.RequestBodyLimitBytes()); err != nil {
+					writeFormError(w, req, app, err)
+					return
+				}
+				method = req.PostForm.Get(MethodOverrideField)
+			}
+			switch method = strings.ToUpper(strings.TrimSpace(method)); method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				req = req.Clone(req.Context())
+				req.Method = method
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package rstf
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodOverrideMiddleware(t *testing.T) {
+	app := NewApp()
+	var gotMethod, gotTitle string
+	handler := NewMethodOverrideMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotMethod = req.Method
+		gotTitle = req.PostFormValue("title")
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		gotMethod, gotTitle = "", ""
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	serve(postForm("/posts/1", "_method=delete&title=Hello", nil))
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "Hello", gotTitle, "handlers still read the parsed form")
+
+	req := postForm("/posts/1", "title=Hello", nil)
+	req.Header.Set(MethodOverrideHeader, "PATCH")
+	serve(req)
+	assert.Equal(t, http.MethodPatch, gotMethod)
+
+	serve(postForm("/posts/1", "_method=GET", nil))
+	assert.Equal(t, http.MethodPost, gotMethod, "only PUT, PATCH, and DELETE can be requested")
+
+	req = httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	req.Header.Set(MethodOverrideHeader, "DELETE")
+	serve(req)
+	assert.Equal(t, http.MethodGet, gotMethod, "only POSTs are overridden")
+
+	req = httptest.NewRequest(http.MethodPost, "/posts/1", strings.NewReader(`{"_method":"DELETE"}`))
+	req.Header.Set("Content-Type", "application/json")
+	serve(req)
+	assert.Equal(t, http.MethodPost, gotMethod, "JSON bodies are not parsed")
+}
+
+func TestMethodOverrideMiddleware_Multipart(t *testing.T) {
+	var body strings.Builder
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField(MethodOverrideField, "PUT"))
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/posts/1", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var gotMethod string
+	NewMethodOverrideMiddleware(NewApp())(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotMethod = req.Method
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, http.MethodPut, gotMethod)
+}
+
+func TestMethodOverrideMiddleware_BodyTooLarge(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetRequestBodyLimitBytes(16))
+	called := false
+	handler := NewMethodOverrideMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm("/posts/1", "_method=DELETE&title="+strings.Repeat("a", 64), nil))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
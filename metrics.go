@@ -0,0 +1,91 @@
+package rstf
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MetricsPath is where the generated server publishes runtime metrics.
+const MetricsPath = "/__rstf/metrics"
+
+// MetricsFunc returns a JSON-serializable snapshot of one metrics source.
+type MetricsFunc func() any
+
+// SetMetricsToken enables the metrics endpoint outside dev mode. Requests
+// must send the token as "Authorization: Bearer <token>".
+func (a *App) SetMetricsToken(token string) error {
+	if strings.TrimSpace(token) == "" {
+		return fmt.Errorf("metrics token must not be empty")
+	}
+	a.metricsToken = token
+	return nil
+}
+
+// AddMetrics publishes source on the metrics endpoint under name. The
+// generated server adds the renderer's stats as "renderer".
+func (a *App) AddMetrics(name string, source MetricsFunc) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("metrics name must not be empty")
+	}
+	if source == nil {
+		return fmt.Errorf("metrics %q: source must not be nil", name)
+	}
+	if _, ok := a.metrics[name]; ok {
+		return fmt.Errorf("metrics %q: already registered", name)
+	}
+	if a.metrics == nil {
+		a.metrics = map[string]MetricsFunc{}
+	}
+	a.metrics[name] = source
+	return nil
+}
+
+// Metrics returns a snapshot of every source added with AddMetrics, keyed by
+// name, plus the DatabaseStats of the pool opened with Database as
+// "database" and the BuildInfo set with SetBuildInfo as "build".
+func (a *App) Metrics() map[string]any {
+	snapshot := make(map[string]any, len(a.metrics)+2)
+	if a.db != nil {
+		snapshot["database"] = newDatabaseStats(a.db.Stats())
+	}
+	if a.buildInfo != nil {
+		snapshot["build"] = *a.buildInfo
+	}
+	for name, source := range a.metrics {
+		snapshot[name] = source()
+	}
+	return snapshot
+}
+
+// NewMetricsHandler serves MetricsPath. A GET responds with Metrics as JSON.
+// The endpoint is open in dev mode and otherwise responds 404 until
+// SetMetricsToken is called.
+func NewMetricsHandler(app *App) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if app.metricsToken == "" && !app.DevMode() {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if app.metricsToken != "" {
+			token, _ := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(app.metricsToken)) != 1 {
+				WriteErrorEnvelope(w, &RequestError{
+					Code:    ErrorCodeUnauthorized,
+					Message: "invalid metrics token",
+				})
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(app.Metrics())
+	})
+}
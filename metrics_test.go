@@ -0,0 +1,67 @@
+package rstf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddMetrics_Validates(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.AddMetrics("", func() any { return nil }))
+	require.Error(t, app.AddMetrics("renderer", nil))
+	require.NoError(t, app.AddMetrics("renderer", func() any { return 1 }))
+	require.ErrorContains(t, app.AddMetrics("renderer", func() any { return 2 }), "already registered")
+}
+
+func TestMetricsHandler_DisabledWithoutTokenOutsideDevMode(t *testing.T) {
+	app := NewApp()
+	app.SetDevMode(false)
+
+	rec := httptest.NewRecorder()
+	NewMetricsHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMetricsHandler_RequiresToken(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetMetricsToken("secret"))
+	require.NoError(t, app.AddMetrics("renderer", func() any { return map[string]int{"renders": 3} }))
+
+	rec := httptest.NewRecorder()
+	NewMetricsHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	NewMetricsHandler(app).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"renderer":{"renders":3}}`, rec.Body.String())
+}
+
+func TestMetricsHandler_OpenInDevMode(t *testing.T) {
+	app := NewApp()
+	app.SetDevMode(true)
+	renders := 0
+	require.NoError(t, app.AddMetrics("renderer", func() any {
+		renders++
+		return map[string]int{"renders": renders}
+	}))
+
+	for want := 1; want <= 2; want++ {
+		rec := httptest.NewRecorder()
+		NewMetricsHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body map[string]map[string]int
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Equal(t, want, body["renderer"]["renders"], "every request takes a fresh snapshot")
+	}
+
+	rec := httptest.NewRecorder()
+	NewMetricsHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, MetricsPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,142 @@
+package rstf
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RoutePolicies maps route URL patterns to the name of the policy that guards
+// them. The layout package declares it in policies.go:
+//
+//	var RoutePolicies = rstf.RoutePolicies{
+//		"/admin/*":  "admin",
+//		"/settings": "signedIn",
+//	}
+//
+// Codegen reads the declaration to generate the checks, so keys and values
+// must be string literals. A pattern matches the route with that URL pattern,
+// and a pattern ending in "/*" also matches every route beneath it. A route
+// matched by several patterns must pass all of their policies, broadest
+// pattern first. The policies themselves are registered with App.SetPolicy.
+type RoutePolicies map[string]string
+
+// Policy decides whether a request may reach a route. It returns nil to let
+// the request through, or an error to reject it, usually a RequestError with
+// ErrorCodeUnauthorized or ErrorCodeForbidden. For page requests it can also
+// answer the request itself, e.g. redirect to a login page with
+// ctx.Redirect, which stops the request as well.
+type Policy func(ctx *Context) error
+
+// SetPolicy registers policy under name, for the routes RoutePolicies maps to
+// name. Registering a name again replaces its policy.
+func (a *App) SetPolicy(name string, policy Policy) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("policy name must not be empty")
+	}
+	if policy == nil {
+		return fmt.Errorf("policy %q must not be nil", name)
+	}
+	if a.policies == nil {
+		a.policies = map[string]Policy{}
+	}
+	a.policies[name] = policy
+	return nil
+}
+
+// CheckPolicies returns an error listing the names with no policy registered.
+// The generated server calls it at startup with every name RoutePolicies
+// uses, so a missing SetPolicy fails the deploy instead of every request.
+func (a *App) CheckPolicies(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if _, ok := a.policies[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("RoutePolicies uses policies with nothing registered: %s; register them with app.SetPolicy in OnServerStart", strings.Join(missing, ", "))
+}
+
+// Authorize runs the named policies in order and returns the first error. A
+// name with no policy registered rejects the request with an internal error.
+func (a *App) Authorize(ctx *Context, names ...string) error {
+	for _, name := range names {
+		policy, ok := a.policies[name]
+		if !ok {
+			return fmt.Errorf("policy %q is not registered", name)
+		}
+		if err := policy(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizeRequest runs the named policies for a request to a route and
+// reports whether it may go on. When a policy rejects it, the request is
+// answered with the error: the JSON error envelope, a plain-text page for a
+// browser navigation, or the server error page for an internal error. A
+// policy that writes a response itself stops the request without one.
+func (a *App) AuthorizeRequest(w http.ResponseWriter, ctx *Context, names ...string) bool {
+	tracker := NewResponseTracker(w)
+	writer := ctx.Writer
+	ctx.Writer = tracker
+	err := a.Authorize(ctx, names...)
+	ctx.Writer = writer
+	if tracker.Written() {
+		return false
+	}
+	if err == nil {
+		return true
+	}
+
+	req := ctx.Request
+	re := requestErrorFrom(err)
+	switch {
+	case re.Code == ErrorCodeInternal:
+		a.ReportError(ctx, err, nil)
+		a.WriteServerError(w, req, err, nil)
+	case prefersHTMLErrors(req.Header.Get("Accept")):
+		http.Error(w, re.Message, re.Status)
+	default:
+		WriteErrorEnvelope(w, err)
+	}
+	return false
+}
+
+// prefersHTMLErrors reports whether accept ranks text/html at least as high
+// as application/json, as a browser navigation does. Only exact media types
+// count, so "*/*" alone gets the JSON envelope.
+func prefersHTMLErrors(accept string) bool {
+	html := acceptQuality(accept, "text/html")
+	return html > 0 && html >= acceptQuality(accept, "application/json")
+}
+
+// acceptQuality returns the quality accept gives mediaType, 0 when it is not
+// listed.
+func acceptQuality(accept, mediaType string) float64 {
+	best := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		listed, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.EqualFold(listed, mediaType) {
+			continue
+		}
+		q := 1.0
+		if qRaw, ok := params["q"]; ok {
+			parsed, err := strconv.ParseFloat(qRaw, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		best = max(best, q)
+	}
+	return best
+}
@@ -0,0 +1,139 @@
+package rstf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedIn(ctx *Context) error {
+	if ctx.Request.Header.Get("X-User") == "" {
+		return &RequestError{Code: ErrorCodeUnauthorized, Message: "sign in first"}
+	}
+	return nil
+}
+
+func admin(ctx *Context) error {
+	if ctx.Request.Header.Get("X-User") != "root" {
+		return &RequestError{Code: ErrorCodeForbidden, Message: "admins only"}
+	}
+	return nil
+}
+
+func TestSetPolicy_Validates(t *testing.T) {
+	app := NewApp()
+	assert.EqualError(t, app.SetPolicy(" ", signedIn), "policy name must not be empty")
+	assert.EqualError(t, app.SetPolicy("admin", nil), `policy "admin" must not be nil`)
+}
+
+func TestCheckPolicies(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetPolicy("signedIn", signedIn))
+
+	assert.NoError(t, app.CheckPolicies("signedIn"))
+	err := app.CheckPolicies("signedIn", "owner", "admin")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nothing registered: admin, owner;")
+}
+
+func TestAuthorize_RunsPoliciesInOrder(t *testing.T) {
+	app := NewApp()
+	var ran []string
+	require.NoError(t, app.SetPolicy("a", func(ctx *Context) error {
+		ran = append(ran, "a")
+		return nil
+	}))
+	require.NoError(t, app.SetPolicy("b", func(ctx *Context) error {
+		ran = append(ran, "b")
+		return errors.New("no")
+	}))
+	require.NoError(t, app.SetPolicy("c", func(ctx *Context) error {
+		ran = append(ran, "c")
+		return nil
+	}))
+
+	err := app.Authorize(NewContext(httptest.NewRequest(http.MethodGet, "/", nil)), "a", "b", "c")
+	assert.EqualError(t, err, "no")
+	assert.Equal(t, []string{"a", "b"}, ran)
+
+	err = app.Authorize(NewContext(httptest.NewRequest(http.MethodGet, "/", nil)), "missing")
+	assert.EqualError(t, err, `policy "missing" is not registered`)
+}
+
+func TestAuthorizeRequest(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetPolicy("signedIn", signedIn))
+	require.NoError(t, app.SetPolicy("admin", admin))
+	require.NoError(t, app.SetPolicy("login", func(ctx *Context) error {
+		if ctx.Request.Header.Get("X-User") == "" {
+			return ctx.Redirect(http.StatusSeeOther, "/login")
+		}
+		return nil
+	}))
+
+	serve := func(user, accept string, names ...string) (*httptest.ResponseRecorder, bool) {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		return rec, app.AuthorizeRequest(rec, NewContext(req), names...)
+	}
+
+	rec, ok := serve("root", "application/json", "signedIn", "admin")
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec, ok = serve("", "application/json", "signedIn", "admin")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"error": {"code": "unauthorized", "message": "sign in first", "details": {}}}`, rec.Body.String())
+
+	rec, ok = serve("guest", "text/html", "signedIn", "admin")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "admins only\n", rec.Body.String())
+
+	rec, ok = serve("", "text/html", "login")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/login", rec.Header().Get("Location"))
+
+	var reported error
+	app.OnError(func(ctx *Context, err error, stack []byte) { reported = err })
+	rec, ok = serve("root", "text/html", "missing")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Error(t, reported, "a 500 is reported to OnError")
+}
+
+func TestAuthorizeRequest_NegotiatesTheErrorFormat(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetPolicy("signedIn", signedIn))
+
+	for accept, html := range map[string]bool{
+		"text/html": true,
+		"text/html,application/xhtml+xml,*/*;q=0.8": true,
+		"application/json, text/html;q=0.5":         false,
+		"text/html;q=0, application/json":           false,
+		"text/html;q=0":                             false,
+		"*/*":                                       false,
+		"":                                          false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		require.False(t, app.AuthorizeRequest(rec, NewContext(req), "signedIn"))
+		if html {
+			assert.Equal(t, "sign in first\n", rec.Body.String(), accept)
+		} else {
+			assert.JSONEq(t, `{"error": {"code": "unauthorized", "message": "sign in first", "details": {}}}`, rec.Body.String(), accept)
+		}
+	}
+}
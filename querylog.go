@@ -0,0 +1,244 @@
+package rstf
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+)
+
+// DefaultSlowQueryThreshold is the QueryLog.SlowThreshold used when none is set.
+const DefaultSlowQueryThreshold = 100 * time.Millisecond
+
+// QueryLog configures the SQL query log enabled with App.SetQueryLog.
+type QueryLog struct {
+	// Logger receives one entry per statement. Defaults to NewLogger().
+	Logger *Logger
+	// SlowThreshold is the duration at which a statement is logged at WARN
+	// with slow=true instead of at INFO. Defaults to DefaultSlowQueryThreshold.
+	SlowThreshold time.Duration
+}
+
+// SetQueryLog makes Database wrap the driver so every statement is logged with
+// its arguments and duration. It only applies in dev mode, so it can be left
+// on in code that also runs in production. Call it before Database.
+func (a *App) SetQueryLog(log QueryLog) {
+	if log.Logger == nil {
+		log.Logger = NewLogger()
+	}
+	if log.SlowThreshold <= 0 {
+		log.SlowThreshold = DefaultSlowQueryThreshold
+	}
+	a.queryLog = &log
+}
+
+// openLoggedDB reopens db's driver behind a connector that logs through log.
+// db is not used afterwards; the caller closes it.
+func openLoggedDB(db *sql.DB, dataSourceName string, log *QueryLog) (*sql.DB, error) {
+	drv := db.Driver()
+	var base driver.Connector = dsnConnector{dsn: dataSourceName, drv: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		base = c
+	}
+	return sql.OpenDB(loggedConnector{base: base, log: log}), nil
+}
+
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+type loggedConnector struct {
+	base driver.Connector
+	log  *QueryLog
+}
+
+func (c loggedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{Conn: conn, log: c.log}, nil
+}
+
+func (c loggedConnector) Driver() driver.Driver { return c.base.Driver() }
+
+// logQuery writes the entry for one statement. Errors the driver uses to
+// redirect database/sql, like driver.ErrSkip, are not statements and are not
+// logged.
+func (l *QueryLog) logQuery(query string, args []driver.NamedValue, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	elapsed := time.Since(start)
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	attrs := []any{"query", query, "args", values, "durationMs", float64(elapsed.Microseconds()) / 1000}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	if elapsed >= l.SlowThreshold {
+		l.Logger.Warn("slow sql query", append(attrs, "slow", true)...)
+		return
+	}
+	l.Logger.Info("sql query", attrs...)
+}
+
+// loggedConn logs statements run directly on the connection and wraps the
+// statements it prepares. Optional driver interfaces the underlying
+// connection lacks fall back the way database/sql expects.
+type loggedConn struct {
+	driver.Conn
+	log *QueryLog
+}
+
+func (c *loggedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{Stmt: stmt, query: query, log: c.log}, nil
+}
+
+func (c *loggedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	pc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{Stmt: stmt, query: query, log: c.log}, nil
+}
+
+func (c *loggedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *loggedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	c.log.logQuery(query, args, start, err)
+	return res, err
+}
+
+func (c *loggedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.log.logQuery(query, args, start, err)
+	return rows, err
+}
+
+func (c *loggedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *loggedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type loggedStmt struct {
+	driver.Stmt
+	query string
+	log   *QueryLog
+}
+
+func (s *loggedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.Stmt.Exec(args)
+	s.log.logQuery(s.query, namedValues(args), start, err)
+	return res, err
+}
+
+func (s *loggedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.log.logQuery(s.query, namedValues(args), start, err)
+	return rows, err
+}
+
+func (s *loggedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return s.Exec(plainValues(args))
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, args)
+	s.log.logQuery(s.query, args, start, err)
+	return res, err
+}
+
+func (s *loggedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return s.Query(plainValues(args))
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, args)
+	s.log.logQuery(s.query, args, start, err)
+	return rows, err
+}
+
+func (s *loggedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func plainValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
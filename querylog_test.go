@@ -0,0 +1,94 @@
+package rstf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryLogEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestQueryLog_LogsStatementsInDevMode(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	app.SetDevMode(true)
+	app.SetQueryLog(QueryLog{Logger: &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))}, SlowThreshold: time.Hour})
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+	app.DB().SetMaxOpenConns(1)
+
+	_, err := app.DB().Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT)`)
+	require.NoError(t, err)
+	_, err = app.DB().Exec(`INSERT INTO posts (title) VALUES (?)`, "Hello")
+	require.NoError(t, err)
+	var title string
+	require.NoError(t, app.DB().QueryRow(`SELECT title FROM posts WHERE id = ?`, 1).Scan(&title))
+	assert.Equal(t, "Hello", title)
+
+	stmt, err := app.DB().Prepare(`SELECT title FROM posts WHERE title = ?`)
+	require.NoError(t, err)
+	require.NoError(t, stmt.QueryRow("Hello").Scan(&title))
+	require.NoError(t, stmt.Close())
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "sql query", entries[1]["msg"])
+	assert.Equal(t, "INFO", entries[1]["level"])
+	assert.Equal(t, "INSERT INTO posts (title) VALUES (?)", entries[1]["query"])
+	assert.Equal(t, []any{"Hello"}, entries[1]["args"])
+	assert.Contains(t, entries[1], "durationMs")
+	assert.Equal(t, "SELECT title FROM posts WHERE id = ?", entries[2]["query"])
+	assert.Equal(t, []any{float64(1)}, entries[2]["args"])
+	assert.Equal(t, "SELECT title FROM posts WHERE title = ?", entries[3]["query"])
+}
+
+func TestQueryLog_HighlightsSlowAndFailedStatements(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	app.SetDevMode(true)
+	app.SetQueryLog(QueryLog{Logger: &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))}, SlowThreshold: time.Nanosecond})
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+
+	_, err := app.DB().Exec(`SELECT * FROM missing`)
+	require.Error(t, err)
+
+	entries := queryLogEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "slow sql query", entries[0]["msg"])
+	assert.Equal(t, "WARN", entries[0]["level"])
+	assert.Equal(t, true, entries[0]["slow"])
+	assert.Contains(t, entries[0]["error"], "no such table: missing")
+}
+
+func TestQueryLog_OffOutsideDevMode(t *testing.T) {
+	var buf bytes.Buffer
+	app := NewApp()
+	app.SetDevMode(false)
+	app.SetQueryLog(QueryLog{Logger: &Logger{slog: slog.New(slog.NewJSONHandler(&buf, nil))}})
+	require.NoError(t, app.Database("sqlite3", ":memory:"))
+	defer app.Close()
+
+	_, err := app.DB().Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
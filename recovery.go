@@ -0,0 +1,60 @@
+package rstf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError wraps a value recovered from a panicking request handler.
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is itself an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// NewRecoveryMiddleware returns middleware that recovers handler panics,
+// reports them through app.ReportError, and responds with WriteServerError when
+// nothing was written yet.
+func NewRecoveryMiddleware(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tracker := NewResponseTracker(w)
+			slot, req := withContextSlot(req)
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				// A panic in a route is reported with the route's Context, so
+				// OnError sees what its policies and handler set on it.
+				ctx := slot.ctx
+				if ctx == nil {
+					ctx = NewContext(req)
+				}
+				ctx.Writer = tracker
+				err := &PanicError{Value: recovered}
+				stack := debug.Stack()
+				app.ReportError(ctx, err, stack)
+
+				if !tracker.Written() {
+					app.WriteServerError(tracker, req, err, stack)
+				}
+			}()
+			next.ServeHTTP(tracker, req)
+		})
+	}
+}
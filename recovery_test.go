@@ -0,0 +1,92 @@
+package rstf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMiddleware_ReportsPanicAndWrites500(t *testing.T) {
+	app := NewApp()
+	var reported error
+	var reportedStack []byte
+	var reportedPath string
+	app.OnError(func(ctx *Context, err error, stack []byte) {
+		reported = err
+		reportedStack = stack
+		reportedPath = ctx.Request.URL.Path
+	})
+
+	h := NewRecoveryMiddleware(app)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Contains(t, rec.Body.String(), `"code":"internal_error"`)
+	require.EqualError(t, reported, "panic: boom")
+	require.NotEmpty(t, reportedStack)
+	require.Equal(t, "/dashboard", reportedPath)
+}
+
+func TestRecoveryMiddleware_UnwrapsPanicErrors(t *testing.T) {
+	app := NewApp()
+	sentinel := errors.New("db down")
+	var reported error
+	app.OnError(func(_ *Context, err error, _ []byte) { reported = err })
+
+	h := NewRecoveryMiddleware(app)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(sentinel)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.ErrorIs(t, reported, sentinel)
+}
+
+func TestRecoveryMiddleware_KeepsWrittenResponse(t *testing.T) {
+	app := NewApp()
+	h := NewRecoveryMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Empty(t, rec.Body.String())
+}
+
+func TestRecoveryMiddleware_RepanicsAbortHandler(t *testing.T) {
+	h := NewRecoveryMiddleware(NewApp())(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecoveryMiddleware_ReportsWithTheRouteContext(t *testing.T) {
+	app := NewApp()
+	var reportedUser string
+	app.OnError(func(ctx *Context, err error, stack []byte) {
+		reportedUser = ctx.Request.Header.Get("X-User")
+	})
+
+	h := NewRecoveryMiddleware(app)(NewLifecycleHandler(app, "/dashboard", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := ContextFromRequest(req)
+		signedIn := req.Clone(req.Context())
+		signedIn.Header.Set("X-User", "user-1")
+		ctx.Request = signedIn
+		panic("boom")
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+
+	require.Equal(t, "user-1", reportedUser)
+}
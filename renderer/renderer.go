@@ -1,12 +1,17 @@
 package renderer
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"rogchap.com/v8go"
@@ -77,11 +82,78 @@ type Renderer struct {
 	iso           *v8go.Isolate
 	ctx           *v8go.Context
 	loadedBundles map[string]time.Time
+
+	flightMu sync.Mutex
+	inflight map[string]*renderCall
+
+	stats rendererStats
+}
+
+// rendererStats holds the counters behind Stats.
+type rendererStats struct {
+	renders       atomic.Int64
+	sharedRenders atomic.Int64
+	errors        atomic.Int64
+	bundleLoads   atomic.Int64
+	bundleHits    atomic.Int64
+	renderTime    atomic.Int64
+	maxRenderTime atomic.Int64
+	waitTime      atomic.Int64
+	waiting       atomic.Int64
+}
+
+// Stats reports renderer activity since New. Render and wait times separate
+// time spent executing React from time spent queued behind other renders,
+// which together with the data time in a route's Server-Timing header tell a
+// slow route's data fetching apart from its rendering.
+type Stats struct {
+	// Renders counts renders executed in the runtime, including failed ones.
+	Renders int64 `json:"renders"`
+	// SharedRenders counts requests answered by an identical render already
+	// in flight instead of rendering again.
+	SharedRenders int64 `json:"sharedRenders"`
+	// Errors counts renders that failed.
+	Errors int64 `json:"errors"`
+	// BundleLoads counts SSR bundles evaluated, including reloads after a
+	// rebuild; BundleHits counts renders that reused a loaded bundle.
+	BundleLoads int64 `json:"bundleLoads"`
+	BundleHits  int64 `json:"bundleHits"`
+	// RenderTime is the total and MaxRenderTime the longest time spent
+	// executing a render.
+	RenderTime    time.Duration `json:"renderTimeNs"`
+	MaxRenderTime time.Duration `json:"maxRenderTimeNs"`
+	// WaitTime is the total time renders spent queued for the runtime.
+	WaitTime time.Duration `json:"waitTimeNs"`
+	// Queued is the number of renders waiting for the runtime right now.
+	Queued int64 `json:"queued"`
+}
+
+// Stats returns a snapshot of the renderer's counters.
+func (r *Renderer) Stats() Stats {
+	return Stats{
+		Renders:       r.stats.renders.Load(),
+		SharedRenders: r.stats.sharedRenders.Load(),
+		Errors:        r.stats.errors.Load(),
+		BundleLoads:   r.stats.bundleLoads.Load(),
+		BundleHits:    r.stats.bundleHits.Load(),
+		RenderTime:    time.Duration(r.stats.renderTime.Load()),
+		MaxRenderTime: time.Duration(r.stats.maxRenderTime.Load()),
+		WaitTime:      time.Duration(r.stats.waitTime.Load()),
+		Queued:        r.stats.waiting.Load(),
+	}
+}
+
+// renderCall is a render in progress that identical requests wait on.
+type renderCall struct {
+	done chan struct{}
+	html string
+	err  error
 }
 
 func New() *Renderer {
 	return &Renderer{
 		loadedBundles: map[string]time.Time{},
+		inflight:      map[string]*renderCall{},
 	}
 }
 
@@ -134,16 +206,117 @@ type RenderRequest struct {
 }
 
 // Render loads the route's SSR bundle into the embedded runtime and returns the
-// rendered HTML string.
+// rendered HTML string. Concurrent requests for the same component, layout,
+// and SSR props share a single render, since the runtime renders one page at
+// a time and the output depends only on those.
 func (r *Renderer) Render(req RenderRequest) (string, error) {
+	payload, err := json.Marshal(req.SSRProps)
+	if err != nil {
+		return "", fmt.Errorf("renderer: marshal SSR props: %w", err)
+	}
+	return r.share(renderKey(req, payload), func() (string, error) {
+		return r.render(req.Component, payload)
+	})
+}
+
+// share runs render for the first caller with key and hands its result to
+// the callers that arrive while it runs. A panic in render becomes the error
+// every caller gets, so the waiters and later renders of key aren't stuck.
+func (r *Renderer) share(key string, render func() (string, error)) (html string, err error) {
+	r.flightMu.Lock()
+	if call, ok := r.inflight[key]; ok {
+		r.flightMu.Unlock()
+		r.stats.sharedRenders.Add(1)
+		<-call.done
+		return call.html, call.err
+	}
+	call := &renderCall{done: make(chan struct{})}
+	r.inflight[key] = call
+	r.flightMu.Unlock()
+
+	defer func() {
+		if p := recover(); p != nil {
+			call.html, call.err = "", fmt.Errorf("renderer: render panicked: %v", p)
+		}
+		r.flightMu.Lock()
+		delete(r.inflight, key)
+		r.flightMu.Unlock()
+		close(call.done)
+		html, err = call.html, call.err
+	}()
+	call.html, call.err = render()
+	return call.html, call.err
+}
+
+// renderKey identifies renders that produce the same HTML.
+func renderKey(req RenderRequest, payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return req.Component + "\x00" + req.Layout + "\x00" + hex.EncodeToString(sum[:])
+}
+
+// RenderTemplate renders the TSX template templates/{name}.tsx with props
+// spread onto its View and returns the static HTML, without the hydration
+// markers a route render carries. It is meant for output that never reaches a
+// browser as a page, such as transactional email bodies.
+func (r *Renderer) RenderTemplate(name string, props any) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" || slices.Contains(strings.Split(name, "/"), "..") {
+		return "", fmt.Errorf("renderer: invalid template name %q", name)
+	}
+	if props == nil {
+		props = map[string]any{}
+	}
+	payload, err := json.Marshal(props)
+	if err != nil {
+		return "", fmt.Errorf("renderer: marshal template props: %w", err)
+	}
+	return r.render(TemplateComponent(name), payload)
+}
+
+// TemplateComponent returns the key a template's SSR bundle registers its
+// render function under.
+//
+//	"emails/welcome" → "templates/emails/welcome"
+func TemplateComponent(name string) string {
+	return "templates/" + name
+}
+
+func (r *Renderer) render(component string, payload []byte) (string, error) {
+	queuedAt := time.Now()
+	r.stats.waiting.Add(1)
 	r.mu.Lock()
+	r.stats.waiting.Add(-1)
 	defer r.mu.Unlock()
+	r.stats.waitTime.Add(int64(time.Since(queuedAt)))
 
 	if r.ctx == nil || r.iso == nil {
 		return "", fmt.Errorf("renderer: not started")
 	}
 
-	if err := r.ensureBundleLoaded(req.Component); err != nil {
+	start := time.Now()
+	html, err := r.renderLocked(component, payload)
+	r.recordRender(time.Since(start), err)
+	return html, err
+}
+
+// recordRender adds one render of duration d to the stats.
+func (r *Renderer) recordRender(d time.Duration, err error) {
+	r.stats.renders.Add(1)
+	if err != nil {
+		r.stats.errors.Add(1)
+	}
+	r.stats.renderTime.Add(int64(d))
+	for {
+		longest := r.stats.maxRenderTime.Load()
+		if int64(d) <= longest || r.stats.maxRenderTime.CompareAndSwap(longest, int64(d)) {
+			return
+		}
+	}
+}
+
+// renderLocked runs component's render function. The caller holds r.mu.
+func (r *Renderer) renderLocked(component string, payload []byte) (string, error) {
+	if err := r.ensureBundleLoaded(component); err != nil {
 		return "", err
 	}
 
@@ -156,22 +329,18 @@ func (r *Renderer) Render(req RenderRequest) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("renderer: renderer registry is not an object: %w", err)
 	}
-	renderFnValue, err := renderersObj.Get(req.Component)
+	renderFnValue, err := renderersObj.Get(component)
 	if err != nil {
-		return "", fmt.Errorf("renderer: get renderer for %s: %w", req.Component, err)
+		return "", fmt.Errorf("renderer: get renderer for %s: %w", component, err)
 	}
 	if renderFnValue == nil || renderFnValue.IsUndefined() || renderFnValue.IsNull() {
-		return "", fmt.Errorf("renderer: no SSR bundle registered for %s", req.Component)
+		return "", fmt.Errorf("renderer: no SSR bundle registered for %s", component)
 	}
 	renderFn, err := renderFnValue.AsFunction()
 	if err != nil {
-		return "", fmt.Errorf("renderer: renderer for %s is not callable: %w", req.Component, err)
+		return "", fmt.Errorf("renderer: renderer for %s is not callable: %w", component, err)
 	}
 
-	payload, err := json.Marshal(req.SSRProps)
-	if err != nil {
-		return "", fmt.Errorf("renderer: marshal SSR props: %w", err)
-	}
 	arg, err := v8go.JSONParse(r.ctx, string(payload))
 	if err != nil {
 		return "", fmt.Errorf("renderer: parse SSR props JSON: %w", err)
@@ -179,12 +348,22 @@ func (r *Renderer) Render(req RenderRequest) (string, error) {
 
 	result, err := renderFn.Call(v8go.Undefined(r.iso), arg)
 	if err != nil {
-		return "", fmt.Errorf("renderer: render %s: %w", req.Component, err)
+		return "", fmt.Errorf("renderer: render %s: %w", component, err)
 	}
 	r.ctx.PerformMicrotaskCheckpoint()
 	return result.String(), nil
 }
 
+// Stack returns the JavaScript stack trace carried by a render error, or nil
+// when err did not originate from the embedded runtime.
+func Stack(err error) []byte {
+	var jsErr *v8go.JSError
+	if !errors.As(err, &jsErr) || jsErr.StackTrace == "" {
+		return nil
+	}
+	return []byte(jsErr.StackTrace)
+}
+
 func (r *Renderer) ensureBundleLoaded(routeDir string) error {
 	bundlePath := filepath.Join(r.root, routeSSRBundlePath(routeDir))
 	info, err := os.Stat(bundlePath)
@@ -197,6 +376,7 @@ func (r *Renderer) ensureBundleLoaded(routeDir string) error {
 
 	if loadedAt, ok := r.loadedBundles[routeDir]; ok {
 		if info.ModTime().Equal(loadedAt) {
+			r.stats.bundleHits.Add(1)
 			return nil
 		}
 		// Bundle changed. Reset the context so dev reloads do not accumulate
@@ -215,6 +395,7 @@ func (r *Renderer) ensureBundleLoaded(routeDir string) error {
 	}
 
 	r.loadedBundles[routeDir] = info.ModTime()
+	r.stats.bundleLoads.Add(1)
 	return nil
 }
 
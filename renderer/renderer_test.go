@@ -1,12 +1,14 @@
 package renderer
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/rafbgarcia/rstf/internal/bundler"
 	"github.com/stretchr/testify/assert"
@@ -23,7 +25,7 @@ var (
 	rendererDepsErr  error
 )
 
-func ensureLocalNodeModules(t *testing.T) {
+func ensureLocalNodeModules(t testing.TB) {
 	t.Helper()
 
 	nodeModulesDir := filepath.Join(testdataDir(), "node_modules")
@@ -62,13 +64,13 @@ func ensureLocalNodeModules(t *testing.T) {
 	require.NoError(t, rendererDepsErr)
 }
 
-func startRenderer(t *testing.T) *Renderer {
+func startRenderer(t testing.TB) *Renderer {
 	t.Helper()
 	ensureLocalNodeModules(t)
 	t.Cleanup(func() { _ = os.RemoveAll(filepath.Join(testdataDir(), "rstf", "ssr")) })
 	require.NoError(t, bundler.BundleSSREntries(testdataDir(), map[string]string{
 		"hello/hello": filepath.Join(testdataDir(), "rstf", "ssr_entries", "hello-hello.ssr.tsx"),
-	}))
+	}, bundler.Options{}))
 	r := New()
 	require.NoError(t, r.Start(testdataDir()))
 	t.Cleanup(func() { r.Stop() })
@@ -80,7 +82,7 @@ func TestStartStop(t *testing.T) {
 	t.Cleanup(func() { _ = os.RemoveAll(filepath.Join(testdataDir(), "rstf", "ssr")) })
 	require.NoError(t, bundler.BundleSSREntries(testdataDir(), map[string]string{
 		"hello/hello": filepath.Join(testdataDir(), "rstf", "ssr_entries", "hello-hello.ssr.tsx"),
-	}))
+	}, bundler.Options{}))
 	r := New()
 	require.NoError(t, r.Start(testdataDir()))
 	require.NotNil(t, r.iso)
@@ -178,6 +180,128 @@ func TestRenderNoServerData(t *testing.T) {
 	assert.Contains(t, html, "Hello")
 }
 
+func TestRenderCoalescesIdenticalRequests(t *testing.T) {
+	r := startRenderer(t)
+	req := RenderRequest{
+		Component: "hello/hello",
+		Layout:    "layout/layout",
+		SSRProps:  map[string]map[string]any{"hello/hello": {"name": "World"}},
+	}
+	payload, err := json.Marshal(req.SSRProps)
+	require.NoError(t, err)
+
+	// Simulate a render already in flight for the same request.
+	call := &renderCall{done: make(chan struct{})}
+	r.inflight[renderKey(req, payload)] = call
+	result := make(chan string, 1)
+	go func() {
+		html, _ := r.Render(req)
+		result <- html
+	}()
+
+	other := req
+	other.SSRProps = map[string]map[string]any{"hello/hello": {"name": "Ada"}}
+	html, err := r.Render(other)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Ada", "different props render separately")
+
+	call.html = "<p>shared</p>"
+	close(call.done)
+	assert.Equal(t, "<p>shared</p>", <-result)
+}
+
+func TestRenderPanicReleasesWaiters(t *testing.T) {
+	r := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := make(chan error, 1)
+	go func() {
+		_, err := r.share("page", func() (string, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+		leader <- err
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := r.share("page", func() (string, error) { return "unexpected", nil })
+		waiter <- err
+	}()
+	require.Eventually(t, func() bool { return r.stats.sharedRenders.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+
+	require.EqualError(t, <-leader, "renderer: render panicked: boom")
+	require.EqualError(t, <-waiter, "renderer: render panicked: boom")
+
+	html, err := r.share("page", func() (string, error) { return "<p>again</p>", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "<p>again</p>", html, "the key is free after the panic")
+}
+
+func TestStats(t *testing.T) {
+	r := startRenderer(t)
+	req := RenderRequest{Component: "hello/hello", Layout: "layout/layout"}
+
+	_, err := r.Render(req)
+	require.NoError(t, err)
+	_, err = r.Render(req)
+	require.NoError(t, err)
+	_, err = r.Render(RenderRequest{Component: "nonexistent/component"})
+	require.Error(t, err)
+
+	stats := r.Stats()
+	assert.Equal(t, int64(3), stats.Renders)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, int64(1), stats.BundleLoads)
+	assert.Equal(t, int64(1), stats.BundleHits)
+	assert.Positive(t, stats.RenderTime)
+	assert.LessOrEqual(t, stats.MaxRenderTime, stats.RenderTime)
+	assert.Zero(t, stats.Queued)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	r := startRenderer(t)
+	require.NoError(t, bundler.BundleSSREntries(testdataDir(), map[string]string{
+		"templates/emails/welcome": filepath.Join(testdataDir(), "rstf", "ssr_entries", "templates-emails-welcome.ssr.tsx"),
+	}, bundler.Options{}))
+
+	html, err := r.RenderTemplate("emails/welcome", map[string]string{"name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Welcome, Ada!</p>", html, "templates render static markup without React markers")
+
+	_, err = r.RenderTemplate("emails/missing", nil)
+	assert.ErrorContains(t, err, "missing SSR bundle")
+
+	_, err = r.RenderTemplate("../hello/hello", nil)
+	assert.ErrorContains(t, err, "invalid template name")
+}
+
+func BenchmarkRender(b *testing.B) {
+	r := startRenderer(b)
+	req := RenderRequest{
+		Component: "hello/hello",
+		Layout:    "layout/layout",
+		SSRProps: map[string]map[string]any{
+			"hello/hello":   {"name": "World", "count": 42},
+			"layout/layout": {"title": "Bench"},
+		},
+	}
+	_, err := r.Render(req)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		// Vary the props so every iteration renders instead of sharing.
+		req.SSRProps["hello/hello"]["count"] = i
+		if _, err := r.Render(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestStopWithoutStart(t *testing.T) {
 	r := New()
 	require.NoError(t, r.Stop())
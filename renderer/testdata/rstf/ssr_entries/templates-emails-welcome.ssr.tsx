@@ -0,0 +1,7 @@
+import { renderToStaticMarkup } from "react-dom/server.browser";
+import { View } from "../../templates/emails/welcome";
+
+const render = (props: Record<string, any>) => renderToStaticMarkup(<View {...props} />);
+
+(globalThis as any).__RSTF_RENDERERS__ = (globalThis as any).__RSTF_RENDERERS__ ?? {};
+(globalThis as any).__RSTF_RENDERERS__["templates/emails/welcome"] = render;
@@ -0,0 +1,3 @@
+export function View({ name }: { name: string }) {
+  return <p>Welcome, {name}!</p>;
+}
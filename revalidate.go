@@ -0,0 +1,96 @@
+package rstf
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RevalidatePath is where the generated server accepts revalidation requests.
+const RevalidatePath = "/__rstf/revalidate"
+
+// RevalidateHandler is called for every accepted revalidation request with the
+// route's URL pattern, such as "/posts/{slug}". Use it to purge caches rstf
+// does not manage, like a CDN or data cached with Context.TenantKey.
+type RevalidateHandler func(ctx *Context, route string) error
+
+type revalidateRequest struct {
+	Route string `json:"route"`
+}
+
+// SetRevalidateToken enables the revalidation endpoint. Requests must send the
+// token as "Authorization: Bearer <token>".
+func (a *App) SetRevalidateToken(token string) error {
+	if strings.TrimSpace(token) == "" {
+		return fmt.Errorf("revalidate token must not be empty")
+	}
+	a.revalidateToken = token
+	return nil
+}
+
+// OnRevalidate registers a handler invoked for every revalidation request.
+// Handlers run in registration order; the first error aborts the request.
+func (a *App) OnRevalidate(handler RevalidateHandler) {
+	if handler == nil {
+		return
+	}
+	a.revalidateHandlers = append(a.revalidateHandlers, handler)
+}
+
+// NewRevalidateHandler serves RevalidatePath. A POST with {"route": pattern}
+// reruns the live queries of the route registered under that URL pattern, so
+// open pages pick up fresh data, then calls the App's OnRevalidate handlers.
+// routes maps URL patterns to route names. The endpoint responds 404 until
+// SetRevalidateToken is called.
+func NewRevalidateHandler(app *App, hub *LiveHub, routes map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if app.revalidateToken == "" {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		token, _ := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(app.revalidateToken)) != 1 {
+			WriteErrorEnvelope(w, &RequestError{
+				Code:    ErrorCodeUnauthorized,
+				Message: "invalid revalidate token",
+			})
+			return
+		}
+
+		ctx := NewContext(req)
+		ctx.Writer = w
+		ctx.App = app
+		_ = ctx.SetRequestBodyLimitBytes(app.RequestBodyLimitBytes())
+
+		var payload revalidateRequest
+		if err := ctx.BindJSON(&payload); err != nil {
+			WriteErrorEnvelope(w, err)
+			return
+		}
+		routeName, ok := routes[payload.Route]
+		if !ok {
+			WriteErrorEnvelope(w, &RequestError{
+				Code:    ErrorCodeInvalidPayload,
+				Message: fmt.Sprintf("unknown route %q", payload.Route),
+				Status:  http.StatusNotFound,
+			})
+			return
+		}
+
+		hub.InvalidateRoute(routeName, ctx.Tenant())
+		for _, handler := range app.revalidateHandlers {
+			if err := handler(ctx, payload.Route); err != nil {
+				app.ReportError(ctx, err, nil)
+				WriteErrorEnvelope(w, err)
+				return
+			}
+		}
+		_ = ctx.JSON(http.StatusOK, map[string]any{"revalidated": payload.Route})
+	})
+}
@@ -0,0 +1,120 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func revalidateRequestFor(route, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, RevalidatePath, strings.NewReader(`{"route":"`+route+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestRevalidateHandler_DisabledWithoutToken(t *testing.T) {
+	handler := NewRevalidateHandler(NewApp(), NewLiveHub(), map[string]string{"/posts": "posts"})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, revalidateRequestFor("/posts", "secret"))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRevalidateHandler_RejectsBadToken(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetRevalidateToken("secret"))
+	handler := NewRevalidateHandler(app, NewLiveHub(), map[string]string{"/posts": "posts"})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, revalidateRequestFor("/posts", "wrong"))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Contains(t, rec.Body.String(), `"unauthorized"`)
+}
+
+func TestRevalidateHandler_UnknownRoute(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetRevalidateToken("secret"))
+	handler := NewRevalidateHandler(app, NewLiveHub(), map[string]string{"/posts": "posts"})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, revalidateRequestFor("/missing", "secret"))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRevalidateHandler_RerunsLiveQueriesAndHooks(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetRevalidateToken("secret"))
+	var revalidated []string
+	app.OnRevalidate(func(ctx *Context, route string) error {
+		revalidated = append(revalidated, route)
+		return nil
+	})
+
+	hub := NewLiveHub()
+	events, disconnect := hub.Connect("c1")
+	defer disconnect()
+	hub.Register(&LiveSubscription{
+		ClientID:       "c1",
+		SubscriptionID: "s1",
+		Key:            NewSubscriptionKey("posts._slug", "Post", map[string]string{"slug": "hello"}),
+		Execute:        func() (any, error) { return "fresh", nil },
+	})
+	hub.Register(&LiveSubscription{
+		ClientID:       "c1",
+		SubscriptionID: "s2",
+		Key:            NewSubscriptionKey("posts", "List", nil),
+		Execute:        func() (any, error) { return "other", nil },
+	})
+
+	handler := NewRevalidateHandler(app, hub, map[string]string{"/posts/{slug}": "posts._slug", "/posts": "posts"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, revalidateRequestFor("/posts/{slug}", "secret"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []string{"/posts/{slug}"}, revalidated)
+
+	select {
+	case event := <-events:
+		require.Equal(t, "s1", event.SubscriptionID)
+		require.Equal(t, "fresh", event.Data)
+	case <-time.After(time.Second):
+		t.Fatal("expected the route's subscription to rerun")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected rerun of %s", event.SubscriptionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLiveHubInvalidateRoute_Tenants(t *testing.T) {
+	hub := NewLiveHub()
+	events, disconnect := hub.Connect("c1")
+	defer disconnect()
+	for _, tenant := range []string{"acme", "globex"} {
+		hub.Register(&LiveSubscription{
+			ClientID:       "c1",
+			SubscriptionID: tenant,
+			Key:            NewSubscriptionKey("posts", "List", nil).ForTenant(tenant),
+			Execute:        func() (any, error) { return nil, nil },
+		})
+	}
+
+	hub.InvalidateRoute("posts", "acme")
+	require.Equal(t, "acme", (<-events).SubscriptionID)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected rerun of %s", event.SubscriptionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	hub.InvalidateRoute("posts", "")
+	got := map[string]bool{(<-events).SubscriptionID: true, (<-events).SubscriptionID: true}
+	require.Equal(t, map[string]bool{"acme": true, "globex": true}, got)
+}
@@ -50,6 +50,11 @@ func (r *Router) Handle(pattern string, handler http.Handler) {
 	r.mux.Handle(pattern, handler)
 }
 
+// Method registers an http.Handler for a single HTTP method at the given pattern.
+func (r *Router) Method(method, pattern string, handler http.Handler) {
+	r.mux.Method(method, pattern, handler)
+}
+
 // ServeHTTP implements http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.mux.ServeHTTP(w, req)
@@ -52,10 +52,18 @@ func NewActionContext(r *http.Request, requestBodyLimit int64) *ActionContext {
 	return &ActionContext{Context: ctx}
 }
 
-// Invalidate reruns all live queries subscribed to the given keys.
+// Invalidate reruns all live queries subscribed to the given keys within the
+// current tenant.
 func (c *MutationContext) Invalidate(keys ...SubscriptionKey) {
 	if c == nil || c.invalidate == nil || len(keys) == 0 {
 		return
 	}
+	if tenant := c.Tenant(); tenant != "" {
+		scoped := make([]SubscriptionKey, len(keys))
+		for i, key := range keys {
+			scoped[i] = key.ForTenant(tenant)
+		}
+		keys = scoped
+	}
 	c.invalidate(keys...)
 }
@@ -0,0 +1,184 @@
+// Package rstftest starts an rstf project for end-to-end tests and drives it
+// with a headless browser.
+package rstftest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rafbgarcia/rstf"
+	"github.com/rafbgarcia/rstf/internal/bundler"
+	"github.com/rafbgarcia/rstf/internal/codegen"
+	"github.com/rafbgarcia/rstf/internal/gotool"
+)
+
+const (
+	// StartTimeout is how long Serve waits for the server to accept requests.
+	StartTimeout = 30 * time.Second
+	// PageTimeout bounds every action on pages opened with Session.Page.
+	PageTimeout = 15 * time.Second
+)
+
+// Session is a running project and the browser connected to it.
+type Session struct {
+	// URL is the server's base URL, e.g. "http://127.0.0.1:54321".
+	URL     string
+	Browser *rod.Browser
+	t       testing.TB
+}
+
+// Browser generates, bundles, and builds the project at projectRoot, starts
+// it on a free port, and launches a headless browser. The server and browser
+// are stopped when the test ends.
+func Browser(t testing.TB, projectRoot string) *Session {
+	t.Helper()
+	url := Serve(t, projectRoot)
+
+	u, err := launcher.New().Headless(true).NoSandbox(true).Launch()
+	if err != nil {
+		t.Fatalf("rstftest: launching browser: %v", err)
+	}
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		t.Fatalf("rstftest: connecting to browser: %v", err)
+	}
+	t.Cleanup(func() { _ = browser.Close() })
+
+	return &Session{URL: url, Browser: browser, t: t}
+}
+
+// Page opens a new tab at path, waits for it to settle, and returns it with
+// PageTimeout applied.
+func (s *Session) Page(path string) *rod.Page {
+	s.t.Helper()
+	page, err := s.Browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		s.t.Fatalf("rstftest: opening page: %v", err)
+	}
+	if _, err := page.SetExtraHeaders([]string{"Accept", "text/html"}); err != nil {
+		s.t.Fatalf("rstftest: setting headers: %v", err)
+	}
+	page = page.Timeout(PageTimeout)
+	if err := page.Navigate(s.URL + path); err != nil {
+		s.t.Fatalf("rstftest: navigating to %s: %v", path, err)
+	}
+	if err := page.WaitStable(300 * time.Millisecond); err != nil {
+		s.t.Fatalf("rstftest: waiting for %s: %v", path, err)
+	}
+	return page
+}
+
+// Serve generates, bundles, and builds the project at projectRoot, starts the
+// server on a free port, and returns its base URL once it accepts requests.
+// The server is stopped when the test ends. Under rstf test, which starts one
+// server for all the tests it runs, Serve returns that server's URL instead.
+func Serve(t testing.TB, projectRoot string) string {
+	t.Helper()
+	if url := os.Getenv(rstf.TestURLEnv); url != "" {
+		return url
+	}
+	root, err := filepath.Abs(projectRoot)
+	if err != nil {
+		t.Fatalf("rstftest: %v", err)
+	}
+
+	result, err := codegen.Generate(root)
+	if err != nil {
+		t.Fatalf("rstftest: codegen: %v", err)
+	}
+	if err := bundler.BundleEntries(root, result.Entries, bundler.Options{}); err != nil {
+		t.Fatalf("rstftest: bundling client entries: %v", err)
+	}
+	if err := bundler.BundleSSREntries(root, result.SSREntries, bundler.Options{}); err != nil {
+		t.Fatalf("rstftest: bundling SSR entries: %v", err)
+	}
+
+	bin := filepath.Join(t.TempDir(), "server")
+	build := exec.Command("go", "build", "-o", bin, "./rstf/server_gen.go")
+	build.Dir = root
+	gotool.Prepare(build)
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("rstftest: compiling server: %v\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("rstftest: finding free port: %v", err)
+	}
+	server := exec.Command(bin, "--port", port)
+	server.Dir = root
+	server.Stdout = os.Stdout
+	server.Stderr = os.Stderr
+	server.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := server.Start(); err != nil {
+		t.Fatalf("rstftest: starting server: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = server.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() { stopProcessGroup(server.Process.Pid, exited) })
+
+	url := "http://127.0.0.1:" + port
+	if err := waitForServer(url, exited, StartTimeout); err != nil {
+		t.Fatalf("rstftest: %v", err)
+	}
+	return url
+}
+
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return fmt.Sprintf("%d", l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// waitForServer polls url until the server answers with any status, failing
+// early if the process exits.
+func waitForServer(url string, exited <-chan struct{}, timeout time.Duration) error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			return fmt.Errorf("server exited before accepting requests")
+		default:
+		}
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("server at %s not ready after %s", url, timeout)
+}
+
+// stopProcessGroup interrupts the server and everything it started, killing
+// the group if it hasn't exited after a second.
+func stopProcessGroup(pid int, exited <-chan struct{}) {
+	_ = syscall.Kill(-pid, syscall.SIGINT)
+	select {
+	case <-exited:
+		return
+	case <-time.After(time.Second):
+	}
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+	}
+}
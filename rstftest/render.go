@@ -0,0 +1,37 @@
+package rstftest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+// Render serves the project at projectRoot like Serve and returns the HTML the
+// server renders for path. A response with an error status fails the test.
+//
+// A route package can render itself with the project root relative to it:
+//
+//	html := rstftest.Render(t, "../..", "/dashboard")
+func Render(t testing.TB, projectRoot, path string) string {
+	t.Helper()
+	url := Serve(t, projectRoot)
+
+	req, err := http.NewRequest("GET", url+path, nil)
+	if err != nil {
+		t.Fatalf("rstftest: %v", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("rstftest: requesting %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("rstftest: reading %s: %v", path, err)
+	}
+	if resp.StatusCode >= 400 {
+		t.Fatalf("rstftest: %s responded %d:\n%s", path, resp.StatusCode, body)
+	}
+	return string(body)
+}
@@ -0,0 +1,55 @@
+package rstf
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SeedFunc is a Go seed declared in the project's seeds/ directory. It runs
+// inside its seed's transaction.
+type SeedFunc func(ctx context.Context, tx *sql.Tx) error
+
+// Seed is one step of seeding the App database: the statements of a .sql file
+// in seeds/, or a SeedFunc declared in a .go file there. Codegen lists a
+// project's seeds in rstf/seeds in the order they run.
+type Seed struct {
+	// Name identifies the seed in errors, e.g. "001_users.sql" or
+	// "002_posts.go:Posts".
+	Name string
+	SQL  string
+	Func SeedFunc
+}
+
+// RunSeeds runs seeds in order against the App database, each in its own
+// transaction. It stops at the first seed that fails, rolling that seed back;
+// seeds that already ran stay committed.
+func (a *App) RunSeeds(ctx context.Context, seeds []Seed) error {
+	if a.db == nil {
+		return fmt.Errorf("seeding: no database configured")
+	}
+	for _, seed := range seeds {
+		if err := a.runSeed(ctx, seed); err != nil {
+			return fmt.Errorf("seed %s: %w", seed.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a *App) runSeed(ctx context.Context, seed Seed) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if seed.SQL != "" {
+		_, err = tx.ExecContext(ctx, seed.SQL)
+	}
+	if err == nil && seed.Func != nil {
+		err = seed.Func(ctx, tx)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
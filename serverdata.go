@@ -0,0 +1,78 @@
+package rstf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ServerDataError is a data function result that cannot be sent to the
+// browser as JSON, such as a struct with a channel or func field, a NaN
+// float, or cyclic data.
+type ServerDataError struct {
+	// Key identifies the data function, e.g. "routes/dashboard" or
+	// "routes/dashboard#Sidebar".
+	Key string
+	Err error
+}
+
+func (e *ServerDataError) Error() string {
+	return fmt.Sprintf("server data %s is not serializable: %v", e.Key, e.Err)
+}
+
+func (e *ServerDataError) Unwrap() error {
+	return e.Err
+}
+
+// ServerDataProps converts a data function's result to the JSON object the
+// page is rendered and hydrated with. It fails when v does not encode as a
+// JSON object. A nil result is an empty object. Fields tagged with FormatTag
+// are converted to their format.
+func ServerDataProps(v any) (map[string]any, error) {
+	v, err := FormatFields(v)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return map[string]any{}, nil
+	}
+	var props map[string]any
+	if err := json.Unmarshal(data, &props); err != nil {
+		return map[string]any{}, fmt.Errorf("encodes as %s, not a JSON object", jsonKind(data))
+	}
+	return props, nil
+}
+
+func jsonKind(data []byte) string {
+	switch data[0] {
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 't', 'f':
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
+
+// CheckServerData logs each ServerDataError of a page. In dev mode it returns
+// them so the request fails and the error page names the data function;
+// otherwise the page renders with the failed results as empty objects.
+func (a *App) CheckServerData(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, err := range errs {
+		NewLogger().Error("server data is not serializable", "error", err.Error())
+	}
+	if !a.DevMode() {
+		return nil
+	}
+	return errors.Join(errs...)
+}
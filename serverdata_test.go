@@ -0,0 +1,64 @@
+package rstf
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serverDataNode struct {
+	Name string
+	Next *serverDataNode
+}
+
+func TestServerDataProps(t *testing.T) {
+	props, err := ServerDataProps(struct {
+		Title string `json:"title"`
+		Count int    `json:"count"`
+	}{"Dashboard", 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "Dashboard", "count": float64(3)}, props)
+
+	var none *struct{ Title string }
+	props, err = ServerDataProps(none)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, props)
+}
+
+func TestServerDataProps_Unserializable(t *testing.T) {
+	cyclic := &serverDataNode{Name: "a"}
+	cyclic.Next = cyclic
+
+	for name, v := range map[string]any{
+		"channel": struct{ Updates chan int }{make(chan int)},
+		"func":    struct{ OnSave func() }{func() {}},
+		"NaN":     struct{ Ratio float64 }{math.NaN()},
+		"cycle":   cyclic,
+		"array":   []string{"a"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			props, err := ServerDataProps(v)
+			assert.Error(t, err)
+			assert.Equal(t, map[string]any{}, props)
+		})
+	}
+}
+
+func TestCheckServerData(t *testing.T) {
+	errs := []error{&ServerDataError{Key: "routes/dashboard#Sidebar", Err: errors.New("json: unsupported type: chan int")}}
+
+	app := NewApp()
+	app.SetDevMode(false)
+	assert.NoError(t, app.CheckServerData(errs), "production renders with empty props")
+	assert.NoError(t, app.CheckServerData(nil))
+
+	app.SetDevMode(true)
+	err := app.CheckServerData(errs)
+	require.Error(t, err)
+	assert.Equal(t, "server data routes/dashboard#Sidebar is not serializable: json: unsupported type: chan int", err.Error())
+	var dataErr *ServerDataError
+	assert.ErrorAs(t, err, &dataErr)
+}
@@ -0,0 +1,85 @@
+package rstf
+
+import (
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// PageShell controls the document rstf builds around each server-rendered
+// page. Set it in OnServerStart with App.SetPageShell:
+//
+//	app.SetPageShell(rstf.PageShell{
+//		Lang: "en",
+//		Head: `<link rel="preconnect" href="https://fonts.gstatic.com">`,
+//	})
+type PageShell struct {
+	// Lang sets the lang attribute of <html> when the layout does not.
+	Lang string
+	// Head is markup added to <head> before the stylesheets, such as
+	// preconnect links or an analytics snippet.
+	Head string
+	// Assemble, when set, builds the document from its parts instead of
+	// DefaultAssemble. The page does not hydrate unless the document
+	// includes Scripts.
+	Assemble func(PageParts) string
+}
+
+// PageParts are the pieces of a server-rendered page.
+type PageParts struct {
+	// HTML is the markup the layout rendered, normally an <html> element.
+	HTML string
+	// Head is the shell's extra head content.
+	Head string
+	// Styles links the page's stylesheets, or inlines their critical rules,
+	// and prefetches the other routes' stylesheets.
+	Styles string
+	// Scripts embeds the page's server data and loads its client bundle.
+	Scripts string
+	// Request is the request the page answers.
+	Request *http.Request
+}
+
+// DefaultAssemble adds the doctype, puts Head and Styles at the end of
+// <head>, and Scripts at the end of <body>.
+func DefaultAssemble(p PageParts) string {
+	page := "<!DOCTYPE html>" + p.HTML
+	if head := p.Head + p.Styles; head != "" {
+		page = strings.Replace(page, "</head>", head+"</head>", 1)
+	}
+	return strings.Replace(page, "</body>", p.Scripts+"</body>", 1)
+}
+
+// SetPageShell sets the shell the generated server assembles pages with.
+func (a *App) SetPageShell(shell PageShell) {
+	a.pageShell = shell
+}
+
+// AssemblePage builds the document for a server-rendered page with the App's
+// PageShell.
+func (a *App) AssemblePage(p PageParts) string {
+	if a.pageShell.Lang != "" {
+		p.HTML = setHTMLLang(p.HTML, a.pageShell.Lang)
+	}
+	p.Head = a.pageShell.Head
+	if a.pageShell.Assemble != nil {
+		return a.pageShell.Assemble(p)
+	}
+	return DefaultAssemble(p)
+}
+
+var (
+	htmlStartTagRe = regexp.MustCompile(`(?i)<html\b[^>]*>`)
+	langAttrRe     = regexp.MustCompile(`(?i)\slang\s*=`)
+)
+
+// setHTMLLang adds lang to the first <html> tag unless it has one.
+func setHTMLLang(page, lang string) string {
+	loc := htmlStartTagRe.FindStringIndex(page)
+	if loc == nil || langAttrRe.MatchString(page[loc[0]:loc[1]]) {
+		return page
+	}
+	insert := loc[0] + len("<html")
+	return page[:insert] + ` lang="` + html.EscapeString(lang) + `"` + page[insert:]
+}
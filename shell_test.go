@@ -0,0 +1,59 @@
+package rstf
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const shellHTML = `<html><head><title>Home</title></head><body><main>hi</main></body></html>`
+
+func TestDefaultAssemble(t *testing.T) {
+	got := DefaultAssemble(PageParts{
+		HTML:    shellHTML,
+		Head:    `<meta name="x">`,
+		Styles:  `<link rel="stylesheet" href="/main.css">`,
+		Scripts: `<script src="/bundle.js"></script>`,
+	})
+	require.Equal(t, `<!DOCTYPE html><html><head><title>Home</title><meta name="x"><link rel="stylesheet" href="/main.css"></head><body><main>hi</main><script src="/bundle.js"></script></body></html>`, got)
+}
+
+func TestAssemblePage_DefaultShell(t *testing.T) {
+	app := NewApp()
+	parts := PageParts{HTML: shellHTML, Scripts: `<script></script>`}
+	require.Equal(t, DefaultAssemble(parts), app.AssemblePage(parts))
+}
+
+func TestAssemblePage_LangAndHead(t *testing.T) {
+	app := NewApp()
+	app.SetPageShell(PageShell{Lang: "pt-BR", Head: `<link rel="preconnect" href="https://cdn.example.com">`})
+
+	got := app.AssemblePage(PageParts{HTML: shellHTML})
+	require.True(t, strings.HasPrefix(got, `<!DOCTYPE html><html lang="pt-BR"><head>`), got)
+	require.Contains(t, got, `<link rel="preconnect" href="https://cdn.example.com"></head>`)
+}
+
+func TestAssemblePage_LangKeepsLayoutLang(t *testing.T) {
+	app := NewApp()
+	app.SetPageShell(PageShell{Lang: "en"})
+
+	got := app.AssemblePage(PageParts{HTML: `<html class="dark" lang="fr"><body></body></html>`})
+	require.Equal(t, `<!DOCTYPE html><html class="dark" lang="fr"><body></body></html>`, got)
+}
+
+func TestAssemblePage_CustomAssemble(t *testing.T) {
+	app := NewApp()
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	app.SetPageShell(PageShell{
+		Head: "<meta>",
+		Assemble: func(p PageParts) string {
+			require.Same(t, req, p.Request)
+			return p.Request.URL.Path + "|" + p.Head + "|" + p.Styles + "|" + p.Scripts
+		},
+	})
+
+	got := app.AssemblePage(PageParts{HTML: shellHTML, Styles: "css", Scripts: "js", Request: req})
+	require.Equal(t, "/dashboard|<meta>|css|js", got)
+}
@@ -0,0 +1,97 @@
+package rstf
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// staticEncodings are the precompressed variants rstf build writes next to
+// static assets, in order of preference.
+var staticEncodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// NewStaticHandler serves the files in dir like http.FileServer, but answers
+// a client that accepts Brotli or gzip with the asset's precompressed .br or
+// .gz variant when one exists. Assets with variants carry
+// Vary: Accept-Encoding so caches keep each encoding apart. A variant older
+// than its asset is ignored, since it was compressed from a previous build.
+func NewStaticHandler(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			files.ServeHTTP(w, req)
+			return
+		}
+		name := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+req.URL.Path)))
+		asset, err := os.Stat(name)
+		if err != nil || asset.IsDir() {
+			files.ServeHTTP(w, req)
+			return
+		}
+
+		accept := req.Header.Get("Accept-Encoding")
+		varied := false
+		for _, enc := range staticEncodings {
+			variant, err := os.Stat(name + enc.ext)
+			if err != nil || variant.ModTime().Before(asset.ModTime()) {
+				continue
+			}
+			varied = true
+			if !acceptsEncoding(accept, enc.name) {
+				continue
+			}
+			f, err := os.Open(name + enc.ext)
+			if err != nil {
+				continue
+			}
+			defer f.Close()
+			w.Header().Add("Vary", "Accept-Encoding")
+			w.Header().Set("Content-Encoding", enc.name)
+			if ctype := mime.TypeByExtension(filepath.Ext(name)); ctype != "" {
+				w.Header().Set("Content-Type", ctype)
+			}
+			http.ServeContent(w, req, name, asset.ModTime(), f)
+			return
+		}
+		if varied {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+		files.ServeHTTP(w, req)
+	})
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding,
+// by name or through "*", with a nonzero quality.
+func acceptsEncoding(header, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encoding && name != "*" {
+			continue
+		}
+		allowed := true
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				allowed = err == nil && q > 0
+			}
+		}
+		if name == encoding {
+			return allowed
+		}
+		wildcard = allowed
+	}
+	return wildcard
+}
@@ -0,0 +1,80 @@
+package rstf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticHandler(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.js"), []byte("console.log('plain')"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.js.br"), []byte("brotli"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.js.gz"), []byte("gzip"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte("png"), 0644))
+	handler := NewStaticHandler(dir)
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/bundle.js", "gzip, deflate, br")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "brotli", rec.Body.String())
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+
+	rec = get("/bundle.js", "gzip, br;q=0")
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "gzip", rec.Body.String())
+
+	rec = get("/bundle.js", "")
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console.log('plain')", rec.Body.String())
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"), "the identity response varies too")
+
+	rec = get("/logo.png", "br")
+	assert.Equal(t, "png", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Vary"))
+
+	rec = get("/../static_test.go", "br")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestStaticHandler_IgnoresStaleVariants(t *testing.T) {
+	dir := t.TempDir()
+	asset := filepath.Join(dir, "main.css")
+	require.NoError(t, os.WriteFile(asset, []byte("body{}"), 0644))
+	require.NoError(t, os.WriteFile(asset+".gz", []byte("old"), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(asset+".gz", past, past))
+
+	req := httptest.NewRequest(http.MethodGet, "/main.css", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	NewStaticHandler(dir).ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "body{}", rec.Body.String())
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	assert.True(t, acceptsEncoding("gzip, br", "br"))
+	assert.True(t, acceptsEncoding("GZIP;q=0.5", "gzip"))
+	assert.True(t, acceptsEncoding("*", "br"))
+	assert.False(t, acceptsEncoding("*, br;q=0", "br"))
+	assert.False(t, acceptsEncoding("gzip", "br"))
+	assert.False(t, acceptsEncoding("", "gzip"))
+}
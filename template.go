@@ -0,0 +1,32 @@
+package rstf
+
+import "fmt"
+
+// TemplateRenderer renders a TSX template from the project's templates/
+// directory to an HTML string. The generated server sets the embedded
+// renderer as the App's TemplateRenderer at startup.
+type TemplateRenderer interface {
+	RenderTemplate(name string, props any) (string, error)
+}
+
+// SetTemplateRenderer sets the renderer RenderTemplate delegates to.
+func (a *App) SetTemplateRenderer(renderer TemplateRenderer) {
+	a.templateRenderer = renderer
+}
+
+// RenderTemplate renders templates/{name}.tsx with data spread onto its View
+// as props and returns the static HTML. It renders outside any route or
+// layout, so use it for markup that leaves the app, such as transactional
+// email bodies:
+//
+//	html, err := app.RenderTemplate("emails/welcome", WelcomeEmail{Name: user.Name})
+func (a *App) RenderTemplate(name string, data any) (string, error) {
+	if a.templateRenderer == nil {
+		return "", fmt.Errorf("render template %q: no template renderer configured", name)
+	}
+	html, err := a.templateRenderer.RenderTemplate(name, data)
+	if err != nil {
+		return "", fmt.Errorf("render template %q: %w", name, err)
+	}
+	return html, nil
+}
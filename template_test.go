@@ -0,0 +1,41 @@
+package rstf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type templateRendererFunc func(name string, props any) (string, error)
+
+func (f templateRendererFunc) RenderTemplate(name string, props any) (string, error) {
+	return f(name, props)
+}
+
+func TestRenderTemplate_DelegatesToRenderer(t *testing.T) {
+	app := NewApp()
+	app.SetTemplateRenderer(templateRendererFunc(func(name string, props any) (string, error) {
+		require.Equal(t, "emails/welcome", name)
+		return "<p>Hi " + props.(map[string]string)["name"] + "</p>", nil
+	}))
+
+	html, err := app.RenderTemplate("emails/welcome", map[string]string{"name": "Ada"})
+	require.NoError(t, err)
+	require.Equal(t, "<p>Hi Ada</p>", html)
+}
+
+func TestRenderTemplate_WrapsRendererErrors(t *testing.T) {
+	app := NewApp()
+	app.SetTemplateRenderer(templateRendererFunc(func(string, any) (string, error) {
+		return "", errors.New("missing SSR bundle")
+	}))
+
+	_, err := app.RenderTemplate("emails/welcome", nil)
+	require.EqualError(t, err, `render template "emails/welcome": missing SSR bundle`)
+}
+
+func TestRenderTemplate_WithoutRenderer(t *testing.T) {
+	_, err := NewApp().RenderTemplate("emails/welcome", nil)
+	require.ErrorContains(t, err, "no template renderer configured")
+}
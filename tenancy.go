@@ -0,0 +1,162 @@
+package rstf
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TenantResolver identifies the tenant a request belongs to. It returns the
+// request to continue with, so path-based resolvers can strip the tenant
+// segment before routing, and ok false when the request names no tenant.
+type TenantResolver func(req *http.Request) (tenant string, next *http.Request, ok bool)
+
+// TenantDatabaseFunc returns the connection pool for a tenant. Implementations
+// typically cache one pool per tenant, or share a pool and select a schema.
+type TenantDatabaseFunc func(tenant string) (*sql.DB, error)
+
+type tenantContextKey struct{}
+
+// HostTenant resolves the tenant from the leftmost subdomain of baseDomain:
+// with baseDomain "example.com", "acme.example.com" is tenant "acme". The bare
+// domain and hosts outside it have no tenant.
+func HostTenant(baseDomain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(req *http.Request) (string, *http.Request, bool) {
+		host := strings.ToLower(req.Host)
+		if h, _, found := strings.Cut(host, ":"); found {
+			host = h
+		}
+		sub, found := strings.CutSuffix(host, suffix)
+		if !found || sub == "" {
+			return "", req, false
+		}
+		if i := strings.LastIndexByte(sub, '.'); i >= 0 {
+			sub = sub[i+1:]
+		}
+		return sub, req, true
+	}
+}
+
+// PathTenant resolves the tenant from the path segment after prefix and strips
+// both before routing: with prefix "/t", "/t/acme/dashboard" is tenant "acme"
+// served by the /dashboard route. An empty prefix uses the first segment.
+func PathTenant(prefix string) TenantResolver {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		prefix = ""
+	}
+	return func(req *http.Request) (string, *http.Request, bool) {
+		rest, found := strings.CutPrefix(req.URL.Path, prefix+"/")
+		if !found {
+			return "", req, false
+		}
+		tenant, path, _ := strings.Cut(rest, "/")
+		if tenant == "" {
+			return "", req, false
+		}
+
+		next := req.Clone(req.Context())
+		next.URL.Path = "/" + path
+		next.URL.RawPath = ""
+		return tenant, next, true
+	}
+}
+
+// SetTenantResolver enables multi-tenancy: every request is passed through
+// resolver and the result is available as Context.Tenant.
+func (a *App) SetTenantResolver(resolver TenantResolver) error {
+	if resolver == nil {
+		return fmt.Errorf("tenant resolver must not be nil")
+	}
+	a.tenantResolver = resolver
+	return nil
+}
+
+// TenantResolver returns the resolver set with SetTenantResolver, or nil.
+func (a *App) TenantResolver() TenantResolver {
+	return a.tenantResolver
+}
+
+// SetTenantDatabase routes tenant requests to the pool returned by fn instead
+// of the App-wide DB. Requests without a tenant keep using DB.
+func (a *App) SetTenantDatabase(fn TenantDatabaseFunc) error {
+	if fn == nil {
+		return fmt.Errorf("tenant database func must not be nil")
+	}
+	a.tenantDatabase = fn
+	return nil
+}
+
+// TenantDB returns the connection pool for tenant: the SetTenantDatabase pool
+// when one is configured and tenant is set, DB otherwise.
+func (a *App) TenantDB(tenant string) (*sql.DB, error) {
+	if tenant == "" || a.tenantDatabase == nil {
+		return a.db, nil
+	}
+	db, err := a.tenantDatabase(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %q database: %w", tenant, err)
+	}
+	return db, nil
+}
+
+// NewTenantMiddleware returns middleware that resolves each request's tenant
+// with the App's TenantResolver. It passes requests through unchanged when no
+// resolver is configured.
+func NewTenantMiddleware(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			resolver := app.TenantResolver()
+			if resolver == nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+			tenant, resolved, ok := resolver(req)
+			if !ok {
+				next.ServeHTTP(w, resolved)
+				return
+			}
+			next.ServeHTTP(w, resolved.WithContext(context.WithValue(resolved.Context(), tenantContextKey{}, tenant)))
+		})
+	}
+}
+
+// TenantFromRequest returns the tenant resolved for req, or "" if none.
+func TenantFromRequest(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	tenant, _ := req.Context().Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// Tenant returns the tenant the current request belongs to, or "" when
+// tenancy is disabled or the request names no tenant.
+func (c *Context) Tenant() string {
+	if c == nil {
+		return ""
+	}
+	return TenantFromRequest(c.Request)
+}
+
+// TenantKey joins parts into a cache key scoped to the current tenant, so
+// entries cached for one tenant are never served to another.
+func (c *Context) TenantKey(parts ...string) string {
+	key := strings.Join(parts, ":")
+	if tenant := c.Tenant(); tenant != "" {
+		return "tenant:" + tenant + ":" + key
+	}
+	return key
+}
+
+// ForTenant scopes a live query key to tenant, so invalidations in one tenant
+// never rerun another tenant's subscriptions.
+func (k SubscriptionKey) ForTenant(tenant string) SubscriptionKey {
+	if tenant == "" {
+		return k
+	}
+	return SubscriptionKey("tenant:" + tenant + ":" + string(k))
+}
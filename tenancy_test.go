@@ -0,0 +1,128 @@
+package rstf
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostTenant(t *testing.T) {
+	resolve := HostTenant("example.com")
+
+	cases := map[string]string{
+		"acme.example.com":      "acme",
+		"ACME.example.com:8080": "acme",
+		"eu.acme.example.com":   "acme",
+		"example.com":           "",
+		"acme.other.com":        "",
+	}
+	for host, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		tenant, _, ok := resolve(req)
+		require.Equal(t, want, tenant, host)
+		require.Equal(t, want != "", ok, host)
+	}
+}
+
+func TestPathTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/t/acme/dashboard?tab=1", nil)
+	tenant, next, ok := PathTenant("/t")(req)
+	require.True(t, ok)
+	require.Equal(t, "acme", tenant)
+	require.Equal(t, "/dashboard", next.URL.Path)
+	require.Equal(t, "tab=1", next.URL.RawQuery)
+	require.Equal(t, "/t/acme/dashboard", req.URL.Path, "original request is not modified")
+
+	tenant, next, ok = PathTenant("")(httptest.NewRequest(http.MethodGet, "/acme", nil))
+	require.True(t, ok)
+	require.Equal(t, "acme", tenant)
+	require.Equal(t, "/", next.URL.Path)
+
+	_, _, ok = PathTenant("/t")(httptest.NewRequest(http.MethodGet, "/about", nil))
+	require.False(t, ok)
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetTenantResolver(PathTenant("/t")))
+
+	var gotTenant, gotPath string
+	h := NewTenantMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := NewContext(req)
+		gotTenant = ctx.Tenant()
+		gotPath = req.URL.Path
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/t/acme/users", nil))
+	require.Equal(t, "acme", gotTenant)
+	require.Equal(t, "/users", gotPath)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pricing", nil))
+	require.Equal(t, "", gotTenant)
+	require.Equal(t, "/pricing", gotPath)
+}
+
+func TestTenantMiddleware_NoResolver(t *testing.T) {
+	called := false
+	h := NewTenantMiddleware(NewApp())(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		require.Equal(t, "", TenantFromRequest(req))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/t/acme", nil))
+	require.True(t, called)
+}
+
+func TestAppTenantDB(t *testing.T) {
+	app := NewApp()
+	require.Error(t, app.SetTenantDatabase(nil))
+
+	db, err := app.TenantDB("acme")
+	require.NoError(t, err)
+	require.Nil(t, db, "without a hook tenants share DB")
+
+	acmeDB := &sql.DB{}
+	require.NoError(t, app.SetTenantDatabase(func(tenant string) (*sql.DB, error) {
+		if tenant == "acme" {
+			return acmeDB, nil
+		}
+		return nil, errors.New("unknown tenant")
+	}))
+
+	db, err = app.TenantDB("acme")
+	require.NoError(t, err)
+	require.Same(t, acmeDB, db)
+
+	_, err = app.TenantDB("globex")
+	require.ErrorContains(t, err, `tenant "globex" database: unknown tenant`)
+
+	db, err = app.TenantDB("")
+	require.NoError(t, err)
+	require.Nil(t, db)
+}
+
+func TestTenantScopedKeys(t *testing.T) {
+	app := NewApp()
+	require.NoError(t, app.SetTenantResolver(HostTenant("example.com")))
+
+	var invalidated []SubscriptionKey
+	h := NewTenantMiddleware(app)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := NewMutationContext(req, nil, DefaultBodyLimit, func(keys ...SubscriptionKey) {
+			invalidated = append(invalidated, keys...)
+		})
+		require.Equal(t, "tenant:acme:posts:1", ctx.TenantKey("posts", "1"))
+		ctx.Invalidate(NewSubscriptionKey("posts", "List", nil))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Host = "acme.example.com"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, []SubscriptionKey{NewSubscriptionKey("posts", "List", nil).ForTenant("acme")}, invalidated)
+	require.Equal(t, SubscriptionKey("tenant:acme:query:posts:List:{}"), invalidated[0])
+	require.Equal(t, "posts:1", NewContext(httptest.NewRequest(http.MethodGet, "/", nil)).TenantKey("posts", "1"))
+}
@@ -25,7 +25,9 @@ func TestCodegen(t *testing.T) {
 		"rstf/generated/client.ts",
 		"rstf/generated/ssr.ts",
 		"rstf/generated/routes.ts",
+		"rstf/generated/format.ts",
 		"rstf/routes/routes_gen.go",
+		"rstf/types/rstf.format.d.ts",
 		"rstf/types/main.d.ts",
 		"rstf/types/get-vs-ssr.d.ts",
 		"rstf/types/live-chat-id.d.ts",
@@ -66,9 +68,10 @@ func TestCodegen(t *testing.T) {
 	dashMod, err := os.ReadFile(filepath.Join(root, "rstf/generated/routes/get-vs-ssr.ts"))
 	require.NoError(t, err)
 	dashModStr := string(dashMod)
-	assert.Contains(t, dashModStr, `import { createSSRWrapper } from "@rstf/ssr"`)
+	assert.Contains(t, dashModStr, `import { createSSRWrapper, createServerDataHook, createServerDataUpdater } from "@rstf/ssr"`)
 	assert.Contains(t, dashModStr, `export type RoutesGetVsSsrSSRProps = RoutesGetVsSsr.ServerData;`)
 	assert.Contains(t, dashModStr, `export const SSR = createSSRWrapper<RoutesGetVsSsr.ServerData>("routes/get-vs-ssr");`)
+	assert.Contains(t, dashModStr, `export const useServerData = createServerDataHook<RoutesGetVsSsr.ServerData>("routes/get-vs-ssr");`)
 
 	avatarMod, err := os.ReadFile(filepath.Join(root, "rstf/generated/shared/ui/user-avatar.ts"))
 	require.NoError(t, err)
@@ -114,13 +117,13 @@ func TestCodegen(t *testing.T) {
 		`useravatar "github.com/rafbgarcia/rstf/tests/integration/test_project/shared/ui/user-avatar"`,
 		`Component: "routes/get-vs-ssr"`,
 		`Layout: "main"`,
-		"structToMap(app.SSR(ctx))",
-		"structToMap(dashboard.SSR(ctx))",
-		`sd["shared/ui/user-avatar"] = structToMap(useravatar.SSR(ctx))`,
+		`serverDataProps(&sdErrs, "main", app.SSR(ctx))`,
+		`serverDataProps(&sdErrs, "routes/get-vs-ssr", dashboard.SSR(ctx))`,
+		`sd["shared/ui/user-avatar"] = serverDataProps(&sdErrs, "shared/ui/user-avatar", useravatar.SSR(ctx))`,
 		"func assemblePage(",
 		`window.__RSTF_SSR_PROPS__`,
 		`rt.Handle("/rstf/static/*"`,
-		"assemblePage(html, sd,",
+		"assemblePage(rstfApp, req, html, sd,",
 	} {
 		assert.Contains(t, serverStr, expected, "server_gen.go missing %q", expected)
 	}
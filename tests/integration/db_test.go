@@ -3,6 +3,7 @@ package integration_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http/httptest"
 	"testing"
 
@@ -258,3 +259,54 @@ func TestContext_DB_Rollback(t *testing.T) {
 	app.DB().QueryRow("SELECT COUNT(*) FROM posts WHERE title = 'Rolled Back'").Scan(&count)
 	assert.Equal(t, 0, count)
 }
+
+func TestApp_RunSeeds(t *testing.T) {
+	app := setupTestApp(t)
+	ctx := context.Background()
+
+	err := app.RunSeeds(ctx, []rstf.Seed{
+		{Name: "001_posts.sql", SQL: `INSERT INTO posts (title, published) VALUES ('Seeded Post', true)`},
+		{Name: "002_posts.go:Drafts", Func: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO posts (title) VALUES ('Seeded Draft')`)
+			return err
+		}},
+	})
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, app.DB().QueryRow(`SELECT COUNT(*) FROM posts WHERE title LIKE 'Seeded%'`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestApp_RunSeeds_StopsAtFailingSeed(t *testing.T) {
+	app := setupTestApp(t)
+	ctx := context.Background()
+
+	err := app.RunSeeds(ctx, []rstf.Seed{
+		{Name: "001_ok.sql", SQL: `INSERT INTO posts (title) VALUES ('Kept')`},
+		{Name: "002_broken.go:Broken", Func: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO posts (title) VALUES ('Rolled Back')`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}},
+		{Name: "003_skipped.sql", SQL: `INSERT INTO posts (title) VALUES ('Skipped')`},
+	})
+	require.EqualError(t, err, "seed 002_broken.go:Broken: boom")
+
+	var titles []string
+	rows, err := app.DB().Query(`SELECT title FROM posts WHERE title IN ('Kept', 'Rolled Back', 'Skipped')`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var title string
+		require.NoError(t, rows.Scan(&title))
+		titles = append(titles, title)
+	}
+	assert.Equal(t, []string{"Kept"}, titles)
+}
+
+func TestApp_RunSeeds_NoDatabase(t *testing.T) {
+	err := rstf.NewApp().RunSeeds(context.Background(), nil)
+	require.EqualError(t, err, "seeding: no database configured")
+}
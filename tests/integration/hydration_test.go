@@ -18,6 +18,7 @@ import (
 	"github.com/rafbgarcia/rstf/internal/bundler"
 	"github.com/rafbgarcia/rstf/internal/codegen"
 	"github.com/rafbgarcia/rstf/internal/gotool"
+	"github.com/rafbgarcia/rstf/rstftest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -37,51 +38,13 @@ func launchTestBrowser(t *testing.T) *rod.Browser {
 
 func TestHydration(t *testing.T) {
 	root := testProjectRoot()
-
-	// Step 1: Run codegen.
-	result, err := codegen.Generate(root)
-	require.NoError(t, err)
 	t.Cleanup(func() { os.RemoveAll(filepath.Join(root, "rstf")) })
 	require.NoError(t, tidyGoModule(root))
 
-	// Step 2: Bundle client JS for each entry.
-	require.NoError(t, bundler.BundleEntries(root, result.Entries))
-	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries))
-
-	// Step 3: Pick a free port.
-	port := freePort(t)
-
-	// Step 4: Build the generated server first to catch compilation errors.
-	build := exec.Command("go", "build", "-o", filepath.Join(root, "rstf", "server"), "./rstf/server_gen.go")
-	build.Dir = root
-	gotool.Prepare(build)
-	if out, err := build.CombinedOutput(); err != nil {
-		require.FailNowf(t, "compiling server", "compiling server: %v\n%s", err, out)
-	}
-
-	// Step 5: Start the compiled server.
-	server := exec.Command(filepath.Join(root, "rstf", "server"), "--port", port)
-	server.Dir = root
-	server.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	require.NoError(t, server.Start())
-	t.Cleanup(func() {
-		stopProcessGroup(t, server, 1*time.Second)
-	})
-
-	// Step 6: Wait for the server to be ready.
-	baseURL := fmt.Sprintf("http://localhost:%s", port)
-	waitForServer(t, baseURL+"/get-vs-ssr", 10*time.Second)
-
-	// Step 7: Launch headless browser.
-	browser := launchTestBrowser(t)
-
-	page := browser.MustPage("about:blank")
-	page.MustSetExtraHeaders("Accept", "text/html")
-	page.MustNavigate(baseURL + "/get-vs-ssr")
-	page = page.Timeout(15 * time.Second)
-	page.MustWaitStable()
+	session := rstftest.Browser(t, root)
+	page := session.Page("/get-vs-ssr")
 
-	// Step 8: Verify SSR content is present.
+	// Verify SSR content is present.
 	body := page.MustElement("body").MustText()
 	for _, expected := range []string{
 		"Welcome to the dashboard!",
@@ -91,7 +54,7 @@ func TestHydration(t *testing.T) {
 		assert.Containsf(t, body, expected, "page missing SSR content %q\n\nbody text:\n%s", expected, body)
 	}
 
-	// Step 9: Click the counter button and verify hydration.
+	// Click the counter button and verify hydration.
 	btn := page.MustElement("[data-testid=counter]")
 	btn.MustClick()
 	require.Eventually(t, func() bool {
@@ -109,47 +72,12 @@ func TestHydration(t *testing.T) {
 
 func TestLiveQueryUpdatesAcrossClients(t *testing.T) {
 	root := testProjectRoot()
-
-	result, err := codegen.Generate(root)
-	require.NoError(t, err)
 	t.Cleanup(func() { os.RemoveAll(filepath.Join(root, "rstf")) })
 	require.NoError(t, tidyGoModule(root))
 
-	require.NoError(t, bundler.BundleEntries(root, result.Entries))
-	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries))
-
-	port := freePort(t)
-	build := exec.Command("go", "build", "-o", filepath.Join(root, "rstf", "server"), "./rstf/server_gen.go")
-	build.Dir = root
-	gotool.Prepare(build)
-	if out, err := build.CombinedOutput(); err != nil {
-		require.FailNowf(t, "compiling server", "compiling server: %v\n%s", err, out)
-	}
-
-	server := exec.Command(filepath.Join(root, "rstf", "server"), "--port", port)
-	server.Dir = root
-	server.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	require.NoError(t, server.Start())
-	t.Cleanup(func() {
-		stopProcessGroup(t, server, 1*time.Second)
-	})
-
-	baseURL := fmt.Sprintf("http://localhost:%s", port)
-	waitForServer(t, baseURL+"/live-chat/room-1", 10*time.Second)
-
-	browser := launchTestBrowser(t)
-
-	pageA := browser.MustPage("about:blank")
-	pageA.MustSetExtraHeaders("Accept", "text/html")
-	pageA.MustNavigate(baseURL + "/live-chat/room-1")
-	pageA = pageA.Timeout(15 * time.Second)
-	pageA.MustWaitStable()
-
-	pageB := browser.MustPage("about:blank")
-	pageB.MustSetExtraHeaders("Accept", "text/html")
-	pageB.MustNavigate(baseURL + "/live-chat/room-1")
-	pageB = pageB.Timeout(15 * time.Second)
-	pageB.MustWaitStable()
+	session := rstftest.Browser(t, root)
+	pageA := session.Page("/live-chat/room-1")
+	pageB := session.Page("/live-chat/room-1")
 
 	require.Eventually(t, func() bool {
 		el, err := pageA.Element("[data-testid=messages-list]")
@@ -169,7 +97,7 @@ func TestLiveQueryUpdatesAcrossClients(t *testing.T) {
 	}, 5*time.Second, 100*time.Millisecond)
 
 	reqBody := bytes.NewBufferString(`{"kind":"mutation","route":"live-chat._id","name":"SendMessage","params":{"id":"room-1"},"input":{"body":"Second message"}}`)
-	resp, err := http.Post(baseURL+"/__rstf/rpc", "application/json", reqBody)
+	resp, err := http.Post(session.URL+"/__rstf/rpc", "application/json", reqBody)
 	require.NoError(t, err)
 	defer resp.Body.Close()
 	require.Equal(t, http.StatusOK, resp.StatusCode)
@@ -196,8 +124,8 @@ func TestCSS(t *testing.T) {
 	require.NoError(t, tidyGoModule(root))
 
 	// Step 2: Bundle client JS.
-	require.NoError(t, bundler.BundleEntries(root, result.Entries))
-	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries))
+	require.NoError(t, bundler.BundleEntries(root, result.Entries, bundler.Options{}))
+	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries, bundler.Options{}))
 
 	// Step 3: Build CSS via PostCSS (same approach as dev.go's buildCSSWithPostCSS).
 	outFile := filepath.Join("rstf", "static", "main.css")
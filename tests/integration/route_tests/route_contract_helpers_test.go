@@ -48,11 +48,11 @@ func ensureRouteContractServerRunning(t *testing.T) string {
 			routeServerErr = err
 			return
 		}
-		if err := bundler.BundleEntries(root, result.Entries); err != nil {
+		if err := bundler.BundleEntries(root, result.Entries, bundler.Options{}); err != nil {
 			routeServerErr = err
 			return
 		}
-		if err := bundler.BundleSSREntries(root, result.SSREntries); err != nil {
+		if err := bundler.BundleSSREntries(root, result.SSREntries, bundler.Options{}); err != nil {
 			routeServerErr = err
 			return
 		}
@@ -85,6 +85,26 @@ func ensureRouteContractServerRunning(t *testing.T) string {
 	return routeServerURL
 }
 
+// startRouteServer starts another instance of the route contract server with
+// env added to its environment, stopped when the test ends.
+func startRouteServer(t *testing.T, env ...string) string {
+	t.Helper()
+	ensureRouteContractServerRunning(t)
+
+	root := testProjectRoot()
+	port := freePort(t)
+	cmd := exec.Command(filepath.Join(root, "rstf", "server"), "--port", port)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoErrorf(t, cmd.Start(), "starting server")
+	t.Cleanup(func() { stopProcessGroup(cmd, 1*time.Second) })
+
+	url := fmt.Sprintf("http://localhost:%s", port)
+	require.NoError(t, waitForServerJSON(url+"/actions-exhaustive-supported-verbs", 10*time.Second))
+	return url
+}
+
 func TestMain(m *testing.M) {
 	code := m.Run()
 	stopRouteContractServer()
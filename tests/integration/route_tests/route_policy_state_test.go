@@ -0,0 +1,44 @@
+package route_tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutePolicyStateReachesHandlers(t *testing.T) {
+	// Without hooks the lifecycle handler takes its fast path; with them it
+	// tracks the response. The policy's Context must reach SSR and the
+	// handlers either way.
+	assertPolicyState(t, ensureRouteContractServerRunning(t))
+	assertPolicyState(t, startRouteServer(t, "RSTF_TEST_LIFECYCLE_HOOKS=1"))
+}
+
+func assertPolicyState(t *testing.T, baseURL string) {
+	t.Helper()
+
+	resp, err := http.Get(baseURL + "/policy-state?_data")
+	require.NoErrorf(t, err, "GET /policy-state?_data")
+	payload, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "GET /policy-state?_data")
+	var sd map[string]struct {
+		Stamp string `json:"stamp"`
+	}
+	require.NoErrorf(t, json.Unmarshal(payload, &sd), "GET /policy-state?_data decode: body=%s", string(payload))
+	require.Equal(t, "stamped", sd["routes/policy-state"].Stamp, "SSR sees the policy's request")
+
+	resp, err = http.Post(baseURL+"/policy-state", "application/json", nil)
+	require.NoErrorf(t, err, "POST /policy-state")
+	payload, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "POST /policy-state")
+	var posted struct {
+		Stamp string `json:"stamp"`
+	}
+	require.NoErrorf(t, json.Unmarshal(payload, &posted), "POST /policy-state decode: body=%s", string(payload))
+	require.Equal(t, "stamped", posted.Stamp, "POST handler sees the policy's request")
+}
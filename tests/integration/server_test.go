@@ -20,7 +20,7 @@ func TestEndToEnd(t *testing.T) {
 	result, err := codegen.Generate(root)
 	require.NoError(t, err)
 	t.Cleanup(func() { os.RemoveAll(filepath.Join(root, "rstf")) })
-	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries))
+	require.NoError(t, bundler.BundleSSREntries(root, result.SSREntries, bundler.Options{}))
 
 	// Step 2: Start the embedded renderer.
 	r := renderer.New()
@@ -1,6 +1,7 @@
 package testproject
 
 import (
+	"os"
 	"time"
 
 	rstf "github.com/rafbgarcia/rstf"
@@ -29,4 +30,17 @@ func OnServerStart(app *rstf.App) {
 	if err := app.SetQueueTimeout(100 * time.Millisecond); err != nil {
 		panic(err)
 	}
+	if err := app.SetPolicy("stamp", func(ctx *rstf.Context) error {
+		stamped := ctx.Request.Clone(ctx.Request.Context())
+		stamped.Header.Set("X-Policy-Stamp", "stamped")
+		ctx.Request = stamped
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+	// The route tests start a second server with hooks to cover both paths
+	// of the lifecycle handler.
+	if os.Getenv("RSTF_TEST_LIFECYCLE_HOOKS") != "" {
+		app.OnResponse(func(ctx *rstf.Context, route string, status int, duration time.Duration) {})
+	}
 }
@@ -0,0 +1,7 @@
+package testproject
+
+import rstf "github.com/rafbgarcia/rstf"
+
+var RoutePolicies = rstf.RoutePolicies{
+	"/policy-state": "stamp",
+}
@@ -0,0 +1,18 @@
+package policystate
+
+import rstf "github.com/rafbgarcia/rstf"
+
+// The "stamp" policy guarding this route replaces the request with one
+// carrying X-Policy-Stamp; SSR and POST read it back.
+
+type ServerData struct {
+	Stamp string `json:"stamp"`
+}
+
+func SSR(ctx *rstf.Context) ServerData {
+	return ServerData{Stamp: ctx.Request.Header.Get("X-Policy-Stamp")}
+}
+
+func POST(ctx *rstf.Context) error {
+	return ctx.JSON(200, ServerData{Stamp: ctx.Request.Header.Get("X-Policy-Stamp")})
+}
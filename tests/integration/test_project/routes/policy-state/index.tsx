@@ -0,0 +1,5 @@
+import { SSR, type RoutesPolicyStateSSRProps } from "@rstf/routes/policy-state";
+
+export const View = SSR(function View({ stamp }: RoutesPolicyStateSSRProps) {
+  return <p data-testid="stamp">{stamp}</p>;
+});
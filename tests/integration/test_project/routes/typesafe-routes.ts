@@ -1,4 +1,4 @@
-import { routes } from "@rstf/routes";
+import { href, routes } from "@rstf/routes";
 
 routes["get-vs-ssr"].url();
 routes["users._id"].url({ id: "1" });
@@ -19,3 +19,15 @@ routes["get-vs-ssr"].url({ id: "1" });
 
 // @ts-expect-error unknown route ids should fail at compile time
 routes["users._missing"].url({ missing: "1" });
+
+href("get-vs-ssr");
+href("users._id", { id: "1" });
+
+// @ts-expect-error missing path params
+href("users._id");
+
+// @ts-expect-error static routes do not accept params
+href("get-vs-ssr", { id: "1" });
+
+// @ts-expect-error unknown route ids should fail at compile time
+href("users._missing", { missing: "1" });